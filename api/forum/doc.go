@@ -0,0 +1,4 @@
+// Package forum holds the gRPC API of the forum service generated from forum.proto.
+package forum
+
+//go:generate protoc -I .. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative forum/forum.proto
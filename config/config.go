@@ -16,7 +16,25 @@ type Config struct {
 	Server      string        `yaml:"server"`
 	Secret      string        `yaml:"secret"`
 	GrpcAddress string        `yaml:"grpc_address"`
+	GrpcServer  string        `yaml:"grpc_server"`
 	LogLevel    string        `yaml:"log_level"`
+
+	// Soft-deleted topics and posts are purged after PurgeRetention, checked every PurgeInterval.
+	PurgeRetention time.Duration `yaml:"purge_retention"`
+	PurgeInterval  time.Duration `yaml:"purge_interval"`
+
+	// Usernames from the user service are cached for UserCacheTTL, users it does not know
+	// for UserCacheNegativeTTL. At most UserCacheSize users are kept.
+	UserCacheTTL         time.Duration `yaml:"user_cache_ttl"`
+	UserCacheNegativeTTL time.Duration `yaml:"user_cache_negative_ttl"`
+	UserCacheSize        int           `yaml:"user_cache_size"`
+
+	// ResponseCache keeps rendered category, topic and post listings in memory for ResponseCacheTTL.
+	ResponseCache    bool          `yaml:"response_cache"`
+	ResponseCacheTTL time.Duration `yaml:"response_cache_ttl"`
+
+	// Authors may edit and delete their chat messages for ChatEditWindow after sending them.
+	ChatEditWindow time.Duration `yaml:"chat_edit_window"`
 }
 
 func NewConfig() (*Config, error) {
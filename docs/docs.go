@@ -17,7 +17,12 @@ const docTemplate = `{
     "paths": {
         "/categories": {
             "get": {
-                "description": "Retrieves a list of all categories.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves all categories ordered by position. With view=tree subcategories are nested under their parents (see response.CategoryTreeResponse), otherwise a flat list is returned. Each category carries topic and post counts and its last post. Hidden categories are only returned to admins.",
                 "produces": [
                     "application/json"
                 ],
@@ -25,11 +30,54 @@ const docTemplate = `{
                     "categories"
                 ],
                 "summary": "Get all categories",
+                "parameters": [
+                    {
+                        "enum": [
+                            "flat",
+                            "tree"
+                        ],
+                        "type": "string",
+                        "description": "Response shape",
+                        "name": "view",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response, 304 is returned if it is still current",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of a previous response, 304 is returned if nothing has changed since",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Successfully retrieved all categories",
                         "schema": {
                             "$ref": "#/definitions/response.CategoriesResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the response"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last change, omitted if it was less than a second ago"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Invalid view",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "500": {
@@ -76,7 +124,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid request payload",
+                        "description": "Invalid request payload or parent category not found",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -102,9 +150,66 @@ const docTemplate = `{
                 }
             }
         },
+        "/categories/order": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sets the display order of categories to the order of the given ids in one transaction. Requires admin privileges.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Reorder categories",
+                "parameters": [
+                    {
+                        "description": "Category ids in the new order",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/categoryrequests.ReorderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Categories reordered successfully"
+                    },
+                    "400": {
+                        "description": "Invalid request payload, duplicate or unknown category ids",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an admin)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to reorder categories",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/categories/{id}": {
             "get": {
-                "description": "Retrieves a specific category by its ID.",
+                "description": "Retrieves a specific category by its ID together with breadcrumbs (its parent categories from the root down), topic and post counts and the last post. Hidden categories are only returned to admins.",
                 "produces": [
                     "application/json"
                 ],
@@ -127,6 +232,12 @@ const docTemplate = `{
                         "description": "Successfully retrieved category",
                         "schema": {
                             "$ref": "#/definitions/response.CategoryResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the category, send it back in If-Match when updating the category"
+                            }
                         }
                     },
                     "400": {
@@ -135,6 +246,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Failed to get category",
                         "schema": {
@@ -149,7 +266,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a category by its ID. Requires admin privileges.",
+                "description": "Deletes a category by its ID together with its topics. Categories with subcategories cannot be deleted. Requires admin privileges.",
                 "tags": [
                     "categories"
                 ],
@@ -186,6 +303,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Category has subcategories",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Failed to delete category",
                         "schema": {
@@ -200,7 +323,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates a category's title and/or description by its ID. Requires admin privileges.",
+                "description": "Updates a category's title and/or description by its ID, optionally moves it under another category (parent_id 0 makes it a root) and shows or hides it. Requires admin privileges.",
                 "consumes": [
                     "application/json"
                 ],
@@ -228,6 +351,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/categoryrequests.UpdateRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the category from a previous GET, the update is rejected if the category has changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -235,7 +364,7 @@ const docTemplate = `{
                         "description": "Category updated successfully"
                     },
                     "400": {
-                        "description": "Invalid category ID or request payload",
+                        "description": "Invalid category ID or request payload, parent category not found or the move would create a cycle",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -252,6 +381,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Category has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Failed to update category",
                         "schema": {
@@ -261,16 +402,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/categories/{id}/topics": {
+        "/categories/{id}/moderators": {
             "get": {
-                "description": "Retrieves a list of topics for a category ID.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists users with moderator rights in a category. Requires admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "topics"
+                    "categories"
                 ],
-                "summary": "Get topics by category ID",
+                "summary": "Get category moderators",
                 "parameters": [
                     {
                         "type": "integer",
@@ -283,9 +429,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved topics",
+                        "description": "Successfully retrieved moderators",
                         "schema": {
-                            "$ref": "#/definitions/response.TopicsResponse"
+                            "$ref": "#/definitions/response.ModeratorsResponse"
                         }
                     },
                     "400": {
@@ -294,6 +440,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an admin)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Category not found",
                         "schema": {
@@ -314,7 +472,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new topic in a category. Requires authentication.",
+                "description": "Gives a user moderator rights in a category: editing, deleting, pinning and locking any content in it. Requires admin role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -322,33 +480,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "topics"
+                    "categories"
                 ],
-                "summary": "Create a new topic",
+                "summary": "Assign a category moderator",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Category ID to create topic in",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Topic data to create. ID, AuthorID, CategoryID, CreatedAt, UpdatedAt will be ignored or overridden.",
-                        "name": "topic",
+                        "description": "User to assign",
+                        "name": "moderator",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entity.Topic"
+                            "$ref": "#/definitions/categoryrequests.AddModeratorRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Topic created successfully",
+                        "description": "Moderator added successfully",
                         "schema": {
-                            "$ref": "#/definitions/response.IDResponse"
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
                         }
                     },
                     "400": {
@@ -364,7 +522,13 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden (user is not authorized or trying to impersonate)",
+                        "description": "Forbidden (user is not an admin)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -378,37 +542,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/{id}": {
+        "/categories/{id}/moderators/{user_id}": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a post by its ID. Requires authentication and ownership or admin role.",
+                "description": "Takes moderator rights in a category away from a user. Requires admin role.",
                 "tags": [
-                    "posts"
+                    "categories"
                 ],
-                "summary": "Delete a post",
+                "summary": "Remove a category moderator",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Post ID",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Post deleted successfully",
+                        "description": "Moderator removed successfully",
                         "schema": {
                             "$ref": "#/definitions/response.SuccessMessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid post ID",
+                        "description": "Invalid category or user ID",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -420,13 +592,115 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden (user is not an owner or admin)",
+                        "description": "Forbidden (user is not an admin)",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Post not found",
+                        "description": "User is not a moderator of the category",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories/{id}/topics": {
+            "get": {
+                "description": "Retrieves a list of topics for a category ID with reply counts and the last reply. Pinned topics always come first. Topics of hidden categories are only returned to admins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Get topics by category ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "latest_activity",
+                            "most_replies"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default newest)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor to fetch the page after (older topics)",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor to fetch the page before (newer topics)",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response, 304 is returned if it is still current",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of a previous response, 304 is returned if nothing has changed since",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved topics",
+                        "schema": {
+                            "$ref": "#/definitions/response.TopicsResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the response"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last change, omitted if it was less than a second ago"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Invalid category ID, sort, limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -439,13 +713,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates a post. Requires authentication and ownership or admin role.",
+                "description": "Creates a new topic in a category together with its opening post. Requires authentication.",
                 "consumes": [
                     "application/json"
                 ],
@@ -453,37 +727,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "topics"
                 ],
-                "summary": "Update a post",
+                "summary": "Create a new topic",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Post ID",
+                        "description": "Category ID to create topic in",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Post update data (only content)",
-                        "name": "post_update",
+                        "description": "Topic title and opening post content",
+                        "name": "topic",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/postrequests.UpdateRequest"
+                            "$ref": "#/definitions/topicrequests.CreateRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Post updated successfully",
+                        "description": "Topic created successfully",
                         "schema": {
-                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                            "$ref": "#/definitions/response.IDResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid post ID or request payload",
+                        "description": "Invalid category ID or request payload",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -495,13 +769,7 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden (user is not an owner or admin)",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Post not found",
+                        "description": "Forbidden (user is not authorized or trying to impersonate)",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -515,63 +783,173 @@ const docTemplate = `{
                 }
             }
         },
-        "/topics/{id}": {
+        "/chat/messages": {
             "get": {
-                "description": "Retrieves a specific topic by its ID.",
+                "description": "Returns messages of a chat room, oldest first. Without before the latest messages are returned, pass the id of the oldest received message to load the previous page. Rooms of hidden categories are only readable by admins.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "topics"
+                    "chat"
                 ],
-                "summary": "Get a topic by ID",
+                "summary": "Get chat room history",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Topic ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Room ID, the general room by default",
+                        "name": "room_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Return messages sent before the message with this ID",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of messages, 50 by default, 100 at most",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved topic",
+                        "description": "Successfully retrieved messages",
                         "schema": {
-                            "$ref": "#/definitions/response.TopicResponse"
+                            "$ref": "#/definitions/response.ChatMessagesResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid topic ID",
+                        "description": "Invalid room ID, before or limit",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Room not found",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Failed to get topic",
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/chat/rooms": {
+            "get": {
+                "description": "Lists the general room, the rooms of categories and the rooms created by admins. Rooms of hidden categories are only listed for admins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "List chat rooms",
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved rooms",
+                        "schema": {
+                            "$ref": "#/definitions/response.ChatRoomsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a chat room. Requires admin role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Create a chat room",
+                "parameters": [
+                    {
+                        "description": "Room name",
+                        "name": "room",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/chatrequests.CreateRoomRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Room created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.ChatRoomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an admin)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chat/rooms/{id}": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a topic by its ID. Requires authentication and ownership or role.",
+                "description": "Deletes a room created by an admin together with its messages. Connected members are notified with room_deleted. The general and category rooms cannot be deleted. Requires admin role.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "topics"
+                    "chat"
                 ],
-                "summary": "Delete a topic",
+                "summary": "Delete a chat room",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Topic ID",
+                        "description": "Room ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -579,13 +957,13 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Topic deleted successfully",
+                        "description": "Room deleted successfully",
                         "schema": {
                             "$ref": "#/definitions/response.SuccessMessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid topic ID",
+                        "description": "Invalid room ID",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -597,13 +975,13 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden (user is not an owner or admin)",
+                        "description": "Forbidden (user is not an admin)",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Topic not found",
+                        "description": "Room not found or not deletable",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -615,52 +993,92 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/conversations": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates a topic. Requires authentication and ownership or admin role.",
-                "consumes": [
+                "description": "Lists the private conversations of the current user with the last message and the number of unread messages, most recent first.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "List private conversations",
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved conversations",
+                        "schema": {
+                            "$ref": "#/definitions/response.ConversationsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/conversations/{id}/messages": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns messages of a conversation of the current user, oldest first. Without before the latest messages are returned and the conversation is marked as read.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "topics"
+                    "chat"
                 ],
-                "summary": "Update a topic",
+                "summary": "Get messages of a private conversation",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Topic ID",
+                        "description": "Conversation ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Topic update data (only title)",
-                        "name": "topic_update",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/topicrequests.UpdateRequest"
-                        }
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Return messages sent before the message with this ID",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of messages, 50 by default, 100 at most",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Topic updated successfully",
+                        "description": "Successfully retrieved messages",
                         "schema": {
-                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                            "$ref": "#/definitions/response.DirectMessagesResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid topic ID or request payload",
+                        "description": "Invalid conversation ID, before or limit",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -671,14 +1089,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden (user is not an owner or admin)",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
                     "404": {
-                        "description": "Topic not found",
+                        "description": "Conversation not found",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -692,21 +1104,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/topics/{id}/posts": {
+        "/posts/{id}": {
             "get": {
-                "description": "Retrieves a list of posts for a topic ID.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a single post, e.g. to edit it. Posts in hidden categories are only visible to admins.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "posts"
                 ],
-                "summary": "Get posts by topic ID",
+                "summary": "Get a post by ID",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Topic ID",
+                        "description": "Post ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -714,19 +1131,25 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved posts",
+                        "description": "Successfully retrieved post",
                         "schema": {
-                            "$ref": "#/definitions/response.PostsResponse"
+                            "$ref": "#/definitions/response.PostResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the post, send it back in If-Match when updating the post"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid topic ID",
+                        "description": "Invalid post ID",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Topic not found",
+                        "description": "Post not found",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -739,13 +1162,73 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new post in atopic. Requires authentication.",
+                "description": "Deletes a post by its ID. The post stays in listings with placeholder content and can be restored by an admin until it is purged. Requires authentication and ownership, admin role or moderator rights in the category.",
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Delete a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Post deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid post ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an owner, admin or moderator of the category)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates a post. Requires authentication and ownership, admin role or moderator rights in the category.",
                 "consumes": [
                     "application/json"
                 ],
@@ -755,35 +1238,41 @@ const docTemplate = `{
                 "tags": [
                     "posts"
                 ],
-                "summary": "Create a new post in a topic",
+                "summary": "Update a post",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Topic ID to create post in",
+                        "description": "Post ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Post data to create. ID, TopicID, AuthorID, Username, CreatedAt, UpdatedAt will be ignored or overridden.",
-                        "name": "post",
+                        "description": "Post update data (only content)",
+                        "name": "post_update",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entity.Post"
+                            "$ref": "#/definitions/postrequests.UpdateRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the post from a previous GET, the update is rejected if the post has changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Post created successfully",
+                        "description": "Post updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/response.IDResponse"
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid topic ID or request payload, or topic not found",
+                        "description": "Invalid post ID or request payload",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -795,7 +1284,19 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden (user is not authorized)",
+                        "description": "Forbidden (user is not an owner, admin or moderator of the category)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Post has been modified since it was read",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -808,55 +1309,1382 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "categoryrequests.UpdateRequest": {
-            "type": "object",
-            "properties": {
-                "description": {
+        },
+        "/posts/{id}/reactions/{reaction}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds the caller's reaction to a post. Adding the same reaction twice has no effect. Requires authentication.",
+                "tags": [
+                    "posts"
+                ],
+                "summary": "React to a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "like",
+                            "dislike",
+                            "heart",
+                            "laugh",
+                            "wow",
+                            "sad",
+                            "fire"
+                        ],
+                        "type": "string",
+                        "description": "Reaction",
+                        "name": "reaction",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reaction added successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid post ID or unknown reaction",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes the caller's reaction from a post. Requires authentication.",
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Remove a reaction from a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "like",
+                            "dislike",
+                            "heart",
+                            "laugh",
+                            "wow",
+                            "sad",
+                            "fire"
+                        ],
+                        "type": "string",
+                        "description": "Reaction",
+                        "name": "reaction",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reaction removed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid post ID or unknown reaction",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/posts/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restores a deleted post. Requires admin role.",
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Restore a deleted post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Post restored successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid post ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an admin)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Deleted post not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/posts/{id}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves previous versions of a post, oldest first. Each revision holds the content before an edit and who made that edit. Requires authentication and ownership, admin role or moderator rights in the category.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Get the edit history of a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved revisions",
+                        "schema": {
+                            "$ref": "#/definitions/response.PostRevisionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid post ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an owner, admin or moderator of the category)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/posts/{id}/revisions/diff": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a line-level diff between two revisions of a post. Without \"to\" the revision is compared with the current content. Requires authentication and ownership, admin role or moderator rights in the category.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Compare two revisions of a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Revision ID to compare from",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Revision ID to compare to (defaults to the current content)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully compared revisions",
+                        "schema": {
+                            "$ref": "#/definitions/response.RevisionDiffResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid post or revision ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an owner, admin or moderator of the category)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post or revision not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/posts/{id}/thread": {
+            "get": {
+                "description": "Retrieves the chain of posts the given post replies to (root first) and the replies nested under it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Get the thread of a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max reply depth (default 5, max 20)",
+                        "name": "depth",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved thread",
+                        "schema": {
+                            "$ref": "#/definitions/response.PostThreadResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the post, send it back in If-Match when updating the post"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid post ID or depth",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Full-text search over topic titles and post contents. Results are ranked and contain highlighted snippets. Results from hidden categories are only returned to admins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search topics and posts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Only results from this category",
+                        "name": "category_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Only results by this author",
+                        "name": "author_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only results created at or after this time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only results created at or before this time (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Search results",
+                        "schema": {
+                            "$ref": "#/definitions/response.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Empty query or invalid filters",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/topics/{id}": {
+            "get": {
+                "description": "Retrieves a specific topic by its ID together with breadcrumbs: the category path from the root down to the topic's category.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Get a topic by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Topic ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved topic",
+                        "schema": {
+                            "$ref": "#/definitions/response.TopicResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the topic, send it back in If-Match when updating the topic"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid topic ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to get topic",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a topic by its ID. The topic and its posts are hidden and can be restored by an admin until they are purged. Requires authentication and ownership, admin role or moderator rights in the category.",
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Delete a topic",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Topic ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Topic deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid topic ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an owner, admin or moderator of the category)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Topic not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates a topic. Requires authentication and ownership, admin role or moderator rights in the category.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Update a topic",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Topic ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Topic update data (only title)",
+                        "name": "topic_update",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/topicrequests.UpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the topic from a previous GET, the update is rejected if the topic has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Topic updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid topic ID or request payload",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an owner, admin or moderator of the category)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Topic not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Topic has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/topics/{id}/posts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a list of posts for a topic ID. Each post carries reaction counts and, for authenticated callers, their own reactions. With view=tree the page is made of top-level posts with their replies nested under them (see response.PostTreeResponse). Topics in hidden categories are only visible to admins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Get posts by topic ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Topic ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "flat",
+                            "tree"
+                        ],
+                        "type": "string",
+                        "description": "Listing mode",
+                        "name": "view",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max reply depth in tree view (default 5, max 20)",
+                        "name": "depth",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor to fetch the page after (newer posts)",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor to fetch the page before (older posts)",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response, 304 is returned if it is still current",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of a previous response, 304 is returned if nothing has changed since",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved posts",
+                        "schema": {
+                            "$ref": "#/definitions/response.PostsResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the response"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last change, omitted if it was less than a second ago"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Invalid topic ID, view, depth, limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Topic not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new post in atopic. Requires authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Create a new post in a topic",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Topic ID to create post in",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Post data to create. ID, TopicID, AuthorID, Username, CreatedAt, UpdatedAt will be ignored or overridden.",
+                        "name": "post",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Post"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Post created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.IDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid topic ID or request payload, topic not found, or reply_to is not a post in this topic",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not authorized)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Topic is locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/topics/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restores a deleted topic together with its posts. Requires admin role.",
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Restore a deleted topic",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Topic ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Topic restored successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid topic ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an admin)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Deleted topic not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/topics/{id}/state": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes the pinned and locked flags of a topic. Omitted flags are left unchanged. Pinned topics are listed first in their category, locked topics do not accept new posts. Requires admin role or moderator rights in the topic's category.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Pin or lock a topic",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Topic ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Flags to change",
+                        "name": "topic_state",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/topicrequests.UpdateStateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Topic state updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.SuccessMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid topic ID or request payload",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (token is missing or invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (user is not an admin or moderator of the category)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Topic not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "categoryrequests.AddModeratorRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "categoryrequests.ReorderRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "categoryrequests.UpdateRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "hidden": {
+                    "type": "boolean"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "chatrequests.CreateRoomRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "entity.Breadcrumb": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Category": {
+            "type": "object",
+            "properties": {
+                "breadcrumbs": {
+                    "description": "Breadcrumbs holds the ancestors of the category, filled in only for a single category.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Breadcrumb"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "hidden": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_post": {
+                    "$ref": "#/definitions/entity.LastPost"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "post_count": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "topic_count": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CategoryModerator": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ChatMessage": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "edited_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "room_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ChatRoom": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "hidden": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "$ref": "#/definitions/entity.ChatRoomKind"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ChatRoomKind": {
+            "type": "string",
+            "enum": [
+                "general",
+                "category",
+                "custom"
+            ],
+            "x-enum-varnames": [
+                "ChatRoomGeneral",
+                "ChatRoomCategory",
+                "ChatRoomCustom"
+            ]
+        },
+        "entity.Conversation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_message": {
+                    "$ref": "#/definitions/entity.DirectMessage"
+                },
+                "peer_id": {
+                    "type": "integer"
+                },
+                "peer_username": {
+                    "type": "string"
+                },
+                "unread_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.DiffLine": {
+            "type": "object",
+            "properties": {
+                "op": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.DirectMessage": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "conversation_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "recipient_id": {
+                    "type": "integer"
+                },
+                "recipient_username": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "integer"
+                },
+                "sender_username": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.LastPost": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "title": {
+                "id": {
+                    "type": "integer"
+                },
+                "topic_id": {
+                    "type": "integer"
+                },
+                "topic_title": {
+                    "type": "string"
+                },
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "entity.Category": {
+        "entity.Post": {
             "type": "object",
             "properties": {
+                "author_id": {
+                    "type": "integer"
+                },
+                "content": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
-                "description": {
+                "deleted_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "title": {
-                    "type": "string"
+                "my_reactions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reactions": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "reply_to": {
+                    "type": "integer"
+                },
+                "topic_id": {
+                    "type": "integer"
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
-        "entity.Post": {
+        "entity.PostNode": {
             "type": "object",
             "properties": {
                 "author_id": {
                     "type": "integer"
                 },
+                "collapsed_count": {
+                    "type": "integer"
+                },
                 "content": {
                     "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "my_reactions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reactions": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "replies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.PostNode"
+                    }
+                },
                 "reply_to": {
                     "type": "integer"
                 },
@@ -871,12 +2699,113 @@ const docTemplate = `{
                 }
             }
         },
+        "entity.PostRevision": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "editor_id": {
+                    "type": "integer"
+                },
+                "editor_role": {
+                    "type": "string"
+                },
+                "editor_username": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "post_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.PostThread": {
+            "type": "object",
+            "properties": {
+                "ancestors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Post"
+                    }
+                },
+                "post": {
+                    "$ref": "#/definitions/entity.PostNode"
+                }
+            }
+        },
+        "entity.RevisionDiff": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.DiffLine"
+                    }
+                },
+                "post_id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.SearchResult": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "integer"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "topic_id": {
+                    "type": "integer"
+                },
+                "topic_title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "entity.Topic": {
             "type": "object",
             "properties": {
                 "author_id": {
                     "type": "integer"
                 },
+                "breadcrumbs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Breadcrumb"
+                    }
+                },
                 "category_id": {
                     "type": "integer"
                 },
@@ -886,6 +2815,27 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "last_activity_at": {
+                    "type": "string"
+                },
+                "last_post_at": {
+                    "type": "string"
+                },
+                "last_post_author": {
+                    "type": "string"
+                },
+                "last_post_author_id": {
+                    "type": "integer"
+                },
+                "locked": {
+                    "type": "boolean"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "reply_count": {
+                    "type": "integer"
+                },
                 "title": {
                     "type": "string"
                 },
@@ -913,6 +2863,10 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/entity.Category"
                     }
+                },
+                "degraded": {
+                    "type": "boolean",
+                    "example": false
                 }
             }
         },
@@ -921,6 +2875,62 @@ const docTemplate = `{
             "properties": {
                 "category": {
                     "$ref": "#/definitions/entity.Category"
+                },
+                "degraded": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "response.ChatMessagesResponse": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ChatMessage"
+                    }
+                }
+            }
+        },
+        "response.ChatRoomResponse": {
+            "type": "object",
+            "properties": {
+                "room": {
+                    "$ref": "#/definitions/entity.ChatRoom"
+                }
+            }
+        },
+        "response.ChatRoomsResponse": {
+            "type": "object",
+            "properties": {
+                "rooms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ChatRoom"
+                    }
+                }
+            }
+        },
+        "response.ConversationsResponse": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Conversation"
+                    }
+                }
+            }
+        },
+        "response.DirectMessagesResponse": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.DirectMessage"
+                    }
                 }
             }
         },
@@ -942,14 +2952,95 @@ const docTemplate = `{
                 }
             }
         },
+        "response.ModeratorsResponse": {
+            "type": "object",
+            "properties": {
+                "moderators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CategoryModerator"
+                    }
+                }
+            }
+        },
+        "response.PostResponse": {
+            "type": "object",
+            "properties": {
+                "degraded": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "post": {
+                    "$ref": "#/definitions/entity.Post"
+                }
+            }
+        },
+        "response.PostRevisionsResponse": {
+            "type": "object",
+            "properties": {
+                "revisions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.PostRevision"
+                    }
+                }
+            }
+        },
+        "response.PostThreadResponse": {
+            "type": "object",
+            "properties": {
+                "degraded": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "thread": {
+                    "$ref": "#/definitions/entity.PostThread"
+                }
+            }
+        },
         "response.PostsResponse": {
             "type": "object",
             "properties": {
+                "degraded": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "MTcxNjIzOTAyMjAwMDAwMDAwMDoxMg"
+                },
                 "posts": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/entity.Post"
                     }
+                },
+                "prev_cursor": {
+                    "type": "string",
+                    "example": ""
+                }
+            }
+        },
+        "response.RevisionDiffResponse": {
+            "type": "object",
+            "properties": {
+                "diff": {
+                    "$ref": "#/definitions/entity.RevisionDiff"
+                }
+            }
+        },
+        "response.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "degraded": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.SearchResult"
+                    }
                 }
             }
         },
@@ -965,6 +3056,10 @@ const docTemplate = `{
         "response.TopicResponse": {
             "type": "object",
             "properties": {
+                "degraded": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "topic": {
                     "$ref": "#/definitions/entity.Topic"
                 }
@@ -973,6 +3068,18 @@ const docTemplate = `{
         "response.TopicsResponse": {
             "type": "object",
             "properties": {
+                "degraded": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "MTcxNjIzOTAyMjAwMDAwMDAwMDoxMg"
+                },
+                "prev_cursor": {
+                    "type": "string",
+                    "example": ""
+                },
                 "topics": {
                     "type": "array",
                     "items": {
@@ -981,6 +3088,21 @@ const docTemplate = `{
                 }
             }
         },
+        "topicrequests.CreateRequest": {
+            "type": "object",
+            "required": [
+                "content",
+                "title"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
         "topicrequests.UpdateRequest": {
             "type": "object",
             "properties": {
@@ -988,6 +3110,17 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "topicrequests.UpdateStateRequest": {
+            "type": "object",
+            "properties": {
+                "locked": {
+                    "type": "boolean"
+                },
+                "pinned": {
+                    "type": "boolean"
+                }
+            }
         }
     }
 }`
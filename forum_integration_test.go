@@ -10,6 +10,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 	"time"
@@ -25,10 +26,13 @@ import (
 	"github.com/keshvan/forum-service-sstu-forum/internal/chat" // Для chat.Hub
 	"github.com/keshvan/forum-service-sstu-forum/internal/client"
 	"github.com/keshvan/forum-service-sstu-forum/internal/controller"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
 	categoryrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/category_requests"
 	postrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/post_requests"
 	topicrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/topic_requests"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/feed"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/forum-service-sstu-forum/internal/repo"
 	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/keshvan/forum-service-sstu-forum/mocks"
@@ -57,7 +61,8 @@ type CreateCategoryRequest struct {
 }
 
 type CreateTopicRequest struct {
-	Title string `json:"title"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
 }
 
 type CreatePostRequest struct {
@@ -156,11 +161,20 @@ func setupTestRouter(t *testing.T, cfg *config.Config, db *postgres.Postgres, jw
 	categoryRepo := repo.NewCategoryRepository(db, appLoggerZerolog) // Передаем *zerolog.Logger
 	topicRepo := repo.NewTopicRepository(db, appLoggerZerolog)
 	postRepo := repo.NewPostRepository(db, appLoggerZerolog)
+	reactionRepo := repo.NewReactionRepository(db, appLoggerZerolog)
+	moderatorRepo := repo.NewModeratorRepository(db, appLoggerZerolog)
+	searchRepo := repo.NewSearchRepository(db, appLoggerZerolog)
+	transactor := repo.NewTransactor(db, appLoggerZerolog)
+
+	responseCache := middleware.NewResponseCache(true, time.Minute)
+	postFeed := feed.New(appLoggerZerolog)
 
 	// Usecases
-	categoryUsecase := usecase.NewCategoryUsecase(categoryRepo, appLoggerZerolog)
-	topicUsecase := usecase.NewTopicUsecase(topicRepo, categoryRepo, userClient, appLoggerZerolog)
-	postUsecase := usecase.NewPostUsecase(postRepo, topicRepo, userClient, appLoggerZerolog)
+	accessPolicy := policy.New(moderatorRepo, topicRepo)
+	categoryUsecase := usecase.NewCategoryUsecase(categoryRepo, moderatorRepo, transactor, userClient, responseCache, appLoggerZerolog)
+	topicUsecase := usecase.NewTopicUsecase(topicRepo, categoryRepo, postRepo, transactor, userClient, accessPolicy, responseCache, postFeed, appLoggerZerolog)
+	postUsecase := usecase.NewPostUsecase(postRepo, topicRepo, categoryRepo, reactionRepo, transactor, userClient, accessPolicy, responseCache, postFeed, appLoggerZerolog)
+	searchUsecase := usecase.NewSearchUsecase(searchRepo, userClient, appLoggerZerolog)
 
 	var mockHub *chat.Hub = nil
 
@@ -169,7 +183,7 @@ func setupTestRouter(t *testing.T, cfg *config.Config, db *postgres.Postgres, jw
 	engine := gin.New()
 	engine.Use(gin.Recovery())
 
-	controller.SetRoutes(engine, categoryUsecase, topicUsecase, postUsecase, jwtService, appLoggerZerolog, mockHub, mockChatUsecase, userClient)
+	controller.SetRoutes(engine, categoryUsecase, topicUsecase, postUsecase, searchUsecase, jwtService, appLoggerZerolog, mockHub, mockChatUsecase, userClient, responseCache)
 
 	return engine
 }
@@ -261,7 +275,8 @@ func TestCategoryEndpoints(t *testing.T) {
 	})
 
 	t.Run("UpdateCategory_AdminOnly", func(t *testing.T) {
-		updateReq := categoryrequests.UpdateRequest{Title: "Updated Admin Category", Description: "Now updated by admin"}
+		title, description := "Updated Admin Category", "Now updated by admin"
+		updateReq := categoryrequests.UpdateRequest{Title: &title, Description: &description}
 		jsonData, _ := json.Marshal(updateReq)
 		resp := doRequest(t, server.URL, http.MethodPatch, fmt.Sprintf("/categories/%d", createdCategoryID), bytes.NewBuffer(jsonData), adminToken)
 		defer resp.Body.Close()
@@ -323,7 +338,7 @@ func TestTopicEndpoints(t *testing.T) {
 
 	var createdTopicID int64
 	t.Run("CreateTopic_AuthUser", func(t *testing.T) {
-		topicData := &CreateTopicRequest{Title: "test title"}
+		topicData := &CreateTopicRequest{Title: "test title", Content: "opening post"}
 		jsonData, _ := json.Marshal(topicData)
 
 		resp := doRequest(t, server.URL, http.MethodPost, fmt.Sprintf("/categories/%d/topics", testCategoryID), bytes.NewBuffer(jsonData), userToken)
@@ -431,7 +446,7 @@ func TestPostEndpoints(t *testing.T) {
 		testCategoryID = respDataCat["id"]
 		require.NotZero(t, testCategoryID)
 
-		topicData := &CreateTopicRequest{Title: "topic topic"}
+		topicData := &CreateTopicRequest{Title: "topic topic", Content: "opening post"}
 		jsonDataTopic, _ := json.Marshal(topicData)
 		respTopic := doRequest(t, server.URL, http.MethodPost, fmt.Sprintf("/categories/%d/topics", testCategoryID), bytes.NewBuffer(jsonDataTopic), userToken)
 		defer respTopic.Body.Close()
@@ -482,9 +497,10 @@ func TestPostEndpoints(t *testing.T) {
 		require.NoError(t, err)
 		posts, ok := respData["posts"]
 		require.True(t, ok)
-		require.Len(t, posts, 1)
-		assert.Equal(t, "test post post", posts[0].Content)
-		assert.Equal(t, "reguser", posts[0].Username)
+		require.Len(t, posts, 2)
+		assert.Equal(t, "opening post", posts[0].Content)
+		assert.Equal(t, "test post post", posts[1].Content)
+		assert.Equal(t, "reguser", posts[1].Username)
 	})
 
 	t.Run("UpdatePost_Owner", func(t *testing.T) {
@@ -509,4 +525,24 @@ func TestPostEndpoints(t *testing.T) {
 		require.NoError(t, err)
 		assert.Zero(t, count)
 	})
+
+	t.Run("SearchEscapesMarkup", func(t *testing.T) {
+		postData := &CreatePostRequest{Content: "<img src=x onerror=alert(1)> экзамен завтра"}
+		jsonData, _ := json.Marshal(postData)
+		resp := doRequest(t, server.URL, http.MethodPost, fmt.Sprintf("/topics/%d/posts", testTopicID), bytes.NewBuffer(jsonData), userToken)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		mockUserCl.On("GetUsernames", mock.Anything, mock.Anything).Return(map[int64]string{testUserIDRegular: "reguser"}, nil).Once()
+		resp = doRequest(t, server.URL, http.MethodGet, "/search?q="+url.QueryEscape("экзамен"), nil, "")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var respData map[string][]entity.SearchResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&respData))
+		require.Len(t, respData["results"], 1)
+		snippet := respData["results"][0].Snippet
+		assert.NotContains(t, snippet, "<img")
+		assert.Contains(t, snippet, "&lt;img")
+		assert.Contains(t, snippet, "<mark>экзамен</mark>")
+	})
 }
@@ -1,6 +1,8 @@
 package app
 
 import (
+	"context"
+	"expvar"
 	"log"
 	"os"
 	"os/signal"
@@ -10,6 +12,11 @@ import (
 	"github.com/keshvan/forum-service-sstu-forum/internal/chat"
 	"github.com/keshvan/forum-service-sstu-forum/internal/client"
 	"github.com/keshvan/forum-service-sstu-forum/internal/controller"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
+	"github.com/keshvan/forum-service-sstu-forum/internal/feed"
+	"github.com/keshvan/forum-service-sstu-forum/internal/grpcserver"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
+	"github.com/keshvan/forum-service-sstu-forum/internal/purge"
 	"github.com/keshvan/forum-service-sstu-forum/internal/repo"
 	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/keshvan/go-common-forum/httpserver"
@@ -33,19 +40,32 @@ func Run(cfg *config.Config) {
 	categoryRepo := repo.NewCategoryRepository(pg, logger)
 	topicRepo := repo.NewTopicRepository(pg, logger)
 	postRepo := repo.NewPostRepository(pg, logger)
+	reactionRepo := repo.NewReactionRepository(pg, logger)
+	moderatorRepo := repo.NewModeratorRepository(pg, logger)
+	searchRepo := repo.NewSearchRepository(pg, logger)
 	chatRepo := repo.NewChatRepository(pg, logger)
+	conversationRepo := repo.NewConversationRepository(pg, logger)
+	transactor := repo.NewTransactor(pg, logger)
 
 	//CLient
-	userClient, err := client.New(cfg.GrpcAddress, logger)
+	grpcUserClient, err := client.New(cfg.GrpcAddress, logger)
 	if err != nil {
 		log.Fatalf("app - Run - client.New: %v", err)
 	}
+	userClient := client.NewCachingUserClient(grpcUserClient, cfg.UserCacheTTL, cfg.UserCacheNegativeTTL, cfg.UserCacheSize)
 	defer userClient.Close()
+	expvar.Publish("user_cache", expvar.Func(func() any { return userClient.Stats() }))
+
+	//Response cache
+	responseCache := middleware.NewResponseCache(cfg.ResponseCache, cfg.ResponseCacheTTL)
 
 	//Usecase
-	categoryUsecase := usecase.NewCategoryUsecase(categoryRepo, logger)
-	topicUsecase := usecase.NewTopicUsecase(topicRepo, categoryRepo, userClient, logger)
-	postUsecase := usecase.NewPostUsecase(postRepo, topicRepo, userClient, logger)
+	accessPolicy := policy.New(moderatorRepo, topicRepo)
+	postFeed := feed.New(logger)
+	categoryUsecase := usecase.NewCategoryUsecase(categoryRepo, moderatorRepo, transactor, userClient, responseCache, logger)
+	topicUsecase := usecase.NewTopicUsecase(topicRepo, categoryRepo, postRepo, transactor, userClient, accessPolicy, responseCache, postFeed, logger)
+	postUsecase := usecase.NewPostUsecase(postRepo, topicRepo, categoryRepo, reactionRepo, transactor, userClient, accessPolicy, responseCache, postFeed, logger)
+	searchUsecase := usecase.NewSearchUsecase(searchRepo, userClient, logger)
 
 	//JWT
 	jwt := jwt.New(cfg.Secret, cfg.AccessTTL, cfg.RefreshTTL)
@@ -53,13 +73,27 @@ func Run(cfg *config.Config) {
 	//Chat
 	hub := chat.NewHub(logger)
 	go hub.Run()
-	chatUsecase := usecase.NewChatUsecase(chatRepo, logger)
+	chatUsecase := usecase.NewChatUsecase(chatRepo, conversationRepo, transactor, userClient, cfg.ChatEditWindow, logger)
+
+	//Purge
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	purger := purge.NewPurger(topicRepo, postRepo, cfg.PurgeRetention, cfg.PurgeInterval, responseCache.Invalidate, logger)
+	go purger.Run(ctx)
 
 	//HTTP-Server
 	httpServer := httpserver.New(cfg.Server)
-	controller.SetRoutes(httpServer.Engine, categoryUsecase, topicUsecase, postUsecase, jwt, logger, hub, chatUsecase, userClient)
+	controller.SetRoutes(httpServer.Engine, categoryUsecase, topicUsecase, postUsecase, searchUsecase, jwt, logger, hub, chatUsecase, userClient, responseCache)
 	httpServer.Run()
 
+	//gRPC-Server
+	grpcServer := grpcserver.New(cfg.GrpcServer, jwt, logger)
+	grpcServer.RegisterForum(categoryUsecase, topicUsecase, postUsecase, postFeed)
+	if err := grpcServer.Run(); err != nil {
+		log.Fatalf("app - Run - grpcServer.Run: %v", err)
+	}
+	defer grpcServer.Shutdown()
+
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 	<-interrupt
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -17,19 +19,38 @@ type Client struct {
 	send         chan []byte
 	UserID       int64
 	Username     string
+	Role         string
 	IsAuthorized bool
 	chatUsecase  usecase.ChatUsecase
+
+	// rooms the client has joined. ReadPump checks it before room commands, the hub
+	// drops deleted rooms from it.
+	roomsMu sync.Mutex
+	rooms   map[int64]bool
+
+	// since is the last message the client saw before reconnecting, 0 for a new session.
+	since int64
+	// replayed holds ids of replayed messages whose broadcast may still be queued, only
+	// used by the hub. It is cleared once the broadcasts queued at the replay are handled.
+	replayed map[int64]bool
+
+	// changes queues edits and deletions for forwardChanges, which hands them to the hub
+	// in order so a full broadcast queue does not stall ReadPump.
+	changes chan roomMessage
 }
 
-func NewAuthorizedClient(hub *Hub, conn *websocket.Conn, userID int64, username string, chatUsecase usecase.ChatUsecase) *Client {
+func NewAuthorizedClient(hub *Hub, conn *websocket.Conn, userID int64, username string, role string, chatUsecase usecase.ChatUsecase) *Client {
 	return &Client{
 		hub:          hub,
 		conn:         conn,
 		send:         make(chan []byte, 64),
 		UserID:       userID,
 		Username:     username,
+		Role:         role,
 		IsAuthorized: true,
 		chatUsecase:  chatUsecase,
+		rooms:        map[int64]bool{entity.GeneralChatRoomID: true},
+		changes:      make(chan roomMessage, changesBufferSize),
 	}
 }
 
@@ -40,14 +61,24 @@ func NewUnauthorizedClient(hub *Hub, conn *websocket.Conn, chatUsecase usecase.C
 		send:         make(chan []byte, 64),
 		IsAuthorized: false,
 		chatUsecase:  chatUsecase,
+		rooms:        map[int64]bool{entity.GeneralChatRoomID: true},
+		changes:      make(chan roomMessage, changesBufferSize),
 	}
 }
 
+// ResumeFrom makes the hub replay the general room messages after messageID on register
+// instead of sending the latest history. Must be called before the client is registered.
+func (c *Client) ResumeFrom(messageID int64) {
+	c.since = messageID
+}
+
 const (
 	writeWait      = 10 * time.Second
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 512
+
+	changesBufferSize = 16
 )
 
 var (
@@ -56,7 +87,9 @@ var (
 )
 
 func (c *Client) ReadPump() {
+	go c.forwardChanges()
 	defer func() {
+		close(c.changes)
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
@@ -82,30 +115,285 @@ func (c *Client) ReadPump() {
 			continue
 		}
 
-		if c.IsAuthorized {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			savedMessage, err := c.chatUsecase.SaveMessage(ctx, c.UserID, c.Username, incomingMessage.Content)
-			cancel()
+		switch incomingMessage.Type {
+		case "", entity.WsTypeSendMessage:
+			c.sendMessage(incomingMessage)
+		case entity.WsTypeJoinRoom:
+			c.joinRoom(incomingMessage.RoomID, incomingMessage.Since)
+		case entity.WsTypeLeaveRoom:
+			c.leaveRoom(incomingMessage.RoomID)
+		case entity.WsTypeSendDirect:
+			c.sendDirect(incomingMessage)
+		case entity.WsTypeLoadHistory:
+			c.loadHistory(incomingMessage)
+		case entity.WsTypeResume:
+			c.resume(incomingMessage)
+		case entity.WsTypeEdit, entity.WsTypeDelete:
+			c.modifyMessage(incomingMessage)
+		default:
+			c.sendErrorToClient("Unknown message type")
+		}
+	}
+}
 
-			if err != nil {
-				c.hub.log.Error().Err(err).Int64("user_id", c.UserID).Str("username", c.Username).Msg("Failed to save message")
-				c.sendErrorToClient("Failed to save message")
-				continue
-			}
+// sendMessage saves a message and broadcasts it to the room. Messages without a room
+// go to the general room.
+func (c *Client) sendMessage(incomingMessage entity.IncomingWsMessage) {
+	if !c.IsAuthorized {
+		c.sendErrorToClient("Отправка сообщений доступна только авторизованным пользователям")
+		return
+	}
 
-			wsMsg := entity.WsMessage{
-				Type:    "new_message",
-				Payload: savedMessage,
-			}
+	roomID := incomingMessage.RoomID
+	if roomID == 0 {
+		roomID = entity.GeneralChatRoomID
+	}
+	if !c.inRoom(roomID) {
+		c.sendErrorToClient("Join the room before sending messages")
+		return
+	}
 
-			select {
-			case c.hub.broadcast <- wsMsg:
-			default:
-				c.hub.log.Warn().Int64("user_id", c.UserID).Str("username", c.Username).Msg("Failed to send message to broadcast")
-			}
-		} else {
-			c.sendErrorToClient("Отправка сообщений доступна только авторизованным пользователям")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	savedMessage, err := c.chatUsecase.SaveMessage(ctx, roomID, c.UserID, c.Username, incomingMessage.Content)
+	cancel()
+
+	if err != nil {
+		c.hub.log.Error().Err(err).Int64("user_id", c.UserID).Str("username", c.Username).Msg("Failed to save message")
+		c.sendErrorToClient("Failed to save message")
+		return
+	}
+
+	wsMsg := entity.WsMessage{
+		Type:    entity.WsTypeNewMessage,
+		Payload: savedMessage,
+	}
+
+	select {
+	case c.hub.broadcast <- roomMessage{roomID: roomID, messageID: savedMessage.ID, message: wsMsg}:
+	default:
+		c.hub.log.Warn().Int64("user_id", c.UserID).Str("username", c.Username).Msg("Failed to send message to broadcast")
+	}
+}
+
+// sendDirect saves a private message and delivers it to every connection of the sender
+// and the recipient.
+func (c *Client) sendDirect(incomingMessage entity.IncomingWsMessage) {
+	if !c.IsAuthorized {
+		c.sendErrorToClient("Личные сообщения доступны только авторизованным пользователям")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	savedMessage, err := c.chatUsecase.SendDirectMessage(ctx, c.UserID, c.Username, incomingMessage.RecipientID, incomingMessage.Content)
+	cancel()
+
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrRecipientNotFound):
+			c.sendErrorToClient("Recipient not found")
+		case errors.Is(err, usecase.ErrInvalidRecipient):
+			c.sendErrorToClient("Cannot send a message to yourself")
+		default:
+			c.hub.log.Error().Err(err).Int64("user_id", c.UserID).Int64("recipient_id", incomingMessage.RecipientID).Msg("Failed to send direct message")
+			c.sendErrorToClient("Failed to send message")
+		}
+		return
+	}
+
+	wsMsg := entity.WsMessage{
+		Type:    entity.WsTypeDirectMessage,
+		Payload: savedMessage,
+	}
+
+	select {
+	case c.hub.direct <- directMessage{userIDs: []int64{c.UserID, incomingMessage.RecipientID}, message: wsMsg}:
+	default:
+		c.hub.log.Warn().Int64("user_id", c.UserID).Str("username", c.Username).Msg("Failed to send direct message to hub")
+	}
+}
+
+// modifyMessage edits or deletes a chat message and tells the members of its room.
+func (c *Client) modifyMessage(incomingMessage entity.IncomingWsMessage) {
+	if !c.IsAuthorized {
+		c.sendErrorToClient("Изменение сообщений доступно только авторизованным пользователям")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	var message *entity.ChatMessage
+	var err error
+	wsType := entity.WsTypeEdited
+	if incomingMessage.Type == entity.WsTypeEdit {
+		message, err = c.chatUsecase.EditMessage(ctx, incomingMessage.MessageID, c.UserID, c.Role, incomingMessage.Content)
+	} else {
+		wsType = entity.WsTypeDeleted
+		message, err = c.chatUsecase.DeleteMessage(ctx, incomingMessage.MessageID, c.UserID, c.Role)
+	}
+	cancel()
+
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrChatMessageNotFound):
+			c.sendErrorToClient("Message not found")
+		case errors.Is(err, usecase.ErrForbidden):
+			c.sendErrorToClient("Not allowed to change this message")
+		case errors.Is(err, usecase.ErrEmptyChatMessage):
+			c.sendErrorToClient("Message cannot be empty")
+		default:
+			c.hub.log.Error().Err(err).Int64("user_id", c.UserID).Int64("message_id", incomingMessage.MessageID).Msg("Failed to change message")
+			c.sendErrorToClient("Failed to change message")
+		}
+		return
+	}
+
+	select {
+	case c.changes <- roomMessage{roomID: message.RoomID, message: entity.WsMessage{Type: wsType, Payload: message}}:
+	default:
+		c.hub.log.Error().Int64("user_id", c.UserID).Int64("message_id", message.ID).Msg("Failed to queue message change")
+		c.sendErrorToClient("Message changed, but the room could not be notified")
+	}
+}
+
+// forwardChanges hands queued message changes to the hub until ReadPump closes changes.
+// The changes are already saved, so it waits for room in the broadcast queue rather than
+// leave the room with the old content.
+func (c *Client) forwardChanges() {
+	for rm := range c.changes {
+		select {
+		case c.hub.broadcast <- rm:
+		case <-time.After(writeWait):
+			c.hub.log.Error().Int64("user_id", c.UserID).Int64("room_id", rm.roomID).Msg("Failed to send message change to broadcast")
+			c.sendErrorToClient("Message changed, but the room could not be notified")
+		}
+	}
+}
+
+// loadHistory sends the client a page of messages of a room it is a member of, older
+// than the message with id Before.
+func (c *Client) loadHistory(incomingMessage entity.IncomingWsMessage) {
+	roomID := incomingMessage.RoomID
+	if roomID == 0 {
+		roomID = entity.GeneralChatRoomID
+	}
+	if !c.inRoom(roomID) {
+		c.sendErrorToClient("Join the room before loading its history")
+		return
+	}
+
+	limit := incomingMessage.Limit
+	if limit <= 0 {
+		limit = historySize
+	}
+	limit = min(limit, maxHistorySize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	messages, err := c.chatUsecase.GetMessageHistory(ctx, roomID, incomingMessage.Before, limit)
+	cancel()
+
+	if err != nil {
+		c.hub.log.Error().Err(err).Int64("user_id", c.UserID).Int64("room_id", roomID).Msg("Failed to load history")
+		c.sendErrorToClient("Failed to load history")
+		return
+	}
+
+	c.hub.reply <- reply{client: c, message: entity.WsMessage{
+		Type:    entity.WsTypeHistory,
+		Payload: entity.ChatHistory{RoomID: roomID, Messages: messages},
+	}}
+}
+
+// sendLatestHistory sends the client the latest messages of a room in one history
+// message. The hub starts it in a goroutine of its own once the client is in the room,
+// so the history follows room_joined and the hub does not wait for the database.
+func (c *Client) sendLatestHistory(roomID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	messages, err := c.chatUsecase.GetMessageHistory(ctx, roomID, 0, historySize)
+	cancel()
+
+	if err != nil {
+		c.hub.log.Error().Err(err).Int64("user_id", c.UserID).Int64("room_id", roomID).Msg("Failed to get message history")
+		return
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	c.hub.reply <- reply{client: c, message: entity.WsMessage{
+		Type:    entity.WsTypeHistory,
+		Payload: entity.ChatHistory{RoomID: roomID, Messages: messages},
+	}}
+}
+
+// readMissedMessages reads the messages of a room after since for a replay and hands
+// them to the hub. The hub starts it in a goroutine of its own.
+func (c *Client) readMissedMessages(roomID int64, since int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), replayTimeout)
+	messages, err := c.chatUsecase.GetMissedMessages(ctx, roomID, since, maxMissedMessages)
+	cancel()
+
+	c.hub.replays <- replayResult{client: c, roomID: roomID, messages: messages, err: err}
+}
+
+// resume replays the messages of a joined room after the message with id Since.
+func (c *Client) resume(incomingMessage entity.IncomingWsMessage) {
+	roomID := incomingMessage.RoomID
+	if roomID == 0 {
+		roomID = entity.GeneralChatRoomID
+	}
+	if !c.inRoom(roomID) {
+		c.sendErrorToClient("Join the room before resuming it")
+		return
+	}
+	if incomingMessage.Since <= 0 {
+		c.sendErrorToClient("Invalid since message id")
+		return
+	}
+
+	c.hub.resume <- resumeRequest{client: c, roomID: roomID, since: incomingMessage.Since}
+}
+
+func (c *Client) joinRoom(roomID int64, since int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	room, err := c.chatUsecase.GetRoom(ctx, roomID, c.Role)
+	cancel()
+
+	if err != nil {
+		if errors.Is(err, usecase.ErrChatRoomNotFound) {
+			c.sendErrorToClient("Room not found")
+			return
 		}
+		c.hub.log.Error().Err(err).Int64("user_id", c.UserID).Int64("room_id", roomID).Msg("Failed to get room")
+		c.sendErrorToClient("Failed to join room")
+		return
+	}
+
+	c.setRoom(room.ID, true)
+	c.hub.membership <- membership{client: c, room: room, since: since}
+}
+
+func (c *Client) leaveRoom(roomID int64) {
+	if !c.inRoom(roomID) {
+		c.sendErrorToClient("Not a member of the room")
+		return
+	}
+
+	c.setRoom(roomID, false)
+	c.hub.membership <- membership{client: c, room: &entity.ChatRoom{ID: roomID}, leave: true}
+}
+
+func (c *Client) inRoom(roomID int64) bool {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+	return c.rooms[roomID]
+}
+
+func (c *Client) setRoom(roomID int64, member bool) {
+	c.roomsMu.Lock()
+	defer c.roomsMu.Unlock()
+	if member {
+		c.rooms[roomID] = true
+	} else {
+		delete(c.rooms, roomID)
 	}
 }
 
@@ -155,17 +443,8 @@ func (c *Client) WritePump() {
 
 }
 
+// sendErrorToClient answers a command with an error. It goes through the hub, which owns
+// the send channel and closes it when the client is removed.
 func (c *Client) sendErrorToClient(errorMsg string) {
-	errMsg := entity.WsMessage{Type: "error", Payload: errorMsg}
-
-	errorBytes, err := json.Marshal(errMsg)
-	if err != nil {
-		c.hub.log.Error().Err(err).Int64("user_id", c.UserID).Str("username", c.Username).Msg("Failed to marshal error")
-		return
-	}
-	select {
-	case c.send <- errorBytes:
-	default:
-		c.hub.log.Warn().Int64("user_id", c.UserID).Str("username", c.Username).Msg("Failed to send error to client")
-	}
+	c.hub.reply <- reply{client: c, message: entity.WsMessage{Type: entity.WsTypeError, Payload: errorMsg}}
 }
@@ -1,11 +1,12 @@
 package chat
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/rs/zerolog"
 )
 
@@ -13,26 +14,123 @@ const (
 	broadcastBufferSize  = 32
 	registerBufferSize   = 8
 	unregisterBufferSize = 8
+	membershipBufferSize = 8
+	directBufferSize     = 32
+	replyBufferSize      = 32
+	replayBufferSize     = 8
+
+	historySize    = 20
+	maxHistorySize = 100
+
+	// maxMissedMessages is the most messages replayed to a reconnecting client, beyond it
+	// the client gets gap_too_large and the latest history instead.
+	maxMissedMessages = 100
+
+	// replayTimeout bounds the missed messages query.
+	replayTimeout = 5 * time.Second
 )
 
+// roomMessage is a message for the members of a room. messageID is set for chat
+// messages, it lets the hub skip messages a client already got in a replay.
+type roomMessage struct {
+	roomID    int64
+	messageID int64
+	message   entity.WsMessage
+}
+
+// membership joins a client to a room or makes it leave. Joins and leaves share a
+// channel so that the hub sees them in the order the client sent them. A join with
+// since replays the messages after it instead of sending the latest history.
+type membership struct {
+	client *Client
+	room   *entity.ChatRoom
+	since  int64
+	leave  bool
+}
+
+// resumeRequest asks the hub to replay the messages of a joined room after since.
+type resumeRequest struct {
+	client *Client
+	roomID int64
+	since  int64
+}
+
+// replayResult carries the missed messages read for a client back to the hub.
+type replayResult struct {
+	client   *Client
+	roomID   int64
+	messages []entity.ChatMessage
+	err      error
+}
+
+// heldBroadcast is a broadcast kept back from a client until its replay of the room is sent.
+type heldBroadcast struct {
+	messageID int64
+	data      []byte
+}
+
+// directMessage is a private message for every connection of the listed users.
+type directMessage struct {
+	userIDs []int64
+	message entity.WsMessage
+}
+
+// reply is a message for a single client, such as an answer to one of its commands.
+type reply struct {
+	client  *Client
+	message entity.WsMessage
+}
+
+// Hub tracks connected clients and the rooms they are in. Every client joins the
+// general room on register, messages are only delivered to the members of their room.
+// Private messages go to all connections of the sender and the recipient.
 type Hub struct {
 	clients    map[*Client]bool
-	broadcast  chan entity.WsMessage
+	rooms      map[int64]map[*Client]bool
+	users      map[int64]map[*Client]bool
+	broadcast  chan roomMessage
+	direct     chan directMessage
+	reply      chan reply
+	resume     chan resumeRequest
+	replays    chan replayResult
 	Register   chan *Client
 	unregister chan *Client
-	log        *zerolog.Logger
+	membership chan membership
+	closeRoom  chan int64
+	// pending holds, per client and room with a replay being read, the broadcasts of the
+	// room kept back until the replay is sent.
+	pending map[*Client]map[int64][]heldBroadcast
+	// replaying counts, per client with replayed messages, the broadcasts that were
+	// queued at its replay and are yet to be handled.
+	replaying map[*Client]int
+	log       *zerolog.Logger
 }
 
 func NewHub(log *zerolog.Logger) *Hub {
 	return &Hub{
-		broadcast:  make(chan entity.WsMessage, broadcastBufferSize),
+		broadcast:  make(chan roomMessage, broadcastBufferSize),
+		direct:     make(chan directMessage, directBufferSize),
+		reply:      make(chan reply, replyBufferSize),
+		resume:     make(chan resumeRequest, membershipBufferSize),
+		replays:    make(chan replayResult, replayBufferSize),
 		Register:   make(chan *Client, registerBufferSize),
 		unregister: make(chan *Client, unregisterBufferSize),
+		membership: make(chan membership, membershipBufferSize),
+		closeRoom:  make(chan int64, membershipBufferSize),
 		clients:    make(map[*Client]bool),
+		rooms:      make(map[int64]map[*Client]bool),
+		users:      make(map[int64]map[*Client]bool),
+		pending:    make(map[*Client]map[int64][]heldBroadcast),
+		replaying:  make(map[*Client]int),
 		log:        log,
 	}
 }
 
+// CloseRoom tells the members of a deleted room that it is gone and removes them from it.
+func (h *Hub) CloseRoom(roomID int64) {
+	h.closeRoom <- roomID
+}
+
 func (h *Hub) Run() {
 	log := h.log.With().Str("component", "chat.Hub").Logger()
 	log.Info().Msg("Starting chat hub")
@@ -40,51 +138,269 @@ func (h *Hub) Run() {
 	for {
 		select {
 		case client := <-h.Register:
-			h.clients[client] = true
-			messages, err := client.chatUsecase.GetMessageHistory(context.Background(), 20)
-			if err != nil {
-				log.Error().Err(err).Msg("Failed to get message history")
+			h.register(&log, client)
+
+		case client := <-h.unregister:
+			if _, ok := h.clients[client]; ok {
+				h.remove(client)
+				log.Info().Int64("user_id", client.UserID).Str("username", client.Username).Bool("is_authenticated", client.IsAuthorized).Int64("total_clients", int64(len(h.clients))).Msg("Client unregistered")
+			}
+
+		case m := <-h.membership:
+			// The client may still be waiting in Register, select does not keep the order
+			// between channels.
+			h.registerPending(&log)
+			if !h.clients[m.client] {
 				continue
 			}
+			if m.leave {
+				h.removeMember(m.room.ID, m.client)
+				h.dropPending(m.client, m.room.ID)
+				h.send(&log, m.client, entity.WsMessage{Type: entity.WsTypeRoomLeft, Payload: roomPayload(m.room.ID)})
+				continue
+			}
+			h.addMember(m.room.ID, m.client)
+			h.send(&log, m.client, entity.WsMessage{Type: entity.WsTypeRoomJoined, Payload: m.room})
+			if h.clients[m.client] {
+				h.catchUp(&log, m.client, m.room.ID, m.since)
+			}
 
-			log.Info().Int64("user_id", client.UserID).Str("username", client.Username).Bool("is_authenticated", client.IsAuthorized).Int64("total_clients", int64(len(h.clients))).Msg("Client registered")
+		case r := <-h.resume:
+			h.registerPending(&log)
+			if h.rooms[r.roomID][r.client] {
+				h.replay(r.client, r.roomID, r.since)
+			}
 
-			for _, message := range messages {
-				wsMsg := &entity.WsMessage{
-					Type:    "new_message",
-					Payload: message,
-				}
+		case r := <-h.replays:
+			h.finishReplay(&log, r)
 
-				messageBytes, err := json.Marshal(wsMsg)
-				if err != nil {
-					log.Error().Err(err).Msg("Failed to marshal message")
-					continue
+		case roomID := <-h.closeRoom:
+			for client := range h.rooms[roomID] {
+				client.setRoom(roomID, false)
+				h.dropPending(client, roomID)
+				h.send(&log, client, entity.WsMessage{Type: entity.WsTypeRoomDeleted, Payload: roomPayload(roomID)})
+			}
+			delete(h.rooms, roomID)
+
+		case dm := <-h.direct:
+			h.registerPending(&log)
+			for _, userID := range dm.userIDs {
+				for client := range h.users[userID] {
+					h.send(&log, client, dm.message)
 				}
-				<-time.After(10 * time.Millisecond)
-				client.send <- messageBytes
 			}
 
-		case client := <-h.unregister:
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-				log.Info().Int64("user_id", client.UserID).Str("username", client.Username).Bool("is_authenticated", client.IsAuthorized).Int64("total_clients", int64(len(h.clients))).Msg("Client unregistered")
+		case r := <-h.reply:
+			h.registerPending(&log)
+			if h.clients[r.client] {
+				h.send(&log, r.client, r.message)
 			}
-		case message := <-h.broadcast:
-			messageBytes, err := json.Marshal(message)
-			log.Info().Msg(string(messageBytes))
+
+		case rm := <-h.broadcast:
+			h.registerPending(&log)
+			messageBytes, err := json.Marshal(rm.message)
 			if err != nil {
 				log.Error().Err(err).Msg("Failed to marshal message")
+				h.drainReplays()
 				continue
 			}
-			for client := range h.clients {
+			log.Debug().Int64("room_id", rm.roomID).Str("type", rm.message.Type).Msg("Broadcasting message")
+			for client := range h.rooms[rm.roomID] {
+				if client.replayed[rm.messageID] {
+					delete(client.replayed, rm.messageID)
+					continue
+				}
+				if held, ok := h.pending[client][rm.roomID]; ok {
+					if len(held) >= maxMissedMessages {
+						h.remove(client)
+						continue
+					}
+					h.pending[client][rm.roomID] = append(held, heldBroadcast{messageID: rm.messageID, data: messageBytes})
+					continue
+				}
 				select {
 				case client.send <- messageBytes:
 				default:
-					close(client.send)
-					delete(h.clients, client)
+					h.remove(client)
 				}
 			}
+			h.drainReplays()
+		}
+	}
+}
+
+func (h *Hub) register(log *zerolog.Logger, client *Client) {
+	h.clients[client] = true
+	h.addMember(entity.GeneralChatRoomID, client)
+	if client.IsAuthorized {
+		if h.users[client.UserID] == nil {
+			h.users[client.UserID] = make(map[*Client]bool)
+		}
+		h.users[client.UserID][client] = true
+	}
+
+	log.Info().Int64("user_id", client.UserID).Str("username", client.Username).Bool("is_authenticated", client.IsAuthorized).Int64("total_clients", int64(len(h.clients))).Msg("Client registered")
+	h.catchUp(log, client, entity.GeneralChatRoomID, client.since)
+}
+
+func (h *Hub) registerPending(log *zerolog.Logger) {
+	for {
+		select {
+		case client := <-h.Register:
+			h.register(log, client)
+		default:
+			return
+		}
+	}
+}
+
+func (h *Hub) addMember(roomID int64, client *Client) {
+	members, ok := h.rooms[roomID]
+	if !ok {
+		members = make(map[*Client]bool)
+		h.rooms[roomID] = members
+	}
+	members[client] = true
+}
+
+func (h *Hub) removeMember(roomID int64, client *Client) {
+	delete(h.rooms[roomID], client)
+	if len(h.rooms[roomID]) == 0 {
+		delete(h.rooms, roomID)
+	}
+}
+
+// remove drops a client from the hub and all its rooms and closes its send channel.
+func (h *Hub) remove(client *Client) {
+	for roomID := range h.rooms {
+		h.removeMember(roomID, client)
+	}
+	if client.IsAuthorized {
+		delete(h.users[client.UserID], client)
+		if len(h.users[client.UserID]) == 0 {
+			delete(h.users, client.UserID)
 		}
 	}
+	delete(h.clients, client)
+	delete(h.pending, client)
+	delete(h.replaying, client)
+	close(client.send)
+}
+
+// send queues a message for a single client, a client that cannot keep up is dropped.
+func (h *Hub) send(log *zerolog.Logger, client *Client, message entity.WsMessage) {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal message")
+		return
+	}
+	select {
+	case client.send <- messageBytes:
+	default:
+		h.remove(client)
+	}
+}
+
+// catchUp sends a client that joined a room what it has not seen yet: the messages after
+// since when it resumes, the latest history otherwise. The history is read off the hub
+// goroutine and comes back through reply.
+func (h *Hub) catchUp(log *zerolog.Logger, client *Client, roomID int64, since int64) {
+	if since != 0 {
+		h.replay(client, roomID, since)
+		return
+	}
+	go client.sendLatestHistory(roomID)
+}
+
+// replay starts reading the messages of a room after since for a client. The query runs
+// off the hub goroutine and its result comes back through replays; until then the
+// broadcasts of the room are kept back from the client, so they follow the replay. A
+// replay already being read for the room covers the new request.
+func (h *Hub) replay(client *Client, roomID int64, since int64) {
+	if _, ok := h.pending[client][roomID]; ok {
+		return
+	}
+	if h.pending[client] == nil {
+		h.pending[client] = make(map[int64][]heldBroadcast)
+	}
+	h.pending[client][roomID] = nil
+	go client.readMissedMessages(roomID, since)
+}
+
+// finishReplay sends a client the messages replayed for a room in one resumed message,
+// followed by the broadcasts kept back meanwhile that are not in it. Broadcasts still
+// queued may repeat replayed messages too, those are skipped for the client when they
+// are handled. The replay starts a little before since, clients drop the messages they
+// already have by id.
+func (h *Hub) finishReplay(log *zerolog.Logger, r replayResult) {
+	held, ok := h.pending[r.client][r.roomID]
+	if !ok {
+		// The client left the room or disconnected while the replay was read.
+		return
+	}
+	h.dropPending(r.client, r.roomID)
+
+	replayed := make(map[int64]bool, len(r.messages))
+	switch {
+	case errors.Is(r.err, usecase.ErrHistoryGapTooLarge):
+		h.send(log, r.client, entity.WsMessage{Type: entity.WsTypeGapTooLarge, Payload: roomPayload(r.roomID)})
+		if h.clients[r.client] {
+			go r.client.sendLatestHistory(r.roomID)
+		}
+	case r.err != nil:
+		log.Error().Err(r.err).Int64("room_id", r.roomID).Msg("Failed to get missed messages")
+	default:
+		for _, message := range r.messages {
+			replayed[message.ID] = true
+		}
+		h.send(log, r.client, entity.WsMessage{Type: entity.WsTypeResumed, Payload: entity.ChatHistory{RoomID: r.roomID, Messages: r.messages}})
+	}
+
+	for _, b := range held {
+		if !h.clients[r.client] {
+			return
+		}
+		if b.messageID != 0 && replayed[b.messageID] {
+			continue
+		}
+		select {
+		case r.client.send <- b.data:
+		default:
+			h.remove(r.client)
+		}
+	}
+
+	if queued := len(h.broadcast); queued > 0 && len(replayed) > 0 && h.clients[r.client] {
+		if r.client.replayed == nil {
+			r.client.replayed = make(map[int64]bool)
+		}
+		for id := range replayed {
+			r.client.replayed[id] = true
+		}
+		h.replaying[r.client] = max(h.replaying[r.client], queued)
+	}
+}
+
+// dropPending stops keeping back the broadcasts of a room for a client.
+func (h *Hub) dropPending(client *Client, roomID int64) {
+	delete(h.pending[client], roomID)
+	if len(h.pending[client]) == 0 {
+		delete(h.pending, client)
+	}
+}
+
+// drainReplays counts a handled broadcast against the replays and forgets the replayed
+// ids of clients whose queued broadcasts have all been handled.
+func (h *Hub) drainReplays() {
+	for client := range h.replaying {
+		h.replaying[client]--
+		if h.replaying[client] <= 0 {
+			client.replayed = nil
+			delete(h.replaying, client)
+		}
+	}
+}
+
+func roomPayload(roomID int64) map[string]int64 {
+	return map[string]int64{"room_id": roomID}
 }
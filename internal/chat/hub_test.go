@@ -0,0 +1,312 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/keshvan/forum-service-sstu-forum/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(hub *Hub, chatUsecase *mocks.ChatUsecase) *Client {
+	return NewUnauthorizedClient(hub, nil, chatUsecase)
+}
+
+func receive(t *testing.T, client *Client) entity.WsMessage {
+	t.Helper()
+	select {
+	case data := <-client.send:
+		var msg entity.WsMessage
+		require.NoError(t, json.Unmarshal(data, &msg))
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return entity.WsMessage{}
+	}
+}
+
+func assertNothingReceived(t *testing.T, client *Client) {
+	t.Helper()
+	select {
+	case data := <-client.send:
+		t.Fatalf("unexpected message %s", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHub_RoutesMessagesToRoomMembers(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(&logger)
+	go hub.Run()
+
+	chatUsecase := mocks.NewChatUsecase(t)
+	chatUsecase.On("GetMessageHistory", mock.Anything, mock.Anything, int64(0), int64(historySize)).Return(nil, nil).Maybe()
+
+	alice := newTestClient(hub, chatUsecase)
+	bob := newTestClient(hub, chatUsecase)
+	hub.Register <- alice
+	hub.Register <- bob
+
+	room := &entity.ChatRoom{ID: 5, Kind: entity.ChatRoomCustom, Name: "Экзамены"}
+	hub.membership <- membership{client: alice, room: room}
+	assert.Equal(t, entity.WsTypeRoomJoined, receive(t, alice).Type)
+
+	hub.broadcast <- roomMessage{roomID: room.ID, message: entity.WsMessage{Type: entity.WsTypeNewMessage, Payload: "in room"}}
+	assert.Equal(t, "in room", receive(t, alice).Payload)
+	assertNothingReceived(t, bob)
+
+	hub.broadcast <- roomMessage{roomID: entity.GeneralChatRoomID, message: entity.WsMessage{Type: entity.WsTypeNewMessage, Payload: "general"}}
+	assert.Equal(t, "general", receive(t, alice).Payload)
+	assert.Equal(t, "general", receive(t, bob).Payload)
+
+	hub.membership <- membership{client: alice, room: room, leave: true}
+	assert.Equal(t, entity.WsTypeRoomLeft, receive(t, alice).Type)
+	hub.broadcast <- roomMessage{roomID: room.ID, message: entity.WsMessage{Type: entity.WsTypeNewMessage, Payload: "in room"}}
+	assertNothingReceived(t, alice)
+}
+
+func TestHub_CloseRoom(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(&logger)
+	go hub.Run()
+
+	chatUsecase := mocks.NewChatUsecase(t)
+	chatUsecase.On("GetMessageHistory", mock.Anything, mock.Anything, int64(0), int64(historySize)).Return(nil, nil).Maybe()
+
+	alice := newTestClient(hub, chatUsecase)
+	hub.Register <- alice
+	room := &entity.ChatRoom{ID: 5, Kind: entity.ChatRoomCustom, Name: "Экзамены"}
+	hub.membership <- membership{client: alice, room: room}
+	receive(t, alice)
+
+	hub.CloseRoom(room.ID)
+	msg := receive(t, alice)
+	assert.Equal(t, entity.WsTypeRoomDeleted, msg.Type)
+	assert.Equal(t, map[string]any{"room_id": float64(5)}, msg.Payload)
+
+	// The client has to join again before it can send to the room.
+	assert.False(t, alice.inRoom(room.ID))
+	assert.True(t, alice.inRoom(entity.GeneralChatRoomID))
+}
+
+func TestHub_DeliversDirectMessagesToAllConnections(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(&logger)
+	go hub.Run()
+
+	chatUsecase := mocks.NewChatUsecase(t)
+	chatUsecase.On("GetMessageHistory", mock.Anything, mock.Anything, int64(0), int64(historySize)).Return(nil, nil).Maybe()
+
+	aliceTab1 := NewAuthorizedClient(hub, nil, 1, "alice", "user", chatUsecase)
+	aliceTab2 := NewAuthorizedClient(hub, nil, 1, "alice", "user", chatUsecase)
+	bob := NewAuthorizedClient(hub, nil, 2, "bob", "user", chatUsecase)
+	carol := NewAuthorizedClient(hub, nil, 3, "carol", "user", chatUsecase)
+	anonymous := newTestClient(hub, chatUsecase)
+	for _, client := range []*Client{aliceTab1, aliceTab2, bob, carol, anonymous} {
+		hub.Register <- client
+	}
+
+	hub.direct <- directMessage{userIDs: []int64{1, 2}, message: entity.WsMessage{Type: entity.WsTypeDirectMessage, Payload: "hi"}}
+	for _, client := range []*Client{aliceTab1, aliceTab2, bob} {
+		msg := receive(t, client)
+		assert.Equal(t, entity.WsTypeDirectMessage, msg.Type)
+		assert.Equal(t, "hi", msg.Payload)
+	}
+	assertNothingReceived(t, carol)
+	assertNothingReceived(t, anonymous)
+
+	hub.unregister <- aliceTab1
+	hub.direct <- directMessage{userIDs: []int64{2, 1}, message: entity.WsMessage{Type: entity.WsTypeDirectMessage, Payload: "reply"}}
+	assert.Equal(t, "reply", receive(t, aliceTab2).Payload)
+	assert.Equal(t, "reply", receive(t, bob).Payload)
+}
+
+func TestClient_LoadHistory(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(&logger)
+	go hub.Run()
+
+	chatUsecase := mocks.NewChatUsecase(t)
+	chatUsecase.On("GetMessageHistory", mock.Anything, mock.Anything, int64(0), int64(historySize)).Return(nil, nil).Maybe()
+	older := []entity.ChatMessage{{ID: 3, RoomID: entity.GeneralChatRoomID, Content: "older"}}
+	chatUsecase.On("GetMessageHistory", mock.Anything, entity.GeneralChatRoomID, int64(40), int64(maxHistorySize)).Return(older, nil).Once()
+
+	alice := newTestClient(hub, chatUsecase)
+	hub.Register <- alice
+
+	alice.loadHistory(entity.IncomingWsMessage{Type: entity.WsTypeLoadHistory, Before: 40, Limit: 1000})
+	msg := receive(t, alice)
+	assert.Equal(t, entity.WsTypeHistory, msg.Type)
+	payload, _ := json.Marshal(msg.Payload)
+	var history entity.ChatHistory
+	require.NoError(t, json.Unmarshal(payload, &history))
+	assert.Equal(t, entity.GeneralChatRoomID, history.RoomID)
+	assert.Equal(t, "older", history.Messages[0].Content)
+
+	alice.loadHistory(entity.IncomingWsMessage{Type: entity.WsTypeLoadHistory, RoomID: 5})
+	assert.Equal(t, entity.WsTypeError, receive(t, alice).Type)
+}
+
+func TestHub_ResumeReplaysMissedMessages(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(&logger)
+	go hub.Run()
+
+	chatUsecase := mocks.NewChatUsecase(t)
+	missed := []entity.ChatMessage{{ID: 11, RoomID: entity.GeneralChatRoomID, Content: "missed"}, {ID: 12, RoomID: entity.GeneralChatRoomID, Content: "queued"}}
+	chatUsecase.On("GetMissedMessages", mock.Anything, entity.GeneralChatRoomID, int64(10), int64(maxMissedMessages)).Run(func(mock.Arguments) {
+		// Message 12 is saved and queued for broadcast while the replay is read,
+		// alice must not get it twice.
+		hub.broadcast <- roomMessage{roomID: entity.GeneralChatRoomID, messageID: 12, message: entity.WsMessage{Type: entity.WsTypeNewMessage, Payload: "queued"}}
+	}).Return(missed, nil).Once()
+
+	alice := newTestClient(hub, chatUsecase)
+	alice.ResumeFrom(10)
+	hub.Register <- alice
+
+	msg := receive(t, alice)
+	assert.Equal(t, entity.WsTypeResumed, msg.Type)
+	payload, _ := json.Marshal(msg.Payload)
+	var resumed entity.ChatHistory
+	require.NoError(t, json.Unmarshal(payload, &resumed))
+	assert.Equal(t, []int64{11, 12}, []int64{resumed.Messages[0].ID, resumed.Messages[1].ID})
+
+	hub.broadcast <- roomMessage{roomID: entity.GeneralChatRoomID, messageID: 13, message: entity.WsMessage{Type: entity.WsTypeNewMessage, Payload: "live"}}
+	assert.Equal(t, "live", receive(t, alice).Payload)
+	assertNothingReceived(t, alice)
+	// The queued broadcast has been handled, the replayed ids are no longer needed.
+	assert.Nil(t, alice.replayed)
+}
+
+func TestHub_ReplayDoesNotBlockHub(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(&logger)
+	go hub.Run()
+
+	chatUsecase := mocks.NewChatUsecase(t)
+	chatUsecase.On("GetMessageHistory", mock.Anything, mock.Anything, int64(0), int64(historySize)).Return(nil, nil).Maybe()
+	release := make(chan struct{})
+	missed := []entity.ChatMessage{{ID: 11, RoomID: entity.GeneralChatRoomID, Content: "missed"}, {ID: 12, RoomID: entity.GeneralChatRoomID, Content: "saved"}}
+	chatUsecase.On("GetMissedMessages", mock.Anything, entity.GeneralChatRoomID, int64(10), int64(maxMissedMessages)).Run(func(mock.Arguments) {
+		<-release
+	}).Return(missed, nil).Once()
+
+	bob := newTestClient(hub, chatUsecase)
+	hub.Register <- bob
+	alice := newTestClient(hub, chatUsecase)
+	alice.ResumeFrom(10)
+	hub.Register <- alice
+
+	// Bob is served while alice's replay is read, alice gets the broadcasts after it.
+	hub.broadcast <- roomMessage{roomID: entity.GeneralChatRoomID, messageID: 12, message: entity.WsMessage{Type: entity.WsTypeNewMessage, Payload: "saved"}}
+	hub.broadcast <- roomMessage{roomID: entity.GeneralChatRoomID, messageID: 13, message: entity.WsMessage{Type: entity.WsTypeNewMessage, Payload: "live"}}
+	assert.Equal(t, "saved", receive(t, bob).Payload)
+	assert.Equal(t, "live", receive(t, bob).Payload)
+	assertNothingReceived(t, alice)
+
+	close(release)
+	msg := receive(t, alice)
+	assert.Equal(t, entity.WsTypeResumed, msg.Type)
+	payload, _ := json.Marshal(msg.Payload)
+	var resumed entity.ChatHistory
+	require.NoError(t, json.Unmarshal(payload, &resumed))
+	assert.Len(t, resumed.Messages, 2)
+	assert.Equal(t, "live", receive(t, alice).Payload)
+	assertNothingReceived(t, alice)
+}
+
+func TestHub_ResumeGapTooLarge(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(&logger)
+	go hub.Run()
+
+	chatUsecase := mocks.NewChatUsecase(t)
+	chatUsecase.On("GetMissedMessages", mock.Anything, entity.GeneralChatRoomID, int64(10), int64(maxMissedMessages)).Return(nil, fmt.Errorf("ChatUsecase - GetMissedMessages: %w", usecase.ErrHistoryGapTooLarge)).Once()
+	chatUsecase.On("GetMessageHistory", mock.Anything, entity.GeneralChatRoomID, int64(0), int64(historySize)).Return([]entity.ChatMessage{{ID: 500, Content: "latest"}}, nil).Once()
+
+	alice := newTestClient(hub, chatUsecase)
+	alice.ResumeFrom(10)
+	hub.Register <- alice
+
+	msg := receive(t, alice)
+	assert.Equal(t, entity.WsTypeGapTooLarge, msg.Type)
+	assert.Equal(t, map[string]any{"room_id": float64(entity.GeneralChatRoomID)}, msg.Payload)
+
+	msg = receive(t, alice)
+	assert.Equal(t, entity.WsTypeHistory, msg.Type)
+	payload, _ := json.Marshal(msg.Payload)
+	var history entity.ChatHistory
+	require.NoError(t, json.Unmarshal(payload, &history))
+	assert.Equal(t, "latest", history.Messages[0].Content)
+}
+
+func TestClient_EditMessageIsBroadcastToRoom(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(&logger)
+	go hub.Run()
+
+	chatUsecase := mocks.NewChatUsecase(t)
+	chatUsecase.On("GetMessageHistory", mock.Anything, mock.Anything, int64(0), int64(historySize)).Return(nil, nil).Maybe()
+	editedAt := time.Now()
+	chatUsecase.On("EditMessage", mock.Anything, int64(7), int64(1), "user", "hello").Return(&entity.ChatMessage{ID: 7, RoomID: entity.GeneralChatRoomID, UserID: 1, Content: "hello", EditedAt: &editedAt}, nil).Once()
+	chatUsecase.On("DeleteMessage", mock.Anything, int64(8), int64(1), "user").Return(nil, fmt.Errorf("ChatUsecase - DeleteMessage: %w", usecase.ErrForbidden)).Once()
+	chatUsecase.On("EditMessage", mock.Anything, int64(7), int64(1), "user", " ").Return(nil, fmt.Errorf("ChatUsecase - EditMessage: %w", usecase.ErrEmptyChatMessage)).Once()
+
+	alice := NewAuthorizedClient(hub, nil, 1, "alice", "user", chatUsecase)
+	bob := newTestClient(hub, chatUsecase)
+	hub.Register <- alice
+	hub.Register <- bob
+	go alice.forwardChanges()
+	defer close(alice.changes)
+
+	alice.modifyMessage(entity.IncomingWsMessage{Type: entity.WsTypeEdit, MessageID: 7, Content: "hello"})
+	for _, client := range []*Client{alice, bob} {
+		msg := receive(t, client)
+		assert.Equal(t, entity.WsTypeEdited, msg.Type)
+		assert.Equal(t, "hello", msg.Payload.(map[string]any)["content"])
+	}
+
+	alice.modifyMessage(entity.IncomingWsMessage{Type: entity.WsTypeDelete, MessageID: 8})
+	assert.Equal(t, entity.WsTypeError, receive(t, alice).Type)
+	assertNothingReceived(t, bob)
+
+	alice.modifyMessage(entity.IncomingWsMessage{Type: entity.WsTypeEdit, MessageID: 7, Content: " "})
+	msg := receive(t, alice)
+	assert.Equal(t, entity.WsTypeError, msg.Type)
+	assert.Equal(t, "Message cannot be empty", msg.Payload)
+	assertNothingReceived(t, bob)
+}
+
+func TestClient_ModifyMessageDoesNotWaitForBroadcast(t *testing.T) {
+	logger := zerolog.Nop()
+	hub := NewHub(&logger)
+	for range broadcastBufferSize {
+		hub.broadcast <- roomMessage{roomID: entity.GeneralChatRoomID, message: entity.WsMessage{Type: entity.WsTypeNewMessage}}
+	}
+
+	chatUsecase := mocks.NewChatUsecase(t)
+	chatUsecase.On("DeleteMessage", mock.Anything, int64(7), int64(1), "user").Return(&entity.ChatMessage{ID: 7, RoomID: entity.GeneralChatRoomID, UserID: 1}, nil).Once()
+
+	alice := NewAuthorizedClient(hub, nil, 1, "alice", "user", chatUsecase)
+	go alice.forwardChanges()
+	defer close(alice.changes)
+
+	done := make(chan struct{})
+	go func() {
+		alice.modifyMessage(entity.IncomingWsMessage{Type: entity.WsTypeDelete, MessageID: 7})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("modifyMessage blocked on a full broadcast queue")
+	}
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 10 * time.Second
+
+	defaultCallTimeout = 5 * time.Second
+	defaultRetries     = 3
+	defaultBackoff     = 100 * time.Millisecond
+)
+
+var ErrCircuitOpen = errors.New("user service circuit breaker is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker stops calling the user service after threshold consecutive failures.
+// Once the cooldown has passed a single probe call is let through, its outcome closes
+// the breaker again or keeps it open for another cooldown.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	log       *zerolog.Logger
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, log *zerolog.Logger) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now, log: log}
+}
+
+// allow returns ErrCircuitOpen if the call must not be made.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		// A probe is already in flight.
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// record reports the outcome of a call let through by allow.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		if b.state != breakerClosed {
+			b.log.Info().Str("op", "UserClient.breaker").Msg("User service is back, circuit breaker closed")
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		if b.state != breakerOpen {
+			b.log.Warn().Str("op", "UserClient.breaker").Int("failures", b.failures).Msg("User service is failing, circuit breaker opened")
+		}
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abandon gives up a call let through by allow without an outcome. An abandoned probe
+// leaves the breaker open, the next call probes again.
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+// isTransient reports whether a failed call may succeed if repeated. Only such
+// failures are retried and count against the breaker.
+func isTransient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// call runs fn through the breaker and retries transient failures with exponential
+// backoff. Every attempt gets its own timeout.
+func (c *userClient) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	backoff := c.backoff
+	for attempt := 1; ; attempt++ {
+		if err := c.breaker.allow(); err != nil {
+			return err
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, c.callTimeout)
+		err := fn(callCtx)
+		cancel()
+
+		// The caller giving up says nothing about the health of the user service.
+		if ctx.Err() != nil {
+			c.breaker.abandon()
+			return err
+		}
+		c.breaker.record(err != nil && isTransient(err))
+		if err == nil || !isTransient(err) || attempt >= c.retries {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		backoff *= 2
+	}
+}
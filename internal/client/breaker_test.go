@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	userpb "github.com/keshvan/protos-forum/user"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeUserService struct {
+	calls int
+	errs  []error
+}
+
+func (f *fakeUserService) GetUsernames(ctx context.Context, in *userpb.GetUsernamesRequest, opts ...grpc.CallOption) (*userpb.GetUsernamesResponse, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &userpb.GetUsernamesResponse{Usernames: map[int64]string{1: "alice"}}, nil
+}
+
+func (f *fakeUserService) GetUsername(ctx context.Context, in *userpb.GetUsernameRequest, opts ...grpc.CallOption) (*userpb.GetUsernameResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "not implemented")
+}
+
+func newTestUserClient(svc userpb.UserServiceClient) *userClient {
+	logger := zerolog.Nop()
+	c := newUserClient(svc, nil, &logger)
+	c.backoff = time.Millisecond
+	return c
+}
+
+func TestUserClient_Retry(t *testing.T) {
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	t.Run("Transient errors are retried", func(t *testing.T) {
+		svc := &fakeUserService{errs: []error{unavailable, unavailable}}
+		c := newTestUserClient(svc)
+
+		usernames, err := c.GetUsernames(ctx, []int64{1})
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]string{1: "alice"}, usernames)
+		assert.Equal(t, 3, svc.calls)
+	})
+
+	t.Run("Gives up after the last attempt", func(t *testing.T) {
+		svc := &fakeUserService{errs: []error{unavailable, unavailable, unavailable, unavailable}}
+		c := newTestUserClient(svc)
+
+		_, err := c.GetUsernames(ctx, []int64{1})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, defaultRetries, svc.calls)
+	})
+
+	t.Run("Other errors are not retried", func(t *testing.T) {
+		svc := &fakeUserService{errs: []error{status.Error(codes.InvalidArgument, "bad request")}}
+		c := newTestUserClient(svc)
+
+		_, err := c.GetUsernames(ctx, []int64{1})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, 1, svc.calls)
+	})
+}
+
+func TestUserClient_CircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	svc := &fakeUserService{}
+	c := newTestUserClient(svc)
+	c.retries = 1
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	c.breaker.now = func() time.Time { return now }
+
+	for range DefaultBreakerThreshold {
+		svc.errs = append(svc.errs, unavailable)
+		_, err := c.GetUsernames(ctx, []int64{1})
+		require.Error(t, err)
+	}
+
+	_, err := c.GetUsernames(ctx, []int64{1})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, DefaultBreakerThreshold, svc.calls, "an open breaker must not call the user service")
+
+	// After the cooldown a failed probe opens the breaker again.
+	now = now.Add(DefaultBreakerCooldown)
+	svc.errs = append(svc.errs, unavailable)
+	_, err = c.GetUsernames(ctx, []int64{1})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = c.GetUsernames(ctx, []int64{1})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// A successful probe closes it.
+	now = now.Add(DefaultBreakerCooldown)
+	usernames, err := c.GetUsernames(ctx, []int64{1})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", usernames[1])
+	_, err = c.GetUsernames(ctx, []int64{1})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultBreakerThreshold+3, svc.calls)
+}
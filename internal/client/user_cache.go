@@ -0,0 +1,227 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultUserCacheTTL         = 5 * time.Minute
+	DefaultUserCacheNegativeTTL = 30 * time.Second
+	DefaultUserCacheSize        = 10000
+
+	// defaultFetchTimeout bounds a shared lookup, which outlives the request that started it.
+	defaultFetchTimeout = 10 * time.Second
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// CacheStats are the counters of a CachingUserClient. Every requested user id counts
+// as one hit or one miss.
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// CachingUserClient is a UserClient that remembers usernames returned by the wrapped
+// client. Users the user service does not know are remembered for a shorter time.
+// Concurrent misses for the same user share a single call to the wrapped client.
+// When the cache is full the least recently used entry is evicted.
+type CachingUserClient struct {
+	next         UserClient
+	ttl          time.Duration
+	negativeTTL  time.Duration
+	size         int
+	fetchTimeout time.Duration
+	now          func() time.Time
+
+	mu       sync.Mutex
+	entries  map[int64]*list.Element
+	lru      *list.List
+	inflight map[int64]*usernameCall
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// usernameEntry is the value of an element of lru, the most recently used entry is at the front.
+type usernameEntry struct {
+	userID   int64
+	username string
+	found    bool
+	expires  time.Time
+}
+
+// usernameCall is a lookup in progress, done is closed once the result is set.
+type usernameCall struct {
+	done     chan struct{}
+	username string
+	found    bool
+	err      error
+}
+
+func NewCachingUserClient(next UserClient, ttl, negativeTTL time.Duration, size int) *CachingUserClient {
+	if ttl <= 0 {
+		ttl = DefaultUserCacheTTL
+	}
+	if negativeTTL <= 0 {
+		negativeTTL = DefaultUserCacheNegativeTTL
+	}
+	if size <= 0 {
+		size = DefaultUserCacheSize
+	}
+
+	return &CachingUserClient{
+		next:         next,
+		ttl:          ttl,
+		negativeTTL:  negativeTTL,
+		size:         size,
+		fetchTimeout: defaultFetchTimeout,
+		now:          time.Now,
+		entries:      make(map[int64]*list.Element),
+		lru:          list.New(),
+		inflight:     make(map[int64]*usernameCall),
+	}
+}
+
+func (c *CachingUserClient) Stats() CacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: entries}
+}
+
+func (c *CachingUserClient) Close() error {
+	return c.next.Close()
+}
+
+// GetUsernames returns the usernames of the known users among userIDs, unknown users are left out.
+func (c *CachingUserClient) GetUsernames(ctx context.Context, userIDs []int64) (map[int64]string, error) {
+	usernames := make(map[int64]string, len(userIDs))
+	var fetch []int64
+	own := make(map[int64]*usernameCall)
+	wait := make(map[int64]*usernameCall)
+
+	c.mu.Lock()
+	now := c.now()
+	for _, id := range userIDs {
+		if _, seen := wait[id]; seen {
+			continue
+		}
+		if el, ok := c.entries[id]; ok {
+			if e := el.Value.(*usernameEntry); now.Before(e.expires) {
+				c.lru.MoveToFront(el)
+				c.hits.Add(1)
+				if e.found {
+					usernames[id] = e.username
+				}
+				continue
+			}
+		}
+
+		c.misses.Add(1)
+		if call, ok := c.inflight[id]; ok {
+			wait[id] = call
+			continue
+		}
+		call := &usernameCall{done: make(chan struct{})}
+		c.inflight[id] = call
+		own[id] = call
+		wait[id] = call
+		fetch = append(fetch, id)
+	}
+	c.mu.Unlock()
+
+	if len(fetch) > 0 {
+		// The lookup is shared with concurrent callers, so it must not be cut short
+		// when the request that started it is canceled.
+		go c.fetch(context.WithoutCancel(ctx), fetch, own)
+	}
+
+	for id, call := range wait {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("client.CachingUserClient - GetUsernames: %w", ctx.Err())
+		}
+		if call.err != nil {
+			return nil, fmt.Errorf("client.CachingUserClient - GetUsernames - next.GetUsernames: %w", call.err)
+		}
+		if call.found {
+			usernames[id] = call.username
+		}
+	}
+
+	return usernames, nil
+}
+
+// GetUsername returns ErrUserNotFound if the user service does not know the user.
+func (c *CachingUserClient) GetUsername(ctx context.Context, userID int64) (string, error) {
+	usernames, err := c.GetUsernames(ctx, []int64{userID})
+	if err != nil {
+		return "", err
+	}
+
+	username, ok := usernames[userID]
+	if !ok {
+		return "", fmt.Errorf("client.CachingUserClient - GetUsername: %w", ErrUserNotFound)
+	}
+	return username, nil
+}
+
+func (c *CachingUserClient) fetch(ctx context.Context, userIDs []int64, calls map[int64]*usernameCall) {
+	ctx, cancel := context.WithTimeout(ctx, c.fetchTimeout)
+	defer cancel()
+
+	usernames, err := c.next.GetUsernames(ctx, userIDs)
+	c.complete(calls, usernames, err)
+}
+
+// complete stores the result of a lookup and wakes up the callers waiting for it.
+// Failed lookups are not cached.
+func (c *CachingUserClient) complete(calls map[int64]*usernameCall, usernames map[int64]string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for id, call := range calls {
+		delete(c.inflight, id)
+		if err != nil {
+			call.err = err
+			close(call.done)
+			continue
+		}
+
+		call.username, call.found = usernames[id]
+		ttl := c.ttl
+		if !call.found {
+			ttl = c.negativeTTL
+		}
+		c.put(usernameEntry{userID: id, username: call.username, found: call.found, expires: now.Add(ttl)})
+		close(call.done)
+	}
+}
+
+// put stores e as the most recently used entry, evicting the least recently used one
+// if the cache is full. c.mu must be held.
+func (c *CachingUserClient) put(e usernameEntry) {
+	if el, ok := c.entries[e.userID]; ok {
+		*el.Value.(*usernameEntry) = e
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	if c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*usernameEntry).userID)
+	}
+	c.entries[e.userID] = c.lru.PushFront(&e)
+}
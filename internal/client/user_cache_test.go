@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keshvan/forum-service-sstu-forum/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingUserClient_GetUsernames(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Caches found and unknown users", func(t *testing.T) {
+		next := mocks.NewUserClient(t)
+		c := NewCachingUserClient(next, time.Minute, time.Second, 10)
+
+		next.On("GetUsernames", mock.Anything, []int64{1, 2}).Return(map[int64]string{1: "alice"}, nil).Once()
+
+		usernames, err := c.GetUsernames(ctx, []int64{1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]string{1: "alice"}, usernames)
+
+		usernames, err = c.GetUsernames(ctx, []int64{2, 1})
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]string{1: "alice"}, usernames)
+
+		assert.Equal(t, CacheStats{Hits: 2, Misses: 2, Entries: 2}, c.Stats())
+	})
+
+	t.Run("Only misses are fetched", func(t *testing.T) {
+		next := mocks.NewUserClient(t)
+		c := NewCachingUserClient(next, time.Minute, time.Second, 10)
+
+		next.On("GetUsernames", mock.Anything, []int64{1}).Return(map[int64]string{1: "alice"}, nil).Once()
+		next.On("GetUsernames", mock.Anything, []int64{2}).Return(map[int64]string{2: "bob"}, nil).Once()
+
+		_, err := c.GetUsernames(ctx, []int64{1})
+		require.NoError(t, err)
+		usernames, err := c.GetUsernames(ctx, []int64{1, 2, 2})
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]string{1: "alice", 2: "bob"}, usernames)
+	})
+
+	t.Run("Entries expire", func(t *testing.T) {
+		next := mocks.NewUserClient(t)
+		c := NewCachingUserClient(next, time.Minute, time.Second, 10)
+		now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+		c.now = func() time.Time { return now }
+
+		next.On("GetUsernames", mock.Anything, []int64{1, 2}).Return(map[int64]string{1: "alice"}, nil).Once()
+		_, err := c.GetUsernames(ctx, []int64{1, 2})
+		require.NoError(t, err)
+
+		// The unknown user expires first.
+		now = now.Add(2 * time.Second)
+		next.On("GetUsernames", mock.Anything, []int64{2}).Return(map[int64]string{2: "bob"}, nil).Once()
+		usernames, err := c.GetUsernames(ctx, []int64{1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]string{1: "alice", 2: "bob"}, usernames)
+
+		now = now.Add(time.Minute)
+		next.On("GetUsernames", mock.Anything, []int64{1, 2}).Return(map[int64]string{1: "alice2", 2: "bob"}, nil).Once()
+		usernames, err = c.GetUsernames(ctx, []int64{1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, "alice2", usernames[1])
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		next := mocks.NewUserClient(t)
+		c := NewCachingUserClient(next, time.Minute, time.Second, 10)
+		rpcErr := errors.New("unavailable")
+
+		next.On("GetUsernames", mock.Anything, []int64{1}).Return(nil, rpcErr).Once()
+		_, err := c.GetUsernames(ctx, []int64{1})
+		assert.ErrorIs(t, err, rpcErr)
+
+		next.On("GetUsernames", mock.Anything, []int64{1}).Return(map[int64]string{1: "alice"}, nil).Once()
+		usernames, err := c.GetUsernames(ctx, []int64{1})
+		require.NoError(t, err)
+		assert.Equal(t, "alice", usernames[1])
+	})
+
+	t.Run("Size is bounded", func(t *testing.T) {
+		next := mocks.NewUserClient(t)
+		c := NewCachingUserClient(next, time.Minute, time.Second, 2)
+
+		next.On("GetUsernames", mock.Anything, mock.Anything).Return(map[int64]string{}, nil)
+		for id := int64(1); id <= 5; id++ {
+			_, err := c.GetUsernames(ctx, []int64{id})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, c.Stats().Entries)
+	})
+
+	t.Run("Least recently used entry is evicted", func(t *testing.T) {
+		next := mocks.NewUserClient(t)
+		c := NewCachingUserClient(next, time.Minute, time.Second, 2)
+
+		next.On("GetUsernames", mock.Anything, []int64{1}).Return(map[int64]string{1: "alice"}, nil).Once()
+		next.On("GetUsernames", mock.Anything, []int64{2}).Return(map[int64]string{2: "bob"}, nil).Once()
+		next.On("GetUsernames", mock.Anything, []int64{3}).Return(map[int64]string{3: "carol"}, nil).Once()
+		for _, ids := range [][]int64{{1}, {2}, {1}, {3}} {
+			_, err := c.GetUsernames(ctx, ids)
+			require.NoError(t, err)
+		}
+
+		// 2 was evicted, 1 is still cached.
+		next.On("GetUsernames", mock.Anything, []int64{2}).Return(map[int64]string{2: "bob"}, nil).Once()
+		usernames, err := c.GetUsernames(ctx, []int64{1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]string{1: "alice", 2: "bob"}, usernames)
+	})
+
+	t.Run("Canceled caller does not cancel the shared lookup", func(t *testing.T) {
+		next := mocks.NewUserClient(t)
+		c := NewCachingUserClient(next, time.Minute, time.Second, 10)
+		release := make(chan struct{})
+		started := make(chan struct{})
+
+		next.On("GetUsernames", mock.Anything, []int64{1}).Return(func(ctx context.Context, _ []int64) (map[int64]string, error) {
+			close(started)
+			<-release
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if _, ok := ctx.Deadline(); !ok {
+				return nil, errors.New("no deadline")
+			}
+			return map[int64]string{1: "alice"}, nil
+		}).Once()
+
+		canceled, cancel := context.WithCancel(ctx)
+		errs := make(chan error, 1)
+		go func() {
+			_, err := c.GetUsernames(canceled, []int64{1})
+			errs <- err
+		}()
+		<-started
+
+		results := make(chan map[int64]string, 1)
+		go func() {
+			usernames, _ := c.GetUsernames(ctx, []int64{1})
+			results <- usernames
+		}()
+		require.Eventually(t, func() bool { return c.Stats().Misses == 2 }, time.Second, time.Millisecond)
+
+		cancel()
+		assert.ErrorIs(t, <-errs, context.Canceled)
+		close(release)
+		assert.Equal(t, map[int64]string{1: "alice"}, <-results)
+	})
+
+	t.Run("Concurrent misses are coalesced", func(t *testing.T) {
+		next := mocks.NewUserClient(t)
+		c := NewCachingUserClient(next, time.Minute, time.Second, 10)
+		release := make(chan struct{})
+		started := make(chan struct{})
+
+		next.On("GetUsernames", mock.Anything, []int64{1}).Return(func(context.Context, []int64) (map[int64]string, error) {
+			close(started)
+			<-release
+			return map[int64]string{1: "alice"}, nil
+		}).Once()
+
+		var wg sync.WaitGroup
+		results := make([]map[int64]string, 5)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[0], _ = c.GetUsernames(ctx, []int64{1})
+		}()
+		<-started
+		for i := 1; i < len(results); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = c.GetUsernames(ctx, []int64{1})
+			}()
+		}
+
+		require.Eventually(t, func() bool { return c.Stats().Misses == uint64(len(results)) }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		for _, usernames := range results {
+			assert.Equal(t, map[int64]string{1: "alice"}, usernames)
+		}
+	})
+}
+
+func TestCachingUserClient_GetUsername(t *testing.T) {
+	ctx := context.Background()
+	next := mocks.NewUserClient(t)
+	c := NewCachingUserClient(next, time.Minute, time.Second, 10)
+
+	next.On("GetUsernames", mock.Anything, []int64{1}).Return(map[int64]string{1: "alice"}, nil).Once()
+	next.On("GetUsernames", mock.Anything, []int64{2}).Return(map[int64]string{}, nil).Once()
+
+	username, err := c.GetUsername(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", username)
+
+	_, err = c.GetUsername(ctx, 2)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
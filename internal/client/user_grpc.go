@@ -21,36 +21,34 @@ type userClient struct {
 	client userpb.UserServiceClient
 	conn   *grpc.ClientConn
 	log    *zerolog.Logger
+
+	breaker     *circuitBreaker
+	callTimeout time.Duration
+	retries     int
+	backoff     time.Duration
 }
 
+// New does not wait for the user service: the connection is established by the first
+// call, so the forum starts and serves reads while the user service is down.
 func New(address string, log *zerolog.Logger) (UserClient, error) {
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
-	if err != nil {
-		return nil, err
-	}
-
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		return nil, fmt.Errorf("client.UserClient - New - grpc.NewClient: %w", err)
 	}
 
-	c := userpb.NewUserServiceClient(conn)
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
-	_, err = c.GetUsernames(ctx, &userpb.GetUsernamesRequest{
-		UserIds: []int64{},
-	})
-
-	if err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("client.UserClient - New - connection warmup failed: %w", err)
-	}
+	return newUserClient(userpb.NewUserServiceClient(conn), conn, log), nil
+}
 
+func newUserClient(client userpb.UserServiceClient, conn *grpc.ClientConn, log *zerolog.Logger) *userClient {
 	return &userClient{
-		client: c,
-		conn:   conn,
-		log:    log,
-	}, nil
+		client:      client,
+		conn:        conn,
+		log:         log,
+		breaker:     newCircuitBreaker(DefaultBreakerThreshold, DefaultBreakerCooldown, log),
+		callTimeout: defaultCallTimeout,
+		retries:     defaultRetries,
+		backoff:     defaultBackoff,
+	}
 }
 
 func (c *userClient) Close() error {
@@ -70,10 +68,11 @@ func (c *userClient) GetUsernames(ctx context.Context, userIDs []int64) (map[int
 		UserIds: userIDs,
 	}
 
-	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	res, err := c.client.GetUsernames(callCtx, req)
+	var res *userpb.GetUsernamesResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.client.GetUsernames(ctx, req)
+		return err
+	})
 	if err != nil {
 		c.log.Error().Err(err).Str("op", "UserClient.GetUsernames").Any("userIDs", userIDs).Msg("Failed to get usernames")
 		return nil, fmt.Errorf("clients.user - GetUsernames - c.client.GetUsernames: %w", err)
@@ -88,10 +87,11 @@ func (c *userClient) GetUsername(ctx context.Context, userID int64) (string, err
 		UserId: userID,
 	}
 
-	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	res, err := c.client.GetUsername(callCtx, req)
+	var res *userpb.GetUsernameResponse
+	err := c.call(ctx, func(ctx context.Context) (err error) {
+		res, err = c.client.GetUsername(ctx, req)
+		return err
+	})
 	if err != nil {
 		c.log.Error().Err(err).Str("op", "UserClient.GetUsername").Any("userID", userID).Msg("Failed to get username")
 		return "", fmt.Errorf("clients.user - GetUsername - c.client.GetUsername: %w", err)
@@ -1,12 +1,15 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
 	categoryrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/category_requests"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/rs/zerolog"
 )
@@ -22,6 +25,11 @@ const (
 	getAllOp   = "CategoryHandler.GetAll"
 	deleteOp   = "CategoryHandler.Delete"
 	updateOp   = "CategoryHandler.Update"
+	reorderOp  = "CategoryHandler.Reorder"
+
+	getModeratorsOp   = "CategoryHandler.GetModerators"
+	addModeratorOp    = "CategoryHandler.AddModerator"
+	removeModeratorOp = "CategoryHandler.RemoveModerator"
 )
 
 // Create godoc
@@ -32,7 +40,7 @@ const (
 // @Produce json
 // @Param category body entity.Category true "Category data to create. ID, CreatedAt, UpdatedAt will be ignored."
 // @Success 201 {object} response.IDResponse "Category created successfully"
-// @Failure 400 {object} response.ErrorResponse "Invalid request payload"
+// @Failure 400 {object} response.ErrorResponse "Invalid request payload or parent category not found"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
 // @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
@@ -50,6 +58,10 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 
 	id, err := h.usecase.Create(c.Request.Context(), category)
 	if err != nil {
+		if errors.Is(err, usecase.ErrParentCategoryNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrParentCategoryNotFound.Error()})
+			return
+		}
 		log.Error().Err(err).Msg("Failed to create category")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -60,12 +72,14 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 
 // GetByID godoc
 // @Summary Get a category by ID
-// @Description Retrieves a specific category by its ID.
+// @Description Retrieves a specific category by its ID together with breadcrumbs (its parent categories from the root down), topic and post counts and the last post. Hidden categories are only returned to admins.
 // @Tags categories
 // @Produce json
 // @Param id path int true "Category ID" Format(int64)
 // @Success 200 {object} response.CategoryResponse "Successfully retrieved category"
+// @Header 200 {string} ETag "Version of the category, send it back in If-Match when updating the category"
 // @Failure 400 {object} response.ErrorResponse "Invalid category ID"
+// @Failure 404 {object} response.ErrorResponse "Category not found"
 // @Failure 500 {object} response.ErrorResponse "Failed to get category"
 // @Router /categories/{id} [get]
 func (h *CategoryHandler) GetByID(c *gin.Context) {
@@ -78,47 +92,91 @@ func (h *CategoryHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	category, err := h.usecase.GetByID(c.Request.Context(), categoryID)
+	role, _ := middleware.GetRoleFromContext(c)
+	category, degraded, err := h.usecase.GetByID(c.Request.Context(), categoryID, policy.IsAdmin(role))
 	if err != nil {
+		if errors.Is(err, usecase.ErrCategoryNotFound) {
+			log.Warn().Int64("category_id", categoryID).Msg("Category not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrCategoryNotFound.Error()})
+			return
+		}
 		log.Error().Err(err).Int64("category_id", categoryID).Msg("Failed to get category")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get category"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"category": category})
+	if degraded {
+		middleware.SkipResponseCache(c)
+	}
+	c.Header("ETag", etag(category.UpdatedAt))
+	c.JSON(http.StatusOK, gin.H{"category": category, "degraded": degraded})
 
 }
 
 // GetAll godoc
 // @Summary Get all categories
-// @Description Retrieves a list of all categories.
+// @Description Retrieves all categories ordered by position. With view=tree subcategories are nested under their parents (see response.CategoryTreeResponse), otherwise a flat list is returned. Each category carries topic and post counts and its last post. Hidden categories are only returned to admins.
 // @Tags categories
 // @Produce json
+// @Param view query string false "Response shape" Enums(flat, tree)
+// @Param If-None-Match header string false "ETag of a previous response, 304 is returned if it is still current"
+// @Param If-Modified-Since header string false "Last-Modified of a previous response, 304 is returned if nothing has changed since"
 // @Success 200 {object} response.CategoriesResponse "Successfully retrieved all categories"
+// @Header 200 {string} ETag "Version of the response"
+// @Header 200 {string} Last-Modified "Time of the last change, omitted if it was less than a second ago"
+// @Success 304 "Not modified"
+// @Failure 400 {object} response.ErrorResponse "Invalid view"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
 // @Router /categories [get]
 func (h *CategoryHandler) GetAll(c *gin.Context) {
 	log := h.getRequestLogger(c).With().Str("op", getAllOp).Logger()
 
-	posts, err := h.usecase.GetAll(c.Request.Context())
+	role, _ := middleware.GetRoleFromContext(c)
+	includeHidden := policy.IsAdmin(role)
+
+	switch c.Query("view") {
+	case "", "flat":
+	case "tree":
+		tree, degraded, err := h.usecase.GetTree(c.Request.Context(), includeHidden)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get category tree")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if degraded {
+			middleware.SkipResponseCache(c)
+		}
+		c.JSON(http.StatusOK, gin.H{"categories": tree, "degraded": degraded})
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view must be flat or tree"})
+		return
+	}
+
+	categories, degraded, err := h.usecase.GetAll(c.Request.Context(), includeHidden)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get all categories")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"categories": posts})
+	if degraded {
+		middleware.SkipResponseCache(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"categories": categories, "degraded": degraded})
 }
 
 // Delete godoc
 // @Summary Delete a category
-// @Description Deletes a category by its ID. Requires admin privileges.
+// @Description Deletes a category by its ID together with its topics. Categories with subcategories cannot be deleted. Requires admin privileges.
 // @Tags categories
 // @Param id path int true "Category ID" Format(int64)
 // @Success 200 "Category deleted successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid category ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
 // @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 409 {object} response.ErrorResponse "Category has subcategories"
 // @Failure 500 {object} response.ErrorResponse "Failed to delete category"
 // @Security ApiKeyAuth
 // @Router /categories/{id} [delete]
@@ -133,6 +191,10 @@ func (h *CategoryHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.usecase.Delete(c.Request.Context(), categoryID); err != nil {
+		if errors.Is(err, usecase.ErrCategoryHasChildren) {
+			c.JSON(http.StatusConflict, gin.H{"error": usecase.ErrCategoryHasChildren.Error()})
+			return
+		}
 		log.Error().Err(err).Msg("Failed to delete category")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete category"})
 		return
@@ -143,16 +205,19 @@ func (h *CategoryHandler) Delete(c *gin.Context) {
 
 // Update godoc
 // @Summary Update a category
-// @Description Updates a category's title and/or description by its ID. Requires admin privileges.
+// @Description Updates a category's title and/or description by its ID, optionally moves it under another category (parent_id 0 makes it a root) and shows or hides it. Requires admin privileges.
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID" Format(int64)
 // @Param category_update body categoryrequests.UpdateRequest true "Category update data"
+// @Param If-Match header string false "ETag of the category from a previous GET, the update is rejected if the category has changed since"
 // @Success 200 "Category updated successfully"
-// @Failure 400 {object} response.ErrorResponse "Invalid category ID or request payload"
+// @Failure 400 {object} response.ErrorResponse "Invalid category ID or request payload, parent category not found or the move would create a cycle"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
 // @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 404 {object} response.ErrorResponse "Category not found"
+// @Failure 412 {object} response.ErrorResponse "Category has been modified since it was read"
 // @Failure 500 {object} response.ErrorResponse "Failed to update category"
 // @Security ApiKeyAuth
 // @Router /categories/{id} [patch]
@@ -173,7 +238,30 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		return
 	}
 
-	if err := h.usecase.Update(c.Request.Context(), categoryID, req.Title, req.Description); err != nil {
+	version, ok := parseIfMatch(c)
+	if !ok {
+		log.Warn().Msg("Unknown If-Match tag")
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": usecase.ErrVersionConflict.Error()})
+		return
+	}
+
+	if err := h.usecase.Update(c.Request.Context(), categoryID, req.Title, req.Description, req.ParentID, req.Hidden, version); err != nil {
+		if errors.Is(err, usecase.ErrParentCategoryNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrParentCategoryNotFound.Error()})
+			return
+		}
+		if errors.Is(err, usecase.ErrCategoryCycle) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrCategoryCycle.Error()})
+			return
+		}
+		if errors.Is(err, usecase.ErrCategoryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrCategoryNotFound.Error()})
+			return
+		}
+		if errors.Is(err, usecase.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": usecase.ErrVersionConflict.Error()})
+			return
+		}
 		log.Error().Err(err).Msg("Failed to update category")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update category"})
 		return
@@ -182,6 +270,174 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// Reorder godoc
+// @Summary Reorder categories
+// @Description Sets the display order of categories to the order of the given ids in one transaction. Requires admin privileges.
+// @Tags categories
+// @Accept json
+// @Param order body categoryrequests.ReorderRequest true "Category ids in the new order"
+// @Success 200 "Categories reordered successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request payload, duplicate or unknown category ids"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 500 {object} response.ErrorResponse "Failed to reorder categories"
+// @Security ApiKeyAuth
+// @Router /categories/order [put]
+func (h *CategoryHandler) Reorder(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", reorderOp).Logger()
+
+	var req categoryrequests.ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Failed to bind request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.usecase.Reorder(c.Request.Context(), req.IDs); err != nil {
+		if errors.Is(err, usecase.ErrInvalidCategoryOrder) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidCategoryOrder.Error()})
+			return
+		}
+		if errors.Is(err, usecase.ErrCategoryNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrCategoryNotFound.Error()})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to reorder categories")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reorder categories"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetModerators godoc
+// @Summary Get category moderators
+// @Description Lists users with moderator rights in a category. Requires admin role.
+// @Tags categories
+// @Produce json
+// @Param id path int true "Category ID" Format(int64)
+// @Success 200 {object} response.ModeratorsResponse "Successfully retrieved moderators"
+// @Failure 400 {object} response.ErrorResponse "Invalid category ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 404 {object} response.ErrorResponse "Category not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /categories/{id}/moderators [get]
+func (h *CategoryHandler) GetModerators(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", getModeratorsOp).Logger()
+
+	categoryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse category id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+
+	moderators, err := h.usecase.GetModerators(c.Request.Context(), categoryID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrCategoryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get moderators")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"moderators": moderators})
+}
+
+// AddModerator godoc
+// @Summary Assign a category moderator
+// @Description Gives a user moderator rights in a category: editing, deleting, pinning and locking any content in it. Requires admin role.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID" Format(int64)
+// @Param moderator body categoryrequests.AddModeratorRequest true "User to assign"
+// @Success 200 {object} response.SuccessMessageResponse "Moderator added successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid category ID or request payload"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 404 {object} response.ErrorResponse "Category not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /categories/{id}/moderators [post]
+func (h *CategoryHandler) AddModerator(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", addModeratorOp).Logger()
+
+	categoryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse category id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+
+	var req categoryrequests.AddModeratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Failed to bind request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.usecase.AddModerator(c.Request.Context(), categoryID, req.UserID); err != nil {
+		if errors.Is(err, usecase.ErrCategoryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to add moderator")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "moderator added"})
+}
+
+// RemoveModerator godoc
+// @Summary Remove a category moderator
+// @Description Takes moderator rights in a category away from a user. Requires admin role.
+// @Tags categories
+// @Param id path int true "Category ID" Format(int64)
+// @Param user_id path int true "User ID" Format(int64)
+// @Success 200 {object} response.SuccessMessageResponse "Moderator removed successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid category or user ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 404 {object} response.ErrorResponse "User is not a moderator of the category"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /categories/{id}/moderators/{user_id} [delete]
+func (h *CategoryHandler) RemoveModerator(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", removeModeratorOp).Logger()
+
+	categoryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse category id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse user id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.usecase.RemoveModerator(c.Request.Context(), categoryID, userID); err != nil {
+		if errors.Is(err, usecase.ErrModeratorNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrModeratorNotFound.Error()})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to remove moderator")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "moderator removed"})
+}
+
 func (h *CategoryHandler) getRequestLogger(c *gin.Context) *zerolog.Logger {
 	reqLog := h.log.With().
 		Str("method", c.Request.Method).
@@ -11,8 +11,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
 	categoryrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/category_requests"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/response"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/keshvan/forum-service-sstu-forum/mocks"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
@@ -114,19 +117,20 @@ func TestCategoryHandler_GetByID_Success(t *testing.T) {
 	categoryID := int64(1)
 	router.GET("/categories/:id", handler.GetByID)
 
-	expectedCategory := &entity.Category{ID: categoryID, Title: "Test", Description: "Test Desc", CreatedAt: time.Now()}
-	mockUsecase.On("GetByID", mock.Anything, categoryID).Return(expectedCategory, nil).Once()
+	expectedCategory := &entity.Category{ID: categoryID, Title: "Test", Description: "Test Desc", CreatedAt: time.Now(), UpdatedAt: time.UnixMicro(1700000000123456)}
+	mockUsecase.On("GetByID", mock.Anything, categoryID, false).Return(expectedCategory, false, nil).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/categories/"+strconv.FormatInt(categoryID, 10), nil)
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
-	var respBody map[string]entity.Category
+	assert.Equal(t, `"1700000000123456"`, rr.Header().Get("ETag"))
+	var respBody response.CategoryResponse
 	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedCategory.ID, respBody["category"].ID)
-	assert.Equal(t, expectedCategory.Title, respBody["category"].Title)
+	assert.Equal(t, expectedCategory.ID, respBody.Category.ID)
+	assert.Equal(t, expectedCategory.Title, respBody.Category.Title)
 	mockUsecase.AssertExpectations(t)
 }
 
@@ -146,7 +150,7 @@ func TestCategoryHandler_GetByID_InvalidID(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	mockUsecase.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestCategoryHandler_GetByID_UsecaseError(t *testing.T) {
@@ -162,7 +166,7 @@ func TestCategoryHandler_GetByID_UsecaseError(t *testing.T) {
 	router.GET("/categories/:id", handler.GetByID)
 
 	usecaseError := errors.New("usecase get by id error")
-	mockUsecase.On("GetByID", mock.Anything, categoryID).Return(nil, usecaseError).Once()
+	mockUsecase.On("GetByID", mock.Anything, categoryID, false).Return(nil, false, usecaseError).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/categories/"+strconv.FormatInt(categoryID, 10), nil)
 	rr := httptest.NewRecorder()
@@ -176,6 +180,53 @@ func TestCategoryHandler_GetByID_UsecaseError(t *testing.T) {
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestCategoryHandler_GetByID_Hidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	categoryID := int64(1)
+	router.GET("/categories/:id", handler.GetByID)
+
+	mockUsecase.On("GetByID", mock.Anything, categoryID, false).Return(nil, false, usecase.ErrCategoryNotFound).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/categories/"+strconv.FormatInt(categoryID, 10), nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCategoryHandler_GetByID_AdminSeesHidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	categoryID := int64(1)
+	router.GET("/categories/:id", func(c *gin.Context) {
+		c.Set(ContextRoleKey, "admin")
+		handler.GetByID(c)
+	})
+
+	mockUsecase.On("GetByID", mock.Anything, categoryID, true).Return(&entity.Category{ID: categoryID, Hidden: true}, false, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/categories/"+strconv.FormatInt(categoryID, 10), nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
 func TestCategoryHandler_GetAll_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -191,21 +242,135 @@ func TestCategoryHandler_GetAll_Success(t *testing.T) {
 		{ID: 1, Title: "Cat1", Description: "D1", CreatedAt: time.Now()},
 		{ID: 2, Title: "Cat2", Description: "D2", CreatedAt: time.Now()},
 	}
-	mockUsecase.On("GetAll", mock.Anything).Return(expectedCategories, nil).Once()
+	mockUsecase.On("GetAll", mock.Anything, false).Return(expectedCategories, false, nil).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/categories", nil)
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
-	var respBody map[string][]entity.Category
+	var respBody response.CategoriesResponse
 	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
 	assert.NoError(t, err)
-	assert.Len(t, respBody["categories"], 2)
-	assert.Equal(t, expectedCategories[0].ID, respBody["categories"][0].ID)
+	assert.Len(t, respBody.Categories, 2)
+	assert.Equal(t, expectedCategories[0].ID, respBody.Categories[0].ID)
+	assert.False(t, respBody.Degraded)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCategoryHandler_GetAll_Tree(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/categories", handler.GetAll)
+
+	parentID := int64(1)
+	tree := []*entity.CategoryNode{
+		{Category: entity.Category{ID: parentID, Title: "Faculty"}, Children: []*entity.CategoryNode{
+			{Category: entity.Category{ID: 2, ParentID: &parentID, Title: "Department"}, Children: []*entity.CategoryNode{}},
+		}},
+	}
+	mockUsecase.On("GetTree", mock.Anything, false).Return(tree, false, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/categories?view=tree", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.CategoryTreeResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Len(t, respBody.Categories, 1)
+	assert.Len(t, respBody.Categories[0].Children, 1)
+	assert.Equal(t, int64(2), respBody.Categories[0].Children[0].ID)
+	mockUsecase.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything)
+}
+
+func TestCategoryHandler_GetAll_AdminSeesHidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/categories", func(c *gin.Context) {
+		c.Set(ContextRoleKey, "admin")
+		handler.GetAll(c)
+	})
+
+	expectedCategories := []entity.Category{
+		{ID: 1, Title: "Cat1", Position: 0},
+		{ID: 2, Title: "Hidden", Position: 1, Hidden: true},
+	}
+	mockUsecase.On("GetAll", mock.Anything, true).Return(expectedCategories, false, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/categories", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.CategoriesResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Len(t, respBody.Categories, 2)
+	assert.True(t, respBody.Categories[1].Hidden)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCategoryHandler_GetAll_DegradedIsNotCached(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	cache := middleware.NewResponseCache(true, time.Minute)
+	router.GET("/categories", cache.Handler(middleware.CategoriesScope), handler.GetAll)
+
+	categories := []entity.Category{{ID: 1, Title: "Cat1", LastPost: &entity.LastPost{ID: 10, Username: "Пользователь"}}}
+	mockUsecase.On("GetAll", mock.Anything, false).Return(categories, true, nil).Twice()
+
+	for range 2 {
+		req, _ := http.NewRequest(http.MethodGet, "/categories", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var respBody response.CategoriesResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+		assert.NoError(t, err)
+		assert.True(t, respBody.Degraded)
+	}
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestCategoryHandler_GetAll_InvalidView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/categories", handler.GetAll)
+
+	req, _ := http.NewRequest(http.MethodGet, "/categories?view=graph", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
 func TestCategoryHandler_GetAll_UsecaseError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -218,7 +383,7 @@ func TestCategoryHandler_GetAll_UsecaseError(t *testing.T) {
 	router.GET("/categories", handler.GetAll)
 
 	usecaseError := errors.New("usecase get all error")
-	mockUsecase.On("GetAll", mock.Anything).Return(nil, usecaseError).Once()
+	mockUsecase.On("GetAll", mock.Anything, false).Return(nil, false, usecaseError).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/categories", nil)
 	rr := httptest.NewRecorder()
@@ -300,6 +465,32 @@ func TestCategoryHandler_Delete_UsecaseError(t *testing.T) {
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestCategoryHandler_Delete_HasChildren(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	categoryID := int64(1)
+	router.DELETE("/categories/:id", handler.Delete)
+
+	mockUsecase.On("Delete", mock.Anything, categoryID).Return(usecase.ErrCategoryHasChildren).Once()
+
+	req, _ := http.NewRequest(http.MethodDelete, "/categories/"+strconv.FormatInt(categoryID, 10), nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ErrCategoryHasChildren.Error(), respBody["error"])
+	mockUsecase.AssertExpectations(t)
+}
+
 func TestCategoryHandler_Update_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -312,8 +503,9 @@ func TestCategoryHandler_Update_Success(t *testing.T) {
 	categoryID := int64(1)
 	router.PUT("/categories/:id", handler.Update)
 
-	reqBody := categoryrequests.UpdateRequest{Title: "updated title", Description: "updated desc"}
-	mockUsecase.On("Update", mock.Anything, categoryID, reqBody.Title, reqBody.Description).Return(nil).Once()
+	title, description := "updated title", "updated desc"
+	reqBody := categoryrequests.UpdateRequest{Title: &title, Description: &description}
+	mockUsecase.On("Update", mock.Anything, categoryID, reqBody.Title, reqBody.Description, (*int64)(nil), (*bool)(nil), (*time.Time)(nil)).Return(nil).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/categories/"+strconv.FormatInt(categoryID, 10), bytes.NewBuffer(jsonBody))
@@ -326,6 +518,31 @@ func TestCategoryHandler_Update_Success(t *testing.T) {
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestCategoryHandler_Update_HiddenOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	categoryID := int64(1)
+	router.PATCH("/categories/:id", handler.Update)
+
+	hidden := true
+	mockUsecase.On("Update", mock.Anything, categoryID, (*string)(nil), (*string)(nil), (*int64)(nil), &hidden, (*time.Time)(nil)).Return(nil).Once()
+
+	req, _ := http.NewRequest(http.MethodPatch, "/categories/"+strconv.FormatInt(categoryID, 10), bytes.NewBufferString(`{"hidden":true}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
 func TestCategoryHandler_Update_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -337,7 +554,8 @@ func TestCategoryHandler_Update_InvalidID(t *testing.T) {
 	}
 	router.PUT("/categories/:id", handler.Update)
 
-	reqBody := categoryrequests.UpdateRequest{Title: "updated title", Description: "updated desc"}
+	title, description := "updated title", "updated desc"
+	reqBody := categoryrequests.UpdateRequest{Title: &title, Description: &description}
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/categories/invalid", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -346,7 +564,7 @@ func TestCategoryHandler_Update_InvalidID(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	mockUsecase.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestCategoryHandler_Update_InvalidJSON(t *testing.T) {
@@ -368,7 +586,7 @@ func TestCategoryHandler_Update_InvalidJSON(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	mockUsecase.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestCategoryHandler_Update_UsecaseError(t *testing.T) {
@@ -383,9 +601,10 @@ func TestCategoryHandler_Update_UsecaseError(t *testing.T) {
 	categoryID := int64(1)
 	router.PUT("/categories/:id", handler.Update)
 
-	reqBody := categoryrequests.UpdateRequest{Title: "updated title", Description: "updated desc"}
+	title, description := "updated title", "updated desc"
+	reqBody := categoryrequests.UpdateRequest{Title: &title, Description: &description}
 	usecaseError := errors.New("usecase update error")
-	mockUsecase.On("Update", mock.Anything, categoryID, reqBody.Title, reqBody.Description).Return(usecaseError).Once()
+	mockUsecase.On("Update", mock.Anything, categoryID, reqBody.Title, reqBody.Description, (*int64)(nil), (*bool)(nil), (*time.Time)(nil)).Return(usecaseError).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/categories/"+strconv.FormatInt(categoryID, 10), bytes.NewBuffer(jsonBody))
@@ -401,3 +620,231 @@ func TestCategoryHandler_Update_UsecaseError(t *testing.T) {
 	assert.Equal(t, "failed to update category", respBody["error"])
 	mockUsecase.AssertExpectations(t)
 }
+
+func TestCategoryHandler_GetModerators_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	categoryID := int64(1)
+	router.GET("/categories/:id/moderators", handler.GetModerators)
+
+	expected := []entity.CategoryModerator{{CategoryID: categoryID, UserID: 5, CreatedAt: time.Now().UTC().Truncate(time.Second)}}
+	mockUsecase.On("GetModerators", mock.Anything, categoryID).Return(expected, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/categories/"+strconv.FormatInt(categoryID, 10)+"/moderators", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.ModeratorsResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, respBody.Moderators)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCategoryHandler_AddModerator_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	categoryID := int64(1)
+	router.POST("/categories/:id/moderators", handler.AddModerator)
+
+	reqBody := categoryrequests.AddModeratorRequest{UserID: 5}
+	mockUsecase.On("AddModerator", mock.Anything, categoryID, reqBody.UserID).Return(nil).Once()
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest(http.MethodPost, "/categories/"+strconv.FormatInt(categoryID, 10)+"/moderators", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCategoryHandler_AddModerator_MissingUserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.POST("/categories/:id/moderators", handler.AddModerator)
+
+	req, _ := http.NewRequest(http.MethodPost, "/categories/1/moderators", bytes.NewBufferString("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockUsecase.AssertNotCalled(t, "AddModerator", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCategoryHandler_RemoveModerator_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.DELETE("/categories/:id/moderators/:user_id", handler.RemoveModerator)
+
+	mockUsecase.On("RemoveModerator", mock.Anything, int64(1), int64(5)).Return(usecase.ErrModeratorNotFound).Once()
+
+	req, _ := http.NewRequest(http.MethodDelete, "/categories/1/moderators/5", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCategoryHandler_Update_Cycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	categoryID := int64(1)
+	router.PATCH("/categories/:id", handler.Update)
+
+	parentID := int64(3)
+	title, description := "title", "desc"
+	reqBody := categoryrequests.UpdateRequest{Title: &title, Description: &description, ParentID: &parentID}
+	mockUsecase.On("Update", mock.Anything, categoryID, reqBody.Title, reqBody.Description, &parentID, (*bool)(nil), (*time.Time)(nil)).Return(usecase.ErrCategoryCycle).Once()
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest(http.MethodPatch, "/categories/"+strconv.FormatInt(categoryID, 10), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ErrCategoryCycle.Error(), respBody["error"])
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCategoryHandler_Update_VersionConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	categoryID := int64(1)
+	router.PATCH("/categories/:id", handler.Update)
+
+	version := time.UnixMicro(1700000000123456)
+	title, description := "title", "desc"
+	reqBody := categoryrequests.UpdateRequest{Title: &title, Description: &description}
+	mockUsecase.On("Update", mock.Anything, categoryID, reqBody.Title, reqBody.Description, (*int64)(nil), (*bool)(nil), &version).Return(usecase.ErrVersionConflict).Once()
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest(http.MethodPatch, "/categories/"+strconv.FormatInt(categoryID, 10), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1700000000123456"`)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ErrVersionConflict.Error(), respBody["error"])
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCategoryHandler_Reorder_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.PUT("/categories/order", handler.Reorder)
+
+	reqBody := categoryrequests.ReorderRequest{IDs: []int64{3, 1, 2}}
+	mockUsecase.On("Reorder", mock.Anything, reqBody.IDs).Return(nil).Once()
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest(http.MethodPut, "/categories/order", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCategoryHandler_Reorder_InvalidJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.PUT("/categories/order", handler.Reorder)
+
+	req, _ := http.NewRequest(http.MethodPut, "/categories/order", bytes.NewBufferString(`{"ids": "1,2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockUsecase.AssertNotCalled(t, "Reorder", mock.Anything, mock.Anything)
+}
+
+func TestCategoryHandler_Reorder_UnknownCategory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewCategoryUsecase(t)
+	logger := zerolog.Nop()
+	handler := &CategoryHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.PUT("/categories/order", handler.Reorder)
+
+	reqBody := categoryrequests.ReorderRequest{IDs: []int64{1, 42}}
+	mockUsecase.On("Reorder", mock.Anything, reqBody.IDs).Return(usecase.ErrCategoryNotFound).Once()
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest(http.MethodPut, "/categories/order", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ErrCategoryNotFound.Error(), respBody["error"])
+	mockUsecase.AssertExpectations(t)
+}
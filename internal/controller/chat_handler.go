@@ -1,17 +1,23 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/keshvan/forum-service-sstu-forum/internal/chat"
 	"github.com/keshvan/forum-service-sstu-forum/internal/client"
 	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
+	chatrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/chat_requests"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/rs/zerolog"
 )
 
+var errInvalidBefore = errors.New("invalid before message id")
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -21,6 +27,19 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+const (
+	getRoomsOp    = "ChatHandler.GetRooms"
+	createRoomOp  = "ChatHandler.CreateRoom"
+	deleteRoomOp  = "ChatHandler.DeleteRoom"
+	getMessagesOp = "ChatHandler.GetMessages"
+
+	getConversationsOp        = "ChatHandler.GetConversations"
+	getConversationMessagesOp = "ChatHandler.GetConversationMessages"
+
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 100
+)
+
 type ChatHandler struct {
 	hub         *chat.Hub
 	chatUsecase usecase.ChatUsecase
@@ -32,7 +51,20 @@ func NewChatHandler(hub *chat.Hub, chatUsecase usecase.ChatUsecase, userClient c
 	return &ChatHandler{hub: hub, chatUsecase: chatUsecase, userClient: userClient, log: log}
 }
 
+// ServeWs upgrades the connection to the chat WebSocket. A reconnecting client passes
+// since, the id of the last message it saw, to get the messages it missed instead of
+// the latest history. The replay may repeat messages the client has, it dedupes by id.
 func (h *ChatHandler) ServeWs(c *gin.Context) {
+	var since int64
+	if v := c.Query("since"); v != "" {
+		var err error
+		since, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || since <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since message id"})
+			return
+		}
+	}
+
 	userID, exists := middleware.GetUserIDFromContext(c)
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -42,6 +74,7 @@ func (h *ChatHandler) ServeWs(c *gin.Context) {
 
 	if !exists {
 		client := chat.NewUnauthorizedClient(h.hub, conn, h.chatUsecase)
+		client.ResumeFrom(since)
 		h.hub.Register <- client
 		go client.WritePump()
 		go client.ReadPump()
@@ -55,10 +88,259 @@ func (h *ChatHandler) ServeWs(c *gin.Context) {
 		return
 	}
 
-	client := chat.NewAuthorizedClient(h.hub, conn, userID, username, h.chatUsecase)
+	role, _ := middleware.GetRoleFromContext(c)
+	client := chat.NewAuthorizedClient(h.hub, conn, userID, username, role, h.chatUsecase)
+	client.ResumeFrom(since)
 	h.hub.Register <- client
 
 	go client.WritePump()
 	go client.ReadPump()
 	c.JSON(http.StatusOK, gin.H{"message": "Connected to chat"})
 }
+
+// GetRooms godoc
+// @Summary List chat rooms
+// @Description Lists the general room, the rooms of categories and the rooms created by admins. Rooms of hidden categories are only listed for admins.
+// @Tags chat
+// @Produce json
+// @Success 200 {object} response.ChatRoomsResponse "Successfully retrieved rooms"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /chat/rooms [get]
+func (h *ChatHandler) GetRooms(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", getRoomsOp).Logger()
+
+	role, _ := middleware.GetRoleFromContext(c)
+	rooms, err := h.chatUsecase.GetRooms(c.Request.Context(), role)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get rooms")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get rooms"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rooms": rooms})
+}
+
+// CreateRoom godoc
+// @Summary Create a chat room
+// @Description Creates a chat room. Requires admin role.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param room body chatrequests.CreateRoomRequest true "Room name"
+// @Success 201 {object} response.ChatRoomResponse "Room created successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /chat/rooms [post]
+func (h *ChatHandler) CreateRoom(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", createRoomOp).Logger()
+
+	var req chatrequests.CreateRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("Failed to bind request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	room, err := h.chatUsecase.CreateRoom(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create room")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create room"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"room": room})
+}
+
+// DeleteRoom godoc
+// @Summary Delete a chat room
+// @Description Deletes a room created by an admin together with its messages. Connected members are notified with room_deleted. The general and category rooms cannot be deleted. Requires admin role.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Room ID" Format(int64)
+// @Success 200 {object} response.SuccessMessageResponse "Room deleted successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid room ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 404 {object} response.ErrorResponse "Room not found or not deletable"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /chat/rooms/{id} [delete]
+func (h *ChatHandler) DeleteRoom(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", deleteRoomOp).Logger()
+
+	roomID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room id"})
+		return
+	}
+
+	if err := h.chatUsecase.DeleteRoom(c.Request.Context(), roomID); err != nil {
+		if errors.Is(err, usecase.ErrChatRoomNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrChatRoomNotFound.Error()})
+			return
+		}
+		log.Error().Err(err).Int64("room_id", roomID).Msg("Failed to delete room")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete room"})
+		return
+	}
+	h.hub.CloseRoom(roomID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "room deleted"})
+}
+
+// GetMessages godoc
+// @Summary Get chat room history
+// @Description Returns messages of a chat room, oldest first. Without before the latest messages are returned, pass the id of the oldest received message to load the previous page. Rooms of hidden categories are only readable by admins.
+// @Tags chat
+// @Produce json
+// @Param room_id query int false "Room ID, the general room by default" Format(int64)
+// @Param before query int false "Return messages sent before the message with this ID" Format(int64)
+// @Param limit query int false "Number of messages, 50 by default, 100 at most"
+// @Success 200 {object} response.ChatMessagesResponse "Successfully retrieved messages"
+// @Failure 400 {object} response.ErrorResponse "Invalid room ID, before or limit"
+// @Failure 404 {object} response.ErrorResponse "Room not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /chat/messages [get]
+func (h *ChatHandler) GetMessages(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", getMessagesOp).Logger()
+
+	roomID := entity.GeneralChatRoomID
+	if v := c.Query("room_id"); v != "" {
+		var err error
+		roomID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room id"})
+			return
+		}
+	}
+
+	before, limit, err := parseMessagesQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, _ := middleware.GetRoleFromContext(c)
+	if _, err := h.chatUsecase.GetRoom(c.Request.Context(), roomID, role); err != nil {
+		if errors.Is(err, usecase.ErrChatRoomNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrChatRoomNotFound.Error()})
+			return
+		}
+		log.Error().Err(err).Int64("room_id", roomID).Msg("Failed to get room")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get messages"})
+		return
+	}
+
+	messages, err := h.chatUsecase.GetMessageHistory(c.Request.Context(), roomID, before, limit)
+	if err != nil {
+		log.Error().Err(err).Int64("room_id", roomID).Msg("Failed to get messages")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
+
+// GetConversations godoc
+// @Summary List private conversations
+// @Description Lists the private conversations of the current user with the last message and the number of unread messages, most recent first.
+// @Tags chat
+// @Produce json
+// @Success 200 {object} response.ConversationsResponse "Successfully retrieved conversations"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /conversations [get]
+func (h *ChatHandler) GetConversations(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", getConversationsOp).Logger()
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	conversations, err := h.chatUsecase.GetConversations(c.Request.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", userID).Msg("Failed to get conversations")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conversations": conversations})
+}
+
+// GetConversationMessages godoc
+// @Summary Get messages of a private conversation
+// @Description Returns messages of a conversation of the current user, oldest first. Without before the latest messages are returned and the conversation is marked as read.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID" Format(int64)
+// @Param before query int false "Return messages sent before the message with this ID" Format(int64)
+// @Param limit query int false "Number of messages, 50 by default, 100 at most"
+// @Success 200 {object} response.DirectMessagesResponse "Successfully retrieved messages"
+// @Failure 400 {object} response.ErrorResponse "Invalid conversation ID, before or limit"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 404 {object} response.ErrorResponse "Conversation not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /conversations/{id}/messages [get]
+func (h *ChatHandler) GetConversationMessages(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", getConversationMessagesOp).Logger()
+
+	conversationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid conversation id"})
+		return
+	}
+
+	before, limit, err := parseMessagesQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	messages, err := h.chatUsecase.GetConversationMessages(c.Request.Context(), conversationID, userID, before, limit)
+	if err != nil {
+		if errors.Is(err, usecase.ErrConversationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrConversationNotFound.Error()})
+			return
+		}
+		log.Error().Err(err).Int64("conversation_id", conversationID).Msg("Failed to get messages")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
+
+// parseMessagesQuery reads the before message id and the page size of a message history request.
+func parseMessagesQuery(c *gin.Context) (before int64, limit int64, err error) {
+	if v := c.Query("before"); v != "" {
+		before, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || before <= 0 {
+			return 0, 0, errInvalidBefore
+		}
+	}
+
+	limit = defaultMessagesLimit
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || limit <= 0 {
+			return 0, 0, errInvalidLimit
+		}
+		limit = min(limit, maxMessagesLimit)
+	}
+
+	return before, limit, nil
+}
+
+func (h *ChatHandler) getRequestLogger(c *gin.Context) *zerolog.Logger {
+	reqLog := h.log.With().
+		Str("method", c.Request.Method).
+		Str("path", c.Request.URL.Path).
+		Str("remote_addr", c.ClientIP())
+
+	logger := reqLog.Logger()
+	return &logger
+}
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,6 +13,9 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/keshvan/forum-service-sstu-forum/internal/chat"
 	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/response"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/keshvan/forum-service-sstu-forum/mocks"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
@@ -120,3 +125,190 @@ func TestChatHandler_ServeWs_UpgradeFail_BadOrigin_NoHubLogic(t *testing.T) {
 		assert.Contains(t, err.Error(), "bad handshake", "Error message should indicate bad handshake")
 	}
 }
+
+func TestChatHandler_ServeWs_InvalidSince(t *testing.T) {
+	logger := zerolog.Nop()
+	chatHandler := NewChatHandler(chat.NewHub(&logger), new(mocks.ChatUsecase), new(mocks.UserClient), &logger)
+	_, wsURL := setupTestServerForChatOnlyUpgrade(t, chatHandler)
+
+	dialer := websocket.Dialer{HandshakeTimeout: 1 * time.Second}
+	conn, resp, err := dialer.Dial(wsURL+"?since=abc", http.Header{"Origin": []string{"http://localhost:5173"}})
+	if conn != nil {
+		defer conn.Close()
+	}
+
+	assert.Error(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestChatHandler_GetRooms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zerolog.Nop()
+	mockUsecase := mocks.NewChatUsecase(t)
+	handler := NewChatHandler(chat.NewHub(&logger), mockUsecase, mocks.NewUserClient(t), &logger)
+	router := gin.New()
+	router.GET("/chat/rooms", func(c *gin.Context) {
+		c.Set(ContextRoleKey, "admin")
+		c.Next()
+	}, handler.GetRooms)
+
+	rooms := []entity.ChatRoom{{ID: 1, Kind: entity.ChatRoomGeneral, Name: "Общий чат"}}
+	mockUsecase.On("GetRooms", mock.Anything, "admin").Return(rooms, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/chat/rooms", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.ChatRoomsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &respBody))
+	assert.Equal(t, rooms, respBody.Rooms)
+}
+
+func TestChatHandler_CreateRoom(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zerolog.Nop()
+	mockUsecase := mocks.NewChatUsecase(t)
+	handler := NewChatHandler(chat.NewHub(&logger), mockUsecase, mocks.NewUserClient(t), &logger)
+	router := gin.New()
+	router.POST("/chat/rooms", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, int64(1))
+		c.Next()
+	}, handler.CreateRoom)
+
+	mockUsecase.On("CreateRoom", mock.Anything, int64(1), "Экзамены").Return(&entity.ChatRoom{ID: 5, Kind: entity.ChatRoomCustom, Name: "Экзамены"}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodPost, "/chat/rooms", strings.NewReader(`{"name":"Экзамены"}`))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/chat/rooms", strings.NewReader(`{}`))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestChatHandler_DeleteRoom_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zerolog.Nop()
+	mockUsecase := mocks.NewChatUsecase(t)
+	handler := NewChatHandler(chat.NewHub(&logger), mockUsecase, mocks.NewUserClient(t), &logger)
+	router := gin.New()
+	router.DELETE("/chat/rooms/:id", handler.DeleteRoom)
+
+	mockUsecase.On("DeleteRoom", mock.Anything, int64(1)).Return(fmt.Errorf("ChatUsecase - DeleteRoom: %w", usecase.ErrChatRoomNotFound)).Once()
+
+	req, _ := http.NewRequest(http.MethodDelete, "/chat/rooms/1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestChatHandler_GetConversations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zerolog.Nop()
+	mockUsecase := mocks.NewChatUsecase(t)
+	handler := NewChatHandler(chat.NewHub(&logger), mockUsecase, mocks.NewUserClient(t), &logger)
+	router := gin.New()
+	router.GET("/conversations", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, int64(1))
+		c.Next()
+	}, handler.GetConversations)
+
+	conversations := []entity.Conversation{{ID: 3, PeerID: 2, PeerUsername: "bob", UnreadCount: 2}}
+	mockUsecase.On("GetConversations", mock.Anything, int64(1)).Return(conversations, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/conversations", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.ConversationsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &respBody))
+	assert.Equal(t, conversations, respBody.Conversations)
+}
+
+func TestChatHandler_GetConversationMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zerolog.Nop()
+	mockUsecase := mocks.NewChatUsecase(t)
+	handler := NewChatHandler(chat.NewHub(&logger), mockUsecase, mocks.NewUserClient(t), &logger)
+	router := gin.New()
+	router.GET("/conversations/:id/messages", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, int64(1))
+		c.Next()
+	}, handler.GetConversationMessages)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		messages := []entity.DirectMessage{{ID: 9, ConversationID: 3, SenderID: 2, Content: "hi"}}
+		mockUsecase.On("GetConversationMessages", mock.Anything, int64(3), int64(1), int64(10), int64(maxMessagesLimit)).Return(messages, nil).Once()
+
+		rr := get("/conversations/3/messages?before=10&limit=1000")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var respBody response.DirectMessagesResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &respBody))
+		assert.Equal(t, messages, respBody.Messages)
+	})
+
+	t.Run("Not a participant", func(t *testing.T) {
+		mockUsecase.On("GetConversationMessages", mock.Anything, int64(4), int64(1), int64(0), int64(defaultMessagesLimit)).Return(nil, fmt.Errorf("ChatUsecase - GetConversationMessages: %w", usecase.ErrConversationNotFound)).Once()
+
+		assert.Equal(t, http.StatusNotFound, get("/conversations/4/messages").Code)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("/conversations/abc/messages").Code)
+		assert.Equal(t, http.StatusBadRequest, get("/conversations/3/messages?before=abc").Code)
+		assert.Equal(t, http.StatusBadRequest, get("/conversations/3/messages?limit=0").Code)
+	})
+}
+
+func TestChatHandler_GetMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zerolog.Nop()
+	mockUsecase := mocks.NewChatUsecase(t)
+	handler := NewChatHandler(chat.NewHub(&logger), mockUsecase, mocks.NewUserClient(t), &logger)
+	router := gin.New()
+	router.GET("/chat/messages", handler.GetMessages)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		messages := []entity.ChatMessage{{ID: 3, RoomID: entity.GeneralChatRoomID, Content: "older"}}
+		mockUsecase.On("GetRoom", mock.Anything, entity.GeneralChatRoomID, "").Return(&entity.ChatRoom{ID: entity.GeneralChatRoomID}, nil).Once()
+		mockUsecase.On("GetMessageHistory", mock.Anything, entity.GeneralChatRoomID, int64(40), int64(20)).Return(messages, nil).Once()
+
+		rr := get("/chat/messages?before=40&limit=20")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var respBody response.ChatMessagesResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &respBody))
+		assert.Equal(t, messages, respBody.Messages)
+	})
+
+	t.Run("Hidden room", func(t *testing.T) {
+		mockUsecase.On("GetRoom", mock.Anything, int64(5), "").Return(nil, fmt.Errorf("ChatUsecase - GetRoom: %w", usecase.ErrChatRoomNotFound)).Once()
+
+		assert.Equal(t, http.StatusNotFound, get("/chat/messages?room_id=5").Code)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("/chat/messages?room_id=abc").Code)
+		assert.Equal(t, http.StatusBadRequest, get("/chat/messages?before=-1").Code)
+	})
+}
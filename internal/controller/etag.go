@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag derives the entity tag of a category, topic or post from its updated_at,
+// so every write through the API produces a new tag.
+func etag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+// parseIfMatch returns the version required by the If-Match header. The version is nil
+// if the header is absent or "*". ok is false if the header holds a tag this service has
+// never issued, such a precondition can never be met.
+func parseIfMatch(c *gin.Context) (version *time.Time, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, false
+	}
+	micros, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	v := time.UnixMicro(micros)
+	return &v, true
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/go-common-forum/jwt"
 	"github.com/mitchellh/mapstructure"
 )
@@ -86,6 +87,31 @@ func (m *AuthMiddleware) ChatAuth() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuth sets user claims when a valid bearer token is present and lets
+// anonymous requests through otherwise.
+func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.Next()
+			return
+		}
+
+		claims, err := m.jwt.ParseToken(parts[1])
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		var accessClaims AccessClaims
+		mapstructure.Decode(claims, &accessClaims)
+		c.Set(ContextUserIDKey, accessClaims.UserID)
+		c.Set(ContextRoleKey, accessClaims.Role)
+
+		c.Next()
+	}
+}
+
 func RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := GetRoleFromContext(c)
@@ -94,7 +120,7 @@ func RequireAdmin() gin.HandlerFunc {
 			return
 		}
 
-		if role != "admin" {
+		if !policy.IsAdmin(role) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
 			return
 		}
@@ -0,0 +1,327 @@
+package middleware
+
+import (
+	"bytes"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultResponseCacheTTL = time.Minute
+
+	responseCacheSize = 1000
+	skipCacheKey      = "skipResponseCache"
+)
+
+// ResponseCache answers conditional GET requests with 304 Not Modified and, when enabled,
+// keeps rendered responses in memory. Every cached response belongs to a scope, the entity
+// it shows. The usecases evict the scopes of an entity once a write to it has committed, so
+// a cached response never outlives a change made through the API. Changes made elsewhere,
+// such as usernames in the user service, show up once the entries expire after the TTL.
+type ResponseCache struct {
+	enabled bool
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]cachedResponse
+	scopes   map[string]map[string]struct{}
+	gen      uint64
+	modified time.Time
+}
+
+type cachedResponse struct {
+	body        []byte
+	contentType string
+	etag        string
+	scope       string
+	// modified is zero if Last-Modified could not be determined to the second.
+	modified time.Time
+	expires  time.Time
+}
+
+// Scope names the entity a cached response shows.
+type Scope func(c *gin.Context) string
+
+const categoriesScope = "categories"
+
+// CategoriesScope is the scope of the category listing. It shows the stats of every
+// category, so it is evicted by any write to a category, topic or post.
+func CategoriesScope(*gin.Context) string {
+	return categoriesScope
+}
+
+// CategoryTopicsScope is the scope of the topic list of the category in the :id parameter.
+func CategoryTopicsScope(c *gin.Context) string {
+	return "category:" + c.Param("id")
+}
+
+// TopicPostsScope is the scope of the post list of the topic in the :id parameter.
+func TopicPostsScope(c *gin.Context) string {
+	return "topic:" + c.Param("id")
+}
+
+func categoryScope(categoryID int64) string {
+	return "category:" + strconv.FormatInt(categoryID, 10)
+}
+
+func topicScope(topicID int64) string {
+	return "topic:" + strconv.FormatInt(topicID, 10)
+}
+
+func NewResponseCache(enabled bool, ttl time.Duration) *ResponseCache {
+	if ttl <= 0 {
+		ttl = DefaultResponseCacheTTL
+	}
+
+	return &ResponseCache{
+		enabled:  enabled,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]cachedResponse),
+		scopes:   make(map[string]map[string]struct{}),
+		modified: time.Now(),
+	}
+}
+
+// Invalidate drops all cached responses and moves Last-Modified to now. It is meant for
+// writes that change what many entities show, such as hiding or moving a category.
+func (rc *ResponseCache) Invalidate() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	clear(rc.entries)
+	clear(rc.scopes)
+	rc.touch()
+}
+
+// InvalidateCategory drops the category listing and the topic list of the category.
+func (rc *ResponseCache) InvalidateCategory(categoryID int64) {
+	rc.invalidate(categoriesScope, categoryScope(categoryID))
+}
+
+// InvalidateTopic drops what InvalidateCategory drops for the category of the topic and
+// the post list of the topic. The category listing and the topic list show the reply
+// counters and the last post, which triggers on posts keep up to date.
+func (rc *ResponseCache) InvalidateTopic(categoryID, topicID int64) {
+	rc.invalidate(categoriesScope, categoryScope(categoryID), topicScope(topicID))
+}
+
+// InvalidatePosts drops the post list of a topic, for changes to posts that leave the
+// counters and the last post of the topic as they are, such as edits and reactions.
+func (rc *ResponseCache) InvalidatePosts(topicID int64) {
+	rc.invalidate(topicScope(topicID))
+}
+
+func (rc *ResponseCache) invalidate(scopes ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, scope := range scopes {
+		for key := range rc.scopes[scope] {
+			delete(rc.entries, key)
+		}
+		delete(rc.scopes, scope)
+	}
+	rc.touch()
+}
+
+// touch moves Last-Modified to now and keeps responses rendered before from being
+// cached. rc.mu must be held.
+func (rc *ResponseCache) touch() {
+	rc.gen++
+	rc.modified = rc.now()
+}
+
+// Handler serves the route from the cache and sets ETag and Last-Modified on its responses.
+// It has to run after the auth middleware, responses are cached per viewer.
+func (rc *ResponseCache) Handler(scope Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := cacheKey(c)
+
+		rc.mu.Lock()
+		entry, ok := rc.entries[key]
+		gen, modified := rc.gen, rc.modified
+		rc.mu.Unlock()
+
+		now := rc.now()
+		if ok && now.Before(entry.expires) {
+			respondCached(c, entry)
+			c.Abort()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.status != http.StatusOK {
+			c.Writer.WriteHeader(w.status)
+			c.Writer.Write(w.body.Bytes())
+			return
+		}
+
+		entry = cachedResponse{
+			body:        w.body.Bytes(),
+			contentType: w.Header().Get("Content-Type"),
+			etag:        bodyETag(w.body.Bytes()),
+			scope:       scope(c),
+			expires:     now.Add(rc.ttl),
+		}
+		// Last-Modified has a resolution of one second. It is only sent when the last write
+		// happened in an earlier second, otherwise a write later in the same second would
+		// not be noticed by If-Modified-Since.
+		if now.Truncate(time.Second).After(modified.Truncate(time.Second)) {
+			entry.modified = modified.Truncate(time.Second)
+		}
+
+		if rc.enabled && !c.GetBool(skipCacheKey) {
+			rc.mu.Lock()
+			// Skip responses rendered while a write invalidated the cache, they may be stale.
+			if rc.gen == gen {
+				if len(rc.entries) >= responseCacheSize {
+					rc.evict(now)
+				}
+				rc.put(key, entry)
+			}
+			rc.mu.Unlock()
+		}
+
+		respondCached(c, entry)
+	}
+}
+
+// SkipResponseCache keeps the response of the current request out of the cache,
+// for responses that are incomplete and should not be served again.
+func SkipResponseCache(c *gin.Context) {
+	c.Set(skipCacheKey, true)
+}
+
+// put stores an entry and indexes it by its scope. rc.mu must be held.
+func (rc *ResponseCache) put(key string, entry cachedResponse) {
+	if old, ok := rc.entries[key]; ok {
+		rc.remove(key, old)
+	}
+	rc.entries[key] = entry
+
+	keys := rc.scopes[entry.scope]
+	if keys == nil {
+		keys = make(map[string]struct{})
+		rc.scopes[entry.scope] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+// remove deletes an entry and its index entry. rc.mu must be held.
+func (rc *ResponseCache) remove(key string, entry cachedResponse) {
+	delete(rc.entries, key)
+
+	keys := rc.scopes[entry.scope]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(rc.scopes, entry.scope)
+	}
+}
+
+// evict removes expired entries, or an arbitrary one if none has expired. rc.mu must be held.
+func (rc *ResponseCache) evict(now time.Time) {
+	for key, entry := range rc.entries {
+		if !now.Before(entry.expires) {
+			rc.remove(key, entry)
+		}
+	}
+	if len(rc.entries) < responseCacheSize {
+		return
+	}
+	for key, entry := range rc.entries {
+		rc.remove(key, entry)
+		return
+	}
+}
+
+func respondCached(c *gin.Context, entry cachedResponse) {
+	c.Header("ETag", entry.etag)
+	if !entry.modified.IsZero() {
+		c.Header("Last-Modified", entry.modified.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Vary", "Authorization")
+
+	if notModified(c.Request, entry) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, entry.contentType, entry.body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since if the former is absent.
+func notModified(r *http.Request, entry cachedResponse) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == entry.etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !entry.modified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !entry.modified.After(t)
+	}
+	return false
+}
+
+func cacheKey(c *gin.Context) string {
+	userID, _ := GetUserIDFromContext(c)
+	role, _ := GetRoleFromContext(c)
+	return c.Request.URL.RequestURI() + "|" + strconv.FormatInt(userID, 10) + "|" + role
+}
+
+func bodyETag(body []byte) string {
+	h := fnv.New64a()
+	h.Write(body)
+	return `"` + strconv.FormatUint(h.Sum64(), 16) + `"`
+}
+
+// bufferedWriter holds back the response of the handler so that it can be cached
+// and replaced by 304 Not Modified.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCacheRouter(rc *ResponseCache, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	router.GET("/items", func(c *gin.Context) {
+		if userID := c.GetHeader("X-User"); userID != "" {
+			c.Set(ContextUserIDKey, int64(len(userID)))
+		}
+		c.Next()
+	}, rc.Handler(CategoriesScope), func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusOK, gin.H{"items": []int{1, 2, 3}})
+	})
+	router.GET("/partial", rc.Handler(CategoriesScope), func(c *gin.Context) {
+		*calls++
+		SkipResponseCache(c)
+		c.JSON(http.StatusOK, gin.H{"items": []int{}})
+	})
+	router.GET("/missing", rc.Handler(CategoriesScope), func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	})
+	router.GET("/categories/:id/topics", rc.Handler(CategoryTopicsScope), func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusOK, gin.H{"topics": []int{}})
+	})
+	router.GET("/topics/:id/posts", rc.Handler(TopicPostsScope), func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusOK, gin.H{"posts": []int{}})
+	})
+
+	return router
+}
+
+func serve(router *gin.Engine, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestResponseCache_IfNoneMatch(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		calls := 0
+		router := newCacheRouter(NewResponseCache(enabled, time.Minute), &calls)
+
+		rr := serve(router, http.MethodGet, "/items", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"items":[1,2,3]}`, rr.Body.String())
+		etag := rr.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		rr = serve(router, http.MethodGet, "/items", map[string]string{"If-None-Match": `"other", ` + etag})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		assert.Equal(t, etag, rr.Header().Get("ETag"))
+
+		rr = serve(router, http.MethodGet, "/items", map[string]string{"If-None-Match": `"other"`})
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		if enabled {
+			assert.Equal(t, 1, calls, "cached responses must not reach the handler")
+		} else {
+			assert.Equal(t, 3, calls)
+		}
+	}
+}
+
+func TestResponseCache_InvalidateTopic(t *testing.T) {
+	calls := 0
+	rc := NewResponseCache(true, time.Minute)
+	router := newCacheRouter(rc, &calls)
+
+	paths := []string{"/items", "/categories/1/topics", "/categories/2/topics", "/topics/10/posts", "/topics/20/posts"}
+	for _, path := range paths {
+		serve(router, http.MethodGet, path, nil)
+	}
+	assert.Equal(t, 5, calls)
+
+	rc.InvalidateTopic(1, 10)
+	for _, path := range paths {
+		serve(router, http.MethodGet, path, nil)
+	}
+	assert.Equal(t, 8, calls, "the listing, category 1 and topic 10 are rendered again")
+
+	calls = 0
+	for _, path := range []string{"/categories/2/topics", "/topics/20/posts"} {
+		serve(router, http.MethodGet, path, nil)
+	}
+	assert.Zero(t, calls, "category 2 and topic 20 stay cached")
+}
+
+func TestResponseCache_InvalidatePosts(t *testing.T) {
+	calls := 0
+	rc := NewResponseCache(true, time.Minute)
+	router := newCacheRouter(rc, &calls)
+
+	paths := []string{"/items", "/categories/1/topics", "/topics/10/posts", "/topics/20/posts"}
+	for _, path := range paths {
+		serve(router, http.MethodGet, path, nil)
+	}
+
+	rc.InvalidatePosts(10)
+	for _, path := range paths {
+		serve(router, http.MethodGet, path, nil)
+	}
+	assert.Equal(t, 5, calls, "only topic 10 is rendered again")
+}
+
+func TestResponseCache_Invalidate(t *testing.T) {
+	calls := 0
+	rc := NewResponseCache(true, time.Minute)
+	router := newCacheRouter(rc, &calls)
+
+	serve(router, http.MethodGet, "/items", nil)
+	serve(router, http.MethodGet, "/topics/10/posts", nil)
+	rc.Invalidate()
+	serve(router, http.MethodGet, "/items", nil)
+	serve(router, http.MethodGet, "/topics/10/posts", nil)
+	assert.Equal(t, 4, calls)
+}
+
+func TestResponseCache_Expires(t *testing.T) {
+	calls := 0
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	rc := NewResponseCache(true, time.Minute)
+	rc.now = func() time.Time { return now }
+	router := newCacheRouter(rc, &calls)
+
+	serve(router, http.MethodGet, "/items", nil)
+	now = now.Add(30 * time.Second)
+	serve(router, http.MethodGet, "/items", nil)
+	assert.Equal(t, 1, calls)
+
+	now = now.Add(time.Minute)
+	serve(router, http.MethodGet, "/items", nil)
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCache_PerViewer(t *testing.T) {
+	calls := 0
+	router := newCacheRouter(NewResponseCache(true, time.Minute), &calls)
+
+	serve(router, http.MethodGet, "/items", nil)
+	serve(router, http.MethodGet, "/items", map[string]string{"X-User": "a"})
+	serve(router, http.MethodGet, "/items", map[string]string{"X-User": "a"})
+	serve(router, http.MethodGet, "/items?limit=1", nil)
+	assert.Equal(t, 3, calls)
+}
+
+func TestResponseCache_ErrorsAreNotCached(t *testing.T) {
+	calls := 0
+	router := newCacheRouter(NewResponseCache(true, time.Minute), &calls)
+
+	for range 2 {
+		rr := serve(router, http.MethodGet, "/missing", nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.JSONEq(t, `{"error":"not found"}`, rr.Body.String())
+		assert.Empty(t, rr.Header().Get("ETag"))
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCache_Skip(t *testing.T) {
+	calls := 0
+	router := newCacheRouter(NewResponseCache(true, time.Minute), &calls)
+
+	for range 2 {
+		rr := serve(router, http.MethodGet, "/partial", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEmpty(t, rr.Header().Get("ETag"))
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCache_IfModifiedSince(t *testing.T) {
+	calls := 0
+	now := time.Date(2025, 5, 20, 12, 0, 0, 500, time.UTC)
+	rc := NewResponseCache(false, time.Minute)
+	rc.now = func() time.Time { return now }
+	router := newCacheRouter(rc, &calls)
+
+	t.Run("Write in the same second", func(t *testing.T) {
+		rc.Invalidate()
+
+		rr := serve(router, http.MethodGet, "/items", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Last-Modified"))
+	})
+
+	t.Run("Write in an earlier second", func(t *testing.T) {
+		now = now.Add(time.Second)
+
+		rr := serve(router, http.MethodGet, "/items", nil)
+		lastModified := rr.Header().Get("Last-Modified")
+		assert.Equal(t, "Tue, 20 May 2025 12:00:00 GMT", lastModified)
+
+		rr = serve(router, http.MethodGet, "/items", map[string]string{"If-Modified-Since": lastModified})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+
+		rc.Invalidate()
+		now = now.Add(time.Second)
+		rr = serve(router, http.MethodGet, "/items", map[string]string{"If-Modified-Since": lastModified})
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("If-None-Match takes precedence", func(t *testing.T) {
+		rr := serve(router, http.MethodGet, "/items", nil)
+
+		rr = serve(router, http.MethodGet, "/items", map[string]string{
+			"If-None-Match":     `"other"`,
+			"If-Modified-Since": rr.Header().Get("Last-Modified"),
+		})
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+)
+
+var errInvalidLimit = errors.New("invalid limit")
+
+func parsePageRequest(c *gin.Context) (entity.PageRequest, error) {
+	var page entity.PageRequest
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n <= 0 {
+			return page, errInvalidLimit
+		}
+		page.Limit = n
+	}
+
+	after, before := c.Query("after"), c.Query("before")
+	if after != "" && before != "" {
+		return page, entity.ErrInvalidCursor
+	}
+
+	if after != "" {
+		cursor, err := entity.DecodeCursor(after)
+		if err != nil {
+			return page, err
+		}
+		page.After = cursor
+	}
+
+	if before != "" {
+		cursor, err := entity.DecodeCursor(before)
+		if err != nil {
+			return page, err
+		}
+		page.Before = cursor
+	}
+
+	return page, nil
+}
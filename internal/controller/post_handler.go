@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
 	postrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/post_requests"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/rs/zerolog"
 )
@@ -27,9 +29,10 @@ type PostHandler struct {
 // @Param id path int true "Topic ID to create post in" Format(int64)
 // @Param post body entity.Post true "Post data to create. ID, TopicID, AuthorID, Username, CreatedAt, UpdatedAt will be ignored or overridden."
 // @Success 200 {object} response.IDResponse "Post created successfully"
-// @Failure 400 {object} response.ErrorResponse "Invalid topic ID or request payload, or topic not found"
+// @Failure 400 {object} response.ErrorResponse "Invalid topic ID or request payload, topic not found, or reply_to is not a post in this topic"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
 // @Failure 403 {object} response.ErrorResponse "Forbidden (user is not authorized)"
+// @Failure 423 {object} response.ErrorResponse "Topic is locked"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
 // @Router /topics/{id}/posts [post]
@@ -61,6 +64,14 @@ func (h *PostHandler) Create(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, usecase.ErrInvalidReplyTarget) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidReplyTarget.Error()})
+			return
+		}
+		if errors.Is(err, usecase.ErrTopicLocked) {
+			c.JSON(http.StatusLocked, gin.H{"error": usecase.ErrTopicLocked.Error()})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -71,14 +82,25 @@ func (h *PostHandler) Create(c *gin.Context) {
 
 // GetByTopic godoc
 // @Summary Get posts by topic ID
-// @Description Retrieves a list of posts for a topic ID.
+// @Description Retrieves a list of posts for a topic ID. Each post carries reaction counts and, for authenticated callers, their own reactions. With view=tree the page is made of top-level posts with their replies nested under them (see response.PostTreeResponse). Topics in hidden categories are only visible to admins.
 // @Tags posts
 // @Produce json
 // @Param id path int true "Topic ID" Format(int64)
+// @Param view query string false "Listing mode" Enums(flat, tree)
+// @Param depth query int false "Max reply depth in tree view (default 5, max 20)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param after query string false "Cursor to fetch the page after (newer posts)"
+// @Param before query string false "Cursor to fetch the page before (older posts)"
+// @Param If-None-Match header string false "ETag of a previous response, 304 is returned if it is still current"
+// @Param If-Modified-Since header string false "Last-Modified of a previous response, 304 is returned if nothing has changed since"
 // @Success 200 {object} response.PostsResponse "Successfully retrieved posts"
-// @Failure 400 {object} response.ErrorResponse "Invalid topic ID"
+// @Header 200 {string} ETag "Version of the response"
+// @Header 200 {string} Last-Modified "Time of the last change, omitted if it was less than a second ago"
+// @Success 304 "Not modified"
+// @Failure 400 {object} response.ErrorResponse "Invalid topic ID, view, depth, limit or cursor"
 // @Failure 404 {object} response.ErrorResponse "Topic not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
 // @Router /topics/{id}/posts [get]
 func (h *PostHandler) GetByTopic(c *gin.Context) {
 	topicID, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -87,7 +109,28 @@ func (h *PostHandler) GetByTopic(c *gin.Context) {
 		return
 	}
 
-	posts, err := h.usecase.GetByTopic(c.Request.Context(), topicID)
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Anonymous readers get viewerID 0 and no "my_reactions".
+	viewerID, _ := middleware.GetUserIDFromContext(c)
+	role, _ := middleware.GetRoleFromContext(c)
+	includeHidden := policy.IsAdmin(role)
+
+	switch c.DefaultQuery("view", "flat") {
+	case "flat":
+	case "tree":
+		h.getTree(c, topicID, viewerID, includeHidden, page)
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid view"})
+		return
+	}
+
+	posts, pageInfo, err := h.usecase.GetByTopic(c.Request.Context(), topicID, viewerID, includeHidden, page)
 	if err != nil {
 		if errors.Is(err, usecase.ErrTopicNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -97,22 +140,147 @@ func (h *PostHandler) GetByTopic(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"posts": posts})
+	if pageInfo.Degraded {
+		middleware.SkipResponseCache(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"posts": posts, "next_cursor": pageInfo.NextCursor, "prev_cursor": pageInfo.PrevCursor, "degraded": pageInfo.Degraded})
+}
+
+func (h *PostHandler) getTree(c *gin.Context, topicID int64, viewerID int64, includeHidden bool, page entity.PageRequest) {
+	depth, err := parseDepth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	posts, pageInfo, err := h.usecase.GetTree(c.Request.Context(), topicID, viewerID, includeHidden, page, depth)
+	if err != nil {
+		if errors.Is(err, usecase.ErrTopicNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrTopicNotFound.Error()})
+			return
+		}
+
+		h.log.Error().Err(err).Str("op", "PostHandler.GetByTopic").Int64("topic_id", topicID).Msg("failed to get post tree")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if pageInfo.Degraded {
+		middleware.SkipResponseCache(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"posts": posts, "next_cursor": pageInfo.NextCursor, "prev_cursor": pageInfo.PrevCursor, "degraded": pageInfo.Degraded})
+}
+
+// GetByID godoc
+// @Summary Get a post by ID
+// @Description Retrieves a single post, e.g. to edit it. Posts in hidden categories are only visible to admins.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID" Format(int64)
+// @Success 200 {object} response.PostResponse "Successfully retrieved post"
+// @Header 200 {string} ETag "Version of the post, send it back in If-Match when updating the post"
+// @Failure 400 {object} response.ErrorResponse "Invalid post ID"
+// @Failure 404 {object} response.ErrorResponse "Post not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /posts/{id} [get]
+func (h *PostHandler) GetByID(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
+		return
+	}
+
+	role, _ := middleware.GetRoleFromContext(c)
+	post, degraded, err := h.usecase.GetByID(c.Request.Context(), postID, policy.IsAdmin(role))
+	if err != nil {
+		if errors.Is(err, usecase.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return
+		}
+
+		h.log.Error().Err(err).Str("op", "PostHandler.GetByID").Int64("post_id", postID).Msg("failed to get post")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if degraded {
+		middleware.SkipResponseCache(c)
+	}
+	c.Header("ETag", etag(post.UpdatedAt))
+	c.JSON(http.StatusOK, gin.H{"post": post, "degraded": degraded})
+}
+
+// GetThread godoc
+// @Summary Get the thread of a post
+// @Description Retrieves the chain of posts the given post replies to (root first) and the replies nested under it.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID" Format(int64)
+// @Param depth query int false "Max reply depth (default 5, max 20)"
+// @Success 200 {object} response.PostThreadResponse "Successfully retrieved thread"
+// @Header 200 {string} ETag "Version of the post, send it back in If-Match when updating the post"
+// @Failure 400 {object} response.ErrorResponse "Invalid post ID or depth"
+// @Failure 404 {object} response.ErrorResponse "Post not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /posts/{id}/thread [get]
+func (h *PostHandler) GetThread(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
+		return
+	}
+
+	depth, err := parseDepth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	thread, degraded, err := h.usecase.GetThread(c.Request.Context(), postID, depth)
+	if err != nil {
+		if errors.Is(err, usecase.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return
+		}
+
+		h.log.Error().Err(err).Str("op", "PostHandler.GetThread").Int64("post_id", postID).Msg("failed to get thread")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if degraded {
+		middleware.SkipResponseCache(c)
+	}
+	c.Header("ETag", etag(thread.Post.UpdatedAt))
+	c.JSON(http.StatusOK, gin.H{"thread": thread, "degraded": degraded})
+}
+
+func parseDepth(c *gin.Context) (int, error) {
+	v := c.Query("depth")
+	if v == "" {
+		return 0, nil
+	}
+
+	depth, err := strconv.Atoi(v)
+	if err != nil || depth <= 0 {
+		return 0, errors.New("invalid depth")
+	}
+	return depth, nil
 }
 
 // Update godoc
 // @Summary Update a post
-// @Description Updates a post. Requires authentication and ownership or admin role.
+// @Description Updates a post. Requires authentication and ownership, admin role or moderator rights in the category.
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param id path int true "Post ID" Format(int64)
 // @Param post_update body postrequests.UpdateRequest true "Post update data (only content)"
+// @Param If-Match header string false "ETag of the post from a previous GET, the update is rejected if the post has changed since"
 // @Success 200 {object} response.SuccessMessageResponse "Post updated successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid post ID or request payload"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
-// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner or admin)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner, admin or moderator of the category)"
 // @Failure 404 {object} response.ErrorResponse "Post not found"
+// @Failure 412 {object} response.ErrorResponse "Post has been modified since it was read"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
 // @Router /posts/{id} [patch]
@@ -136,7 +304,13 @@ func (h *PostHandler) Update(c *gin.Context) {
 		return
 	}
 
-	err = h.usecase.Update(c.Request.Context(), postID, userID, role, req.Content)
+	version, ok := parseIfMatch(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": usecase.ErrVersionConflict.Error()})
+		return
+	}
+
+	err = h.usecase.Update(c.Request.Context(), postID, userID, role, req.Content, version)
 	if err != nil {
 		if errors.Is(err, usecase.ErrForbidden) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
@@ -146,6 +320,10 @@ func (h *PostHandler) Update(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
 			return
 		}
+		if errors.Is(err, usecase.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": usecase.ErrVersionConflict.Error()})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		return
@@ -156,13 +334,13 @@ func (h *PostHandler) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a post
-// @Description Deletes a post by its ID. Requires authentication and ownership or admin role.
+// @Description Deletes a post by its ID. The post stays in listings with placeholder content and can be restored by an admin until it is purged. Requires authentication and ownership, admin role or moderator rights in the category.
 // @Tags posts
 // @Param id path int true "Post ID" Format(int64)
 // @Success 200 {object} response.SuccessMessageResponse "Post deleted successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid post ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
-// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner or admin)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner, admin or moderator of the category)"
 // @Failure 404 {object} response.ErrorResponse "Post not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
@@ -197,3 +375,212 @@ func (h *PostHandler) Delete(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "post deleted"})
 }
+
+// Restore godoc
+// @Summary Restore a deleted post
+// @Description Restores a deleted post. Requires admin role.
+// @Tags posts
+// @Param id path int true "Post ID" Format(int64)
+// @Success 200 {object} response.SuccessMessageResponse "Post restored successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid post ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 404 {object} response.ErrorResponse "Deleted post not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /posts/{id}/restore [post]
+func (h *PostHandler) Restore(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
+		return
+	}
+
+	if err := h.usecase.Restore(c.Request.Context(), postID); err != nil {
+		if errors.Is(err, usecase.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "post restored"})
+}
+
+// GetRevisions godoc
+// @Summary Get the edit history of a post
+// @Description Retrieves previous versions of a post, oldest first. Each revision holds the content before an edit and who made that edit. Requires authentication and ownership, admin role or moderator rights in the category.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID" Format(int64)
+// @Success 200 {object} response.PostRevisionsResponse "Successfully retrieved revisions"
+// @Failure 400 {object} response.ErrorResponse "Invalid post ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner, admin or moderator of the category)"
+// @Failure 404 {object} response.ErrorResponse "Post not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /posts/{id}/revisions [get]
+func (h *PostHandler) GetRevisions(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+	role, _ := middleware.GetRoleFromContext(c)
+
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
+		return
+	}
+
+	revisions, err := h.usecase.GetRevisions(c.Request.Context(), postID, userID, role)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			return
+		}
+		if errors.Is(err, usecase.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return
+		}
+
+		h.log.Error().Err(err).Str("op", "PostHandler.GetRevisions").Int64("post_id", postID).Msg("failed to get revisions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// DiffRevisions godoc
+// @Summary Compare two revisions of a post
+// @Description Returns a line-level diff between two revisions of a post. Without "to" the revision is compared with the current content. Requires authentication and ownership, admin role or moderator rights in the category.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID" Format(int64)
+// @Param from query int true "Revision ID to compare from" Format(int64)
+// @Param to query int false "Revision ID to compare to (defaults to the current content)" Format(int64)
+// @Success 200 {object} response.RevisionDiffResponse "Successfully compared revisions"
+// @Failure 400 {object} response.ErrorResponse "Invalid post or revision ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner, admin or moderator of the category)"
+// @Failure 404 {object} response.ErrorResponse "Post or revision not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /posts/{id}/revisions/diff [get]
+func (h *PostHandler) DiffRevisions(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+	role, _ := middleware.GetRoleFromContext(c)
+
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
+		return
+	}
+
+	from, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from revision id"})
+		return
+	}
+
+	var to *int64
+	if v := c.Query("to"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to revision id"})
+			return
+		}
+		to = &id
+	}
+
+	diff, err := h.usecase.DiffRevisions(c.Request.Context(), postID, userID, role, from, to)
+	if err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			return
+		}
+		if errors.Is(err, usecase.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return
+		}
+		if errors.Is(err, usecase.ErrRevisionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrRevisionNotFound.Error()})
+			return
+		}
+
+		h.log.Error().Err(err).Str("op", "PostHandler.DiffRevisions").Int64("post_id", postID).Msg("failed to diff revisions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"diff": diff})
+}
+
+// AddReaction godoc
+// @Summary React to a post
+// @Description Adds the caller's reaction to a post. Adding the same reaction twice has no effect. Requires authentication.
+// @Tags posts
+// @Param id path int true "Post ID" Format(int64)
+// @Param reaction path string true "Reaction" Enums(like, dislike, heart, laugh, wow, sad, fire)
+// @Success 200 {object} response.SuccessMessageResponse "Reaction added successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid post ID or unknown reaction"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 404 {object} response.ErrorResponse "Post not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /posts/{id}/reactions/{reaction} [put]
+func (h *PostHandler) AddReaction(c *gin.Context) {
+	h.handleReaction(c, h.usecase.AddReaction, "reaction added")
+}
+
+// RemoveReaction godoc
+// @Summary Remove a reaction from a post
+// @Description Removes the caller's reaction from a post. Requires authentication.
+// @Tags posts
+// @Param id path int true "Post ID" Format(int64)
+// @Param reaction path string true "Reaction" Enums(like, dislike, heart, laugh, wow, sad, fire)
+// @Success 200 {object} response.SuccessMessageResponse "Reaction removed successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid post ID or unknown reaction"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 404 {object} response.ErrorResponse "Post not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /posts/{id}/reactions/{reaction} [delete]
+func (h *PostHandler) RemoveReaction(c *gin.Context) {
+	h.handleReaction(c, h.usecase.RemoveReaction, "reaction removed")
+}
+
+func (h *PostHandler) handleReaction(c *gin.Context, apply func(ctx context.Context, postID int64, userID int64, reaction string) error, message string) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
+		return
+	}
+
+	if err := apply(c.Request.Context(), postID, userID, c.Param("reaction")); err != nil {
+		if errors.Is(err, usecase.ErrInvalidReaction) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidReaction.Error()})
+			return
+		}
+		if errors.Is(err, usecase.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	postrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/post_requests"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/response"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/forum-service-sstu-forum/internal/usecase" // Импортируем usecase для ошибок
 	"github.com/keshvan/forum-service-sstu-forum/mocks"
@@ -184,6 +187,83 @@ func TestPostHandler_Create_TopicNotFound(t *testing.T) {
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestPostHandler_Create_InvalidReplyTarget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	userID := int64(10)
+	router.POST("/topics/:id/posts", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextRoleKey, "user")
+		handler.Create(c)
+	})
+
+	replyTo := int64(99)
+	reqBody := entity.Post{Content: "Test Content", ReplyTo: &replyTo}
+	wrappedErr := fmt.Errorf("ForumService - PostUsecase - checkReplyTarget: %w", usecase.ErrInvalidReplyTarget)
+
+	expectedEntityPost := entity.Post{TopicID: topicID, AuthorID: &userID, Content: reqBody.Content, ReplyTo: &replyTo}
+	mockUsecase.On("Create", mock.Anything, expectedEntityPost).Return(int64(0), wrappedErr).Once()
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest(http.MethodPost, "/topics/"+strconv.FormatInt(topicID, 10)+"/posts", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ErrInvalidReplyTarget.Error(), respBody["error"])
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_Create_TopicLocked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	userID := int64(10)
+	router.POST("/topics/:id/posts", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextRoleKey, "user")
+		handler.Create(c)
+	})
+
+	reqBody := entity.Post{Content: "Test Content"}
+	wrappedErr := fmt.Errorf("ForumService - PostUsecase - Create: %w", usecase.ErrTopicLocked)
+
+	expectedEntityPost := entity.Post{TopicID: topicID, AuthorID: &userID, Content: reqBody.Content}
+	mockUsecase.On("Create", mock.Anything, expectedEntityPost).Return(int64(0), wrappedErr).Once()
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest(http.MethodPost, "/topics/"+strconv.FormatInt(topicID, 10)+"/posts", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusLocked, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ErrTopicLocked.Error(), respBody["error"])
+	mockUsecase.AssertExpectations(t)
+}
+
 func TestPostHandler_Create_UsecaseError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -238,21 +318,128 @@ func TestPostHandler_GetByTopic_Success(t *testing.T) {
 		{ID: 1, TopicID: topicID, Content: "Post 1", Username: "User1"},
 		{ID: 2, TopicID: topicID, Content: "Post 2", Username: "User2"},
 	}
-	mockUsecase.On("GetByTopic", mock.Anything, topicID).Return(expectedPosts, nil).Once()
+	mockUsecase.On("GetByTopic", mock.Anything, topicID, int64(0), false, entity.PageRequest{}).Return(expectedPosts, entity.PageInfo{NextCursor: "next"}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/topics/"+strconv.FormatInt(topicID, 10)+"/posts", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.PostsResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Len(t, respBody.Posts, 2)
+	assert.Equal(t, expectedPosts[0].Content, respBody.Posts[0].Content)
+	assert.Equal(t, "next", respBody.NextCursor)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_GetByTopic_Degraded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	router.GET("/topics/:id/posts", handler.GetByTopic)
+
+	expectedPosts := []entity.Post{{ID: 1, TopicID: topicID, Content: "Post 1", Username: "Пользователь"}}
+	mockUsecase.On("GetByTopic", mock.Anything, topicID, int64(0), false, entity.PageRequest{}).Return(expectedPosts, entity.PageInfo{Degraded: true}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/topics/"+strconv.FormatInt(topicID, 10)+"/posts", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.PostsResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.True(t, respBody.Degraded)
+	assert.Len(t, respBody.Posts, 1)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_GetByTopic_WithCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	router.GET("/topics/:id/posts", handler.GetByTopic)
+
+	cursor := entity.Cursor{CreatedAt: time.Unix(1700000000, 0).UTC(), ID: 7}
+	expectedPage := entity.PageRequest{Limit: 10, After: &cursor}
+	mockUsecase.On("GetByTopic", mock.Anything, topicID, int64(0), false, expectedPage).Return([]entity.Post{}, entity.PageInfo{}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/topics/"+strconv.FormatInt(topicID, 10)+"/posts?limit=10&after="+cursor.Encode(), nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_GetByTopic_AuthenticatedViewer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	viewerID := int64(7)
+	router.GET("/topics/:id/posts", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, viewerID)
+		handler.GetByTopic(c)
+	})
+
+	expectedPosts := []entity.Post{{ID: 1, TopicID: topicID, Content: "Post 1", Reactions: map[string]int64{"like": 2}, MyReactions: []string{"like"}}}
+	mockUsecase.On("GetByTopic", mock.Anything, topicID, viewerID, false, entity.PageRequest{}).Return(expectedPosts, entity.PageInfo{}, nil).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/topics/"+strconv.FormatInt(topicID, 10)+"/posts", nil)
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
-	var respBody map[string][]entity.Post
+	var respBody response.PostsResponse
 	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
 	assert.NoError(t, err)
-	assert.Len(t, respBody["posts"], 2)
-	assert.Equal(t, expectedPosts[0].Content, respBody["posts"][0].Content)
+	assert.Equal(t, expectedPosts, respBody.Posts)
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestPostHandler_GetByTopic_InvalidCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/topics/:id/posts", handler.GetByTopic)
+
+	req, _ := http.NewRequest(http.MethodGet, "/topics/1/posts?after=not-a-cursor", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.ErrInvalidCursor.Error(), respBody["error"])
+	mockUsecase.AssertNotCalled(t, "GetByTopic", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestPostHandler_GetByTopic_InvalidTopicID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -273,7 +460,7 @@ func TestPostHandler_GetByTopic_InvalidTopicID(t *testing.T) {
 	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
 	assert.NoError(t, err)
 	assert.Equal(t, "invalid topic id", respBody["error"])
-	mockUsecase.AssertNotCalled(t, "GetByTopic", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "GetByTopic", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_GetByTopic_TopicNotFound(t *testing.T) {
@@ -289,7 +476,7 @@ func TestPostHandler_GetByTopic_TopicNotFound(t *testing.T) {
 	router.GET("/topics/:id/posts", handler.GetByTopic)
 
 	usecaseError := usecase.ErrTopicNotFound
-	mockUsecase.On("GetByTopic", mock.Anything, topicID).Return(nil, usecaseError).Once()
+	mockUsecase.On("GetByTopic", mock.Anything, topicID, int64(0), false, entity.PageRequest{}).Return(nil, entity.PageInfo{}, usecaseError).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/topics/"+strconv.FormatInt(topicID, 10)+"/posts", nil)
 	rr := httptest.NewRecorder()
@@ -316,7 +503,7 @@ func TestPostHandler_GetByTopic_UsecaseError(t *testing.T) {
 	router.GET("/topics/:id/posts", handler.GetByTopic)
 
 	usecaseError := errors.New("some other get by topic error")
-	mockUsecase.On("GetByTopic", mock.Anything, topicID).Return(nil, usecaseError).Once()
+	mockUsecase.On("GetByTopic", mock.Anything, topicID, int64(0), false, entity.PageRequest{}).Return(nil, entity.PageInfo{}, usecaseError).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/topics/"+strconv.FormatInt(topicID, 10)+"/posts", nil)
 	rr := httptest.NewRecorder()
@@ -330,6 +517,152 @@ func TestPostHandler_GetByTopic_UsecaseError(t *testing.T) {
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestPostHandler_GetByTopic_TreeView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	router.GET("/topics/:id/posts", handler.GetByTopic)
+
+	rootID := int64(1)
+	reply := &entity.PostNode{Post: entity.Post{ID: 2, TopicID: topicID, Content: "reply", ReplyTo: &rootID}, Depth: 1, Replies: []*entity.PostNode{}}
+	tree := []*entity.PostNode{{Post: entity.Post{ID: rootID, TopicID: topicID, Content: "root"}, Replies: []*entity.PostNode{reply}}}
+	mockUsecase.On("GetTree", mock.Anything, topicID, int64(0), false, entity.PageRequest{}, 3).Return(tree, entity.PageInfo{}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/topics/"+strconv.FormatInt(topicID, 10)+"/posts?view=tree&depth=3", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.PostTreeResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Len(t, respBody.Posts, 1)
+	assert.Len(t, respBody.Posts[0].Replies, 1)
+	assert.Equal(t, "reply", respBody.Posts[0].Replies[0].Content)
+	mockUsecase.AssertNotCalled(t, "GetByTopic", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostHandler_GetByTopic_InvalidView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/topics/:id/posts", handler.GetByTopic)
+
+	req, _ := http.NewRequest(http.MethodGet, "/topics/1/posts?view=graph", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestPostHandler_GetByID_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	postID := int64(2)
+	router.GET("/posts/:id", handler.GetByID)
+
+	post := &entity.Post{ID: postID, Content: "post", UpdatedAt: time.UnixMicro(1700000000123456)}
+	mockUsecase.On("GetByID", mock.Anything, postID, false).Return(post, false, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/"+strconv.FormatInt(postID, 10), nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"1700000000123456"`, rr.Header().Get("ETag"))
+	var respBody response.PostResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, postID, respBody.Post.ID)
+}
+
+func TestPostHandler_GetByID_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/posts/:id", handler.GetByID)
+
+	mockUsecase.On("GetByID", mock.Anything, int64(9), false).Return(nil, false, usecase.ErrPostNotFound).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/9", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestPostHandler_GetThread_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	postID := int64(2)
+	router.GET("/posts/:id/thread", handler.GetThread)
+
+	thread := &entity.PostThread{
+		Ancestors: []entity.Post{{ID: 1, Content: "root"}},
+		Post:      &entity.PostNode{Post: entity.Post{ID: postID, Content: "post"}, Replies: []*entity.PostNode{}},
+	}
+	mockUsecase.On("GetThread", mock.Anything, postID, 0).Return(thread, false, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/"+strconv.FormatInt(postID, 10)+"/thread", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.PostThreadResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Len(t, respBody.Thread.Ancestors, 1)
+	assert.Equal(t, postID, respBody.Thread.Post.ID)
+}
+
+func TestPostHandler_GetThread_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/posts/:id/thread", handler.GetThread)
+
+	mockUsecase.On("GetThread", mock.Anything, int64(9), 0).Return(nil, false, usecase.ErrPostNotFound).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/9/thread", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
 func TestPostHandler_Update_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -350,7 +683,7 @@ func TestPostHandler_Update_Success(t *testing.T) {
 	})
 
 	reqBody := postrequests.UpdateRequest{Content: "updated content"}
-	mockUsecase.On("Update", mock.Anything, postID, userID, userRole, reqBody.Content).Return(nil).Once()
+	mockUsecase.On("Update", mock.Anything, postID, userID, userRole, reqBody.Content, (*time.Time)(nil)).Return(nil).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/posts/"+strconv.FormatInt(postID, 10), bytes.NewBuffer(jsonBody))
@@ -472,7 +805,7 @@ func TestPostHandler_Update_Forbidden(t *testing.T) {
 
 	reqBody := postrequests.UpdateRequest{Content: "updated content"}
 	usecaseError := usecase.ErrForbidden
-	mockUsecase.On("Update", mock.Anything, postID, userID, userRole, reqBody.Content).Return(usecaseError).Once()
+	mockUsecase.On("Update", mock.Anything, postID, userID, userRole, reqBody.Content, (*time.Time)(nil)).Return(usecaseError).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/posts/"+strconv.FormatInt(postID, 10), bytes.NewBuffer(jsonBody))
@@ -509,7 +842,7 @@ func TestPostHandler_Update_PostNotFound(t *testing.T) {
 
 	reqBody := postrequests.UpdateRequest{Content: "updated content"}
 	usecaseError := usecase.ErrPostNotFound
-	mockUsecase.On("Update", mock.Anything, postID, userID, userRole, reqBody.Content).Return(usecaseError).Once()
+	mockUsecase.On("Update", mock.Anything, postID, userID, userRole, reqBody.Content, (*time.Time)(nil)).Return(usecaseError).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/posts/"+strconv.FormatInt(postID, 10), bytes.NewBuffer(jsonBody))
@@ -526,6 +859,40 @@ func TestPostHandler_Update_PostNotFound(t *testing.T) {
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestPostHandler_Update_VersionConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	postID := int64(1)
+	userID := int64(10)
+	userRole := "user"
+	router.PATCH("/posts/:id", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextRoleKey, userRole)
+		handler.Update(c)
+	})
+
+	version := time.UnixMicro(1700000000123456)
+	reqBody := postrequests.UpdateRequest{Content: "updated content"}
+	mockUsecase.On("Update", mock.Anything, postID, userID, userRole, reqBody.Content, &version).Return(usecase.ErrVersionConflict).Once()
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest(http.MethodPatch, "/posts/"+strconv.FormatInt(postID, 10), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1700000000123456"`)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
 func TestPostHandler_Update_UsecaseError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -546,7 +913,7 @@ func TestPostHandler_Update_UsecaseError(t *testing.T) {
 
 	reqBody := postrequests.UpdateRequest{Content: "updated content"}
 	usecaseError := errors.New("some other update error")
-	mockUsecase.On("Update", mock.Anything, postID, userID, userRole, reqBody.Content).Return(usecaseError).Once()
+	mockUsecase.On("Update", mock.Anything, postID, userID, userRole, reqBody.Content, (*time.Time)(nil)).Return(usecaseError).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/posts/"+strconv.FormatInt(postID, 10), bytes.NewBuffer(jsonBody))
@@ -743,3 +1110,297 @@ func TestPostHandler_Delete_UsecaseError(t *testing.T) {
 	assert.Equal(t, "internal server error", respBody["error"])
 	mockUsecase.AssertExpectations(t)
 }
+
+func TestPostHandler_AddReaction_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	userID := int64(10)
+	postID := int64(1)
+	router.PUT("/posts/:id/reactions/:reaction", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		handler.AddReaction(c)
+	})
+
+	mockUsecase.On("AddReaction", mock.Anything, postID, userID, "like").Return(nil).Once()
+
+	req, _ := http.NewRequest(http.MethodPut, "/posts/"+strconv.FormatInt(postID, 10)+"/reactions/like", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, "reaction added", respBody["message"])
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_AddReaction_InvalidReaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	userID := int64(10)
+	postID := int64(1)
+	router.PUT("/posts/:id/reactions/:reaction", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		handler.AddReaction(c)
+	})
+
+	mockUsecase.On("AddReaction", mock.Anything, postID, userID, "poop").Return(fmt.Errorf("wrapped: %w", usecase.ErrInvalidReaction)).Once()
+
+	req, _ := http.NewRequest(http.MethodPut, "/posts/"+strconv.FormatInt(postID, 10)+"/reactions/poop", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_AddReaction_NoUserIDInContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.PUT("/posts/:id/reactions/:reaction", handler.AddReaction)
+
+	req, _ := http.NewRequest(http.MethodPut, "/posts/1/reactions/like", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockUsecase.AssertNotCalled(t, "AddReaction", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostHandler_RemoveReaction_PostNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	userID := int64(10)
+	postID := int64(1)
+	router.DELETE("/posts/:id/reactions/:reaction", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		handler.RemoveReaction(c)
+	})
+
+	mockUsecase.On("RemoveReaction", mock.Anything, postID, userID, "like").Return(usecase.ErrPostNotFound).Once()
+
+	req, _ := http.NewRequest(http.MethodDelete, "/posts/"+strconv.FormatInt(postID, 10)+"/reactions/like", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_GetRevisions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	userID := int64(10)
+	userRole := "user"
+	postID := int64(1)
+	router.GET("/posts/:id/revisions", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextRoleKey, userRole)
+		handler.GetRevisions(c)
+	})
+
+	expectedRevisions := []entity.PostRevision{{ID: 1, PostID: postID, Content: "old", EditorID: &userID, EditorRole: userRole, EditorUsername: "user"}}
+	mockUsecase.On("GetRevisions", mock.Anything, postID, userID, userRole).Return(expectedRevisions, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/"+strconv.FormatInt(postID, 10)+"/revisions", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.PostRevisionsResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRevisions, respBody.Revisions)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_GetRevisions_Forbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	userID := int64(10)
+	userRole := "user"
+	postID := int64(1)
+	router.GET("/posts/:id/revisions", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextRoleKey, userRole)
+		handler.GetRevisions(c)
+	})
+
+	mockUsecase.On("GetRevisions", mock.Anything, postID, userID, userRole).Return(nil, usecase.ErrForbidden).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/"+strconv.FormatInt(postID, 10)+"/revisions", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_DiffRevisions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	userID := int64(10)
+	userRole := "admin"
+	postID := int64(1)
+	to := int64(3)
+	router.GET("/posts/:id/revisions/diff", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextRoleKey, userRole)
+		handler.DiffRevisions(c)
+	})
+
+	expectedDiff := &entity.RevisionDiff{PostID: postID, From: 2, To: &to, Lines: []entity.DiffLine{{Op: entity.DiffInsert, Text: "new"}}}
+	mockUsecase.On("DiffRevisions", mock.Anything, postID, userID, userRole, int64(2), &to).Return(expectedDiff, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/"+strconv.FormatInt(postID, 10)+"/revisions/diff?from=2&to=3", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.RevisionDiffResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, *expectedDiff, respBody.Diff)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_DiffRevisions_MissingFrom(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/posts/:id/revisions/diff", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, int64(10))
+		c.Set(ContextRoleKey, "user")
+		handler.DiffRevisions(c)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1/revisions/diff", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockUsecase.AssertNotCalled(t, "DiffRevisions", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostHandler_DiffRevisions_RevisionNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	userID := int64(10)
+	postID := int64(1)
+	router.GET("/posts/:id/revisions/diff", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextRoleKey, "user")
+		handler.DiffRevisions(c)
+	})
+
+	mockUsecase.On("DiffRevisions", mock.Anything, postID, userID, "user", int64(7), (*int64)(nil)).Return(nil, usecase.ErrRevisionNotFound).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1/revisions/diff?from=7", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_Restore_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	postID := int64(1)
+	router.POST("/posts/:id/restore", handler.Restore)
+
+	mockUsecase.On("Restore", mock.Anything, postID).Return(nil).Once()
+
+	req, _ := http.NewRequest(http.MethodPost, "/posts/"+strconv.FormatInt(postID, 10)+"/restore", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, "post restored", respBody["message"])
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestPostHandler_Restore_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewPostUsecase(t)
+	logger := zerolog.Nop()
+	handler := &PostHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	postID := int64(1)
+	router.POST("/posts/:id/restore", handler.Restore)
+
+	mockUsecase.On("Restore", mock.Anything, postID).Return(usecase.ErrPostNotFound).Once()
+
+	req, _ := http.NewRequest(http.MethodPost, "/posts/"+strconv.FormatInt(postID, 10)+"/restore", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
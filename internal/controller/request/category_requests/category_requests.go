@@ -1,6 +1,19 @@
 package categoryrequests
 
+// UpdateRequest changes a category, omitted fields keep their value. ParentID moves it:
+// 0 makes it a root category.
 type UpdateRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	ParentID    *int64  `json:"parent_id"`
+	Hidden      *bool   `json:"hidden"`
+}
+
+// ReorderRequest lists category ids in the new display order.
+type ReorderRequest struct {
+	IDs []int64 `json:"ids" binding:"required"`
+}
+
+type AddModeratorRequest struct {
+	UserID int64 `json:"user_id" binding:"required"`
 }
@@ -0,0 +1,5 @@
+package chatrequests
+
+type CreateRoomRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
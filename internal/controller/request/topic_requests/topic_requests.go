@@ -1,5 +1,17 @@
 package topicrequests
 
+// CreateRequest opens a topic; Content becomes its first post.
+type CreateRequest struct {
+	Title   string `json:"title" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
 type UpdateRequest struct {
 	Title string `json:"title"`
 }
+
+// UpdateStateRequest changes only the flags that are present in the body.
+type UpdateStateRequest struct {
+	Pinned *bool `json:"pinned"`
+	Locked *bool `json:"locked"`
+}
@@ -16,20 +16,88 @@ type IDResponse struct {
 
 type CategoryResponse struct {
 	Category entity.Category `json:"category"`
+	Degraded bool            `json:"degraded" example:"false"`
 }
 
 type CategoriesResponse struct {
 	Categories []entity.Category `json:"categories"`
+	Degraded   bool              `json:"degraded" example:"false"`
+}
+
+type CategoryTreeResponse struct {
+	Categories []entity.CategoryNode `json:"categories"`
+	Degraded   bool                  `json:"degraded" example:"false"`
+}
+
+type ModeratorsResponse struct {
+	Moderators []entity.CategoryModerator `json:"moderators"`
 }
 
 type TopicResponse struct {
-	Topic entity.Topic `json:"topic"`
+	Topic    entity.Topic `json:"topic"`
+	Degraded bool         `json:"degraded" example:"false"`
 }
 
 type TopicsResponse struct {
-	Topics []entity.Topic `json:"topics"`
+	Topics     []entity.Topic `json:"topics"`
+	NextCursor string         `json:"next_cursor" example:"MTcxNjIzOTAyMjAwMDAwMDAwMDoxMg"`
+	PrevCursor string         `json:"prev_cursor" example:""`
+	Degraded   bool           `json:"degraded" example:"false"`
 }
 
 type PostsResponse struct {
-	Posts []entity.Post `json:"posts"`
+	Posts      []entity.Post `json:"posts"`
+	NextCursor string        `json:"next_cursor" example:"MTcxNjIzOTAyMjAwMDAwMDAwMDoxMg"`
+	PrevCursor string        `json:"prev_cursor" example:""`
+	Degraded   bool          `json:"degraded" example:"false"`
+}
+
+type PostTreeResponse struct {
+	Posts      []entity.PostNode `json:"posts"`
+	NextCursor string            `json:"next_cursor" example:"MTcxNjIzOTAyMjAwMDAwMDAwMDoxMg"`
+	PrevCursor string            `json:"prev_cursor" example:""`
+	Degraded   bool              `json:"degraded" example:"false"`
+}
+
+type PostResponse struct {
+	Post     entity.Post `json:"post"`
+	Degraded bool        `json:"degraded" example:"false"`
+}
+
+type PostThreadResponse struct {
+	Thread   entity.PostThread `json:"thread"`
+	Degraded bool              `json:"degraded" example:"false"`
+}
+
+type PostRevisionsResponse struct {
+	Revisions []entity.PostRevision `json:"revisions"`
+}
+
+type RevisionDiffResponse struct {
+	Diff entity.RevisionDiff `json:"diff"`
+}
+
+type SearchResponse struct {
+	Results  []entity.SearchResult `json:"results"`
+	Degraded bool                  `json:"degraded" example:"false"`
+}
+
+type ChatRoomResponse struct {
+	Room entity.ChatRoom `json:"room"`
+}
+
+type ChatRoomsResponse struct {
+	Rooms []entity.ChatRoom `json:"rooms"`
+}
+
+type ChatMessagesResponse struct {
+	Messages []entity.ChatMessage `json:"messages"`
+}
+
+type ConversationsResponse struct {
+	Conversations []entity.Conversation `json:"conversations"`
+}
+
+type DirectMessagesResponse struct {
+	Messages []entity.DirectMessage `json:"messages"`
 }
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"expvar"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -16,38 +17,55 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetRoutes(engine *gin.Engine, categoryUsecase usecase.CategoryUsecase, topicUsecase usecase.TopicUsecase, postUsecase usecase.PostUsecase, jwt *jwt.JWT, log *zerolog.Logger, hub *chat.Hub, chatUsecase usecase.ChatUsecase, userClient client.UserClient) {
+func SetRoutes(engine *gin.Engine, categoryUsecase usecase.CategoryUsecase, topicUsecase usecase.TopicUsecase, postUsecase usecase.PostUsecase, searchUsecase usecase.SearchUsecase, jwt *jwt.JWT, log *zerolog.Logger, hub *chat.Hub, chatUsecase usecase.ChatUsecase, userClient client.UserClient, cache *middleware.ResponseCache) {
 	categoryHandler := &CategoryHandler{categoryUsecase, log}
 	topicHandler := &TopicHandler{topicUsecase, log}
 	postHandler := &PostHandler{postUsecase, log}
+	searchHandler := &SearchHandler{searchUsecase, log}
 	auth := middleware.NewAuthMiddleware(jwt)
 	chatHandler := NewChatHandler(hub, chatUsecase, userClient, log)
 
 	engine.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
-		AllowMethods:     []string{"GET", "POST", "PATCH", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-Match", "If-None-Match", "If-Modified-Since"},
+		ExposeHeaders:    []string{"ETag", "Last-Modified"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	engine.GET("/ws", auth.ChatAuth(), chatHandler.ServeWs)
 
+	chatGroup := engine.Group("/chat")
+	{
+		chatGroup.GET("/rooms", auth.OptionalAuth(), chatHandler.GetRooms)
+		chatGroup.GET("/messages", auth.OptionalAuth(), chatHandler.GetMessages)
+		chatGroup.POST("/rooms", auth.Auth(), middleware.RequireAdmin(), chatHandler.CreateRoom)
+		chatGroup.DELETE("/rooms/:id", auth.Auth(), middleware.RequireAdmin(), chatHandler.DeleteRoom)
+	}
+
+	engine.GET("/conversations", auth.Auth(), chatHandler.GetConversations)
+	engine.GET("/conversations/:id/messages", auth.Auth(), chatHandler.GetConversationMessages)
+
 	categories := engine.Group("/categories")
 	{
-		categories.GET("", categoryHandler.GetAll)
-		categories.GET("/:id", categoryHandler.GetByID)
+		categories.GET("", auth.OptionalAuth(), cache.Handler(middleware.CategoriesScope), categoryHandler.GetAll)
+		categories.GET("/:id", auth.OptionalAuth(), categoryHandler.GetByID)
 
 		adminCategories := categories.Group("")
 		adminCategories.Use(auth.Auth(), middleware.RequireAdmin())
 		{
 			adminCategories.POST("", categoryHandler.Create)
+			adminCategories.PUT("/order", categoryHandler.Reorder)
 			adminCategories.DELETE("/:id", categoryHandler.Delete)
 			adminCategories.PATCH("/:id", categoryHandler.Update)
+			adminCategories.GET("/:id/moderators", categoryHandler.GetModerators)
+			adminCategories.POST("/:id/moderators", categoryHandler.AddModerator)
+			adminCategories.DELETE("/:id/moderators/:user_id", categoryHandler.RemoveModerator)
 		}
 	}
 
-	engine.GET("/categories/:id/topics", topicHandler.GetByCategory)
+	engine.GET("/categories/:id/topics", auth.OptionalAuth(), cache.Handler(middleware.CategoryTopicsScope), topicHandler.GetByCategory)
 	engine.POST("/categories/:id/topics", auth.Auth(), topicHandler.Create)
 
 	engine.GET("/topics/:id", topicHandler.GetByID)
@@ -55,16 +73,29 @@ func SetRoutes(engine *gin.Engine, categoryUsecase usecase.CategoryUsecase, topi
 	{
 		topics.DELETE("/:id", topicHandler.Delete)
 		topics.PATCH("/:id", topicHandler.Update)
+		topics.POST("/:id/restore", middleware.RequireAdmin(), topicHandler.Restore)
+		topics.PATCH("/:id/state", topicHandler.UpdateState)
 	}
 
-	engine.GET("/topics/:id/posts", postHandler.GetByTopic)
+	engine.GET("/topics/:id/posts", auth.OptionalAuth(), cache.Handler(middleware.TopicPostsScope), postHandler.GetByTopic)
 	engine.POST("/topics/:id/posts", auth.Auth(), postHandler.Create)
 
+	engine.GET("/posts/:id", auth.OptionalAuth(), postHandler.GetByID)
+	engine.GET("/posts/:id/thread", postHandler.GetThread)
 	posts := engine.Group("/posts").Use(auth.Auth())
 	{
 		posts.DELETE("/:id", postHandler.Delete)
 		posts.PATCH("/:id", postHandler.Update)
+		posts.POST("/:id/restore", middleware.RequireAdmin(), postHandler.Restore)
+		posts.GET("/:id/revisions", postHandler.GetRevisions)
+		posts.GET("/:id/revisions/diff", postHandler.DiffRevisions)
+		posts.PUT("/:id/reactions/:reaction", postHandler.AddReaction)
+		posts.DELETE("/:id/reactions/:reaction", postHandler.RemoveReaction)
 	}
 
+	engine.GET("/search", auth.OptionalAuth(), searchHandler.Search)
+
+	engine.GET("/debug/vars", auth.Auth(), middleware.RequireAdmin(), gin.WrapH(expvar.Handler()))
+
 	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/rs/zerolog"
+)
+
+type SearchHandler struct {
+	usecase usecase.SearchUsecase
+	log     *zerolog.Logger
+}
+
+const searchOp = "SearchHandler.Search"
+
+// Search godoc
+// @Summary Search topics and posts
+// @Description Full-text search over topic titles and post contents. Results are ranked and contain highlighted snippets. Results from hidden categories are only returned to admins.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param category_id query int false "Only results from this category" Format(int64)
+// @Param author_id query int false "Only results by this author" Format(int64)
+// @Param from query string false "Only results created at or after this time (RFC3339)"
+// @Param to query string false "Only results created at or before this time (RFC3339)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param offset query int false "Number of results to skip"
+// @Success 200 {object} response.SearchResponse "Search results"
+// @Failure 400 {object} response.ErrorResponse "Empty query or invalid filters"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", searchOp).Logger()
+
+	query, err := parseSearchQuery(c)
+	if err != nil {
+		log.Warn().Err(err).Msg("invalid search params")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, _ := middleware.GetRoleFromContext(c)
+	query.IncludeHidden = policy.IsAdmin(role)
+
+	results, degraded, err := h.usecase.Search(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, usecase.ErrEmptySearchQuery) {
+			log.Warn().Msg("empty search query")
+			c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrEmptySearchQuery.Error()})
+			return
+		}
+
+		log.Error().Err(err).Msg("failed to search")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	if degraded {
+		middleware.SkipResponseCache(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results, "degraded": degraded})
+}
+
+func parseSearchQuery(c *gin.Context) (entity.SearchQuery, error) {
+	query := entity.SearchQuery{Query: c.Query("q")}
+
+	if v := c.Query("category_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return query, errors.New("invalid category id")
+		}
+		query.CategoryID = &id
+	}
+
+	if v := c.Query("author_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return query, errors.New("invalid author id")
+		}
+		query.AuthorID = &id
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, errors.New("invalid from date")
+		}
+		query.From = &from
+	}
+
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, errors.New("invalid to date")
+		}
+		query.To = &to
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit <= 0 {
+			return query, errInvalidLimit
+		}
+		query.Limit = limit
+	}
+
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || offset < 0 {
+			return query, errors.New("invalid offset")
+		}
+		query.Offset = offset
+	}
+
+	return query, nil
+}
+
+func (h *SearchHandler) getRequestLogger(c *gin.Context) *zerolog.Logger {
+	reqLog := h.log.With().
+		Str("method", c.Request.Method).
+		Str("path", c.Request.URL.Path).
+		Str("remote_addr", c.ClientIP())
+
+	logger := reqLog.Logger()
+	return &logger
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/response"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/keshvan/forum-service-sstu-forum/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchHandler_Search_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewSearchUsecase(t)
+	logger := zerolog.Nop()
+	handler := &SearchHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/search", handler.Search)
+
+	categoryID := int64(2)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	expectedQuery := entity.SearchQuery{Query: "сессия", CategoryID: &categoryID, From: &from, Limit: 10}
+	expectedResults := []entity.SearchResult{{Type: entity.SearchResultPost, ID: 1, TopicID: 3, Snippet: "<mark>сессия</mark>", Username: "User1"}}
+	mockUsecase.On("Search", mock.Anything, expectedQuery).Return(expectedResults, false, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/search?q=сессия&category_id=2&from=2025-01-01T00:00:00Z&limit=10", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody response.SearchResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResults, respBody.Results)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestSearchHandler_Search_AdminIncludesHidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewSearchUsecase(t)
+	logger := zerolog.Nop()
+	handler := &SearchHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/search", func(c *gin.Context) {
+		c.Set(ContextRoleKey, "admin")
+		handler.Search(c)
+	})
+
+	mockUsecase.On("Search", mock.Anything, entity.SearchQuery{Query: "сессия", IncludeHidden: true}).Return([]entity.SearchResult{}, false, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/search?q=сессия", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestSearchHandler_Search_InvalidFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewSearchUsecase(t)
+	logger := zerolog.Nop()
+	handler := &SearchHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/search", handler.Search)
+
+	req, _ := http.NewRequest(http.MethodGet, "/search?q=test&to=yesterday", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockUsecase.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+}
+
+func TestSearchHandler_Search_EmptyQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewSearchUsecase(t)
+	logger := zerolog.Nop()
+	handler := &SearchHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/search", handler.Search)
+
+	mockUsecase.On("Search", mock.Anything, entity.SearchQuery{}).Return(nil, false, usecase.ErrEmptySearchQuery).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/search", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ErrEmptySearchQuery.Error(), respBody["error"])
+}
+
+func TestSearchHandler_Search_UsecaseError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewSearchUsecase(t)
+	logger := zerolog.Nop()
+	handler := &SearchHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/search", handler.Search)
+
+	mockUsecase.On("Search", mock.Anything, mock.Anything).Return(nil, false, errors.New("db down")).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/search?q=test", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
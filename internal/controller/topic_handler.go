@@ -9,6 +9,7 @@ import (
 	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
 	topicrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/topic_requests"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/rs/zerolog"
 )
@@ -23,17 +24,19 @@ const (
 	getByCategoryOp = "TopicHandler.GetByCategory"
 	deleteTopicOp   = "TopicHandler.Delete"
 	updateTopicOp   = "TopicHandler.Update"
+	restoreTopicOp  = "TopicHandler.Restore"
+	updateStateOp   = "TopicHandler.UpdateState"
 	getByIDTopicOP  = "TopicHandler.GetByID"
 )
 
 // Create godoc
 // @Summary Create a new topic
-// @Description Creates a new topic in a category. Requires authentication.
+// @Description Creates a new topic in a category together with its opening post. Requires authentication.
 // @Tags topics
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID to create topic in" Format(int64)
-// @Param topic body entity.Topic true "Topic data to create. ID, AuthorID, CategoryID, CreatedAt, UpdatedAt will be ignored or overridden."
+// @Param topic body topicrequests.CreateRequest true "Topic title and opening post content"
 // @Success 200 {object} response.IDResponse "Topic created successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid category ID or request payload"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
@@ -58,17 +61,16 @@ func (h *TopicHandler) Create(c *gin.Context) {
 		return
 	}
 
-	var topic entity.Topic
-	if err := c.ShouldBindJSON(&topic); err != nil {
+	var req topicrequests.CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Warn().Err(err).Msg("failed to bind request")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	topic.AuthorID = &userID
-	topic.CategoryID = categoryID
+	topic := entity.Topic{CategoryID: categoryID, Title: req.Title, AuthorID: &userID}
 
-	id, err := h.usecase.Create(c.Request.Context(), topic)
+	id, err := h.usecase.Create(c.Request.Context(), topic, req.Content)
 	if err != nil {
 		if errors.Is(err, usecase.ErrCategoryNotFound) {
 			log.Warn().Msg("category not found")
@@ -86,11 +88,12 @@ func (h *TopicHandler) Create(c *gin.Context) {
 
 // GetByID godoc
 // @Summary Get a topic by ID
-// @Description Retrieves a specific topic by its ID.
+// @Description Retrieves a specific topic by its ID together with breadcrumbs: the category path from the root down to the topic's category.
 // @Tags topics
 // @Produce json
 // @Param id path int true "Topic ID" Format(int64)
 // @Success 200 {object} response.TopicResponse "Successfully retrieved topic"
+// @Header 200 {string} ETag "Version of the topic, send it back in If-Match when updating the topic"
 // @Failure 400 {object} response.ErrorResponse "Invalid topic ID"
 // @Failure 500 {object} response.ErrorResponse "Failed to get topic"
 // @Router /topics/{id} [get]
@@ -104,25 +107,38 @@ func (h *TopicHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	topic, err := h.usecase.GetByID(c.Request.Context(), topicID)
+	topic, degraded, err := h.usecase.GetByID(c.Request.Context(), topicID)
 	if err != nil {
 		log.Error().Err(err).Int64("topic_id", topicID).Msg("Failed to get topic")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get topic"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"topic": topic})
+	if degraded {
+		middleware.SkipResponseCache(c)
+	}
+	c.Header("ETag", etag(topic.UpdatedAt))
+	c.JSON(http.StatusOK, gin.H{"topic": topic, "degraded": degraded})
 
 }
 
 // GetByCategory godoc
 // @Summary Get topics by category ID
-// @Description Retrieves a list of topics for a category ID.
+// @Description Retrieves a list of topics for a category ID with reply counts and the last reply. Pinned topics always come first. Topics of hidden categories are only returned to admins.
 // @Tags topics
 // @Produce json
 // @Param id path int true "Category ID" Format(int64)
+// @Param sort query string false "Sort order (default newest)" Enums(newest, latest_activity, most_replies)
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param after query string false "Cursor to fetch the page after (older topics)"
+// @Param before query string false "Cursor to fetch the page before (newer topics)"
+// @Param If-None-Match header string false "ETag of a previous response, 304 is returned if it is still current"
+// @Param If-Modified-Since header string false "Last-Modified of a previous response, 304 is returned if nothing has changed since"
 // @Success 200 {object} response.TopicsResponse "Successfully retrieved topics"
-// @Failure 400 {object} response.ErrorResponse "Invalid category ID"
+// @Header 200 {string} ETag "Version of the response"
+// @Header 200 {string} Last-Modified "Time of the last change, omitted if it was less than a second ago"
+// @Success 304 "Not modified"
+// @Failure 400 {object} response.ErrorResponse "Invalid category ID, sort, limit or cursor"
 // @Failure 404 {object} response.ErrorResponse "Category not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /categories/{id}/topics [get]
@@ -136,7 +152,23 @@ func (h *TopicHandler) GetByCategory(c *gin.Context) {
 		return
 	}
 
-	topics, err := h.usecase.GetByCategory(c.Request.Context(), categoryID)
+	sort := entity.TopicSort(c.Query("sort"))
+	switch sort {
+	case "", entity.TopicSortNewest, entity.TopicSortLatestActivity, entity.TopicSortMostReplies:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be newest, latest_activity or most_replies"})
+		return
+	}
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		log.Warn().Err(err).Msg("invalid pagination params")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, _ := middleware.GetRoleFromContext(c)
+	topics, pageInfo, err := h.usecase.GetByCategory(c.Request.Context(), categoryID, policy.IsAdmin(role), sort, page)
 	if err != nil {
 		if errors.Is(err, usecase.ErrCategoryNotFound) {
 			log.Warn().Msg("category not found")
@@ -149,22 +181,27 @@ func (h *TopicHandler) GetByCategory(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"topics": topics})
+	if pageInfo.Degraded {
+		middleware.SkipResponseCache(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"topics": topics, "next_cursor": pageInfo.NextCursor, "prev_cursor": pageInfo.PrevCursor, "degraded": pageInfo.Degraded})
 }
 
 // Update godoc
 // @Summary Update a topic
-// @Description Updates a topic. Requires authentication and ownership or admin role.
+// @Description Updates a topic. Requires authentication and ownership, admin role or moderator rights in the category.
 // @Tags topics
 // @Accept json
 // @Produce json
 // @Param id path int true "Topic ID" Format(int64)
 // @Param topic_update body topicrequests.UpdateRequest true "Topic update data (only title)"
+// @Param If-Match header string false "ETag of the topic from a previous GET, the update is rejected if the topic has changed since"
 // @Success 200 {object} response.SuccessMessageResponse "Topic updated successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid topic ID or request payload"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
-// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner or admin)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner, admin or moderator of the category)"
 // @Failure 404 {object} response.ErrorResponse "Topic not found"
+// @Failure 412 {object} response.ErrorResponse "Topic has been modified since it was read"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
 // @Router /topics/{id} [patch]
@@ -192,16 +229,28 @@ func (h *TopicHandler) Update(c *gin.Context) {
 		return
 	}
 
-	err = h.usecase.Update(c.Request.Context(), topicID, userID, role, req.Title)
+	version, ok := parseIfMatch(c)
+	if !ok {
+		log.Warn().Msg("unknown If-Match tag")
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": usecase.ErrVersionConflict.Error()})
+		return
+	}
+
+	err = h.usecase.Update(c.Request.Context(), topicID, userID, role, req.Title, version)
 	if err != nil {
 		if errors.Is(err, usecase.ErrForbidden) {
 			log.Warn().Msg("insufficient permissions")
 			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
 			return
 		}
-		if errors.Is(err, usecase.ErrPostNotFound) {
-			log.Warn().Msg("post not found")
-			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		if errors.Is(err, usecase.ErrTopicNotFound) {
+			log.Warn().Msg("topic not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrTopicNotFound.Error()})
+			return
+		}
+		if errors.Is(err, usecase.ErrVersionConflict) {
+			log.Warn().Msg("topic version conflict")
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": usecase.ErrVersionConflict.Error()})
 			return
 		}
 
@@ -214,13 +263,13 @@ func (h *TopicHandler) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a topic
-// @Description Deletes a topic by its ID. Requires authentication and ownership or role.
+// @Description Deletes a topic by its ID. The topic and its posts are hidden and can be restored by an admin until they are purged. Requires authentication and ownership, admin role or moderator rights in the category.
 // @Tags topics
 // @Param id path int true "Topic ID" Format(int64)
 // @Success 200 {object} response.SuccessMessageResponse "Topic deleted successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid topic ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
-// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner or admin)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an owner, admin or moderator of the category)"
 // @Failure 404 {object} response.ErrorResponse "Topic not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
@@ -250,9 +299,9 @@ func (h *TopicHandler) Delete(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
 			return
 		}
-		if errors.Is(err, usecase.ErrPostNotFound) {
-			log.Warn().Msg("post not found")
-			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		if errors.Is(err, usecase.ErrTopicNotFound) {
+			log.Warn().Msg("topic not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": usecase.ErrTopicNotFound.Error()})
 			return
 		}
 		log.Error().Err(err).Msg("failed to delete topic")
@@ -263,6 +312,108 @@ func (h *TopicHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "topic deleted"})
 }
 
+// Restore godoc
+// @Summary Restore a deleted topic
+// @Description Restores a deleted topic together with its posts. Requires admin role.
+// @Tags topics
+// @Param id path int true "Topic ID" Format(int64)
+// @Success 200 {object} response.SuccessMessageResponse "Topic restored successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid topic ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin)"
+// @Failure 404 {object} response.ErrorResponse "Deleted topic not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /topics/{id}/restore [post]
+func (h *TopicHandler) Restore(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", restoreTopicOp).Logger()
+
+	topicID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Warn().Msg("invalid topic id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid topic id"})
+		return
+	}
+
+	if err := h.usecase.Restore(c.Request.Context(), topicID); err != nil {
+		if errors.Is(err, usecase.ErrTopicNotFound) {
+			log.Warn().Int64("topic_id", topicID).Msg("deleted topic not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": "topic not found"})
+			return
+		}
+		log.Error().Err(err).Msg("failed to restore topic")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "topic restored"})
+}
+
+// UpdateState godoc
+// @Summary Pin or lock a topic
+// @Description Changes the pinned and locked flags of a topic. Omitted flags are left unchanged. Pinned topics are listed first in their category, locked topics do not accept new posts. Requires admin role or moderator rights in the topic's category.
+// @Tags topics
+// @Accept json
+// @Produce json
+// @Param id path int true "Topic ID" Format(int64)
+// @Param topic_state body topicrequests.UpdateStateRequest true "Flags to change"
+// @Success 200 {object} response.SuccessMessageResponse "Topic state updated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid topic ID or request payload"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized (token is missing or invalid)"
+// @Failure 403 {object} response.ErrorResponse "Forbidden (user is not an admin or moderator of the category)"
+// @Failure 404 {object} response.ErrorResponse "Topic not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /topics/{id}/state [patch]
+func (h *TopicHandler) UpdateState(c *gin.Context) {
+	log := h.getRequestLogger(c).With().Str("op", updateStateOp).Logger()
+
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		log.Warn().Msg("insufficient permissions")
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return
+	}
+	role, _ := middleware.GetRoleFromContext(c)
+
+	topicID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Warn().Msg("invalid topic id")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid topic id"})
+		return
+	}
+
+	var req topicrequests.UpdateStateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warn().Err(err).Msg("failed to bind request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Pinned == nil && req.Locked == nil {
+		log.Warn().Msg("empty state update")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pinned or locked is required"})
+		return
+	}
+
+	if err := h.usecase.SetState(c.Request.Context(), topicID, userID, role, req.Pinned, req.Locked); err != nil {
+		if errors.Is(err, usecase.ErrForbidden) {
+			log.Warn().Msg("insufficient permissions")
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			return
+		}
+		if errors.Is(err, usecase.ErrTopicNotFound) {
+			log.Warn().Int64("topic_id", topicID).Msg("topic not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": "topic not found"})
+			return
+		}
+		log.Error().Err(err).Msg("failed to update topic state")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "topic state updated"})
+}
+
 func (h *TopicHandler) getRequestLogger(c *gin.Context) *zerolog.Logger {
 	reqLog := h.log.With().
 		Str("method", c.Request.Method).
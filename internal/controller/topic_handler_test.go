@@ -8,9 +8,11 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	topicrequests "github.com/keshvan/forum-service-sstu-forum/internal/controller/request/topic_requests"
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/response"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
 	"github.com/keshvan/forum-service-sstu-forum/mocks"
@@ -37,11 +39,11 @@ func TestTopicHandler_Create_Success(t *testing.T) {
 		handler.Create(c)
 	})
 
-	reqBody := entity.Topic{Title: "new topic"}
+	reqBody := topicrequests.CreateRequest{Title: "new topic", Content: "opening post"}
 	expectedTopicID := int64(5)
 
 	expectedEntityTopic := entity.Topic{CategoryID: categoryID, AuthorID: &userID, Title: reqBody.Title}
-	mockUsecase.On("Create", mock.Anything, expectedEntityTopic).Return(expectedTopicID, nil).Once()
+	mockUsecase.On("Create", mock.Anything, expectedEntityTopic, reqBody.Content).Return(expectedTopicID, nil).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPost, "/categories/"+strconv.FormatInt(categoryID, 10)+"/topics", bytes.NewBuffer(jsonBody))
@@ -70,7 +72,7 @@ func TestTopicHandler_Create_NoUserIDInContext(t *testing.T) {
 	categoryID := int64(1)
 	router.POST("/categories/:id/topics", handler.Create)
 
-	reqBody := entity.Topic{Title: "new topic"}
+	reqBody := topicrequests.CreateRequest{Title: "new topic", Content: "opening post"}
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPost, "/categories/"+strconv.FormatInt(categoryID, 10)+"/topics", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -79,7 +81,7 @@ func TestTopicHandler_Create_NoUserIDInContext(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusForbidden, rr.Code)
-	mockUsecase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestTopicHandler_Create_InvalidCategoryID(t *testing.T) {
@@ -98,7 +100,7 @@ func TestTopicHandler_Create_InvalidCategoryID(t *testing.T) {
 		handler.Create(c)
 	})
 
-	reqBody := entity.Topic{Title: "new topic"}
+	reqBody := topicrequests.CreateRequest{Title: "new topic", Content: "opening post"}
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPost, "/categories/invalid/topics", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -111,7 +113,7 @@ func TestTopicHandler_Create_InvalidCategoryID(t *testing.T) {
 	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
 	assert.NoError(t, err)
 	assert.Equal(t, "invalid category id", respBody["error"])
-	mockUsecase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestTopicHandler_Create_InvalidJSON(t *testing.T) {
@@ -138,7 +140,34 @@ func TestTopicHandler_Create_InvalidJSON(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	mockUsecase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTopicHandler_Create_MissingContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	userID := int64(10)
+	router.POST("/categories/:id/topics", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextRoleKey, "user")
+		handler.Create(c)
+	})
+
+	jsonBody, _ := json.Marshal(topicrequests.CreateRequest{Title: "new topic"})
+	req, _ := http.NewRequest(http.MethodPost, "/categories/1/topics", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockUsecase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestTopicHandler_Create_CategoryNotFound(t *testing.T) {
@@ -158,11 +187,11 @@ func TestTopicHandler_Create_CategoryNotFound(t *testing.T) {
 		handler.Create(c)
 	})
 
-	reqBody := entity.Topic{Title: "new topic"}
+	reqBody := topicrequests.CreateRequest{Title: "new topic", Content: "opening post"}
 	usecaseError := usecase.ErrCategoryNotFound
 
 	expectedEntityTopic := entity.Topic{CategoryID: categoryID, AuthorID: &userID, Title: reqBody.Title}
-	mockUsecase.On("Create", mock.Anything, expectedEntityTopic).Return(int64(0), usecaseError).Once()
+	mockUsecase.On("Create", mock.Anything, expectedEntityTopic, reqBody.Content).Return(int64(0), usecaseError).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPost, "/categories/"+strconv.FormatInt(categoryID, 10)+"/topics", bytes.NewBuffer(jsonBody))
@@ -196,11 +225,11 @@ func TestTopicHandler_Create_UsecaseError(t *testing.T) {
 		handler.Create(c)
 	})
 
-	reqBody := entity.Topic{Title: "new topic"}
+	reqBody := topicrequests.CreateRequest{Title: "new topic", Content: "opening post"}
 	usecaseError := errors.New("some other create error")
 
 	expectedEntityTopic := entity.Topic{CategoryID: categoryID, AuthorID: &userID, Title: reqBody.Title}
-	mockUsecase.On("Create", mock.Anything, expectedEntityTopic).Return(int64(0), usecaseError).Once()
+	mockUsecase.On("Create", mock.Anything, expectedEntityTopic, reqBody.Content).Return(int64(0), usecaseError).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPost, "/categories/"+strconv.FormatInt(categoryID, 10)+"/topics", bytes.NewBuffer(jsonBody))
@@ -229,18 +258,19 @@ func TestTopicHandler_GetByID_Success(t *testing.T) {
 	topicID := int64(1)
 	router.GET("/topics/:id", handler.GetByID)
 
-	expectedTopic := &entity.Topic{ID: topicID, Title: "Test Topic", Username: "Author"}
-	mockUsecase.On("GetByID", mock.Anything, topicID).Return(expectedTopic, nil).Once()
+	expectedTopic := &entity.Topic{ID: topicID, Title: "Test Topic", Username: "Author", UpdatedAt: time.UnixMicro(1700000000123456)}
+	mockUsecase.On("GetByID", mock.Anything, topicID).Return(expectedTopic, false, nil).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/topics/"+strconv.FormatInt(topicID, 10), nil)
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
-	var respBody map[string]entity.Topic
+	assert.Equal(t, `"1700000000123456"`, rr.Header().Get("ETag"))
+	var respBody response.TopicResponse
 	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedTopic.Title, respBody["topic"].Title)
+	assert.Equal(t, expectedTopic.Title, respBody.Topic.Title)
 	mockUsecase.AssertExpectations(t)
 }
 
@@ -276,7 +306,7 @@ func TestTopicHandler_GetByID_UsecaseError(t *testing.T) {
 	router.GET("/topics/:id", handler.GetByID)
 
 	usecaseError := errors.New("usecase get by id error")
-	mockUsecase.On("GetByID", mock.Anything, topicID).Return(nil, usecaseError).Once()
+	mockUsecase.On("GetByID", mock.Anything, topicID).Return(nil, false, usecaseError).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/topics/"+strconv.FormatInt(topicID, 10), nil)
 	rr := httptest.NewRecorder()
@@ -306,21 +336,81 @@ func TestTopicHandler_GetByCategory_Success(t *testing.T) {
 		{ID: 1, CategoryID: categoryID, Title: "Topic 1", Username: "User1"},
 		{ID: 2, CategoryID: categoryID, Title: "Topic 2", Username: "User2"},
 	}
-	mockUsecase.On("GetByCategory", mock.Anything, categoryID).Return(expectedTopics, nil).Once()
+	mockUsecase.On("GetByCategory", mock.Anything, categoryID, false, entity.TopicSort(""), entity.PageRequest{}).Return(expectedTopics, entity.PageInfo{}, nil).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/categories/"+strconv.FormatInt(categoryID, 10)+"/topics", nil)
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
-	var respBody map[string][]entity.Topic
+	var respBody response.TopicsResponse
 	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
 	assert.NoError(t, err)
-	assert.Len(t, respBody["topics"], 2)
-	assert.Equal(t, expectedTopics[0].Title, respBody["topics"][0].Title)
+	assert.Len(t, respBody.Topics, 2)
+	assert.Equal(t, expectedTopics[0].Title, respBody.Topics[0].Title)
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestTopicHandler_GetByCategory_Sort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	categoryID := int64(1)
+	router.GET("/categories/:id/topics", handler.GetByCategory)
+
+	mockUsecase.On("GetByCategory", mock.Anything, categoryID, false, entity.TopicSortLatestActivity, entity.PageRequest{}).Return([]entity.Topic{}, entity.PageInfo{}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/categories/1/topics?sort=latest_activity", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestTopicHandler_GetByCategory_InvalidSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/categories/:id/topics", handler.GetByCategory)
+
+	req, _ := http.NewRequest(http.MethodGet, "/categories/1/topics?sort=oldest", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockUsecase.AssertNotCalled(t, "GetByCategory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTopicHandler_GetByCategory_InvalidLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.GET("/categories/:id/topics", handler.GetByCategory)
+
+	req, _ := http.NewRequest(http.MethodGet, "/categories/1/topics?limit=-5", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockUsecase.AssertNotCalled(t, "GetByCategory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestTopicHandler_GetByCategory_InvalidCategoryID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -337,7 +427,7 @@ func TestTopicHandler_GetByCategory_InvalidCategoryID(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	mockUsecase.AssertNotCalled(t, "GetByCategory", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "GetByCategory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestTopicHandler_GetByCategory_CategoryNotFound(t *testing.T) {
@@ -353,7 +443,7 @@ func TestTopicHandler_GetByCategory_CategoryNotFound(t *testing.T) {
 	router.GET("/categories/:id/topics", handler.GetByCategory)
 
 	usecaseError := usecase.ErrCategoryNotFound
-	mockUsecase.On("GetByCategory", mock.Anything, categoryID).Return(nil, usecaseError).Once()
+	mockUsecase.On("GetByCategory", mock.Anything, categoryID, false, entity.TopicSort(""), entity.PageRequest{}).Return(nil, entity.PageInfo{}, usecaseError).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/categories/"+strconv.FormatInt(categoryID, 10)+"/topics", nil)
 	rr := httptest.NewRecorder()
@@ -380,7 +470,7 @@ func TestTopicHandler_GetByCategory_UsecaseError(t *testing.T) {
 	router.GET("/categories/:id/topics", handler.GetByCategory)
 
 	usecaseError := errors.New("some other get by category error")
-	mockUsecase.On("GetByCategory", mock.Anything, categoryID).Return(nil, usecaseError).Once()
+	mockUsecase.On("GetByCategory", mock.Anything, categoryID, false, entity.TopicSort(""), entity.PageRequest{}).Return(nil, entity.PageInfo{}, usecaseError).Once()
 
 	req, _ := http.NewRequest(http.MethodGet, "/categories/"+strconv.FormatInt(categoryID, 10)+"/topics", nil)
 	rr := httptest.NewRecorder()
@@ -414,7 +504,7 @@ func TestTopicHandler_Update_Success(t *testing.T) {
 	})
 
 	reqBody := topicrequests.UpdateRequest{Title: "Updated Topic Title"}
-	mockUsecase.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title).Return(nil).Once()
+	mockUsecase.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title, (*time.Time)(nil)).Return(nil).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/topics/"+strconv.FormatInt(topicID, 10), bytes.NewBuffer(jsonBody))
@@ -431,6 +521,79 @@ func TestTopicHandler_Update_Success(t *testing.T) {
 	mockUsecase.AssertExpectations(t)
 }
 
+func TestTopicHandler_Update_IfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zerolog.Nop()
+	topicID := int64(1)
+	userID := int64(10)
+	userRole := "user"
+	version := time.UnixMicro(1700000000123456)
+	reqBody := topicrequests.UpdateRequest{Title: "Updated Topic Title"}
+
+	tests := []struct {
+		name         string
+		ifMatch      string
+		setupMock    func(m *mocks.TopicUsecase)
+		expectedCode int
+	}{
+		{
+			name:    "Matching version",
+			ifMatch: `"1700000000123456"`,
+			setupMock: func(m *mocks.TopicUsecase) {
+				m.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title, &version).Return(nil).Once()
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:    "Stale version",
+			ifMatch: `"1700000000123456"`,
+			setupMock: func(m *mocks.TopicUsecase) {
+				m.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title, &version).Return(usecase.ErrVersionConflict).Once()
+			},
+			expectedCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "Any version",
+			ifMatch: "*",
+			setupMock: func(m *mocks.TopicUsecase) {
+				m.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title, (*time.Time)(nil)).Return(nil).Once()
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Unknown tag",
+			ifMatch:      `W/"abc"`,
+			setupMock:    func(m *mocks.TopicUsecase) {},
+			expectedCode: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			mockUsecase := mocks.NewTopicUsecase(t)
+			handler := &TopicHandler{usecase: mockUsecase, log: &logger}
+			router.PATCH("/topics/:id", func(c *gin.Context) {
+				c.Set(ContextUserIDKey, userID)
+				c.Set(ContextRoleKey, userRole)
+				handler.Update(c)
+			})
+			tc.setupMock(mockUsecase)
+
+			jsonBody, _ := json.Marshal(reqBody)
+			req, _ := http.NewRequest(http.MethodPatch, "/topics/"+strconv.FormatInt(topicID, 10), bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tc.ifMatch)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedCode, rr.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
 func TestTopicHandler_Update_NoUserIDInContext(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -532,7 +695,7 @@ func TestTopicHandler_Update_Forbidden(t *testing.T) {
 
 	reqBody := topicrequests.UpdateRequest{Title: "Updated Topic Title"}
 	usecaseError := usecase.ErrForbidden
-	mockUsecase.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title).Return(usecaseError).Once()
+	mockUsecase.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title, (*time.Time)(nil)).Return(usecaseError).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/topics/"+strconv.FormatInt(topicID, 10), bytes.NewBuffer(jsonBody))
@@ -549,7 +712,7 @@ func TestTopicHandler_Update_Forbidden(t *testing.T) {
 	mockUsecase.AssertExpectations(t)
 }
 
-func TestTopicHandler_Update_TopicOrPostNotFound(t *testing.T) {
+func TestTopicHandler_Update_TopicNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	mockUsecase := mocks.NewTopicUsecase(t)
@@ -569,7 +732,7 @@ func TestTopicHandler_Update_TopicOrPostNotFound(t *testing.T) {
 
 	reqBody := topicrequests.UpdateRequest{Title: "Updated Topic Title"}
 	usecaseError := usecase.ErrTopicNotFound
-	mockUsecase.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title).Return(usecaseError).Once()
+	mockUsecase.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title, (*time.Time)(nil)).Return(usecaseError).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/topics/"+strconv.FormatInt(topicID, 10), bytes.NewBuffer(jsonBody))
@@ -578,8 +741,8 @@ func TestTopicHandler_Update_TopicOrPostNotFound(t *testing.T) {
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusInternalServerError, rr.Code)
-	assert.Contains(t, rr.Body.String(), "internal server error")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), usecase.ErrTopicNotFound.Error())
 
 	mockUsecase.AssertExpectations(t)
 }
@@ -604,7 +767,7 @@ func TestTopicHandler_Update_UsecaseError(t *testing.T) {
 
 	reqBody := topicrequests.UpdateRequest{Title: "Updated Topic Title"}
 	usecaseError := errors.New("some other update error")
-	mockUsecase.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title).Return(usecaseError).Once()
+	mockUsecase.On("Update", mock.Anything, topicID, userID, userRole, reqBody.Title, (*time.Time)(nil)).Return(usecaseError).Once()
 
 	jsonBody, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest(http.MethodPut, "/topics/"+strconv.FormatInt(topicID, 10), bytes.NewBuffer(jsonBody))
@@ -724,7 +887,7 @@ func TestTopicHandler_Delete_Forbidden(t *testing.T) {
 	mockUsecase.AssertExpectations(t)
 }
 
-func TestTopicHandler_Delete_TopicOrPostNotFound(t *testing.T) {
+func TestTopicHandler_Delete_TopicNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	mockUsecase := mocks.NewTopicUsecase(t)
@@ -749,8 +912,8 @@ func TestTopicHandler_Delete_TopicOrPostNotFound(t *testing.T) {
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusInternalServerError, rr.Code)
-	assert.Contains(t, rr.Body.String(), "internal server error")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), usecase.ErrTopicNotFound.Error())
 
 	mockUsecase.AssertExpectations(t)
 }
@@ -783,3 +946,167 @@ func TestTopicHandler_Delete_UsecaseError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	mockUsecase.AssertExpectations(t)
 }
+
+func TestTopicHandler_Restore_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	router.POST("/topics/:id/restore", handler.Restore)
+
+	mockUsecase.On("Restore", mock.Anything, topicID).Return(nil).Once()
+
+	req, _ := http.NewRequest(http.MethodPost, "/topics/"+strconv.FormatInt(topicID, 10)+"/restore", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, "topic restored", respBody["message"])
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestTopicHandler_Restore_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	router.POST("/topics/:id/restore", handler.Restore)
+
+	mockUsecase.On("Restore", mock.Anything, topicID).Return(usecase.ErrTopicNotFound).Once()
+
+	req, _ := http.NewRequest(http.MethodPost, "/topics/"+strconv.FormatInt(topicID, 10)+"/restore", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestTopicHandler_UpdateState_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	router.PATCH("/topics/:id/state", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, int64(10))
+		c.Set(ContextRoleKey, "admin")
+		handler.UpdateState(c)
+	})
+
+	pinned := true
+	mockUsecase.On("SetState", mock.Anything, topicID, int64(10), "admin", &pinned, (*bool)(nil)).Return(nil).Once()
+
+	jsonBody, _ := json.Marshal(topicrequests.UpdateStateRequest{Pinned: &pinned})
+	req, _ := http.NewRequest(http.MethodPatch, "/topics/"+strconv.FormatInt(topicID, 10)+"/state", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var respBody map[string]string
+	err := json.Unmarshal(rr.Body.Bytes(), &respBody)
+	assert.NoError(t, err)
+	assert.Equal(t, "topic state updated", respBody["message"])
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestTopicHandler_UpdateState_EmptyBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	router.PATCH("/topics/:id/state", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, int64(10))
+		c.Set(ContextRoleKey, "admin")
+		handler.UpdateState(c)
+	})
+
+	req, _ := http.NewRequest(http.MethodPatch, "/topics/1/state", bytes.NewBufferString("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockUsecase.AssertNotCalled(t, "SetState", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTopicHandler_UpdateState_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	router.PATCH("/topics/:id/state", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, int64(10))
+		c.Set(ContextRoleKey, "admin")
+		handler.UpdateState(c)
+	})
+
+	locked := true
+	mockUsecase.On("SetState", mock.Anything, topicID, int64(10), "admin", (*bool)(nil), &locked).Return(usecase.ErrTopicNotFound).Once()
+
+	jsonBody, _ := json.Marshal(topicrequests.UpdateStateRequest{Locked: &locked})
+	req, _ := http.NewRequest(http.MethodPatch, "/topics/"+strconv.FormatInt(topicID, 10)+"/state", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestTopicHandler_UpdateState_Forbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockUsecase := mocks.NewTopicUsecase(t)
+	logger := zerolog.Nop()
+	handler := &TopicHandler{
+		usecase: mockUsecase,
+		log:     &logger,
+	}
+	topicID := int64(1)
+	userID := int64(20)
+	router.PATCH("/topics/:id/state", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, userID)
+		c.Set(ContextRoleKey, "user")
+		handler.UpdateState(c)
+	})
+
+	locked := true
+	mockUsecase.On("SetState", mock.Anything, topicID, userID, "user", (*bool)(nil), &locked).Return(usecase.ErrForbidden).Once()
+
+	jsonBody, _ := json.Marshal(topicrequests.UpdateStateRequest{Locked: &locked})
+	req, _ := http.NewRequest(http.MethodPatch, "/topics/"+strconv.FormatInt(topicID, 10)+"/state", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockUsecase.AssertExpectations(t)
+}
@@ -4,8 +4,40 @@ import "time"
 
 type Category struct {
 	ID          int64     `json:"id"`
+	ParentID    *int64    `json:"parent_id"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
+	Position    int       `json:"position"`
+	Hidden      bool      `json:"hidden"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	TopicCount int64     `json:"topic_count"`
+	PostCount  int64     `json:"post_count"`
+	LastPost   *LastPost `json:"last_post"`
+
+	// Breadcrumbs holds the ancestors of the category, filled in only for a single category.
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs,omitempty"`
+}
+
+// LastPost is the most recent post in a category.
+type LastPost struct {
+	ID         int64     `json:"id"`
+	TopicID    int64     `json:"topic_id"`
+	TopicTitle string    `json:"topic_title"`
+	AuthorID   *int64    `json:"author_id"`
+	Username   string    `json:"username"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CategoryNode is a category together with its subcategories.
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// Breadcrumb is one step of the path from a root category down to a page.
+type Breadcrumb struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
 }
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+type ChatRoomKind string
+
+const (
+	ChatRoomGeneral  ChatRoomKind = "general"
+	ChatRoomCategory ChatRoomKind = "category"
+	ChatRoomCustom   ChatRoomKind = "custom"
+)
+
+// GeneralChatRoomID is the room every client joins on connect.
+const GeneralChatRoomID int64 = 1
+
+// ChatRoom is the general room, the room of a category or a room created by an admin.
+// Hidden is set for rooms of hidden categories, which only admins can see.
+type ChatRoom struct {
+	ID         int64        `json:"id"`
+	Kind       ChatRoomKind `json:"kind"`
+	Name       string       `json:"name"`
+	CategoryID *int64       `json:"category_id,omitempty"`
+	CreatedBy  *int64       `json:"created_by,omitempty"`
+	Hidden     bool         `json:"hidden"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// Conversation is a private conversation as seen by one of its two participants:
+// the peer is the other participant, UnreadCount counts the peer's messages not read yet.
+type Conversation struct {
+	ID           int64          `json:"id"`
+	PeerID       int64          `json:"peer_id"`
+	PeerUsername string         `json:"peer_username"`
+	LastMessage  *DirectMessage `json:"last_message,omitempty"`
+	UnreadCount  int64          `json:"unread_count"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+type DirectMessage struct {
+	ID                int64     `json:"id"`
+	ConversationID    int64     `json:"conversation_id"`
+	SenderID          int64     `json:"sender_id"`
+	SenderUsername    string    `json:"sender_username,omitempty"`
+	RecipientID       int64     `json:"recipient_id,omitempty"`
+	RecipientUsername string    `json:"recipient_username,omitempty"`
+	Content           string    `json:"content"`
+	CreatedAt         time.Time `json:"created_at"`
+}
@@ -2,10 +2,15 @@ package entity
 
 import "time"
 
+// ChatMessage is a message of a chat room. A deleted message keeps its place in the
+// history with empty content.
 type ChatMessage struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Username  string    `json:"username"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64      `json:"id"`
+	RoomID    int64      `json:"room_id"`
+	UserID    int64      `json:"user_id"`
+	Username  string     `json:"username"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
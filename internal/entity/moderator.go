@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// CategoryModerator grants a user moderation rights inside one category.
+type CategoryModerator struct {
+	CategoryID int64     `json:"category_id"`
+	UserID     int64     `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit int64 = 20
+	MaxPageLimit     int64 = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at a row in a listing ordered by (created_at, id).
+// Topic listings put pinned topics first, so their cursors also carry Pinned.
+// A topic listing sorted by latest activity keeps the last activity time in CreatedAt,
+// one sorted by most replies keeps the reply count in Replies.
+type Cursor struct {
+	Pinned    bool
+	Replies   int64
+	CreatedAt time.Time
+	ID        int64
+}
+
+type PageRequest struct {
+	Limit  int64
+	After  *Cursor
+	Before *Cursor
+}
+
+// PageInfo is Degraded when the page was served without usernames because the
+// user service was unavailable.
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Degraded   bool   `json:"degraded,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	if c.Pinned {
+		raw += ":p"
+	}
+	if c.Replies != 0 {
+		raw += fmt.Sprintf(":r%d", c.Replies)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) < 2 || len(parts) > 4 {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}
+	for i, part := range parts[2:] {
+		switch {
+		case part == "p" && i == 0:
+			cursor.Pinned = true
+		case strings.HasPrefix(part, "r") && cursor.Replies == 0:
+			replies, err := strconv.ParseInt(part[1:], 10, 64)
+			if err != nil || replies <= 0 {
+				return nil, ErrInvalidCursor
+			}
+			cursor.Replies = replies
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return cursor, nil
+}
@@ -5,12 +5,15 @@ import (
 )
 
 type Post struct {
-	ID        int64     `json:"id"`
-	TopicID   int64     `json:"topic_id"`
-	AuthorID  *int64    `json:"author_id"`
-	Username  string    `json:"username"`
-	Content   string    `json:"content"`
-	ReplyTo   *int64    `json:"reply_to"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          int64            `json:"id"`
+	TopicID     int64            `json:"topic_id"`
+	AuthorID    *int64           `json:"author_id"`
+	Username    string           `json:"username"`
+	Content     string           `json:"content"`
+	ReplyTo     *int64           `json:"reply_to"`
+	Reactions   map[string]int64 `json:"reactions,omitempty"`
+	MyReactions []string         `json:"my_reactions,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	DeletedAt   *time.Time       `json:"deleted_at,omitempty"`
 }
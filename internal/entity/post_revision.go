@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// PostRevision is the content a post had before an edit. EditorID and
+// EditorRole describe who made the edit, CreatedAt is when it happened.
+type PostRevision struct {
+	ID             int64     `json:"id"`
+	PostID         int64     `json:"post_id"`
+	Content        string    `json:"content"`
+	EditorID       *int64    `json:"editor_id"`
+	EditorRole     string    `json:"editor_role"`
+	EditorUsername string    `json:"editor_username"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// RevisionDiff is a line-level diff between two revisions of a post.
+// A nil To means the current content of the post.
+type RevisionDiff struct {
+	PostID int64      `json:"post_id"`
+	From   int64      `json:"from"`
+	To     *int64     `json:"to"`
+	Lines  []DiffLine `json:"lines"`
+}
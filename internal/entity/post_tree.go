@@ -0,0 +1,20 @@
+package entity
+
+const (
+	DefaultTreeDepth = 5
+	MaxTreeDepth     = 20
+)
+
+// PostNode is a post together with the replies nested under it.
+// CollapsedCount is the number of descendants cut off by the depth limit.
+type PostNode struct {
+	Post
+	Depth          int         `json:"depth"`
+	CollapsedCount int64       `json:"collapsed_count"`
+	Replies        []*PostNode `json:"replies"`
+}
+
+type PostThread struct {
+	Ancestors []Post    `json:"ancestors"`
+	Post      *PostNode `json:"post"`
+}
@@ -0,0 +1,20 @@
+package entity
+
+var AllowedReactions = map[string]bool{
+	"like":    true,
+	"dislike": true,
+	"heart":   true,
+	"laugh":   true,
+	"wow":     true,
+	"sad":     true,
+	"fire":    true,
+}
+
+// ReactionCount is the number of users who left a reaction on a post,
+// ReactedByUser tells whether the requesting user is one of them.
+type ReactionCount struct {
+	PostID        int64
+	Reaction      string
+	Count         int64
+	ReactedByUser bool
+}
@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+const (
+	SearchResultTopic = "topic"
+	SearchResultPost  = "post"
+)
+
+type SearchQuery struct {
+	Query      string
+	CategoryID *int64
+	AuthorID   *int64
+	From       *time.Time
+	To         *time.Time
+	Limit      int64
+	Offset     int64
+	// IncludeHidden lets results from hidden categories and their subcategories through.
+	IncludeHidden bool
+}
+
+type SearchResult struct {
+	Type       string    `json:"type"`
+	ID         int64     `json:"id"`
+	TopicID    int64     `json:"topic_id"`
+	CategoryID int64     `json:"category_id"`
+	TopicTitle string    `json:"topic_title"`
+	AuthorID   *int64    `json:"author_id"`
+	Username   string    `json:"username"`
+	Snippet    string    `json:"snippet"`
+	Rank       float64   `json:"rank"`
+	CreatedAt  time.Time `json:"created_at"`
+}
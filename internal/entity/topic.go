@@ -4,12 +4,31 @@ import (
 	"time"
 )
 
+// TopicSort is the order of a topic listing. Pinned topics always come first.
+type TopicSort string
+
+const (
+	TopicSortNewest         TopicSort = "newest"
+	TopicSortLatestActivity TopicSort = "latest_activity"
+	TopicSortMostReplies    TopicSort = "most_replies"
+)
+
 type Topic struct {
 	ID         int64     `json:"id"`
 	CategoryID int64     `json:"category_id"`
 	Title      string    `json:"title"`
 	AuthorID   *int64    `json:"author_id"`
 	Username   string    `json:"username"`
+	Pinned     bool      `json:"pinned"`
+	Locked     bool      `json:"locked"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+
+	ReplyCount       int64      `json:"reply_count"`
+	LastPostAt       *time.Time `json:"last_post_at"`
+	LastPostAuthorID *int64     `json:"last_post_author_id"`
+	LastPostAuthor   string     `json:"last_post_author,omitempty"`
+	LastActivityAt   time.Time  `json:"last_activity_at"`
+
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs,omitempty"`
 }
@@ -1,10 +1,55 @@
 package entity
 
+// Types of messages sent by clients.
+const (
+	WsTypeSendMessage = "send_message"
+	WsTypeJoinRoom    = "join_room"
+	WsTypeLeaveRoom   = "leave_room"
+	WsTypeSendDirect  = "send_direct"
+	WsTypeLoadHistory = "load_history"
+	WsTypeResume      = "resume"
+	WsTypeEdit        = "edit_message"
+	WsTypeDelete      = "delete_message"
+)
+
+// Types of messages sent to clients.
+const (
+	WsTypeNewMessage    = "new_message"
+	WsTypeRoomJoined    = "room_joined"
+	WsTypeRoomLeft      = "room_left"
+	WsTypeRoomDeleted   = "room_deleted"
+	WsTypeDirectMessage = "direct_message"
+	WsTypeHistory       = "history"
+	WsTypeResumed       = "resumed"
+	WsTypeGapTooLarge   = "gap_too_large"
+	WsTypeEdited        = "message_edited"
+	WsTypeDeleted       = "message_deleted"
+	WsTypeError         = "error"
+)
+
 type WsMessage struct {
 	Type    string      `json:"type"`
 	Payload interface{} `json:"payload,omitempty"`
 }
 
+// IncomingWsMessage is a command sent by a client. A message without a type is sent
+// to the general room, RecipientID is only used by send_direct, Before and Limit by
+// load_history, Since by resume and join_room and MessageID by edit_message and
+// delete_message.
 type IncomingWsMessage struct {
-	Content string `json:"content"`
+	Type        string `json:"type"`
+	RoomID      int64  `json:"room_id"`
+	RecipientID int64  `json:"recipient_id"`
+	Content     string `json:"content"`
+	Before      int64  `json:"before"`
+	Limit       int64  `json:"limit"`
+	Since       int64  `json:"since"`
+	MessageID   int64  `json:"message_id"`
+}
+
+// ChatHistory is a page of older messages of a room sent in reply to load_history, or
+// the messages a reconnecting client missed.
+type ChatHistory struct {
+	RoomID   int64         `json:"room_id"`
+	Messages []ChatMessage `json:"messages"`
 }
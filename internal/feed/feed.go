@@ -0,0 +1,63 @@
+package feed
+
+import (
+	"sync"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/rs/zerolog"
+)
+
+const DefaultBuffer = 64
+
+// Feed fans new posts out to its subscribers. Publish never blocks: a subscriber whose
+// buffer is full is dropped and its channel closed, so it notices the gap and can
+// resubscribe instead of silently missing posts.
+type Feed struct {
+	mu   sync.Mutex
+	subs map[chan entity.Post]struct{}
+	log  *zerolog.Logger
+}
+
+func New(log *zerolog.Logger) *Feed {
+	return &Feed{subs: make(map[chan entity.Post]struct{}), log: log}
+}
+
+// Subscribe returns a channel of posts published from now on. cancel must be called
+// once the subscriber is done, it is safe to call after the subscriber was dropped.
+func (f *Feed) Subscribe(buffer int) (posts <-chan entity.Post, cancel func()) {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	ch := make(chan entity.Post, buffer)
+
+	f.mu.Lock()
+	f.subs[ch] = struct{}{}
+	f.mu.Unlock()
+
+	return ch, func() { f.remove(ch) }
+}
+
+func (f *Feed) Publish(post entity.Post) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subs {
+		select {
+		case ch <- post:
+		default:
+			f.log.Warn().Str("op", "feed.Publish").Int64("post_id", post.ID).Msg("Subscriber fell behind, dropping it")
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (f *Feed) remove(ch chan entity.Post) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subs[ch]; ok {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,50 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeed(t *testing.T) {
+	logger := zerolog.Nop()
+
+	t.Run("Subscribers get published posts", func(t *testing.T) {
+		f := New(&logger)
+		first, cancelFirst := f.Subscribe(1)
+		defer cancelFirst()
+		second, cancelSecond := f.Subscribe(1)
+		defer cancelSecond()
+
+		f.Publish(entity.Post{ID: 1})
+
+		assert.Equal(t, int64(1), (<-first).ID)
+		assert.Equal(t, int64(1), (<-second).ID)
+	})
+
+	t.Run("Slow subscriber is dropped", func(t *testing.T) {
+		f := New(&logger)
+		posts, cancel := f.Subscribe(1)
+
+		f.Publish(entity.Post{ID: 1})
+		f.Publish(entity.Post{ID: 2})
+
+		assert.Equal(t, int64(1), (<-posts).ID)
+		_, ok := <-posts
+		assert.False(t, ok)
+		cancel()
+	})
+
+	t.Run("Canceled subscriber gets nothing", func(t *testing.T) {
+		f := New(&logger)
+		posts, cancel := f.Subscribe(1)
+		cancel()
+
+		f.Publish(entity.Post{ID: 1})
+
+		_, ok := <-posts
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,123 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/controller/middleware"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TokenParser validates access tokens, *jwt.JWT implements it.
+type TokenParser interface {
+	ParseToken(token string) (map[string]interface{}, error)
+}
+
+type claimsKey struct{}
+
+// AuthInterceptor is the gRPC counterpart of the HTTP auth middleware. Calls without
+// an authorization header stay anonymous, as with OptionalAuth, and services decide
+// which RPCs require a user. A header with an invalid token is rejected.
+type AuthInterceptor struct {
+	jwt TokenParser
+}
+
+func NewAuthInterceptor(jwt TokenParser) *AuthInterceptor {
+	return &AuthInterceptor{jwt: jwt}
+}
+
+func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := i.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (i *AuthInterceptor) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ctx, nil
+	}
+
+	parts := strings.Split(values[0], " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization header format")
+	}
+
+	claims, err := i.jwt.ParseToken(parts[1])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	var accessClaims middleware.AccessClaims
+	if err := mapstructure.Decode(claims, &accessClaims); err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token claims")
+	}
+	return context.WithValue(ctx, claimsKey{}, accessClaims), nil
+}
+
+// UserFromContext returns the id and role of the caller, ok is false for anonymous calls.
+func UserFromContext(ctx context.Context) (userID int64, role string, ok bool) {
+	claims, ok := ctx.Value(claimsKey{}).(middleware.AccessClaims)
+	if !ok {
+		return 0, "", false
+	}
+	return claims.UserID, claims.Role, true
+}
+
+// RequireUser returns codes.Unauthenticated for anonymous calls.
+func RequireUser(ctx context.Context) (userID int64, role string, err error) {
+	userID, role, ok := UserFromContext(ctx)
+	if !ok {
+		return 0, "", status.Error(codes.Unauthenticated, "authorization metadata is required")
+	}
+	return userID, role, nil
+}
+
+// RequireAdmin returns codes.Unauthenticated for anonymous calls and codes.PermissionDenied
+// for callers that are not admins.
+func RequireAdmin(ctx context.Context) (userID int64, err error) {
+	userID, role, err := RequireUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !policy.IsAdmin(role) {
+		return 0, status.Error(codes.PermissionDenied, "insufficient permissions")
+	}
+	return userID, nil
+}
+
+// isAdmin reports whether the caller is an admin, admins also read hidden categories.
+func isAdmin(ctx context.Context) bool {
+	_, role, _ := UserFromContext(ctx)
+	return policy.IsAdmin(role)
+}
+
+// authenticatedStream replaces the context of a stream with the authenticated one.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,92 @@
+package grpcserver
+
+import (
+	"context"
+
+	forumpb "github.com/keshvan/forum-service-sstu-forum/api/forum"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	listCategoriesOp = "CategoryService.ListCategories"
+	getCategoryOp    = "CategoryService.GetCategory"
+	createCategoryOp = "CategoryService.CreateCategory"
+	updateCategoryOp = "CategoryService.UpdateCategory"
+	deleteCategoryOp = "CategoryService.DeleteCategory"
+)
+
+// CategoryService serves categories over gRPC. Hidden categories are only visible to
+// admins, writes require an admin.
+type CategoryService struct {
+	forumpb.UnimplementedCategoryServiceServer
+	usecase usecase.CategoryUsecase
+	log     *zerolog.Logger
+}
+
+func NewCategoryService(usecase usecase.CategoryUsecase, log *zerolog.Logger) *CategoryService {
+	return &CategoryService{usecase: usecase, log: log}
+}
+
+func (s *CategoryService) ListCategories(ctx context.Context, req *forumpb.ListCategoriesRequest) (*forumpb.ListCategoriesResponse, error) {
+	categories, _, err := s.usecase.GetAll(ctx, isAdmin(ctx))
+	if err != nil {
+		return nil, toStatus(s.log, listCategoriesOp, err)
+	}
+
+	res := &forumpb.ListCategoriesResponse{Categories: make([]*forumpb.Category, len(categories))}
+	for i := range categories {
+		res.Categories[i] = toCategoryPB(&categories[i])
+	}
+	return res, nil
+}
+
+func (s *CategoryService) GetCategory(ctx context.Context, req *forumpb.GetCategoryRequest) (*forumpb.Category, error) {
+	category, _, err := s.usecase.GetByID(ctx, req.GetId(), isAdmin(ctx))
+	if err != nil {
+		return nil, toStatus(s.log, getCategoryOp, err)
+	}
+	return toCategoryPB(category), nil
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, req *forumpb.CreateCategoryRequest) (*forumpb.CreateResponse, error) {
+	if _, err := RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetTitle() == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is required")
+	}
+
+	category := entity.Category{Title: req.GetTitle(), Description: req.GetDescription(), ParentID: req.ParentId}
+	id, err := s.usecase.Create(ctx, category)
+	if err != nil {
+		return nil, toStatus(s.log, createCategoryOp, err)
+	}
+	return &forumpb.CreateResponse{Id: id}, nil
+}
+
+func (s *CategoryService) UpdateCategory(ctx context.Context, req *forumpb.UpdateCategoryRequest) (*emptypb.Empty, error) {
+	if _, err := RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.usecase.Update(ctx, req.GetId(), req.Title, req.Description, req.ParentId, req.Hidden, fromVersionPB(req.GetVersion())); err != nil {
+		return nil, toStatus(s.log, updateCategoryOp, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *CategoryService) DeleteCategory(ctx context.Context, req *forumpb.DeleteCategoryRequest) (*emptypb.Empty, error) {
+	if _, err := RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.usecase.Delete(ctx, req.GetId()); err != nil {
+		return nil, toStatus(s.log, deleteCategoryOp, err)
+	}
+	return &emptypb.Empty{}, nil
+}
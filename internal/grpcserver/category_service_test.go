@@ -0,0 +1,115 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	forumpb "github.com/keshvan/forum-service-sstu-forum/api/forum"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/keshvan/forum-service-sstu-forum/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newCategoryClient(t *testing.T) (forumpb.CategoryServiceClient, *mocks.CategoryUsecase) {
+	categoryUsecase := mocks.NewCategoryUsecase(t)
+	conn := newTestConn(t, func(srv *Server) {
+		srv.RegisterForum(categoryUsecase, mocks.NewTopicUsecase(t), mocks.NewPostUsecase(t), &fakeSubscriber{})
+	})
+	return forumpb.NewCategoryServiceClient(conn), categoryUsecase
+}
+
+func TestCategoryService_ListCategories(t *testing.T) {
+	client, categoryUsecase := newCategoryClient(t)
+	parentID := int64(1)
+
+	categoryUsecase.On("GetAll", mock.Anything, false).Return([]entity.Category{{ID: 2, ParentID: &parentID, Title: "Course", TopicCount: 3}}, false, nil).Once()
+	res, err := client.ListCategories(context.Background(), &forumpb.ListCategoriesRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.GetCategories(), 1)
+	assert.Equal(t, int64(2), res.GetCategories()[0].GetId())
+	assert.Equal(t, parentID, res.GetCategories()[0].GetParentId())
+	assert.Equal(t, "Course", res.GetCategories()[0].GetTitle())
+	assert.Equal(t, int64(3), res.GetCategories()[0].GetTopicCount())
+
+	// Admins also see hidden categories.
+	categoryUsecase.On("GetAll", mock.Anything, true).Return([]entity.Category{}, false, nil).Once()
+	_, err = client.ListCategories(withToken("valid"), &forumpb.ListCategoriesRequest{})
+	require.NoError(t, err)
+}
+
+func TestCategoryService_GetCategory(t *testing.T) {
+	client, categoryUsecase := newCategoryClient(t)
+
+	categoryUsecase.On("GetByID", mock.Anything, int64(1), false).Return(&entity.Category{ID: 1, Title: "Faculty"}, false, nil).Once()
+	res, err := client.GetCategory(context.Background(), &forumpb.GetCategoryRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Faculty", res.GetTitle())
+	assert.Nil(t, res.ParentId)
+
+	categoryUsecase.On("GetByID", mock.Anything, int64(2), false).Return(nil, false, usecase.ErrCategoryNotFound).Once()
+	_, err = client.GetCategory(context.Background(), &forumpb.GetCategoryRequest{Id: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestCategoryService_CreateCategory(t *testing.T) {
+	client, categoryUsecase := newCategoryClient(t)
+	req := &forumpb.CreateCategoryRequest{Title: "Faculty", Description: "desc"}
+
+	_, err := client.CreateCategory(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreateCategory(withToken("user"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.CreateCategory(withToken("valid"), &forumpb.CreateCategoryRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	categoryUsecase.On("Create", mock.Anything, entity.Category{Title: "Faculty", Description: "desc"}).Return(int64(5), nil).Once()
+	res, err := client.CreateCategory(withToken("valid"), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), res.GetId())
+}
+
+func TestCategoryService_UpdateCategory(t *testing.T) {
+	client, categoryUsecase := newCategoryClient(t)
+	version := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	hidden := true
+	title := "Faculty"
+	req := &forumpb.UpdateCategoryRequest{Id: 1, Title: &title, Hidden: &hidden, Version: timestamppb.New(version)}
+
+	_, err := client.UpdateCategory(withToken("user"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	categoryUsecase.On("Update", mock.Anything, int64(1), &title, (*string)(nil), (*int64)(nil), &hidden, &version).Return(nil).Once()
+	_, err = client.UpdateCategory(withToken("valid"), req)
+	require.NoError(t, err)
+
+	categoryUsecase.On("Update", mock.Anything, int64(1), &title, (*string)(nil), (*int64)(nil), &hidden, &version).Return(usecase.ErrVersionConflict).Once()
+	_, err = client.UpdateCategory(withToken("valid"), req)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestCategoryService_DeleteCategory(t *testing.T) {
+	client, categoryUsecase := newCategoryClient(t)
+
+	_, err := client.DeleteCategory(context.Background(), &forumpb.DeleteCategoryRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	categoryUsecase.On("Delete", mock.Anything, int64(1)).Return(nil).Once()
+	_, err = client.DeleteCategory(withToken("valid"), &forumpb.DeleteCategoryRequest{Id: 1})
+	require.NoError(t, err)
+
+	// Unexpected errors are not passed on to the caller.
+	categoryUsecase.On("Delete", mock.Anything, int64(2)).Return(errors.New("connection refused")).Once()
+	_, err = client.DeleteCategory(withToken("valid"), &forumpb.DeleteCategoryRequest{Id: 2})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal error", status.Convert(err).Message())
+}
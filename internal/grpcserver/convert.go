@@ -0,0 +1,99 @@
+package grpcserver
+
+import (
+	"time"
+
+	forumpb "github.com/keshvan/forum-service-sstu-forum/api/forum"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toCategoryPB(c *entity.Category) *forumpb.Category {
+	return &forumpb.Category{
+		Id:          c.ID,
+		ParentId:    c.ParentID,
+		Title:       c.Title,
+		Description: c.Description,
+		Position:    int32(c.Position),
+		Hidden:      c.Hidden,
+		TopicCount:  c.TopicCount,
+		PostCount:   c.PostCount,
+		CreatedAt:   timestamppb.New(c.CreatedAt),
+		UpdatedAt:   timestamppb.New(c.UpdatedAt),
+	}
+}
+
+func toTopicPB(t *entity.Topic) *forumpb.Topic {
+	return &forumpb.Topic{
+		Id:             t.ID,
+		CategoryId:     t.CategoryID,
+		Title:          t.Title,
+		AuthorId:       t.AuthorID,
+		Username:       t.Username,
+		Pinned:         t.Pinned,
+		Locked:         t.Locked,
+		ReplyCount:     t.ReplyCount,
+		CreatedAt:      timestamppb.New(t.CreatedAt),
+		UpdatedAt:      timestamppb.New(t.UpdatedAt),
+		LastActivityAt: timestamppb.New(t.LastActivityAt),
+	}
+}
+
+func toPostPB(p *entity.Post) *forumpb.Post {
+	return &forumpb.Post{
+		Id:        p.ID,
+		TopicId:   p.TopicID,
+		AuthorId:  p.AuthorID,
+		Username:  p.Username,
+		Content:   p.Content,
+		ReplyTo:   p.ReplyTo,
+		CreatedAt: timestamppb.New(p.CreatedAt),
+		UpdatedAt: timestamppb.New(p.UpdatedAt),
+	}
+}
+
+func toPageInfoPB(info entity.PageInfo) *forumpb.PageInfo {
+	return &forumpb.PageInfo{NextCursor: info.NextCursor, PrevCursor: info.PrevCursor, Degraded: info.Degraded}
+}
+
+// fromPageRequestPB is the gRPC counterpart of parsePageRequest in the HTTP controller.
+func fromPageRequestPB(req *forumpb.PageRequest) (entity.PageRequest, error) {
+	var page entity.PageRequest
+	if req == nil {
+		return page, nil
+	}
+
+	if req.GetLimit() < 0 {
+		return page, status.Error(codes.InvalidArgument, "invalid limit")
+	}
+	page.Limit = req.GetLimit()
+
+	if req.GetAfter() != "" && req.GetBefore() != "" {
+		return page, status.Error(codes.InvalidArgument, entity.ErrInvalidCursor.Error())
+	}
+
+	var err error
+	if req.GetAfter() != "" {
+		if page.After, err = entity.DecodeCursor(req.GetAfter()); err != nil {
+			return page, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if req.GetBefore() != "" {
+		if page.Before, err = entity.DecodeCursor(req.GetBefore()); err != nil {
+			return page, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	return page, nil
+}
+
+// fromVersionPB returns nil if the caller did not send a version.
+func fromVersionPB(version *timestamppb.Timestamp) *time.Time {
+	if version == nil {
+		return nil
+	}
+	t := version.AsTime()
+	return &t
+}
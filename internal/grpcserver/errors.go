@@ -0,0 +1,41 @@
+package grpcserver
+
+import (
+	"errors"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodes maps usecase errors to the codes matching the HTTP statuses the handlers
+// return for them.
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{usecase.ErrCategoryNotFound, codes.NotFound},
+	{usecase.ErrTopicNotFound, codes.NotFound},
+	{usecase.ErrPostNotFound, codes.NotFound},
+	{usecase.ErrForbidden, codes.PermissionDenied},
+	{usecase.ErrVersionConflict, codes.Aborted},
+	{usecase.ErrTopicLocked, codes.FailedPrecondition},
+	{usecase.ErrInvalidReplyTarget, codes.InvalidArgument},
+	{usecase.ErrParentCategoryNotFound, codes.InvalidArgument},
+	{usecase.ErrCategoryCycle, codes.InvalidArgument},
+	{usecase.ErrCategoryHasChildren, codes.FailedPrecondition},
+}
+
+// toStatus converts a usecase error into a gRPC status. Unexpected errors are logged
+// and reported as codes.Internal without their details.
+func toStatus(log *zerolog.Logger, op string, err error) error {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return status.Error(e.code, e.err.Error())
+		}
+	}
+
+	log.Error().Err(err).Str("op", op).Msg("Request failed")
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,147 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	forumpb "github.com/keshvan/forum-service-sstu-forum/api/forum"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	listPostsOp  = "PostService.ListPosts"
+	getPostOp    = "PostService.GetPost"
+	createPostOp = "PostService.CreatePost"
+	updatePostOp = "PostService.UpdatePost"
+	deletePostOp = "PostService.DeletePost"
+	watchPostsOp = "PostService.WatchPosts"
+)
+
+// PostSubscriber hands out subscriptions to new posts, *feed.Feed implements it.
+type PostSubscriber interface {
+	Subscribe(buffer int) (posts <-chan entity.Post, cancel func())
+}
+
+// PostService serves posts over gRPC, writes require a user.
+type PostService struct {
+	forumpb.UnimplementedPostServiceServer
+	usecase usecase.PostUsecase
+	feed    PostSubscriber
+	log     *zerolog.Logger
+}
+
+func NewPostService(usecase usecase.PostUsecase, feed PostSubscriber, log *zerolog.Logger) *PostService {
+	return &PostService{usecase: usecase, feed: feed, log: log}
+}
+
+func (s *PostService) ListPosts(ctx context.Context, req *forumpb.ListPostsRequest) (*forumpb.ListPostsResponse, error) {
+	page, err := fromPageRequestPB(req.GetPage())
+	if err != nil {
+		return nil, err
+	}
+
+	// Anonymous callers get viewerID 0, as over HTTP.
+	viewerID, _, _ := UserFromContext(ctx)
+	posts, pageInfo, err := s.usecase.GetByTopic(ctx, req.GetTopicId(), viewerID, isAdmin(ctx), page)
+	if err != nil {
+		return nil, toStatus(s.log, listPostsOp, err)
+	}
+
+	res := &forumpb.ListPostsResponse{Posts: make([]*forumpb.Post, len(posts)), PageInfo: toPageInfoPB(pageInfo)}
+	for i := range posts {
+		res.Posts[i] = toPostPB(&posts[i])
+	}
+	return res, nil
+}
+
+func (s *PostService) GetPost(ctx context.Context, req *forumpb.GetPostRequest) (*forumpb.Post, error) {
+	post, _, err := s.usecase.GetByID(ctx, req.GetId(), isAdmin(ctx))
+	if err != nil {
+		return nil, toStatus(s.log, getPostOp, err)
+	}
+	return toPostPB(post), nil
+}
+
+func (s *PostService) CreatePost(ctx context.Context, req *forumpb.CreatePostRequest) (*forumpb.CreateResponse, error) {
+	userID, _, err := RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetContent() == "" {
+		return nil, status.Error(codes.InvalidArgument, "content is required")
+	}
+
+	post := entity.Post{TopicID: req.GetTopicId(), AuthorID: &userID, Content: req.GetContent(), ReplyTo: req.ReplyTo}
+	id, err := s.usecase.Create(ctx, post)
+	if err != nil {
+		return nil, toStatus(s.log, createPostOp, err)
+	}
+	return &forumpb.CreateResponse{Id: id}, nil
+}
+
+func (s *PostService) UpdatePost(ctx context.Context, req *forumpb.UpdatePostRequest) (*emptypb.Empty, error) {
+	userID, role, err := RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.usecase.Update(ctx, req.GetId(), userID, role, req.GetContent(), fromVersionPB(req.GetVersion())); err != nil {
+		return nil, toStatus(s.log, updatePostOp, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *PostService) DeletePost(ctx context.Context, req *forumpb.DeletePostRequest) (*emptypb.Empty, error) {
+	userID, role, err := RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.usecase.Delete(ctx, req.GetId(), userID, role); err != nil {
+		return nil, toStatus(s.log, deletePostOp, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// WatchPosts sends posts as they are created. The feed only carries what was written,
+// so each post is read back through the usecase for its username and timestamps. Posts
+// in hidden categories are only sent to admins.
+func (s *PostService) WatchPosts(req *forumpb.WatchPostsRequest, stream grpc.ServerStreamingServer[forumpb.Post]) error {
+	ctx := stream.Context()
+	includeHidden := isAdmin(ctx)
+	posts, cancel := s.feed.Subscribe(0)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case post, ok := <-posts:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell behind, resubscribe")
+			}
+			if req.GetTopicId() != 0 && post.TopicID != req.GetTopicId() {
+				continue
+			}
+
+			created, _, err := s.usecase.GetByID(ctx, post.ID, includeHidden)
+			if err != nil {
+				// The post or its topic has been deleted in the meantime, or is in a
+				// hidden category.
+				if errors.Is(err, usecase.ErrPostNotFound) {
+					continue
+				}
+				return toStatus(s.log, watchPostsOp, err)
+			}
+			if err := stream.Send(toPostPB(created)); err != nil {
+				return err
+			}
+		}
+	}
+}
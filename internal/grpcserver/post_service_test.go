@@ -0,0 +1,181 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	forumpb "github.com/keshvan/forum-service-sstu-forum/api/forum"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/keshvan/forum-service-sstu-forum/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeSubscriber hands out a single subscription and reports when it was taken.
+type fakeSubscriber struct {
+	posts      chan entity.Post
+	subscribed chan struct{}
+}
+
+func (s *fakeSubscriber) Subscribe(buffer int) (<-chan entity.Post, func()) {
+	close(s.subscribed)
+	return s.posts, func() {}
+}
+
+func newPostClient(t *testing.T) (forumpb.PostServiceClient, *mocks.PostUsecase, *fakeSubscriber) {
+	postUsecase := mocks.NewPostUsecase(t)
+	feed := &fakeSubscriber{posts: make(chan entity.Post, 10), subscribed: make(chan struct{})}
+	conn := newTestConn(t, func(srv *Server) {
+		srv.RegisterForum(mocks.NewCategoryUsecase(t), mocks.NewTopicUsecase(t), postUsecase, feed)
+	})
+	return forumpb.NewPostServiceClient(conn), postUsecase, feed
+}
+
+func TestPostService_ListPosts(t *testing.T) {
+	client, postUsecase, _ := newPostClient(t)
+
+	// Authenticated callers get their own reactions.
+	postUsecase.On("GetByTopic", mock.Anything, int64(3), int64(8), false, entity.PageRequest{Limit: 5}).
+		Return([]entity.Post{{ID: 1, TopicID: 3, Content: "hello"}}, entity.PageInfo{PrevCursor: "prev"}, nil).Once()
+	res, err := client.ListPosts(withToken("user"), &forumpb.ListPostsRequest{TopicId: 3, Page: &forumpb.PageRequest{Limit: 5}})
+	require.NoError(t, err)
+	require.Len(t, res.GetPosts(), 1)
+	assert.Equal(t, "hello", res.GetPosts()[0].GetContent())
+	assert.Equal(t, "prev", res.GetPageInfo().GetPrevCursor())
+
+	_, err = client.ListPosts(context.Background(), &forumpb.ListPostsRequest{TopicId: 3, Page: &forumpb.PageRequest{Limit: -1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	postUsecase.On("GetByTopic", mock.Anything, int64(4), int64(0), false, entity.PageRequest{}).Return(nil, entity.PageInfo{}, usecase.ErrTopicNotFound).Once()
+	_, err = client.ListPosts(context.Background(), &forumpb.ListPostsRequest{TopicId: 4})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Only admins see the posts of topics in hidden categories.
+	postUsecase.On("GetByTopic", mock.Anything, int64(5), int64(7), true, entity.PageRequest{}).Return([]entity.Post{{ID: 9, TopicID: 5}}, entity.PageInfo{}, nil).Once()
+	res, err = client.ListPosts(withToken("valid"), &forumpb.ListPostsRequest{TopicId: 5})
+	require.NoError(t, err)
+	assert.Len(t, res.GetPosts(), 1)
+}
+
+func TestPostService_GetPost(t *testing.T) {
+	client, postUsecase, _ := newPostClient(t)
+	replyTo := int64(1)
+	createdAt := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+
+	postUsecase.On("GetByID", mock.Anything, int64(2), false).Return(&entity.Post{ID: 2, ReplyTo: &replyTo, Username: "alice", CreatedAt: createdAt}, false, nil).Once()
+	res, err := client.GetPost(context.Background(), &forumpb.GetPostRequest{Id: 2})
+	require.NoError(t, err)
+	assert.Equal(t, replyTo, res.GetReplyTo())
+	assert.Equal(t, "alice", res.GetUsername())
+	assert.Equal(t, createdAt, res.GetCreatedAt().AsTime())
+	assert.Nil(t, res.AuthorId)
+
+	postUsecase.On("GetByID", mock.Anything, int64(3), false).Return(nil, false, usecase.ErrPostNotFound).Once()
+	_, err = client.GetPost(context.Background(), &forumpb.GetPostRequest{Id: 3})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	postUsecase.On("GetByID", mock.Anything, int64(3), true).Return(&entity.Post{ID: 3}, false, nil).Once()
+	res, err = client.GetPost(withToken("valid"), &forumpb.GetPostRequest{Id: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), res.GetId())
+}
+
+func TestPostService_CreatePost(t *testing.T) {
+	client, postUsecase, _ := newPostClient(t)
+	replyTo := int64(1)
+	req := &forumpb.CreatePostRequest{TopicId: 3, Content: "hello", ReplyTo: &replyTo}
+
+	_, err := client.CreatePost(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreatePost(withToken("user"), &forumpb.CreatePostRequest{TopicId: 3})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	authorID := int64(8)
+	post := entity.Post{TopicID: 3, AuthorID: &authorID, Content: "hello", ReplyTo: &replyTo}
+	postUsecase.On("Create", mock.Anything, post).Return(int64(2), nil).Once()
+	res, err := client.CreatePost(withToken("user"), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.GetId())
+
+	postUsecase.On("Create", mock.Anything, post).Return(int64(0), usecase.ErrTopicLocked).Once()
+	_, err = client.CreatePost(withToken("user"), req)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestPostService_UpdatePost(t *testing.T) {
+	client, postUsecase, _ := newPostClient(t)
+	req := &forumpb.UpdatePostRequest{Id: 2, Content: "edited"}
+
+	_, err := client.UpdatePost(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	postUsecase.On("Update", mock.Anything, int64(2), int64(8), "user", "edited", (*time.Time)(nil)).Return(nil).Once()
+	_, err = client.UpdatePost(withToken("user"), req)
+	require.NoError(t, err)
+
+	postUsecase.On("Update", mock.Anything, int64(2), int64(8), "user", "edited", (*time.Time)(nil)).Return(usecase.ErrForbidden).Once()
+	_, err = client.UpdatePost(withToken("user"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestPostService_DeletePost(t *testing.T) {
+	client, postUsecase, _ := newPostClient(t)
+
+	_, err := client.DeletePost(context.Background(), &forumpb.DeletePostRequest{Id: 2})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	postUsecase.On("Delete", mock.Anything, int64(2), int64(8), "user").Return(nil).Once()
+	_, err = client.DeletePost(withToken("user"), &forumpb.DeletePostRequest{Id: 2})
+	require.NoError(t, err)
+}
+
+func TestPostService_WatchPosts(t *testing.T) {
+	client, postUsecase, feed := newPostClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.WatchPosts(ctx, &forumpb.WatchPostsRequest{TopicId: 3})
+	require.NoError(t, err)
+	<-feed.subscribed
+
+	// Post 1 belongs to another topic and post 2 was deleted before it was read back,
+	// or is in a hidden category.
+	postUsecase.On("GetByID", mock.Anything, int64(2), false).Return(nil, false, usecase.ErrPostNotFound).Once()
+	postUsecase.On("GetByID", mock.Anything, int64(3), false).Return(&entity.Post{ID: 3, TopicID: 3, Username: "alice", Content: "hello"}, false, nil).Once()
+	feed.posts <- entity.Post{ID: 1, TopicID: 4}
+	feed.posts <- entity.Post{ID: 2, TopicID: 3}
+	feed.posts <- entity.Post{ID: 3, TopicID: 3}
+
+	post, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), post.GetId())
+	assert.Equal(t, "alice", post.GetUsername())
+
+	// The feed drops subscribers that fall behind.
+	close(feed.posts)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestPostService_WatchPostsAdminSeesHidden(t *testing.T) {
+	client, postUsecase, feed := newPostClient(t)
+	ctx, cancel := context.WithCancel(withToken("valid"))
+	defer cancel()
+
+	stream, err := client.WatchPosts(ctx, &forumpb.WatchPostsRequest{})
+	require.NoError(t, err)
+	<-feed.subscribed
+
+	postUsecase.On("GetByID", mock.Anything, int64(4), true).Return(&entity.Post{ID: 4, TopicID: 7, Content: "hidden"}, false, nil).Once()
+	feed.posts <- entity.Post{ID: 4, TopicID: 7}
+
+	post, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "hidden", post.GetContent())
+}
@@ -0,0 +1,71 @@
+package grpcserver
+
+import (
+	"fmt"
+	"net"
+
+	forumpb "github.com/keshvan/forum-service-sstu-forum/api/forum"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Server runs next to the HTTP server. Services are registered on Server before Run,
+// every call passes through the auth interceptor.
+type Server struct {
+	Server  *grpc.Server
+	address string
+	health  *health.Server
+	log     *zerolog.Logger
+}
+
+func New(address string, jwt TokenParser, log *zerolog.Logger) *Server {
+	auth := NewAuthInterceptor(jwt)
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.Unary()),
+		grpc.ChainStreamInterceptor(auth.Stream()),
+	)
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(srv, healthServer)
+
+	return &Server{Server: srv, address: address, health: healthServer, log: log}
+}
+
+// RegisterForum registers the category, topic and post services, feed backs the
+// stream of new posts.
+func (s *Server) RegisterForum(categoryUsecase usecase.CategoryUsecase, topicUsecase usecase.TopicUsecase, postUsecase usecase.PostUsecase, feed PostSubscriber) {
+	forumpb.RegisterCategoryServiceServer(s.Server, NewCategoryService(categoryUsecase, s.log))
+	forumpb.RegisterTopicServiceServer(s.Server, NewTopicService(topicUsecase, s.log))
+	forumpb.RegisterPostServiceServer(s.Server, NewPostService(postUsecase, feed, s.log))
+}
+
+// Run listens on the configured address and serves in the background.
+func (s *Server) Run() error {
+	lis, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("grpcserver - Run - net.Listen: %w", err)
+	}
+
+	go func() {
+		if err := s.Serve(lis); err != nil {
+			s.log.Error().Err(err).Str("op", "grpcserver.Run").Msg("gRPC server stopped")
+		}
+	}()
+
+	s.log.Info().Str("op", "grpcserver.Run").Str("address", s.address).Msg("gRPC server started")
+	return nil
+}
+
+// Serve blocks serving lis, tests pass a bufconn listener.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.Server.Serve(lis)
+}
+
+// Shutdown reports the service as not serving and waits for running calls to finish.
+func (s *Server) Shutdown() {
+	s.health.Shutdown()
+	s.Server.GracefulStop()
+}
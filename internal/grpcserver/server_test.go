@@ -0,0 +1,113 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeJWT struct{}
+
+// fakeJWT accepts "valid" for admin 7, "user" for user 8 and "claims" with a user id
+// that is not a number.
+func (fakeJWT) ParseToken(token string) (map[string]interface{}, error) {
+	switch token {
+	case "valid":
+		return map[string]interface{}{"user_id": int64(7), "role": "admin"}, nil
+	case "user":
+		return map[string]interface{}{"user_id": int64(8), "role": "user"}, nil
+	case "claims":
+		return map[string]interface{}{"user_id": "seven", "role": "admin"}, nil
+	}
+	return nil, errors.New("token is malformed")
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// newTestConn serves srv over bufconn, register adds services before it starts serving.
+func newTestConn(t *testing.T, register func(srv *Server)) *grpc.ClientConn {
+	logger := zerolog.Nop()
+	srv := New("", fakeJWT{}, &logger)
+	if register != nil {
+		register(srv)
+	}
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Shutdown)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestServer_Auth(t *testing.T) {
+	health := healthpb.NewHealthClient(newTestConn(t, nil))
+
+	t.Run("Anonymous calls pass", func(t *testing.T) {
+		res, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.GetStatus())
+	})
+
+	t.Run("Valid token passes", func(t *testing.T) {
+		ctx := withToken("valid")
+		_, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+		assert.NoError(t, err)
+	})
+
+	for name, header := range map[string]string{"Invalid token": "Bearer expired", "Invalid format": "valid", "Invalid claims": "Bearer claims"} {
+		t.Run(name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", header)
+			_, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+
+	t.Run("Streams are checked", func(t *testing.T) {
+		ctx := withToken("expired")
+		stream, err := health.Watch(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestAuthInterceptor_UserFromContext(t *testing.T) {
+	interceptor := NewAuthInterceptor(fakeJWT{}).Unary()
+	call := func(ctx context.Context) (int64, string, error) {
+		var userID int64
+		var role string
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+			var err error
+			userID, role, err = RequireUser(ctx)
+			return nil, err
+		})
+		return userID, role, err
+	}
+
+	userID, role, err := call(metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer valid")))
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), userID)
+	assert.Equal(t, "admin", role)
+
+	_, _, err = call(context.Background())
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
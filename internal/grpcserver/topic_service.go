@@ -0,0 +1,106 @@
+package grpcserver
+
+import (
+	"context"
+
+	forumpb "github.com/keshvan/forum-service-sstu-forum/api/forum"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	listTopicsOp  = "TopicService.ListTopics"
+	getTopicOp    = "TopicService.GetTopic"
+	createTopicOp = "TopicService.CreateTopic"
+	updateTopicOp = "TopicService.UpdateTopic"
+	deleteTopicOp = "TopicService.DeleteTopic"
+)
+
+// TopicService serves topics over gRPC, writes require a user.
+type TopicService struct {
+	forumpb.UnimplementedTopicServiceServer
+	usecase usecase.TopicUsecase
+	log     *zerolog.Logger
+}
+
+func NewTopicService(usecase usecase.TopicUsecase, log *zerolog.Logger) *TopicService {
+	return &TopicService{usecase: usecase, log: log}
+}
+
+func (s *TopicService) ListTopics(ctx context.Context, req *forumpb.ListTopicsRequest) (*forumpb.ListTopicsResponse, error) {
+	sort := entity.TopicSort(req.GetSort())
+	switch sort {
+	case "", entity.TopicSortNewest, entity.TopicSortLatestActivity, entity.TopicSortMostReplies:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "sort must be newest, latest_activity or most_replies")
+	}
+
+	page, err := fromPageRequestPB(req.GetPage())
+	if err != nil {
+		return nil, err
+	}
+
+	topics, pageInfo, err := s.usecase.GetByCategory(ctx, req.GetCategoryId(), isAdmin(ctx), sort, page)
+	if err != nil {
+		return nil, toStatus(s.log, listTopicsOp, err)
+	}
+
+	res := &forumpb.ListTopicsResponse{Topics: make([]*forumpb.Topic, len(topics)), PageInfo: toPageInfoPB(pageInfo)}
+	for i := range topics {
+		res.Topics[i] = toTopicPB(&topics[i])
+	}
+	return res, nil
+}
+
+func (s *TopicService) GetTopic(ctx context.Context, req *forumpb.GetTopicRequest) (*forumpb.Topic, error) {
+	topic, _, err := s.usecase.GetByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(s.log, getTopicOp, err)
+	}
+	return toTopicPB(topic), nil
+}
+
+func (s *TopicService) CreateTopic(ctx context.Context, req *forumpb.CreateTopicRequest) (*forumpb.CreateResponse, error) {
+	userID, _, err := RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetTitle() == "" || req.GetContent() == "" {
+		return nil, status.Error(codes.InvalidArgument, "title and content are required")
+	}
+
+	topic := entity.Topic{CategoryID: req.GetCategoryId(), Title: req.GetTitle(), AuthorID: &userID}
+	id, err := s.usecase.Create(ctx, topic, req.GetContent())
+	if err != nil {
+		return nil, toStatus(s.log, createTopicOp, err)
+	}
+	return &forumpb.CreateResponse{Id: id}, nil
+}
+
+func (s *TopicService) UpdateTopic(ctx context.Context, req *forumpb.UpdateTopicRequest) (*emptypb.Empty, error) {
+	userID, role, err := RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.usecase.Update(ctx, req.GetId(), userID, role, req.GetTitle(), fromVersionPB(req.GetVersion())); err != nil {
+		return nil, toStatus(s.log, updateTopicOp, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *TopicService) DeleteTopic(ctx context.Context, req *forumpb.DeleteTopicRequest) (*emptypb.Empty, error) {
+	userID, role, err := RequireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.usecase.Delete(ctx, req.GetId(), userID, role); err != nil {
+		return nil, toStatus(s.log, deleteTopicOp, err)
+	}
+	return &emptypb.Empty{}, nil
+}
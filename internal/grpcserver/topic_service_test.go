@@ -0,0 +1,120 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	forumpb "github.com/keshvan/forum-service-sstu-forum/api/forum"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/usecase"
+	"github.com/keshvan/forum-service-sstu-forum/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTopicClient(t *testing.T) (forumpb.TopicServiceClient, *mocks.TopicUsecase) {
+	topicUsecase := mocks.NewTopicUsecase(t)
+	conn := newTestConn(t, func(srv *Server) {
+		srv.RegisterForum(mocks.NewCategoryUsecase(t), topicUsecase, mocks.NewPostUsecase(t), &fakeSubscriber{})
+	})
+	return forumpb.NewTopicServiceClient(conn), topicUsecase
+}
+
+func TestTopicService_ListTopics(t *testing.T) {
+	client, topicUsecase := newTopicClient(t)
+	cursor := entity.Cursor{CreatedAt: time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC), ID: 9}
+	page := entity.PageRequest{Limit: 10, After: &cursor}
+
+	topicUsecase.On("GetByCategory", mock.Anything, int64(1), false, entity.TopicSortMostReplies, page).
+		Return([]entity.Topic{{ID: 3, Title: "Exams", ReplyCount: 4}}, entity.PageInfo{NextCursor: "next", Degraded: true}, nil).Once()
+	res, err := client.ListTopics(context.Background(), &forumpb.ListTopicsRequest{
+		CategoryId: 1,
+		Sort:       "most_replies",
+		Page:       &forumpb.PageRequest{Limit: 10, After: cursor.Encode()},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.GetTopics(), 1)
+	assert.Equal(t, "Exams", res.GetTopics()[0].GetTitle())
+	assert.Equal(t, int64(4), res.GetTopics()[0].GetReplyCount())
+	assert.Equal(t, "next", res.GetPageInfo().GetNextCursor())
+	assert.True(t, res.GetPageInfo().GetDegraded())
+
+	_, err = client.ListTopics(context.Background(), &forumpb.ListTopicsRequest{CategoryId: 1, Sort: "oldest"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.ListTopics(context.Background(), &forumpb.ListTopicsRequest{CategoryId: 1, Page: &forumpb.PageRequest{After: "!"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	topicUsecase.On("GetByCategory", mock.Anything, int64(2), false, entity.TopicSort(""), entity.PageRequest{}).
+		Return(nil, entity.PageInfo{}, usecase.ErrCategoryNotFound).Once()
+	_, err = client.ListTopics(context.Background(), &forumpb.ListTopicsRequest{CategoryId: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTopicService_GetTopic(t *testing.T) {
+	client, topicUsecase := newTopicClient(t)
+	authorID := int64(8)
+
+	topicUsecase.On("GetByID", mock.Anything, int64(3)).Return(&entity.Topic{ID: 3, AuthorID: &authorID, Username: "alice", Locked: true}, false, nil).Once()
+	res, err := client.GetTopic(context.Background(), &forumpb.GetTopicRequest{Id: 3})
+	require.NoError(t, err)
+	assert.Equal(t, authorID, res.GetAuthorId())
+	assert.Equal(t, "alice", res.GetUsername())
+	assert.True(t, res.GetLocked())
+
+	topicUsecase.On("GetByID", mock.Anything, int64(4)).Return(nil, false, usecase.ErrTopicNotFound).Once()
+	_, err = client.GetTopic(context.Background(), &forumpb.GetTopicRequest{Id: 4})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTopicService_CreateTopic(t *testing.T) {
+	client, topicUsecase := newTopicClient(t)
+	req := &forumpb.CreateTopicRequest{CategoryId: 1, Title: "Exams", Content: "When?"}
+
+	_, err := client.CreateTopic(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreateTopic(withToken("user"), &forumpb.CreateTopicRequest{CategoryId: 1, Title: "Exams"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	authorID := int64(8)
+	topicUsecase.On("Create", mock.Anything, entity.Topic{CategoryID: 1, Title: "Exams", AuthorID: &authorID}, "When?").Return(int64(3), nil).Once()
+	res, err := client.CreateTopic(withToken("user"), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), res.GetId())
+}
+
+func TestTopicService_UpdateTopic(t *testing.T) {
+	client, topicUsecase := newTopicClient(t)
+	req := &forumpb.UpdateTopicRequest{Id: 3, Title: "Exams 2025"}
+
+	_, err := client.UpdateTopic(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	topicUsecase.On("Update", mock.Anything, int64(3), int64(8), "user", "Exams 2025", (*time.Time)(nil)).Return(nil).Once()
+	_, err = client.UpdateTopic(withToken("user"), req)
+	require.NoError(t, err)
+
+	topicUsecase.On("Update", mock.Anything, int64(3), int64(8), "user", "Exams 2025", (*time.Time)(nil)).Return(usecase.ErrForbidden).Once()
+	_, err = client.UpdateTopic(withToken("user"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestTopicService_DeleteTopic(t *testing.T) {
+	client, topicUsecase := newTopicClient(t)
+
+	_, err := client.DeleteTopic(context.Background(), &forumpb.DeleteTopicRequest{Id: 3})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	topicUsecase.On("Delete", mock.Anything, int64(3), int64(7), "admin").Return(nil).Once()
+	_, err = client.DeleteTopic(withToken("valid"), &forumpb.DeleteTopicRequest{Id: 3})
+	require.NoError(t, err)
+
+	topicUsecase.On("Delete", mock.Anything, int64(4), int64(7), "admin").Return(usecase.ErrTopicNotFound).Once()
+	_, err = client.DeleteTopic(withToken("valid"), &forumpb.DeleteTopicRequest{Id: 4})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
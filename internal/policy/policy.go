@@ -0,0 +1,86 @@
+// Package policy decides who may change forum content. Admins may moderate
+// everything, moderators only the categories assigned to them in the
+// category_moderators table, and regular users only their own topics and posts.
+// Chat messages are changed by their authors for a while after sending and by admins.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/repo"
+)
+
+const RoleAdmin = "admin"
+
+type Policy struct {
+	moderatorRepo repo.ModeratorRepository
+	topicRepo     repo.TopicRepository
+}
+
+func New(moderatorRepo repo.ModeratorRepository, topicRepo repo.TopicRepository) *Policy {
+	return &Policy{moderatorRepo: moderatorRepo, topicRepo: topicRepo}
+}
+
+func IsAdmin(role string) bool {
+	return role == RoleAdmin
+}
+
+// CanModerate reports whether the user may edit, delete, pin and lock any content in the category.
+func (p *Policy) CanModerate(ctx context.Context, userID int64, role string, categoryID int64) (bool, error) {
+	if IsAdmin(role) {
+		return true, nil
+	}
+
+	ok, err := p.moderatorRepo.IsModerator(ctx, categoryID, userID)
+	if err != nil {
+		return false, fmt.Errorf("ForumService - Policy - CanModerate - moderatorRepo.IsModerator(): %w", err)
+	}
+
+	return ok, nil
+}
+
+// CanModifyTopic reports whether the user may edit or delete the topic.
+func (p *Policy) CanModifyTopic(ctx context.Context, userID int64, role string, topic *entity.Topic) (bool, error) {
+	if isAuthor(topic.AuthorID, userID) {
+		return true, nil
+	}
+
+	return p.CanModerate(ctx, userID, role, topic.CategoryID)
+}
+
+// CanModifyPost reports whether the user may edit or delete the post.
+// The post's topic is only loaded when the user is not its author.
+func (p *Policy) CanModifyPost(ctx context.Context, userID int64, role string, post *entity.Post) (bool, error) {
+	if isAuthor(post.AuthorID, userID) || IsAdmin(role) {
+		return true, nil
+	}
+
+	topic, err := p.topicRepo.GetByID(ctx, post.TopicID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("ForumService - Policy - CanModifyPost - topicRepo.GetByID(): %w", err)
+	}
+
+	return p.CanModerate(ctx, userID, role, topic.CategoryID)
+}
+
+// CanModifyChatMessage reports whether the user may edit or delete the chat message.
+// Authors may do so for editWindow after sending it, admins always.
+func CanModifyChatMessage(userID int64, role string, message *entity.ChatMessage, editWindow time.Duration) bool {
+	if IsAdmin(role) {
+		return true
+	}
+
+	return message.UserID == userID && time.Since(message.CreatedAt) <= editWindow
+}
+
+func isAuthor(authorID *int64, userID int64) bool {
+	return authorID != nil && *authorID == userID
+}
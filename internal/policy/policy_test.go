@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPolicy_CanModerate(t *testing.T) {
+	ctx := context.Background()
+	categoryID := int64(3)
+
+	t.Run("Admin", func(t *testing.T) {
+		moderatorRepo := mocks.NewModeratorRepository(t)
+		p := New(moderatorRepo, mocks.NewTopicRepository(t))
+
+		ok, err := p.CanModerate(ctx, 1, RoleAdmin, categoryID)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		moderatorRepo.AssertNotCalled(t, "IsModerator", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Moderator of category", func(t *testing.T) {
+		moderatorRepo := mocks.NewModeratorRepository(t)
+		p := New(moderatorRepo, mocks.NewTopicRepository(t))
+		moderatorRepo.On("IsModerator", ctx, categoryID, int64(5)).Return(true, nil).Once()
+
+		ok, err := p.CanModerate(ctx, 5, "user", categoryID)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Repo error", func(t *testing.T) {
+		moderatorRepo := mocks.NewModeratorRepository(t)
+		p := New(moderatorRepo, mocks.NewTopicRepository(t))
+		dbErr := errors.New("db error")
+		moderatorRepo.On("IsModerator", ctx, categoryID, int64(5)).Return(false, dbErr).Once()
+
+		ok, err := p.CanModerate(ctx, 5, "user", categoryID)
+		assert.ErrorIs(t, err, dbErr)
+		assert.False(t, ok)
+	})
+}
+
+func TestPolicy_CanModifyPost(t *testing.T) {
+	ctx := context.Background()
+	authorID := int64(1)
+	post := &entity.Post{ID: 10, TopicID: 7, AuthorID: &authorID}
+
+	t.Run("Author", func(t *testing.T) {
+		topicRepo := mocks.NewTopicRepository(t)
+		p := New(mocks.NewModeratorRepository(t), topicRepo)
+
+		ok, err := p.CanModifyPost(ctx, authorID, "user", post)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		topicRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Moderator of another category", func(t *testing.T) {
+		moderatorRepo := mocks.NewModeratorRepository(t)
+		topicRepo := mocks.NewTopicRepository(t)
+		p := New(moderatorRepo, topicRepo)
+		topicRepo.On("GetByID", ctx, int64(7)).Return(&entity.Topic{ID: 7, CategoryID: 3}, nil).Once()
+		moderatorRepo.On("IsModerator", ctx, int64(3), int64(5)).Return(false, nil).Once()
+
+		ok, err := p.CanModifyPost(ctx, 5, "user", post)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Topic deleted", func(t *testing.T) {
+		topicRepo := mocks.NewTopicRepository(t)
+		p := New(mocks.NewModeratorRepository(t), topicRepo)
+		topicRepo.On("GetByID", ctx, int64(7)).Return(nil, pgx.ErrNoRows).Once()
+
+		ok, err := p.CanModifyPost(ctx, 5, "user", post)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestCanModifyChatMessage(t *testing.T) {
+	window := 15 * time.Minute
+	recent := &entity.ChatMessage{UserID: 5, CreatedAt: time.Now().Add(-time.Minute)}
+	old := &entity.ChatMessage{UserID: 5, CreatedAt: time.Now().Add(-time.Hour)}
+
+	assert.True(t, CanModifyChatMessage(5, "user", recent, window))
+	assert.False(t, CanModifyChatMessage(5, "user", old, window))
+	assert.False(t, CanModifyChatMessage(6, "user", recent, window))
+	assert.True(t, CanModifyChatMessage(6, RoleAdmin, old, window))
+}
@@ -0,0 +1,91 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/repo"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultRetention = 30 * 24 * time.Hour
+	DefaultInterval  = time.Hour
+)
+
+// Purger removes soft-deleted topics and posts for good once they have
+// been deleted for longer than the retention period.
+type Purger struct {
+	topicRepo repo.TopicRepository
+	postRepo  repo.PostRepository
+	retention time.Duration
+	interval  time.Duration
+	onPurged  func()
+	now       func() time.Time
+	log       *zerolog.Logger
+}
+
+// NewPurger creates a purger. onPurged, if not nil, is called whenever rows have been removed.
+func NewPurger(topicRepo repo.TopicRepository, postRepo repo.PostRepository, retention, interval time.Duration, onPurged func(), log *zerolog.Logger) *Purger {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Purger{
+		topicRepo: topicRepo,
+		postRepo:  postRepo,
+		retention: retention,
+		interval:  interval,
+		onPurged:  onPurged,
+		now:       time.Now,
+		log:       log,
+	}
+}
+
+// Run purges once right away and then on every interval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	log := p.log.With().Str("component", "purge.Purger").Logger()
+	log.Info().Dur("retention", p.retention).Dur("interval", p.interval).Msg("Starting purger")
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Purge(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to purge deleted rows")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopping purger")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) Purge(ctx context.Context) error {
+	before := p.now().Add(-p.retention)
+
+	posts, err := p.postRepo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return fmt.Errorf("Purger - Purge - postRepo.PurgeDeleted(): %w", err)
+	}
+
+	topics, err := p.topicRepo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return fmt.Errorf("Purger - Purge - topicRepo.PurgeDeleted(): %w", err)
+	}
+
+	if posts > 0 || topics > 0 {
+		p.log.Info().Str("component", "purge.Purger").Int64("posts", posts).Int64("topics", topics).Time("before", before).Msg("Deleted rows purged")
+		if p.onPurged != nil {
+			p.onPurged()
+		}
+	}
+	return nil
+}
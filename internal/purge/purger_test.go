@@ -0,0 +1,75 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keshvan/forum-service-sstu-forum/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPurger_Purge(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	retention := 48 * time.Hour
+	before := now.Add(-retention)
+
+	t.Run("Success", func(t *testing.T) {
+		topicRepo := mocks.NewTopicRepository(t)
+		postRepo := mocks.NewPostRepository(t)
+		p := NewPurger(topicRepo, postRepo, retention, time.Minute, nil, &logger)
+		p.now = func() time.Time { return now }
+
+		postRepo.On("PurgeDeleted", ctx, before).Return(int64(3), nil).Once()
+		topicRepo.On("PurgeDeleted", ctx, before).Return(int64(1), nil).Once()
+
+		err := p.Purge(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Calls onPurged only when rows were removed", func(t *testing.T) {
+		topicRepo := mocks.NewTopicRepository(t)
+		postRepo := mocks.NewPostRepository(t)
+		calls := 0
+		p := NewPurger(topicRepo, postRepo, retention, time.Minute, func() { calls++ }, &logger)
+		p.now = func() time.Time { return now }
+
+		postRepo.On("PurgeDeleted", ctx, before).Return(int64(0), nil).Once()
+		topicRepo.On("PurgeDeleted", ctx, before).Return(int64(0), nil).Once()
+		assert.NoError(t, p.Purge(ctx))
+		assert.Equal(t, 0, calls)
+
+		postRepo.On("PurgeDeleted", ctx, before).Return(int64(2), nil).Once()
+		topicRepo.On("PurgeDeleted", ctx, before).Return(int64(0), nil).Once()
+		assert.NoError(t, p.Purge(ctx))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Post repo error", func(t *testing.T) {
+		topicRepo := mocks.NewTopicRepository(t)
+		postRepo := mocks.NewPostRepository(t)
+		p := NewPurger(topicRepo, postRepo, retention, time.Minute, nil, &logger)
+		p.now = func() time.Time { return now }
+		dbErr := errors.New("db error")
+
+		postRepo.On("PurgeDeleted", ctx, before).Return(int64(0), dbErr).Once()
+
+		err := p.Purge(ctx)
+		assert.ErrorIs(t, err, dbErr)
+		topicRepo.AssertNotCalled(t, "PurgeDeleted", mock.Anything, mock.Anything)
+	})
+}
+
+func TestNewPurger_Defaults(t *testing.T) {
+	logger := zerolog.Nop()
+
+	p := NewPurger(nil, nil, 0, 0, nil, &logger)
+
+	assert.Equal(t, DefaultRetention, p.retention)
+	assert.Equal(t, DefaultInterval, p.interval)
+}
@@ -3,7 +3,9 @@ package repo
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/go-common-forum/postgres"
 	"github.com/rs/zerolog"
@@ -15,19 +17,44 @@ type categoryRepository struct {
 }
 
 const (
-	createOp  = "CategoryRepository.Create"
-	getByIdOp = "CategoryRepository.GetById"
-	getAllOp  = "CategoryRepository.GetAll"
-	deleteOp  = "CategoryRepository.Delete"
-	updateOp  = "CategoryRepository.Update"
+	createOp    = "CategoryRepository.Create"
+	getByIdOp   = "CategoryRepository.GetById"
+	getAllOp    = "CategoryRepository.GetAll"
+	deleteOp    = "CategoryRepository.Delete"
+	updateOp    = "CategoryRepository.Update"
+	setParentOp = "CategoryRepository.SetParent"
+	getPathOp   = "CategoryRepository.GetPath"
+	isHiddenOp  = "CategoryRepository.IsHidden"
+	reorderOp   = "CategoryRepository.Reorder"
+	lockTreeOp  = "CategoryRepository.LockTree"
+	hasChildOp  = "CategoryRepository.HasChildren"
 )
 
+// categoryColumns and categoryStatsJoin select a category "c" together with the counters kept
+// in category_stats by triggers and the latest post, so reading stats never scans posts.
+const (
+	categoryColumns = `c.id, c.parent_id, c.title, c.description, c.position, c.hidden, c.created_at, c.updated_at,
+	COALESCE(s.topic_count, 0), COALESCE(s.post_count, 0), lp.id, lp.topic_id, lt.title, lp.author_id, lp.created_at`
+	categoryStatsJoin = `LEFT JOIN category_stats s ON s.category_id = c.id
+	LEFT JOIN posts lp ON lp.id = s.last_post_id
+	LEFT JOIN topics lt ON lt.id = lp.topic_id`
+)
+
+// maxCategoryDepth bounds the path walks in GetPath and IsHidden.
+const maxCategoryDepth = 32
+
+// categoryTreeLockKey is the advisory lock key taken by LockTree.
+const categoryTreeLockKey = 0x63617467
+
 func NewCategoryRepository(pg *postgres.Postgres, log *zerolog.Logger) CategoryRepository {
 	return &categoryRepository{pg, log}
 }
 
 func (r *categoryRepository) Create(ctx context.Context, category entity.Category) (int64, error) {
-	row := r.pg.Pool.QueryRow(ctx, "INSERT INTO categories (title, description) VALUES($1, $2) RETURNING id", category.Title, category.Description)
+	row := conn(ctx, r.pg).QueryRow(ctx, `
+	INSERT INTO categories (parent_id, title, description, hidden, position)
+	VALUES($1, $2, $3, $4, (SELECT COALESCE(MAX(position), -1) + 1 FROM categories))
+	RETURNING id`, category.ParentID, category.Title, category.Description, category.Hidden)
 
 	var id int64
 	if err := row.Scan(&id); err != nil {
@@ -39,10 +66,10 @@ func (r *categoryRepository) Create(ctx context.Context, category entity.Categor
 }
 
 func (r *categoryRepository) GetByID(ctx context.Context, id int64) (*entity.Category, error) {
-	row := r.pg.Pool.QueryRow(ctx, "SELECT id, title, description, created_at, updated_at FROM categories WHERE id = $1", id)
+	row := conn(ctx, r.pg).QueryRow(ctx, "SELECT "+categoryColumns+" FROM categories c "+categoryStatsJoin+" WHERE c.id = $1", id)
 
-	var c entity.Category
-	if err := row.Scan(&c.ID, &c.Title, &c.Description, &c.CreatedAt, &c.UpdatedAt); err != nil {
+	c, err := scanCategory(row)
+	if err != nil {
 		r.log.Error().Err(err).Str("op", getByIdOp).Int64("id", id).Msg("Failed to get category")
 		return nil, fmt.Errorf("CategoryRepository - GetByID - row.Scan(): %w", err)
 	}
@@ -50,8 +77,28 @@ func (r *categoryRepository) GetByID(ctx context.Context, id int64) (*entity.Cat
 	return &c, nil
 }
 
-func (r *categoryRepository) GetAll(ctx context.Context) ([]entity.Category, error) {
-	rows, err := r.pg.Pool.Query(ctx, "SELECT id, title, description, created_at, updated_at FROM categories ORDER BY id")
+// GetAll returns categories ordered by position. Unless includeHidden is set, hidden categories
+// are left out together with everything below them.
+func (r *categoryRepository) GetAll(ctx context.Context, includeHidden bool) ([]entity.Category, error) {
+	query := "SELECT " + categoryColumns + " FROM categories c " + categoryStatsJoin + " ORDER BY c.position, c.id"
+	var args []any
+	if !includeHidden {
+		query = `
+		WITH RECURSIVE visible AS (
+			SELECT id, 0 AS depth
+			FROM categories
+			WHERE parent_id IS NULL AND NOT hidden
+			UNION ALL
+			SELECT c.id, v.depth + 1
+			FROM categories c
+			JOIN visible v ON c.parent_id = v.id
+			WHERE NOT c.hidden AND v.depth < $1
+		)
+		SELECT ` + categoryColumns + ` FROM visible v JOIN categories c ON c.id = v.id ` + categoryStatsJoin + ` ORDER BY c.position, c.id`
+		args = append(args, maxCategoryDepth)
+	}
+
+	rows, err := conn(ctx, r.pg).Query(ctx, query, args...)
 	if err != nil {
 		r.log.Error().Err(err).Str("op", getAllOp).Msg("Failed to get categories")
 		return nil, fmt.Errorf("CategoryRepository - GetCategories - pg.Pool.Query: %w", err)
@@ -59,9 +106,8 @@ func (r *categoryRepository) GetAll(ctx context.Context) ([]entity.Category, err
 	defer rows.Close()
 
 	var categories []entity.Category
-	var c entity.Category
 	for rows.Next() {
-		err := rows.Scan(&c.ID, &c.Title, &c.Description, &c.CreatedAt, &c.UpdatedAt)
+		c, err := scanCategory(rows)
 		if err != nil {
 			r.log.Error().Err(err).Str("op", getAllOp).Msg("Failed to scan category")
 			return nil, fmt.Errorf("CategoryRepository - GetCategories - rows.Next() - rows.Scan(): %w", err)
@@ -72,26 +118,159 @@ func (r *categoryRepository) GetAll(ctx context.Context) ([]entity.Category, err
 	return categories, nil
 }
 
-func (r *categoryRepository) Update(ctx context.Context, id int64, title, description string) error {
-	_, err := r.pg.Pool.Exec(ctx, `
+// Update changes a category, nil fields keep their value. A non-nil version makes the
+// update conditional on updated_at still being equal to it. Returns pgx.ErrNoRows if no
+// category was updated.
+func (r *categoryRepository) Update(ctx context.Context, id int64, title, description *string, hidden *bool, version *time.Time) error {
+	tag, err := conn(ctx, r.pg).Exec(ctx, `
 	UPDATE categories
 	SET
 		title = COALESCE($1, title),
 		description = COALESCE($2, description),
+		hidden = COALESCE($3, hidden),
 		updated_at = now()
-	WHERE id = $3
-	`, title, description, id)
+	WHERE id = $4 AND ($5::timestamptz IS NULL OR updated_at = $5)
+	`, title, description, hidden, id, version)
 
 	if err != nil {
 		r.log.Error().Err(err).Str("op", updateOp).Msg("Failed to update category")
 		return fmt.Errorf("CategoryRepository - Update - Exec: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("CategoryRepository - Update: %w", pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// SetParent moves the category under parentID, nil makes it a root category.
+func (r *categoryRepository) SetParent(ctx context.Context, id int64, parentID *int64) error {
+	if _, err := conn(ctx, r.pg).Exec(ctx, `UPDATE categories SET parent_id = $1, updated_at = now() WHERE id = $2`, parentID, id); err != nil {
+		r.log.Error().Err(err).Str("op", setParentOp).Int64("id", id).Msg("Failed to set category parent")
+		return fmt.Errorf("CategoryRepository - SetParent - pg.Pool.Exec(): %w", err)
+	}
+	return nil
+}
+
+func scanCategory(row pgx.Row) (entity.Category, error) {
+	var c entity.Category
+	var lastPostID, lastTopicID *int64
+	var lastTopicTitle *string
+	var lastAuthorID *int64
+	var lastCreatedAt *time.Time
+	if err := row.Scan(&c.ID, &c.ParentID, &c.Title, &c.Description, &c.Position, &c.Hidden, &c.CreatedAt, &c.UpdatedAt,
+		&c.TopicCount, &c.PostCount, &lastPostID, &lastTopicID, &lastTopicTitle, &lastAuthorID, &lastCreatedAt); err != nil {
+		return entity.Category{}, err
+	}
+
+	if lastPostID != nil && lastTopicID != nil && lastTopicTitle != nil && lastCreatedAt != nil {
+		c.LastPost = &entity.LastPost{
+			ID:         *lastPostID,
+			TopicID:    *lastTopicID,
+			TopicTitle: *lastTopicTitle,
+			AuthorID:   lastAuthorID,
+			CreatedAt:  *lastCreatedAt,
+		}
+	}
+	return c, nil
+}
+
+// Reorder sets the position of each category to its index in ids. If any id does not exist
+// a wrapped pgx.ErrNoRows is returned; callers run it within a transaction to discard the
+// partial update in that case.
+func (r *categoryRepository) Reorder(ctx context.Context, ids []int64) error {
+	tag, err := conn(ctx, r.pg).Exec(ctx, `
+	UPDATE categories c
+	SET position = o.ord - 1, updated_at = now()
+	FROM unnest($1::bigint[]) WITH ORDINALITY AS o(id, ord)
+	WHERE c.id = o.id`, ids)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", reorderOp).Ints64("ids", ids).Msg("Failed to reorder categories")
+		return fmt.Errorf("CategoryRepository - Reorder - pg.Pool.Exec(): %w", err)
+	}
+	if tag.RowsAffected() != int64(len(ids)) {
+		return fmt.Errorf("CategoryRepository - Reorder: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
 
+// LockTree takes a transaction-scoped lock on the category tree, so that moves checking for
+// cycles and deletes checking for subcategories see each other's changes. It must be called
+// inside a transaction.
+func (r *categoryRepository) LockTree(ctx context.Context) error {
+	if _, err := conn(ctx, r.pg).Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, categoryTreeLockKey); err != nil {
+		r.log.Error().Err(err).Str("op", lockTreeOp).Msg("Failed to lock category tree")
+		return fmt.Errorf("CategoryRepository - LockTree - pg.Pool.Exec(): %w", err)
+	}
 	return nil
 }
 
+// GetPath returns the chain of categories from the root down to the category itself.
+// The result is empty if the category does not exist.
+func (r *categoryRepository) GetPath(ctx context.Context, id int64) ([]entity.Breadcrumb, error) {
+	rows, err := conn(ctx, r.pg).Query(ctx, `
+	WITH RECURSIVE path AS (
+		SELECT id, parent_id, title, 0 AS depth FROM categories WHERE id = $1
+		UNION ALL
+		SELECT c.id, c.parent_id, c.title, p.depth + 1
+		FROM categories c
+		JOIN path p ON c.id = p.parent_id
+		WHERE p.depth < $2
+	)
+	SELECT id, title FROM path ORDER BY depth DESC`, id, maxCategoryDepth)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", getPathOp).Int64("id", id).Msg("Failed to get category path")
+		return nil, fmt.Errorf("CategoryRepository - GetPath - pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var path []entity.Breadcrumb
+	var b entity.Breadcrumb
+	for rows.Next() {
+		if err := rows.Scan(&b.ID, &b.Title); err != nil {
+			r.log.Error().Err(err).Str("op", getPathOp).Int64("id", id).Msg("Failed to scan category path")
+			return nil, fmt.Errorf("CategoryRepository - GetPath - rows.Next() - rows.Scan(): %w", err)
+		}
+		path = append(path, b)
+	}
+
+	return path, nil
+}
+
+// IsHidden reports whether the category or one of its ancestors is hidden.
+// It returns false if the category does not exist.
+func (r *categoryRepository) IsHidden(ctx context.Context, id int64) (bool, error) {
+	var hidden bool
+	err := conn(ctx, r.pg).QueryRow(ctx, `
+	WITH RECURSIVE path AS (
+		SELECT id, parent_id, hidden, 0 AS depth FROM categories WHERE id = $1
+		UNION ALL
+		SELECT c.id, c.parent_id, c.hidden, p.depth + 1
+		FROM categories c
+		JOIN path p ON c.id = p.parent_id
+		WHERE p.depth < $2
+	)
+	SELECT COALESCE(bool_or(hidden), false) FROM path`, id, maxCategoryDepth).Scan(&hidden)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", isHiddenOp).Int64("id", id).Msg("Failed to check category visibility")
+		return false, fmt.Errorf("CategoryRepository - IsHidden - pg.Pool.QueryRow: %w", err)
+	}
+
+	return hidden, nil
+}
+
+// HasChildren reports whether the category has subcategories.
+func (r *categoryRepository) HasChildren(ctx context.Context, id int64) (bool, error) {
+	var exists bool
+	if err := conn(ctx, r.pg).QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)`, id).Scan(&exists); err != nil {
+		r.log.Error().Err(err).Str("op", hasChildOp).Int64("id", id).Msg("Failed to check subcategories")
+		return false, fmt.Errorf("CategoryRepository - HasChildren - pg.Pool.QueryRow: %w", err)
+	}
+	return exists, nil
+}
+
 func (r *categoryRepository) Delete(ctx context.Context, id int64) error {
-	if _, err := r.pg.Pool.Exec(ctx, `DELETE FROM categories WHERE id = $1`, id); err != nil {
+	if _, err := conn(ctx, r.pg).Exec(ctx, `DELETE FROM categories WHERE id = $1`, id); err != nil {
 		r.log.Error().Err(err).Str("op", deleteOp).Msg("Failed to delete category")
 		return fmt.Errorf("CategoryRepository - Delete - pg.Pool.Exec(): %w", err)
 	}
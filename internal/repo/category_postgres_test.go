@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/go-common-forum/postgres"
 	"github.com/pashagolub/pgxmock/v4"
@@ -30,7 +31,7 @@ func TestCategoryRepository_Create(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		row := pgxmock.NewRows([]string{"id"}).AddRow(expectedID)
-		mockPool.ExpectQuery("INSERT INTO categories").WithArgs(testCategory.Title, testCategory.Description).WillReturnRows(row)
+		mockPool.ExpectQuery("INSERT INTO categories").WithArgs(testCategory.ParentID, testCategory.Title, testCategory.Description, testCategory.Hidden).WillReturnRows(row)
 
 		id, err := repo.Create(ctx, testCategory)
 		assert.NoError(t, err)
@@ -40,7 +41,7 @@ func TestCategoryRepository_Create(t *testing.T) {
 
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectQuery("INSERT INTO categories").WithArgs(testCategory.Title, testCategory.Description).WillReturnError(dbErr)
+		mockPool.ExpectQuery("INSERT INTO categories").WithArgs(testCategory.ParentID, testCategory.Title, testCategory.Description, testCategory.Hidden).WillReturnError(dbErr)
 		_, err := repo.Create(ctx, testCategory)
 
 		assert.Error(t, err)
@@ -50,6 +51,19 @@ func TestCategoryRepository_Create(t *testing.T) {
 	})
 }
 
+var categoryRowColumns = []string{"id", "parent_id", "title", "description", "position", "hidden", "created_at", "updated_at",
+	"topic_count", "post_count", "last_post_id", "last_topic_id", "last_topic_title", "last_author_id", "last_post_at"}
+
+func addCategoryRow(rows *pgxmock.Rows, c entity.Category) *pgxmock.Rows {
+	if c.LastPost == nil {
+		return rows.AddRow(c.ID, c.ParentID, c.Title, c.Description, c.Position, c.Hidden, c.CreatedAt, c.UpdatedAt,
+			c.TopicCount, c.PostCount, nil, nil, nil, nil, nil)
+	}
+	lp := c.LastPost
+	return rows.AddRow(c.ID, c.ParentID, c.Title, c.Description, c.Position, c.Hidden, c.CreatedAt, c.UpdatedAt,
+		c.TopicCount, c.PostCount, &lp.ID, &lp.TopicID, &lp.TopicTitle, lp.AuthorID, &lp.CreatedAt)
+}
+
 func TestCategoryRepository_GetByID(t *testing.T) {
 	ctx := context.Background()
 	logger := zerolog.Nop()
@@ -62,11 +76,16 @@ func TestCategoryRepository_GetByID(t *testing.T) {
 	repo := NewCategoryRepository(pg, &logger)
 
 	id := int64(1)
-	expectedCategory := &entity.Category{ID: id, Title: "test", Description: "test", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	authorID := int64(7)
+	expectedCategory := &entity.Category{
+		ID: id, Title: "test", Description: "test", CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		TopicCount: 2, PostCount: 5,
+		LastPost: &entity.LastPost{ID: 11, TopicID: 3, TopicTitle: "topic", AuthorID: &authorID, CreatedAt: time.Now()},
+	}
 
 	t.Run("Success", func(t *testing.T) {
-		row := pgxmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).AddRow(expectedCategory.ID, expectedCategory.Title, expectedCategory.Description, expectedCategory.CreatedAt, expectedCategory.UpdatedAt)
-		mockPool.ExpectQuery("SELECT id, title, description, created_at, updated_at FROM categories WHERE id").WithArgs(id).WillReturnRows(row)
+		row := addCategoryRow(pgxmock.NewRows(categoryRowColumns), *expectedCategory)
+		mockPool.ExpectQuery("SELECT c.id, c.parent_id, .* FROM categories c LEFT JOIN category_stats s .* WHERE c.id = \\$1").WithArgs(id).WillReturnRows(row)
 
 		category, err := repo.GetByID(ctx, id)
 		assert.NoError(t, err)
@@ -74,9 +93,21 @@ func TestCategoryRepository_GetByID(t *testing.T) {
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
 
+	t.Run("No posts", func(t *testing.T) {
+		empty := entity.Category{ID: id, Title: "test", Description: "test", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		row := addCategoryRow(pgxmock.NewRows(categoryRowColumns), empty)
+		mockPool.ExpectQuery("FROM categories c LEFT JOIN category_stats s").WithArgs(id).WillReturnRows(row)
+
+		category, err := repo.GetByID(ctx, id)
+		assert.NoError(t, err)
+		assert.Nil(t, category.LastPost)
+		assert.Zero(t, category.PostCount)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectQuery("SELECT id, title, description, created_at, updated_at FROM categories WHERE id").WithArgs(id).WillReturnError(dbErr)
+		mockPool.ExpectQuery("FROM categories c LEFT JOIN category_stats s").WithArgs(id).WillReturnError(dbErr)
 
 		_, err := repo.GetByID(ctx, id)
 		assert.Error(t, err)
@@ -98,16 +129,20 @@ func TestCategoryRepository_GetAll(t *testing.T) {
 	repo := NewCategoryRepository(pg, &logger)
 
 	expectedCategories := []entity.Category{
-		{ID: 1, Title: "test1", Description: "test1", CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{ID: 2, Title: "test2", Description: "test2", CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ID: 1, Title: "test1", Description: "test1", Position: 0, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+			TopicCount: 1, PostCount: 1, LastPost: &entity.LastPost{ID: 4, TopicID: 2, TopicTitle: "topic", CreatedAt: time.Now()}},
+		{ID: 2, Title: "test2", Description: "test2", Position: 1, Hidden: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
 	}
+	allSql := "SELECT c.id, .* FROM categories c LEFT JOIN category_stats s .* ORDER BY c.position, c.id"
 
 	t.Run("Success", func(t *testing.T) {
-		rows := pgxmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).AddRow(expectedCategories[0].ID, expectedCategories[0].Title, expectedCategories[0].Description, expectedCategories[0].CreatedAt, expectedCategories[0].UpdatedAt).
-			AddRow(expectedCategories[1].ID, expectedCategories[1].Title, expectedCategories[1].Description, expectedCategories[1].CreatedAt, expectedCategories[1].UpdatedAt)
-		mockPool.ExpectQuery("SELECT id, title, description, created_at, updated_at FROM categories ORDER BY id").WillReturnRows(rows)
+		rows := pgxmock.NewRows(categoryRowColumns)
+		for _, c := range expectedCategories {
+			rows = addCategoryRow(rows, c)
+		}
+		mockPool.ExpectQuery(allSql).WillReturnRows(rows)
 
-		categories, err := repo.GetAll(ctx)
+		categories, err := repo.GetAll(ctx, true)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedCategories, categories)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
@@ -115,9 +150,9 @@ func TestCategoryRepository_GetAll(t *testing.T) {
 
 	t.Run("Query error", func(t *testing.T) {
 		dbErr := errors.New("query db error")
-		mockPool.ExpectQuery("SELECT id, title, description, created_at, updated_at FROM categories ORDER BY id").WillReturnError(dbErr)
+		mockPool.ExpectQuery(allSql).WillReturnError(dbErr)
 
-		_, err := repo.GetAll(ctx)
+		_, err := repo.GetAll(ctx, true)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CategoryRepository - GetCategories - pg.Pool.Query")
 		assert.ErrorIs(t, err, dbErr)
@@ -126,17 +161,26 @@ func TestCategoryRepository_GetAll(t *testing.T) {
 
 	t.Run("Scan error", func(t *testing.T) {
 		dbErr := errors.New("scan error")
-		rows := pgxmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).AddRow(1, "test1", "test1", time.Now(), time.Now()).
-			RowError(0, dbErr)
+		rows := addCategoryRow(pgxmock.NewRows(categoryRowColumns), expectedCategories[1]).RowError(0, dbErr)
 
-		mockPool.ExpectQuery("SELECT id, title, description, created_at, updated_at FROM categories ORDER BY id").WillReturnRows(rows)
+		mockPool.ExpectQuery(allSql).WillReturnRows(rows)
 
-		_, err := repo.GetAll(ctx)
+		_, err := repo.GetAll(ctx, true)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CategoryRepository - GetCategories - rows.Next() - rows.Scan()")
 		assert.ErrorIs(t, err, dbErr)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
+
+	t.Run("Visible only", func(t *testing.T) {
+		rows := addCategoryRow(pgxmock.NewRows(categoryRowColumns), expectedCategories[0])
+		mockPool.ExpectQuery("WITH RECURSIVE visible AS .* FROM visible v JOIN categories c").WithArgs(maxCategoryDepth).WillReturnRows(rows)
+
+		categories, err := repo.GetAll(ctx, false)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedCategories[:1], categories)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
 }
 
 func TestCategoryRepository_Update(t *testing.T) {
@@ -150,25 +194,43 @@ func TestCategoryRepository_Update(t *testing.T) {
 	pg := postgres.NewWithPool(mockPool)
 	repo := NewCategoryRepository(pg, &logger)
 
-	expectedSql := "UPDATE categories SET title = COALESCE\\(\\$1, title\\), description = COALESCE\\(\\$2, description\\), updated_at = now\\(\\) WHERE id = \\$3"
+	expectedSql := "UPDATE categories SET title = COALESCE\\(\\$1, title\\), description = COALESCE\\(\\$2, description\\), hidden = COALESCE\\(\\$3, hidden\\), updated_at = now\\(\\) WHERE id = \\$4 AND \\(\\$5::timestamptz IS NULL OR updated_at = \\$5\\)"
 
 	id := int64(1)
 	title := "updated title"
 	description := "updated description"
+	hidden := true
 
 	t.Run("Success", func(t *testing.T) {
-		mockPool.ExpectExec(expectedSql).WithArgs(title, description, id).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mockPool.ExpectExec(expectedSql).WithArgs(&title, &description, &hidden, id, (*time.Time)(nil)).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := repo.Update(ctx, id, title, description)
+		err := repo.Update(ctx, id, &title, &description, &hidden, nil)
 		assert.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
 
+	t.Run("Hidden only keeps title and description", func(t *testing.T) {
+		mockPool.ExpectExec(expectedSql).WithArgs((*string)(nil), (*string)(nil), &hidden, id, (*time.Time)(nil)).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.Update(ctx, id, nil, nil, &hidden, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Stale version", func(t *testing.T) {
+		version := time.Now()
+		mockPool.ExpectExec(expectedSql).WithArgs(&title, &description, &hidden, id, &version).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.Update(ctx, id, &title, &description, &hidden, &version)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectExec(expectedSql).WithArgs(title, description, id).WillReturnError(dbErr)
+		mockPool.ExpectExec(expectedSql).WithArgs(&title, &description, &hidden, id, (*time.Time)(nil)).WillReturnError(dbErr)
 
-		err := repo.Update(ctx, id, title, description)
+		err := repo.Update(ctx, id, &title, &description, &hidden, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "CategoryRepository - Update - Exec")
 		assert.ErrorIs(t, err, dbErr)
@@ -176,6 +238,217 @@ func TestCategoryRepository_Update(t *testing.T) {
 	})
 }
 
+func TestCategoryRepository_Reorder(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewCategoryRepository(pg, &logger)
+
+	ids := []int64{3, 1, 2}
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec("UPDATE categories c SET position").WithArgs(ids).WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+		err := repo.Reorder(ctx, ids)
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown category rolls back transaction", func(t *testing.T) {
+		mockPool.ExpectBegin()
+		mockPool.ExpectExec("UPDATE categories c SET position").WithArgs(ids).WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+		mockPool.ExpectRollback()
+
+		err := NewTransactor(pg, &logger).WithinTx(ctx, func(ctx context.Context) error {
+			return repo.Reorder(ctx, ids)
+		})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectExec("UPDATE categories c SET position").WithArgs(ids).WillReturnError(dbErr)
+
+		err := repo.Reorder(ctx, ids)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CategoryRepository - Reorder - pg.Pool.Exec()")
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestCategoryRepository_SetParent(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewCategoryRepository(pg, &logger)
+
+	id := int64(3)
+	parentID := int64(1)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec("UPDATE categories SET parent_id = \\$1").WithArgs(&parentID, id).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.SetParent(ctx, id, &parentID)
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectExec("UPDATE categories SET parent_id").WithArgs((*int64)(nil), id).WillReturnError(dbErr)
+
+		err := repo.SetParent(ctx, id, nil)
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestCategoryRepository_LockTree(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewCategoryRepository(pg, &logger)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec("SELECT pg_advisory_xact_lock\\(\\$1\\)").WithArgs(categoryTreeLockKey).WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+		err := repo.LockTree(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectExec("SELECT pg_advisory_xact_lock\\(\\$1\\)").WithArgs(categoryTreeLockKey).WillReturnError(dbErr)
+
+		err := repo.LockTree(ctx)
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestCategoryRepository_GetPath(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewCategoryRepository(pg, &logger)
+
+	id := int64(3)
+
+	t.Run("Success", func(t *testing.T) {
+		expected := []entity.Breadcrumb{{ID: 1, Title: "Faculty"}, {ID: 2, Title: "Department"}, {ID: 3, Title: "Course"}}
+		rows := pgxmock.NewRows([]string{"id", "title"})
+		for _, b := range expected {
+			rows.AddRow(b.ID, b.Title)
+		}
+		mockPool.ExpectQuery("WITH RECURSIVE path").WithArgs(id, maxCategoryDepth).WillReturnRows(rows)
+
+		path, err := repo.GetPath(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, path)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectQuery("WITH RECURSIVE path").WithArgs(id, maxCategoryDepth).WillReturnError(dbErr)
+
+		path, err := repo.GetPath(ctx, id)
+		assert.ErrorIs(t, err, dbErr)
+		assert.Nil(t, path)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestCategoryRepository_IsHidden(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewCategoryRepository(pg, &logger)
+
+	id := int64(3)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectQuery("WITH RECURSIVE path .* SELECT COALESCE\\(bool_or\\(hidden\\), false\\) FROM path").
+			WithArgs(id, maxCategoryDepth).
+			WillReturnRows(pgxmock.NewRows([]string{"hidden"}).AddRow(true))
+
+		hidden, err := repo.IsHidden(ctx, id)
+		assert.NoError(t, err)
+		assert.True(t, hidden)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectQuery("WITH RECURSIVE path").WithArgs(id, maxCategoryDepth).WillReturnError(dbErr)
+
+		hidden, err := repo.IsHidden(ctx, id)
+		assert.ErrorIs(t, err, dbErr)
+		assert.False(t, hidden)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestCategoryRepository_HasChildren(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewCategoryRepository(pg, &logger)
+
+	id := int64(1)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM categories WHERE parent_id = \\$1\\)").WithArgs(id).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+		hasChildren, err := repo.HasChildren(ctx, id)
+		assert.NoError(t, err)
+		assert.True(t, hasChildren)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM categories WHERE parent_id = \\$1\\)").WithArgs(id).WillReturnError(dbErr)
+
+		_, err := repo.HasChildren(ctx, id)
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
 func TestCategoryRepository_Delete(t *testing.T) {
 	ctx := context.Background()
 	logger := zerolog.Nop()
@@ -3,6 +3,9 @@ package repo
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
 
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/go-common-forum/postgres"
@@ -19,7 +22,7 @@ func NewChatRepository(pg *postgres.Postgres, log *zerolog.Logger) ChatRepositor
 }
 
 func (r *chatRepository) SaveMessage(ctx context.Context, message *entity.ChatMessage) (int64, error) {
-	row := r.pg.Pool.QueryRow(ctx, "INSERT INTO messages (user_id, username, content, created_at) VALUES($1, $2, $3, $4) RETURNING id", message.UserID, message.Username, message.Content, message.CreatedAt)
+	row := conn(ctx, r.pg).QueryRow(ctx, "INSERT INTO messages (room_id, user_id, username, content, created_at) VALUES($1, $2, $3, $4, $5) RETURNING id", message.RoomID, message.UserID, message.Username, message.Content, message.CreatedAt)
 
 	var id int64
 	if err := row.Scan(&id); err != nil {
@@ -30,18 +33,51 @@ func (r *chatRepository) SaveMessage(ctx context.Context, message *entity.ChatMe
 	return id, nil
 }
 
-func (r *chatRepository) GetMessages(ctx context.Context, limit int64) ([]entity.ChatMessage, error) {
-	rows, err := r.pg.Pool.Query(ctx, "SELECT id, user_id, username, content, created_at FROM (SELECT id, user_id, username, content, created_at FROM messages ORDER BY created_at DESC LIMIT $1) AS recent_mesages ORDER BY created_at ASC", limit)
+const chatMessageColumns = "id, room_id, user_id, username, content, created_at, edited_at, deleted_at"
+
+// replayOverlap is how far before the last seen message a replay starts. It covers the
+// time a message may take from created_at to its commit, which the chat client bounds
+// by its 10 second save timeout.
+const replayOverlap = "interval '15 seconds'"
+
+func scanChatMessage(row pgx.Row) (entity.ChatMessage, error) {
+	var message entity.ChatMessage
+	err := row.Scan(&message.ID, &message.RoomID, &message.UserID, &message.Username, &message.Content, &message.CreatedAt, &message.EditedAt, &message.DeletedAt)
+	return message, err
+}
+
+// GetMessages returns up to limit messages of a room sent before the message with id
+// before, or the latest ones if before is 0. Messages are ordered oldest first.
+func (r *chatRepository) GetMessages(ctx context.Context, roomID int64, before int64, limit int64) ([]entity.ChatMessage, error) {
+	query := `
+		SELECT ` + chatMessageColumns + ` FROM (
+			SELECT ` + chatMessageColumns + ` FROM messages
+			WHERE room_id = $1
+			ORDER BY created_at DESC, id DESC LIMIT $2
+		) AS page ORDER BY created_at, id`
+	args := []any{roomID, limit}
+	if before != 0 {
+		query = `
+		SELECT ` + chatMessageColumns + ` FROM (
+			SELECT ` + chatMessageColumns + ` FROM messages
+			WHERE room_id = $1
+			  AND (created_at, id) < (SELECT created_at, id FROM messages WHERE id = $3)
+			ORDER BY created_at DESC, id DESC LIMIT $2
+		) AS page ORDER BY created_at, id`
+		args = append(args, before)
+	}
+
+	rows, err := conn(ctx, r.pg).Query(ctx, query, args...)
 	if err != nil {
-		r.log.Error().Err(err).Str("op", "ChatRepository.GetMessages").Msg("Failed to get messages")
+		r.log.Error().Err(err).Str("op", "ChatRepository.GetMessages").Int64("room_id", roomID).Msg("Failed to get messages")
 		return nil, fmt.Errorf("ChatRepository - GetMessages - r.pg.Pool.Query(): %w", err)
 	}
 	defer rows.Close()
 
 	var messages []entity.ChatMessage
 	for rows.Next() {
-		var message entity.ChatMessage
-		if err := rows.Scan(&message.ID, &message.UserID, &message.Username, &message.Content, &message.CreatedAt); err != nil {
+		message, err := scanChatMessage(rows)
+		if err != nil {
 			r.log.Error().Err(err).Str("op", "ChatRepository.GetMessages").Msg("Failed to scan message")
 			return nil, fmt.Errorf("ChatRepository - GetMessages - rows.Next(): %w", err)
 		}
@@ -50,3 +86,154 @@ func (r *chatRepository) GetMessages(ctx context.Context, limit int64) ([]entity
 
 	return messages, nil
 }
+
+// GetMessagesSince returns up to limit messages of a room with ids after since, oldest
+// first. Ids are taken on insert but become visible on commit, so a message with a lower
+// id can show up after since was read. The result therefore also holds the messages with
+// lower ids from replayOverlap before since, which do not count against limit and may
+// repeat messages the caller already has.
+func (r *chatRepository) GetMessagesSince(ctx context.Context, roomID int64, since int64, limit int64) ([]entity.ChatMessage, error) {
+	query := `
+		SELECT ` + chatMessageColumns + ` FROM (
+			(SELECT ` + chatMessageColumns + ` FROM messages
+			WHERE room_id = $1 AND id < $2
+			  AND created_at >= (SELECT created_at FROM messages WHERE id = $2) - ` + replayOverlap + `)
+			UNION ALL
+			(SELECT ` + chatMessageColumns + ` FROM messages
+			WHERE room_id = $1 AND id > $2
+			ORDER BY id LIMIT $3)
+		) AS missed ORDER BY created_at, id`
+
+	rows, err := conn(ctx, r.pg).Query(ctx, query, roomID, since, limit)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", "ChatRepository.GetMessagesSince").Int64("room_id", roomID).Msg("Failed to get messages")
+		return nil, fmt.Errorf("ChatRepository - GetMessagesSince - r.pg.Pool.Query(): %w", err)
+	}
+	defer rows.Close()
+
+	var messages []entity.ChatMessage
+	for rows.Next() {
+		message, err := scanChatMessage(rows)
+		if err != nil {
+			r.log.Error().Err(err).Str("op", "ChatRepository.GetMessagesSince").Msg("Failed to scan message")
+			return nil, fmt.Errorf("ChatRepository - GetMessagesSince - rows.Next(): %w", err)
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// GetMessage returns pgx.ErrNoRows if the message does not exist.
+func (r *chatRepository) GetMessage(ctx context.Context, id int64) (*entity.ChatMessage, error) {
+	message, err := scanChatMessage(conn(ctx, r.pg).QueryRow(ctx, "SELECT "+chatMessageColumns+" FROM messages WHERE id = $1", id))
+	if err != nil {
+		return nil, fmt.Errorf("ChatRepository - GetMessage - row.Scan(): %w", err)
+	}
+
+	return &message, nil
+}
+
+// UpdateMessage replaces the content of a message and returns the edit time. Deleted
+// messages cannot be edited, pgx.ErrNoRows is returned for them.
+func (r *chatRepository) UpdateMessage(ctx context.Context, id int64, content string) (time.Time, error) {
+	row := conn(ctx, r.pg).QueryRow(ctx, "UPDATE messages SET content = $2, edited_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING edited_at", id, content)
+
+	var editedAt time.Time
+	if err := row.Scan(&editedAt); err != nil {
+		return time.Time{}, fmt.Errorf("ChatRepository - UpdateMessage - row.Scan(): %w", err)
+	}
+
+	return editedAt, nil
+}
+
+// DeleteMessage clears the content of a message and returns the deletion time, or
+// pgx.ErrNoRows if it is already deleted.
+func (r *chatRepository) DeleteMessage(ctx context.Context, id int64) (time.Time, error) {
+	row := conn(ctx, r.pg).QueryRow(ctx, "UPDATE messages SET content = '', deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING deleted_at", id)
+
+	var deletedAt time.Time
+	if err := row.Scan(&deletedAt); err != nil {
+		return time.Time{}, fmt.Errorf("ChatRepository - DeleteMessage - row.Scan(): %w", err)
+	}
+
+	return deletedAt, nil
+}
+
+// chatRoomQuery selects rooms with the name of their category and whether the category
+// or one of its ancestors is hidden. The general room comes first, then category rooms
+// in category order, then custom rooms.
+const chatRoomQuery = `
+	WITH RECURSIVE hidden_categories AS (
+		SELECT id FROM categories WHERE hidden
+		UNION
+		SELECT c.id FROM categories c JOIN hidden_categories h ON c.parent_id = h.id
+	)
+	SELECT r.id, r.kind, COALESCE(c.title, r.name), r.category_id, r.created_by,
+		r.category_id IS NOT NULL AND r.category_id IN (SELECT id FROM hidden_categories), r.created_at
+	FROM chat_rooms r
+	LEFT JOIN categories c ON c.id = r.category_id`
+
+func scanChatRoom(row pgx.Row) (entity.ChatRoom, error) {
+	var room entity.ChatRoom
+	var kind string
+	err := row.Scan(&room.ID, &kind, &room.Name, &room.CategoryID, &room.CreatedBy, &room.Hidden, &room.CreatedAt)
+	room.Kind = entity.ChatRoomKind(kind)
+	return room, err
+}
+
+func (r *chatRepository) GetRooms(ctx context.Context) ([]entity.ChatRoom, error) {
+	rows, err := conn(ctx, r.pg).Query(ctx, chatRoomQuery+" ORDER BY r.kind <> 'general', r.kind <> 'category', c.position, r.id")
+	if err != nil {
+		r.log.Error().Err(err).Str("op", "ChatRepository.GetRooms").Msg("Failed to get rooms")
+		return nil, fmt.Errorf("ChatRepository - GetRooms - r.pg.Pool.Query(): %w", err)
+	}
+	defer rows.Close()
+
+	var rooms []entity.ChatRoom
+	for rows.Next() {
+		room, err := scanChatRoom(rows)
+		if err != nil {
+			r.log.Error().Err(err).Str("op", "ChatRepository.GetRooms").Msg("Failed to scan room")
+			return nil, fmt.Errorf("ChatRepository - GetRooms - rows.Next(): %w", err)
+		}
+		rooms = append(rooms, room)
+	}
+
+	return rooms, nil
+}
+
+func (r *chatRepository) GetRoom(ctx context.Context, id int64) (*entity.ChatRoom, error) {
+	room, err := scanChatRoom(conn(ctx, r.pg).QueryRow(ctx, chatRoomQuery+" WHERE r.id = $1", id))
+	if err != nil {
+		return nil, fmt.Errorf("ChatRepository - GetRoom - row.Scan(): %w", err)
+	}
+
+	return &room, nil
+}
+
+func (r *chatRepository) CreateRoom(ctx context.Context, room *entity.ChatRoom) (int64, error) {
+	row := conn(ctx, r.pg).QueryRow(ctx, "INSERT INTO chat_rooms (kind, name, created_by) VALUES ($1, $2, $3) RETURNING id, created_at", string(room.Kind), room.Name, room.CreatedBy)
+
+	if err := row.Scan(&room.ID, &room.CreatedAt); err != nil {
+		r.log.Error().Err(err).Str("op", "ChatRepository.CreateRoom").Any("room", room).Msg("Failed to insert room")
+		return 0, fmt.Errorf("ChatRepository - CreateRoom - row.Scan(): %w", err)
+	}
+
+	return room.ID, nil
+}
+
+// DeleteRoom deletes a custom room with its messages. Returns pgx.ErrNoRows if there is
+// no custom room with the id.
+func (r *chatRepository) DeleteRoom(ctx context.Context, id int64) error {
+	tag, err := conn(ctx, r.pg).Exec(ctx, "DELETE FROM chat_rooms WHERE id = $1 AND kind = $2", id, string(entity.ChatRoomCustom))
+	if err != nil {
+		r.log.Error().Err(err).Str("op", "ChatRepository.DeleteRoom").Int64("room_id", id).Msg("Failed to delete room")
+		return fmt.Errorf("ChatRepository - DeleteRoom - r.pg.Pool.Exec(): %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("ChatRepository - DeleteRoom: %w", pgx.ErrNoRows)
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/go-common-forum/postgres"
 	"github.com/pashagolub/pgxmock/v4"
@@ -14,6 +15,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+var chatMessageTestColumns = []string{"id", "room_id", "user_id", "username", "content", "created_at", "edited_at", "deleted_at"}
+
 func TestChatRepository_SaveMessage(t *testing.T) {
 	ctx := context.Background()
 	logger := zerolog.Nop()
@@ -26,6 +29,7 @@ func TestChatRepository_SaveMessage(t *testing.T) {
 	repo := NewChatRepository(pg, &logger)
 
 	testMessage := &entity.ChatMessage{
+		RoomID:    entity.GeneralChatRoomID,
 		UserID:    1,
 		Username:  "user",
 		Content:   "test message",
@@ -36,7 +40,7 @@ func TestChatRepository_SaveMessage(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		row := pgxmock.NewRows([]string{"id"}).AddRow(expectedID)
-		mockPool.ExpectQuery("INSERT INTO messages \\(user_id, username, content, created_at\\) VALUES\\(\\$1, \\$2, \\$3, \\$4\\) RETURNING id").WithArgs(testMessage.UserID, testMessage.Username, testMessage.Content, testMessage.CreatedAt).WillReturnRows(row)
+		mockPool.ExpectQuery("INSERT INTO messages \\(room_id, user_id, username, content, created_at\\) VALUES\\(\\$1, \\$2, \\$3, \\$4, \\$5\\) RETURNING id").WithArgs(testMessage.RoomID, testMessage.UserID, testMessage.Username, testMessage.Content, testMessage.CreatedAt).WillReturnRows(row)
 
 		id, err := repo.SaveMessage(ctx, testMessage)
 		assert.NoError(t, err)
@@ -46,7 +50,7 @@ func TestChatRepository_SaveMessage(t *testing.T) {
 
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectQuery("INSERT INTO messages \\(user_id, username, content, created_at\\) VALUES\\(\\$1, \\$2, \\$3, \\$4\\) RETURNING id").WithArgs(testMessage.UserID, testMessage.Username, testMessage.Content, testMessage.CreatedAt).WillReturnError(dbErr)
+		mockPool.ExpectQuery("INSERT INTO messages \\(room_id, user_id, username, content, created_at\\) VALUES\\(\\$1, \\$2, \\$3, \\$4, \\$5\\) RETURNING id").WithArgs(testMessage.RoomID, testMessage.UserID, testMessage.Username, testMessage.Content, testMessage.CreatedAt).WillReturnError(dbErr)
 
 		_, err := repo.SaveMessage(ctx, testMessage)
 		assert.Error(t, err)
@@ -68,19 +72,21 @@ func TestChatRepository_GetMessages(t *testing.T) {
 	repo := NewChatRepository(pg, &logger)
 
 	expectedMessages := []entity.ChatMessage{
-		{UserID: 1, Username: "user1", Content: "message1", CreatedAt: time.Now()},
-		{UserID: 2, Username: "user2", Content: "message2", CreatedAt: time.Now()},
+		{RoomID: 3, UserID: 1, Username: "user1", Content: "message1", CreatedAt: time.Now()},
+		{RoomID: 3, UserID: 2, Username: "user2", Content: "message2", CreatedAt: time.Now()},
 	}
 
+	roomID := int64(3)
 	expectedLimit := int64(2)
+	getMessagesQuery := "SELECT id, room_id, user_id, username, content, created_at, edited_at, deleted_at FROM \\( SELECT id, room_id, user_id, username, content, created_at, edited_at, deleted_at FROM messages WHERE room_id = \\$1 ORDER BY created_at DESC, id DESC LIMIT \\$2 \\) AS page ORDER BY created_at, id"
 
 	t.Run("Success", func(t *testing.T) {
-		rows := pgxmock.NewRows([]string{"id", "user_id", "username", "content", "created_at"}).
-			AddRow(expectedMessages[0].ID, expectedMessages[0].UserID, expectedMessages[0].Username, expectedMessages[0].Content, expectedMessages[0].CreatedAt).
-			AddRow(expectedMessages[1].ID, expectedMessages[1].UserID, expectedMessages[1].Username, expectedMessages[1].Content, expectedMessages[1].CreatedAt)
-		mockPool.ExpectQuery("SELECT id, user_id, username, content, created_at FROM \\(SELECT id, user_id, username, content, created_at FROM messages ORDER BY created_at DESC LIMIT \\$1\\) AS recent_mesages ORDER BY created_at ASC").WithArgs(expectedLimit).WillReturnRows(rows)
+		rows := pgxmock.NewRows(chatMessageTestColumns).
+			AddRow(expectedMessages[0].ID, expectedMessages[0].RoomID, expectedMessages[0].UserID, expectedMessages[0].Username, expectedMessages[0].Content, expectedMessages[0].CreatedAt, nil, nil).
+			AddRow(expectedMessages[1].ID, expectedMessages[1].RoomID, expectedMessages[1].UserID, expectedMessages[1].Username, expectedMessages[1].Content, expectedMessages[1].CreatedAt, nil, nil)
+		mockPool.ExpectQuery(getMessagesQuery).WithArgs(roomID, expectedLimit).WillReturnRows(rows)
 
-		messages, err := repo.GetMessages(ctx, expectedLimit)
+		messages, err := repo.GetMessages(ctx, roomID, 0, expectedLimit)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedMessages, messages)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
@@ -88,9 +94,9 @@ func TestChatRepository_GetMessages(t *testing.T) {
 
 	t.Run("Query error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectQuery("SELECT id, user_id, username, content, created_at FROM \\(SELECT id, user_id, username, content, created_at FROM messages ORDER BY created_at DESC LIMIT \\$1\\) AS recent_mesages ORDER BY created_at ASC").WithArgs(expectedLimit).WillReturnError(dbErr)
+		mockPool.ExpectQuery(getMessagesQuery).WithArgs(roomID, expectedLimit).WillReturnError(dbErr)
 
-		_, err := repo.GetMessages(ctx, expectedLimit)
+		_, err := repo.GetMessages(ctx, roomID, 0, expectedLimit)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "ChatRepository - GetMessages - r.pg.Pool.Query()")
 		assert.ErrorIs(t, err, dbErr)
@@ -99,16 +105,163 @@ func TestChatRepository_GetMessages(t *testing.T) {
 
 	t.Run("Scan error	", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		rows := pgxmock.NewRows([]string{"id", "user_id", "username", "content", "created_at"}).
-			AddRow(expectedMessages[0].ID, expectedMessages[0].UserID, expectedMessages[0].Username, expectedMessages[0].Content, expectedMessages[0].CreatedAt).
-			AddRow(expectedMessages[1].ID, expectedMessages[1].UserID, expectedMessages[1].Username, expectedMessages[1].Content, expectedMessages[1].CreatedAt).
+		rows := pgxmock.NewRows(chatMessageTestColumns).
+			AddRow(expectedMessages[0].ID, expectedMessages[0].RoomID, expectedMessages[0].UserID, expectedMessages[0].Username, expectedMessages[0].Content, expectedMessages[0].CreatedAt, nil, nil).
+			AddRow(expectedMessages[1].ID, expectedMessages[1].RoomID, expectedMessages[1].UserID, expectedMessages[1].Username, expectedMessages[1].Content, expectedMessages[1].CreatedAt, nil, nil).
 			RowError(1, dbErr)
-		mockPool.ExpectQuery("SELECT id, user_id, username, content, created_at FROM \\(SELECT id, user_id, username, content, created_at FROM messages ORDER BY created_at DESC LIMIT \\$1\\) AS recent_mesages ORDER BY created_at ASC").WithArgs(expectedLimit).WillReturnRows(rows)
+		mockPool.ExpectQuery(getMessagesQuery).WithArgs(roomID, expectedLimit).WillReturnRows(rows)
 
-		_, err := repo.GetMessages(ctx, expectedLimit)
+		_, err := repo.GetMessages(ctx, roomID, 0, expectedLimit)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "ChatRepository - GetMessages - rows.Next()")
 		assert.ErrorIs(t, err, dbErr)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
+
+	t.Run("Before a message", func(t *testing.T) {
+		rows := pgxmock.NewRows(chatMessageTestColumns).
+			AddRow(expectedMessages[0].ID, expectedMessages[0].RoomID, expectedMessages[0].UserID, expectedMessages[0].Username, expectedMessages[0].Content, expectedMessages[0].CreatedAt, nil, nil)
+		mockPool.ExpectQuery("WHERE room_id = \\$1 AND \\(created_at, id\\) < \\(SELECT created_at, id FROM messages WHERE id = \\$3\\) ORDER BY created_at DESC, id DESC LIMIT \\$2").
+			WithArgs(roomID, expectedLimit, int64(40)).
+			WillReturnRows(rows)
+
+		messages, err := repo.GetMessages(ctx, roomID, 40, expectedLimit)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedMessages[:1], messages)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestChatRepository_GetMessagesSince(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewChatRepository(pg, &logger)
+
+	now := time.Now()
+	rows := pgxmock.NewRows(chatMessageTestColumns).
+		AddRow(int64(11), int64(3), int64(1), "user1", "missed", now, nil, nil)
+	mockPool.ExpectQuery("WHERE room_id = \\$1 AND id < \\$2\\s+AND created_at >= \\(SELECT created_at FROM messages WHERE id = \\$2\\) - interval '15 seconds'\\)\\s+UNION ALL\\s+\\(SELECT .+ FROM messages\\s+WHERE room_id = \\$1 AND id > \\$2\\s+ORDER BY id LIMIT \\$3\\)\\s+\\) AS missed ORDER BY created_at, id").
+		WithArgs(int64(3), int64(10), int64(101)).
+		WillReturnRows(rows)
+
+	messages, err := repo.GetMessagesSince(ctx, 3, 10, 101)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.ChatMessage{{ID: 11, RoomID: 3, UserID: 1, Username: "user1", Content: "missed", CreatedAt: now}}, messages)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestChatRepository_ModifyMessage(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewChatRepository(pg, &logger)
+	now := time.Now()
+
+	t.Run("GetMessage", func(t *testing.T) {
+		mockPool.ExpectQuery("FROM messages WHERE id = \\$1").WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows(chatMessageTestColumns).AddRow(int64(7), int64(3), int64(1), "user1", "", now, nil, &now))
+
+		message, err := repo.GetMessage(ctx, 7)
+		require.NoError(t, err)
+		assert.Equal(t, &entity.ChatMessage{ID: 7, RoomID: 3, UserID: 1, Username: "user1", CreatedAt: now, DeletedAt: &now}, message)
+
+		mockPool.ExpectQuery("FROM messages WHERE id = \\$1").WithArgs(int64(8)).WillReturnError(pgx.ErrNoRows)
+		_, err = repo.GetMessage(ctx, 8)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateMessage", func(t *testing.T) {
+		mockPool.ExpectQuery("UPDATE messages SET content = \\$2, edited_at = now\\(\\) WHERE id = \\$1 AND deleted_at IS NULL RETURNING edited_at").
+			WithArgs(int64(7), "hello").
+			WillReturnRows(pgxmock.NewRows([]string{"edited_at"}).AddRow(now))
+
+		editedAt, err := repo.UpdateMessage(ctx, 7, "hello")
+		require.NoError(t, err)
+		assert.Equal(t, now, editedAt)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteMessage already deleted", func(t *testing.T) {
+		mockPool.ExpectQuery("UPDATE messages SET content = '', deleted_at = now\\(\\) WHERE id = \\$1 AND deleted_at IS NULL RETURNING deleted_at").
+			WithArgs(int64(7)).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.DeleteMessage(ctx, 7)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestChatRepository_Rooms(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewChatRepository(pg, &logger)
+
+	categoryID := int64(4)
+	createdBy := int64(1)
+	now := time.Now()
+	roomColumns := []string{"id", "kind", "name", "category_id", "created_by", "hidden", "created_at"}
+
+	t.Run("GetRooms", func(t *testing.T) {
+		rows := pgxmock.NewRows(roomColumns).
+			AddRow(int64(1), "general", "Общий чат", nil, nil, false, now).
+			AddRow(int64(2), "category", "Новости", &categoryID, nil, true, now)
+		mockPool.ExpectQuery("WITH RECURSIVE hidden_categories AS .* FROM chat_rooms r LEFT JOIN categories c ON c.id = r.category_id ORDER BY").WillReturnRows(rows)
+
+		rooms, err := repo.GetRooms(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []entity.ChatRoom{
+			{ID: 1, Kind: entity.ChatRoomGeneral, Name: "Общий чат", CreatedAt: now},
+			{ID: 2, Kind: entity.ChatRoomCategory, Name: "Новости", CategoryID: &categoryID, Hidden: true, CreatedAt: now},
+		}, rooms)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("GetRoom not found", func(t *testing.T) {
+		mockPool.ExpectQuery("WHERE r.id = \\$1").WithArgs(int64(9)).WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.GetRoom(ctx, 9)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("CreateRoom", func(t *testing.T) {
+		room := &entity.ChatRoom{Kind: entity.ChatRoomCustom, Name: "Экзамены", CreatedBy: &createdBy}
+		mockPool.ExpectQuery("INSERT INTO chat_rooms \\(kind, name, created_by\\) VALUES \\(\\$1, \\$2, \\$3\\) RETURNING id, created_at").
+			WithArgs("custom", room.Name, room.CreatedBy).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(5), now))
+
+		id, err := repo.CreateRoom(ctx, room)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), id)
+		assert.Equal(t, now, room.CreatedAt)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteRoom", func(t *testing.T) {
+		mockPool.ExpectExec("DELETE FROM chat_rooms WHERE id = \\$1 AND kind = \\$2").WithArgs(int64(5), "custom").WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		assert.NoError(t, repo.DeleteRoom(ctx, 5))
+
+		mockPool.ExpectExec("DELETE FROM chat_rooms WHERE id = \\$1 AND kind = \\$2").WithArgs(int64(1), "custom").WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		assert.ErrorIs(t, repo.DeleteRoom(ctx, 1), pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
 }
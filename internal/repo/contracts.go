@@ -2,37 +2,96 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 )
 
 type (
+	// Transactor runs fn in a database transaction. Repository calls made with the
+	// context passed to fn take part in it; a nested WithinTx joins the outer transaction.
+	Transactor interface {
+		WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	}
+
 	CategoryRepository interface {
 		Create(context.Context, entity.Category) (int64, error)
 		GetByID(context.Context, int64) (*entity.Category, error)
-		GetAll(context.Context) ([]entity.Category, error)
-		Update(ctx context.Context, id int64, title, description string) error
+		GetAll(ctx context.Context, includeHidden bool) ([]entity.Category, error)
+		Update(ctx context.Context, id int64, title, description *string, hidden *bool, version *time.Time) error
+		Reorder(ctx context.Context, ids []int64) error
+		SetParent(ctx context.Context, id int64, parentID *int64) error
+		LockTree(ctx context.Context) error
+		GetPath(ctx context.Context, id int64) ([]entity.Breadcrumb, error)
+		IsHidden(ctx context.Context, id int64) (bool, error)
+		HasChildren(ctx context.Context, id int64) (bool, error)
 		Delete(ctx context.Context, id int64) error
 	}
 
 	TopicRepository interface {
 		Create(context.Context, entity.Topic) (int64, error)
 		GetByID(context.Context, int64) (*entity.Topic, error)
-		GetByCategory(ct context.Context, categoryID int64) ([]entity.Topic, error)
-		Update(ctx context.Context, id int64, title string) error
-		Delete(ctx context.Context, id int64) error
+		GetByIDForUpdate(context.Context, int64) (*entity.Topic, error)
+		GetByCategory(ctx context.Context, categoryID int64, sort entity.TopicSort, page entity.PageRequest) ([]entity.Topic, error)
+		Update(ctx context.Context, id int64, title string, version *time.Time) error
+		UpdateState(ctx context.Context, id int64, pinned *bool, locked *bool) error
+		Delete(ctx context.Context, id int64, deletedBy int64) error
+		Restore(ctx context.Context, id int64) error
+		PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 	}
 
 	PostRepository interface {
 		Create(context.Context, entity.Post) (int64, error)
 		GetByID(context.Context, int64) (*entity.Post, error)
-		GetByTopic(ctx context.Context, topicID int64) ([]entity.Post, error)
-		Update(ctx context.Context, id int64, content string) error
-		Delete(ctx context.Context, id int64) error
+		GetByIDForUpdate(context.Context, int64) (*entity.Post, error)
+		GetByTopic(ctx context.Context, topicID int64, page entity.PageRequest) ([]entity.Post, error)
+		GetTree(ctx context.Context, topicID int64, page entity.PageRequest, maxDepth int) ([]entity.PostNode, error)
+		GetSubtree(ctx context.Context, postID int64, maxDepth int) ([]entity.PostNode, error)
+		GetAncestors(ctx context.Context, postID int64) ([]entity.Post, error)
+		Update(ctx context.Context, id int64, content string, editorID int64, editorRole string, version *time.Time) error
+		Delete(ctx context.Context, id int64, deletedBy int64) error
+		Restore(ctx context.Context, id int64) error
+		PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+		GetRevisions(ctx context.Context, postID int64) ([]entity.PostRevision, error)
+		GetRevision(ctx context.Context, postID int64, revisionID int64) (*entity.PostRevision, error)
+	}
+
+	ReactionRepository interface {
+		Add(ctx context.Context, postID int64, userID int64, reaction string) error
+		Remove(ctx context.Context, postID int64, userID int64, reaction string) error
+		GetByPosts(ctx context.Context, postIDs []int64, userID int64) ([]entity.ReactionCount, error)
+	}
+
+	ModeratorRepository interface {
+		Add(ctx context.Context, categoryID int64, userID int64) error
+		Remove(ctx context.Context, categoryID int64, userID int64) error
+		GetByCategory(ctx context.Context, categoryID int64) ([]entity.CategoryModerator, error)
+		IsModerator(ctx context.Context, categoryID int64, userID int64) (bool, error)
+	}
+
+	SearchRepository interface {
+		Search(ctx context.Context, query entity.SearchQuery) ([]entity.SearchResult, error)
 	}
 
 	ChatRepository interface {
 		SaveMessage(ctx context.Context, message *entity.ChatMessage) (int64, error)
-		GetMessages(ctx context.Context, limit int64) ([]entity.ChatMessage, error)
+		GetMessages(ctx context.Context, roomID int64, before int64, limit int64) ([]entity.ChatMessage, error)
+		GetMessagesSince(ctx context.Context, roomID int64, since int64, limit int64) ([]entity.ChatMessage, error)
+		GetMessage(ctx context.Context, id int64) (*entity.ChatMessage, error)
+		UpdateMessage(ctx context.Context, id int64, content string) (time.Time, error)
+		DeleteMessage(ctx context.Context, id int64) (time.Time, error)
+		GetRooms(ctx context.Context) ([]entity.ChatRoom, error)
+		GetRoom(ctx context.Context, id int64) (*entity.ChatRoom, error)
+		CreateRoom(ctx context.Context, room *entity.ChatRoom) (int64, error)
+		DeleteRoom(ctx context.Context, id int64) error
+	}
+
+	ConversationRepository interface {
+		GetOrCreate(ctx context.Context, userID, peerID int64) (int64, error)
+		GetPeer(ctx context.Context, conversationID, userID int64) (int64, error)
+		GetByUser(ctx context.Context, userID int64) ([]entity.Conversation, error)
+		SaveMessage(ctx context.Context, message *entity.DirectMessage) (int64, error)
+		GetMessages(ctx context.Context, conversationID int64, before int64, limit int64) ([]entity.DirectMessage, error)
+		MarkRead(ctx context.Context, conversationID, userID, messageID int64) error
 	}
 )
@@ -0,0 +1,170 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/rs/zerolog"
+)
+
+type conversationRepository struct {
+	pg  *postgres.Postgres
+	log *zerolog.Logger
+}
+
+func NewConversationRepository(pg *postgres.Postgres, log *zerolog.Logger) ConversationRepository {
+	return &conversationRepository{pg, log}
+}
+
+// GetOrCreate returns the conversation between two users, creating it on the first message.
+func (r *conversationRepository) GetOrCreate(ctx context.Context, userID, peerID int64) (int64, error) {
+	low, high := min(userID, peerID), max(userID, peerID)
+	row := conn(ctx, r.pg).QueryRow(ctx, `
+		INSERT INTO conversations (user_low, user_high) VALUES ($1, $2)
+		ON CONFLICT (user_low, user_high) DO UPDATE SET user_low = EXCLUDED.user_low
+		RETURNING id`, low, high)
+
+	var id int64
+	if err := row.Scan(&id); err != nil {
+		r.log.Error().Err(err).Str("op", "ConversationRepository.GetOrCreate").Int64("user_id", userID).Int64("peer_id", peerID).Msg("Failed to get or create conversation")
+		return 0, fmt.Errorf("ConversationRepository - GetOrCreate - row.Scan(): %w", err)
+	}
+
+	return id, nil
+}
+
+// GetPeer returns the other participant of a conversation. Returns pgx.ErrNoRows if
+// userID does not take part in it.
+func (r *conversationRepository) GetPeer(ctx context.Context, conversationID, userID int64) (int64, error) {
+	row := conn(ctx, r.pg).QueryRow(ctx, `
+		SELECT CASE WHEN user_low = $2 THEN user_high ELSE user_low END
+		FROM conversations WHERE id = $1 AND (user_low = $2 OR user_high = $2)`, conversationID, userID)
+
+	var peerID int64
+	if err := row.Scan(&peerID); err != nil {
+		return 0, fmt.Errorf("ConversationRepository - GetPeer - row.Scan(): %w", err)
+	}
+
+	return peerID, nil
+}
+
+// GetByUser lists the conversations of a user with their last message, most recent first.
+// Usernames are left empty.
+func (r *conversationRepository) GetByUser(ctx context.Context, userID int64) ([]entity.Conversation, error) {
+	rows, err := conn(ctx, r.pg).Query(ctx, `
+		SELECT c.id, CASE WHEN c.user_low = $1 THEN c.user_high ELSE c.user_low END, c.created_at,
+			(SELECT COUNT(*) FROM direct_messages d
+			 WHERE d.conversation_id = c.id AND d.sender_id <> $1
+			   AND d.id > CASE WHEN c.user_low = $1 THEN c.user_low_last_read ELSE c.user_high_last_read END),
+			m.id, m.sender_id, m.content, m.created_at
+		FROM conversations c
+		LEFT JOIN LATERAL (
+			SELECT id, sender_id, content, created_at FROM direct_messages
+			WHERE conversation_id = c.id ORDER BY created_at DESC, id DESC LIMIT 1
+		) m ON true
+		WHERE c.user_low = $1 OR c.user_high = $1
+		ORDER BY COALESCE(m.created_at, c.created_at) DESC, c.id DESC`, userID)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", "ConversationRepository.GetByUser").Int64("user_id", userID).Msg("Failed to get conversations")
+		return nil, fmt.Errorf("ConversationRepository - GetByUser - r.pg.Pool.Query(): %w", err)
+	}
+	defer rows.Close()
+
+	var conversations []entity.Conversation
+	for rows.Next() {
+		var c entity.Conversation
+		var msgID, senderID *int64
+		var content *string
+		var createdAt *time.Time
+		if err := rows.Scan(&c.ID, &c.PeerID, &c.CreatedAt, &c.UnreadCount, &msgID, &senderID, &content, &createdAt); err != nil {
+			r.log.Error().Err(err).Str("op", "ConversationRepository.GetByUser").Msg("Failed to scan conversation")
+			return nil, fmt.Errorf("ConversationRepository - GetByUser - rows.Next(): %w", err)
+		}
+		if msgID != nil {
+			c.LastMessage = &entity.DirectMessage{ID: *msgID, ConversationID: c.ID, SenderID: *senderID, Content: *content, CreatedAt: *createdAt}
+		}
+		conversations = append(conversations, c)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error().Err(err).Str("op", "ConversationRepository.GetByUser").Int64("user_id", userID).Msg("Failed to read conversations")
+		return nil, fmt.Errorf("ConversationRepository - GetByUser - rows.Err(): %w", err)
+	}
+
+	return conversations, nil
+}
+
+func (r *conversationRepository) SaveMessage(ctx context.Context, message *entity.DirectMessage) (int64, error) {
+	row := conn(ctx, r.pg).QueryRow(ctx, "INSERT INTO direct_messages (conversation_id, sender_id, content, created_at) VALUES ($1, $2, $3, $4) RETURNING id", message.ConversationID, message.SenderID, message.Content, message.CreatedAt)
+
+	var id int64
+	if err := row.Scan(&id); err != nil {
+		r.log.Error().Err(err).Str("op", "ConversationRepository.SaveMessage").Int64("conversation_id", message.ConversationID).Msg("Failed to insert message")
+		return 0, fmt.Errorf("ConversationRepository - SaveMessage - row.Scan(): %w", err)
+	}
+
+	return id, nil
+}
+
+// GetMessages returns up to limit messages of a conversation sent before the message
+// with id before, or the latest ones if before is 0. Messages are ordered oldest first.
+func (r *conversationRepository) GetMessages(ctx context.Context, conversationID int64, before int64, limit int64) ([]entity.DirectMessage, error) {
+	query := `
+		SELECT id, conversation_id, sender_id, content, created_at FROM (
+			SELECT id, conversation_id, sender_id, content, created_at FROM direct_messages
+			WHERE conversation_id = $1
+			ORDER BY created_at DESC, id DESC LIMIT $2
+		) AS page ORDER BY created_at, id`
+	args := []any{conversationID, limit}
+	if before != 0 {
+		query = `
+		SELECT id, conversation_id, sender_id, content, created_at FROM (
+			SELECT id, conversation_id, sender_id, content, created_at FROM direct_messages
+			WHERE conversation_id = $1
+			  AND (created_at, id) < (SELECT created_at, id FROM direct_messages WHERE id = $3)
+			ORDER BY created_at DESC, id DESC LIMIT $2
+		) AS page ORDER BY created_at, id`
+		args = append(args, before)
+	}
+
+	rows, err := conn(ctx, r.pg).Query(ctx, query, args...)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", "ConversationRepository.GetMessages").Int64("conversation_id", conversationID).Msg("Failed to get messages")
+		return nil, fmt.Errorf("ConversationRepository - GetMessages - r.pg.Pool.Query(): %w", err)
+	}
+	defer rows.Close()
+
+	var messages []entity.DirectMessage
+	for rows.Next() {
+		var m entity.DirectMessage
+		if err := rows.Scan(&m.ID, &m.ConversationID, &m.SenderID, &m.Content, &m.CreatedAt); err != nil {
+			r.log.Error().Err(err).Str("op", "ConversationRepository.GetMessages").Msg("Failed to scan message")
+			return nil, fmt.Errorf("ConversationRepository - GetMessages - rows.Next(): %w", err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error().Err(err).Str("op", "ConversationRepository.GetMessages").Int64("conversation_id", conversationID).Msg("Failed to read messages")
+		return nil, fmt.Errorf("ConversationRepository - GetMessages - rows.Err(): %w", err)
+	}
+
+	return messages, nil
+}
+
+// MarkRead records that userID has read the conversation up to messageID. The mark
+// never moves back.
+func (r *conversationRepository) MarkRead(ctx context.Context, conversationID, userID, messageID int64) error {
+	_, err := conn(ctx, r.pg).Exec(ctx, `
+		UPDATE conversations SET
+			user_low_last_read = CASE WHEN user_low = $2 THEN GREATEST(user_low_last_read, $3) ELSE user_low_last_read END,
+			user_high_last_read = CASE WHEN user_high = $2 THEN GREATEST(user_high_last_read, $3) ELSE user_high_last_read END
+		WHERE id = $1`, conversationID, userID, messageID)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", "ConversationRepository.MarkRead").Int64("conversation_id", conversationID).Msg("Failed to mark conversation read")
+		return fmt.Errorf("ConversationRepository - MarkRead - r.pg.Pool.Exec(): %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationRepository(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewConversationRepository(pg, &logger)
+	now := time.Now()
+
+	t.Run("GetOrCreate orders the pair", func(t *testing.T) {
+		mockPool.ExpectQuery("INSERT INTO conversations \\(user_low, user_high\\) VALUES \\(\\$1, \\$2\\) ON CONFLICT \\(user_low, user_high\\)").
+			WithArgs(int64(2), int64(7)).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(3)))
+
+		id, err := repo.GetOrCreate(ctx, 7, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), id)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("GetPeer of a stranger", func(t *testing.T) {
+		mockPool.ExpectQuery("FROM conversations WHERE id = \\$1 AND \\(user_low = \\$2 OR user_high = \\$2\\)").
+			WithArgs(int64(3), int64(9)).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.GetPeer(ctx, 3, 9)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("GetByUser", func(t *testing.T) {
+		msgID, senderID, content := int64(10), int64(2), "hi"
+		rows := pgxmock.NewRows([]string{"id", "peer_id", "created_at", "unread", "m_id", "m_sender_id", "m_content", "m_created_at"}).
+			AddRow(int64(3), int64(2), now, int64(1), &msgID, &senderID, &content, &now).
+			AddRow(int64(4), int64(5), now, int64(0), nil, nil, nil, nil)
+		mockPool.ExpectQuery("FROM conversations c LEFT JOIN LATERAL").WithArgs(int64(7)).WillReturnRows(rows)
+
+		conversations, err := repo.GetByUser(ctx, 7)
+		require.NoError(t, err)
+		assert.Equal(t, []entity.Conversation{
+			{ID: 3, PeerID: 2, UnreadCount: 1, CreatedAt: now, LastMessage: &entity.DirectMessage{ID: 10, ConversationID: 3, SenderID: 2, Content: "hi", CreatedAt: now}},
+			{ID: 4, PeerID: 5, CreatedAt: now},
+		}, conversations)
+
+		// A connection lost halfway through the rows must not pass for a shorter list.
+		rows = pgxmock.NewRows([]string{"id", "peer_id", "created_at", "unread", "m_id", "m_sender_id", "m_content", "m_created_at"}).
+			AddRow(int64(3), int64(2), now, int64(1), &msgID, &senderID, &content, &now).
+			AddRow(int64(4), int64(5), now, int64(0), nil, nil, nil, nil).
+			RowError(2, errors.New("connection reset"))
+		mockPool.ExpectQuery("FROM conversations c LEFT JOIN LATERAL").WithArgs(int64(7)).WillReturnRows(rows)
+
+		_, err = repo.GetByUser(ctx, 7)
+		assert.ErrorContains(t, err, "ConversationRepository - GetByUser - rows.Err()")
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("SaveMessage", func(t *testing.T) {
+		message := &entity.DirectMessage{ConversationID: 3, SenderID: 7, Content: "hi", CreatedAt: now}
+		mockPool.ExpectQuery("INSERT INTO direct_messages \\(conversation_id, sender_id, content, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) RETURNING id").
+			WithArgs(message.ConversationID, message.SenderID, message.Content, message.CreatedAt).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(11)))
+
+		id, err := repo.SaveMessage(ctx, message)
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), id)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("GetMessages", func(t *testing.T) {
+		columns := []string{"id", "conversation_id", "sender_id", "content", "created_at"}
+		mockPool.ExpectQuery("WHERE conversation_id = \\$1 ORDER BY created_at DESC, id DESC LIMIT \\$2").
+			WithArgs(int64(3), int64(20)).
+			WillReturnRows(pgxmock.NewRows(columns).AddRow(int64(10), int64(3), int64(2), "hi", now))
+
+		messages, err := repo.GetMessages(ctx, 3, 0, 20)
+		require.NoError(t, err)
+		assert.Equal(t, []entity.DirectMessage{{ID: 10, ConversationID: 3, SenderID: 2, Content: "hi", CreatedAt: now}}, messages)
+
+		mockPool.ExpectQuery("AND \\(created_at, id\\) < \\(SELECT created_at, id FROM direct_messages WHERE id = \\$3\\)").
+			WithArgs(int64(3), int64(20), int64(10)).
+			WillReturnError(errors.New("some db error"))
+
+		_, err = repo.GetMessages(ctx, 3, 10, 20)
+		assert.ErrorContains(t, err, "ConversationRepository - GetMessages - r.pg.Pool.Query()")
+
+		mockPool.ExpectQuery("WHERE conversation_id = \\$1 ORDER BY created_at DESC, id DESC LIMIT \\$2").
+			WithArgs(int64(3), int64(20)).
+			WillReturnRows(pgxmock.NewRows(columns).AddRow(int64(10), int64(3), int64(2), "hi", now).RowError(1, errors.New("connection reset")))
+
+		_, err = repo.GetMessages(ctx, 3, 0, 20)
+		assert.ErrorContains(t, err, "ConversationRepository - GetMessages - rows.Err()")
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("MarkRead", func(t *testing.T) {
+		mockPool.ExpectExec("UPDATE conversations SET .*GREATEST").
+			WithArgs(int64(3), int64(7), int64(10)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		assert.NoError(t, repo.MarkRead(ctx, 3, 7, 10))
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
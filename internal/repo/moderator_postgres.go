@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/rs/zerolog"
+)
+
+type moderatorRepository struct {
+	pg  *postgres.Postgres
+	log *zerolog.Logger
+}
+
+const (
+	addModeratorOp       = "ModeratorRepository.Add"
+	removeModeratorOp    = "ModeratorRepository.Remove"
+	getByCategoryModerOp = "ModeratorRepository.GetByCategory"
+	isModeratorOp        = "ModeratorRepository.IsModerator"
+)
+
+func NewModeratorRepository(pg *postgres.Postgres, log *zerolog.Logger) ModeratorRepository {
+	return &moderatorRepository{pg, log}
+}
+
+func (r *moderatorRepository) Add(ctx context.Context, categoryID int64, userID int64) error {
+	if _, err := conn(ctx, r.pg).Exec(ctx, "INSERT INTO category_moderators (category_id, user_id) VALUES($1, $2) ON CONFLICT DO NOTHING", categoryID, userID); err != nil {
+		r.log.Error().Err(err).Str("op", addModeratorOp).Int64("category_id", categoryID).Int64("user_id", userID).Msg("Failed to add moderator")
+		return fmt.Errorf("ModeratorRepository - Add - pg.Pool.Exec(): %w", err)
+	}
+	return nil
+}
+
+// Remove returns pgx.ErrNoRows if the user is not a moderator of the category.
+func (r *moderatorRepository) Remove(ctx context.Context, categoryID int64, userID int64) error {
+	tag, err := conn(ctx, r.pg).Exec(ctx, "DELETE FROM category_moderators WHERE category_id = $1 AND user_id = $2", categoryID, userID)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", removeModeratorOp).Int64("category_id", categoryID).Int64("user_id", userID).Msg("Failed to remove moderator")
+		return fmt.Errorf("ModeratorRepository - Remove - pg.Pool.Exec(): %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("ModeratorRepository - Remove: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *moderatorRepository) GetByCategory(ctx context.Context, categoryID int64) ([]entity.CategoryModerator, error) {
+	rows, err := conn(ctx, r.pg).Query(ctx, "SELECT category_id, user_id, created_at FROM category_moderators WHERE category_id = $1 ORDER BY created_at, user_id", categoryID)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", getByCategoryModerOp).Int64("category_id", categoryID).Msg("Failed to get moderators")
+		return nil, fmt.Errorf("ModeratorRepository - GetByCategory - pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var moderators []entity.CategoryModerator
+	for rows.Next() {
+		var m entity.CategoryModerator
+		if err := rows.Scan(&m.CategoryID, &m.UserID, &m.CreatedAt); err != nil {
+			r.log.Error().Err(err).Str("op", getByCategoryModerOp).Int64("category_id", categoryID).Msg("Failed to scan moderator")
+			return nil, fmt.Errorf("ModeratorRepository - GetByCategory - rows.Next() - rows.Scan(): %w", err)
+		}
+		moderators = append(moderators, m)
+	}
+
+	return moderators, nil
+}
+
+func (r *moderatorRepository) IsModerator(ctx context.Context, categoryID int64, userID int64) (bool, error) {
+	row := conn(ctx, r.pg).QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM category_moderators WHERE category_id = $1 AND user_id = $2)", categoryID, userID)
+
+	var ok bool
+	if err := row.Scan(&ok); err != nil {
+		r.log.Error().Err(err).Str("op", isModeratorOp).Int64("category_id", categoryID).Int64("user_id", userID).Msg("Failed to check moderator")
+		return false, fmt.Errorf("ModeratorRepository - IsModerator - row.Scan(): %w", err)
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,145 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModeratorRepository_Add(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewModeratorRepository(pg, &logger)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec("INSERT INTO category_moderators").WithArgs(int64(1), int64(2)).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		err := repo.Add(ctx, 1, 2)
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Exec error", func(t *testing.T) {
+		dbErr := errors.New("db error")
+		mockPool.ExpectExec("INSERT INTO category_moderators").WithArgs(int64(1), int64(2)).WillReturnError(dbErr)
+
+		err := repo.Add(ctx, 1, 2)
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestModeratorRepository_Remove(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewModeratorRepository(pg, &logger)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec("DELETE FROM category_moderators").WithArgs(int64(1), int64(2)).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+		err := repo.Remove(ctx, 1, 2)
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Not a moderator", func(t *testing.T) {
+		mockPool.ExpectExec("DELETE FROM category_moderators").WithArgs(int64(1), int64(2)).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+		err := repo.Remove(ctx, 1, 2)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestModeratorRepository_GetByCategory(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewModeratorRepository(pg, &logger)
+
+	categoryID := int64(1)
+
+	t.Run("Success", func(t *testing.T) {
+		expected := []entity.CategoryModerator{
+			{CategoryID: categoryID, UserID: 2, CreatedAt: time.Now()},
+			{CategoryID: categoryID, UserID: 3, CreatedAt: time.Now()},
+		}
+		rows := pgxmock.NewRows([]string{"category_id", "user_id", "created_at"})
+		for _, m := range expected {
+			rows.AddRow(m.CategoryID, m.UserID, m.CreatedAt)
+		}
+		mockPool.ExpectQuery("SELECT category_id, user_id, created_at FROM category_moderators WHERE category_id = \\$1").WithArgs(categoryID).WillReturnRows(rows)
+
+		moderators, err := repo.GetByCategory(ctx, categoryID)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, moderators)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		dbErr := errors.New("db error")
+		mockPool.ExpectQuery("FROM category_moderators").WithArgs(categoryID).WillReturnError(dbErr)
+
+		moderators, err := repo.GetByCategory(ctx, categoryID)
+		assert.ErrorIs(t, err, dbErr)
+		assert.Nil(t, moderators)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestModeratorRepository_IsModerator(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewModeratorRepository(pg, &logger)
+
+	t.Run("Moderator", func(t *testing.T) {
+		mockPool.ExpectQuery("SELECT EXISTS").WithArgs(int64(1), int64(2)).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+		ok, err := repo.IsModerator(ctx, 1, 2)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		dbErr := errors.New("db error")
+		mockPool.ExpectQuery("SELECT EXISTS").WithArgs(int64(1), int64(2)).WillReturnError(dbErr)
+
+		ok, err := repo.IsModerator(ctx, 1, 2)
+		assert.ErrorIs(t, err, dbErr)
+		assert.False(t, ok)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
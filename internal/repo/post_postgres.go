@@ -3,7 +3,10 @@ package repo
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/go-common-forum/postgres"
 	"github.com/rs/zerolog"
@@ -17,9 +20,20 @@ type postRepository struct {
 const (
 	createPostOp  = "PoptRepository.Create"
 	getByIdPostOp = "PostRepository.GetById"
+	lockPostOp    = "PostRepository.GetByIDForUpdate"
 	getByTopicOp  = "PostRepository.GetAll"
 	deletePostOp  = "PostRepository.Delete"
 	updatePostOp  = "PostRepository.Update"
+
+	getTreeOp      = "PostRepository.GetTree"
+	getSubtreeOp   = "PostRepository.GetSubtree"
+	getAncestorsOp = "PostRepository.GetAncestors"
+
+	getRevisionsOp = "PostRepository.GetRevisions"
+	getRevisionOp  = "PostRepository.GetRevision"
+
+	restorePostOp = "PostRepository.Restore"
+	purgePostsOp  = "PostRepository.PurgeDeleted"
 )
 
 func NewPostRepository(pg *postgres.Postgres, log *zerolog.Logger) PostRepository {
@@ -27,7 +41,7 @@ func NewPostRepository(pg *postgres.Postgres, log *zerolog.Logger) PostRepositor
 }
 
 func (r *postRepository) Create(ctx context.Context, post entity.Post) (int64, error) {
-	row := r.pg.Pool.QueryRow(ctx, "INSERT INTO posts (topic_id, author_id, content, reply_to) VALUES($1, $2, $3, $4) RETURNING id", post.TopicID, post.AuthorID, post.Content, post.ReplyTo)
+	row := conn(ctx, r.pg).QueryRow(ctx, "INSERT INTO posts (topic_id, author_id, content, reply_to) VALUES($1, $2, $3, $4) RETURNING id", post.TopicID, post.AuthorID, post.Content, post.ReplyTo)
 
 	var id int64
 	if err := row.Scan(&id); err != nil {
@@ -38,11 +52,13 @@ func (r *postRepository) Create(ctx context.Context, post entity.Post) (int64, e
 	return id, nil
 }
 
+const postByIDQuery = "SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE id = $1 AND deleted_at IS NULL"
+
 func (r *postRepository) GetByID(ctx context.Context, id int64) (*entity.Post, error) {
-	row := r.pg.Pool.QueryRow(ctx, "SELECT id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE id = $1", id)
+	row := conn(ctx, r.pg).QueryRow(ctx, postByIDQuery, id)
 
 	var p entity.Post
-	if err := row.Scan(&p.ID, &p.Content, &p.AuthorID, &p.ReplyTo, &p.CreatedAt, &p.UpdatedAt); err != nil {
+	if err := row.Scan(&p.ID, &p.TopicID, &p.Content, &p.AuthorID, &p.ReplyTo, &p.CreatedAt, &p.UpdatedAt); err != nil {
 		r.log.Error().Err(err).Str("op", getByIdPostOp).Int64("id", id).Msg("Failed to get post")
 		return nil, fmt.Errorf("PostRepository - GetByID - row.Scan(): %w", err)
 	}
@@ -50,8 +66,39 @@ func (r *postRepository) GetByID(ctx context.Context, id int64) (*entity.Post, e
 	return &p, nil
 }
 
-func (r *postRepository) GetByTopic(ctx context.Context, topicID int64) ([]entity.Post, error) {
-	rows, err := r.pg.Pool.Query(ctx, "SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE topic_id = $1 ORDER BY created_at", topicID)
+// GetByIDForUpdate is GetByID that also locks the post row until the surrounding
+// transaction ends, so a concurrent writer cannot change it between a check and a write.
+func (r *postRepository) GetByIDForUpdate(ctx context.Context, id int64) (*entity.Post, error) {
+	row := conn(ctx, r.pg).QueryRow(ctx, postByIDQuery+" FOR UPDATE", id)
+
+	var p entity.Post
+	if err := row.Scan(&p.ID, &p.TopicID, &p.Content, &p.AuthorID, &p.ReplyTo, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		r.log.Error().Err(err).Str("op", lockPostOp).Int64("id", id).Msg("Failed to get post for update")
+		return nil, fmt.Errorf("PostRepository - GetByIDForUpdate - row.Scan(): %w", err)
+	}
+
+	return &p, nil
+}
+
+func (r *postRepository) GetByTopic(ctx context.Context, topicID int64, page entity.PageRequest) ([]entity.Post, error) {
+	query := "SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at, deleted_at FROM posts WHERE topic_id = $1"
+	args := []any{topicID}
+	order := "ASC"
+
+	switch {
+	case page.After != nil:
+		query += " AND (created_at, id) > ($2, $3)"
+		args = append(args, page.After.CreatedAt, page.After.ID)
+	case page.Before != nil:
+		query += " AND (created_at, id) < ($2, $3)"
+		args = append(args, page.Before.CreatedAt, page.Before.ID)
+		order = "DESC"
+	}
+
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT $%d", order, order, len(args)+1)
+	args = append(args, page.Limit)
+
+	rows, err := conn(ctx, r.pg).Query(ctx, query, args...)
 	if err != nil {
 		r.log.Error().Err(err).Str("op", getByTopicOp).Int64("topic_id", topicID).Msg("Failed to get posts")
 		return nil, fmt.Errorf("PostRepository - GetByTopic - pg.Pool.Query: %w", err)
@@ -61,7 +108,7 @@ func (r *postRepository) GetByTopic(ctx context.Context, topicID int64) ([]entit
 	var posts []entity.Post
 	var p entity.Post
 	for rows.Next() {
-		err := rows.Scan(&p.ID, &p.TopicID, &p.Content, &p.AuthorID, &p.ReplyTo, &p.CreatedAt, &p.UpdatedAt)
+		err := rows.Scan(&p.ID, &p.TopicID, &p.Content, &p.AuthorID, &p.ReplyTo, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt)
 		if err != nil {
 			r.log.Error().Err(err).Str("op", getByTopicOp).Int64("topic_id", topicID).Msg("Failed to scan post")
 			return nil, fmt.Errorf("PostRepository - GetByTopic - rows.Next() - rows.Scan(): %w", err)
@@ -69,20 +116,215 @@ func (r *postRepository) GetByTopic(ctx context.Context, topicID int64) ([]entit
 		posts = append(posts, p)
 	}
 
+	if page.Before != nil {
+		slices.Reverse(posts)
+	}
+
 	return posts, nil
 }
 
-func (r *postRepository) Update(ctx context.Context, id int64, content string) error {
-	if _, err := r.pg.Pool.Exec(ctx, "UPDATE posts SET content = $1, updated_at = now() WHERE id = $2", content, id); err != nil {
+// Update replaces the post content and archives the previous one in post_revisions
+// within the same statement, so no edit is ever lost. A non-nil version makes the update
+// conditional on updated_at still being equal to it. Returns pgx.ErrNoRows if no post was updated.
+func (r *postRepository) Update(ctx context.Context, id int64, content string, editorID int64, editorRole string, version *time.Time) error {
+	tag, err := conn(ctx, r.pg).Exec(ctx, `
+WITH old AS (
+	SELECT id, content FROM posts WHERE id = $2 AND ($5::timestamptz IS NULL OR updated_at = $5) FOR UPDATE
+), revision AS (
+	INSERT INTO post_revisions (post_id, content, editor_id, editor_role)
+	SELECT id, content, $3, $4 FROM old
+)
+UPDATE posts SET content = $1, updated_at = now() WHERE id = (SELECT id FROM old)`, content, id, editorID, editorRole, version)
+	if err != nil {
 		r.log.Error().Err(err).Str("op", getByTopicOp).Int64("id", id).Msg("Failed to update post")
 		return fmt.Errorf("PostRepository - Update - Exec: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("PostRepository - Update: %w", pgx.ErrNoRows)
+	}
 	return nil
 }
 
-func (r *postRepository) Delete(ctx context.Context, id int64) error {
-	if _, err := r.pg.Pool.Exec(ctx, `DELETE FROM posts WHERE id = $1`, id); err != nil {
+// Delete only marks the post as deleted, it is removed for good by PurgeDeleted.
+func (r *postRepository) Delete(ctx context.Context, id int64, deletedBy int64) error {
+	if _, err := conn(ctx, r.pg).Exec(ctx, `UPDATE posts SET deleted_at = now(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL`, id, deletedBy); err != nil {
 		return fmt.Errorf("PostRepository - Delete - pg.Pool.Exec(): %w", err)
 	}
 	return nil
 }
+
+// Restore returns pgx.ErrNoRows if there is no deleted post with the given id.
+func (r *postRepository) Restore(ctx context.Context, id int64) error {
+	tag, err := conn(ctx, r.pg).Exec(ctx, `UPDATE posts SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", restorePostOp).Int64("id", id).Msg("Failed to restore post")
+		return fmt.Errorf("PostRepository - Restore - pg.Pool.Exec(): %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("PostRepository - Restore: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *postRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := conn(ctx, r.pg).Exec(ctx, `DELETE FROM posts WHERE deleted_at < $1`, before)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", purgePostsOp).Time("before", before).Msg("Failed to purge posts")
+		return 0, fmt.Errorf("PostRepository - PurgeDeleted - pg.Pool.Exec(): %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// postTreeSelect reads rows from a recursive "tree" CTE. Posts sitting at the depth limit ($2)
+// get the size of their cut off subtree so clients can render "N more replies".
+const postTreeSelect = `
+SELECT tree.id, tree.topic_id, tree.content, tree.author_id, tree.reply_to, tree.created_at, tree.updated_at, tree.deleted_at, tree.depth,
+	CASE WHEN tree.depth = $2 THEN (
+		WITH RECURSIVE sub AS (
+			SELECT c.id FROM posts c WHERE c.reply_to = tree.id
+			UNION ALL
+			SELECT c.id FROM posts c JOIN sub ON c.reply_to = sub.id
+		)
+		SELECT count(*) FROM sub
+	) ELSE 0 END AS collapsed_count
+FROM tree
+ORDER BY tree.depth, tree.created_at, tree.id`
+
+const postTreeRecursion = `
+	UNION ALL
+	SELECT p.id, p.topic_id, p.content, p.author_id, p.reply_to, p.created_at, p.updated_at, p.deleted_at, tree.depth + 1
+	FROM posts p JOIN tree ON p.reply_to = tree.id
+	WHERE tree.depth < $2
+)`
+
+func (r *postRepository) GetTree(ctx context.Context, topicID int64, page entity.PageRequest, maxDepth int) ([]entity.PostNode, error) {
+	rootsQuery := "SELECT id FROM posts WHERE topic_id = $1 AND reply_to IS NULL"
+	args := []any{topicID, maxDepth}
+	order := "ASC"
+
+	switch {
+	case page.After != nil:
+		rootsQuery += " AND (created_at, id) > ($3, $4)"
+		args = append(args, page.After.CreatedAt, page.After.ID)
+	case page.Before != nil:
+		rootsQuery += " AND (created_at, id) < ($3, $4)"
+		args = append(args, page.Before.CreatedAt, page.Before.ID)
+		order = "DESC"
+	}
+
+	rootsQuery += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT $%d", order, order, len(args)+1)
+	args = append(args, page.Limit)
+
+	query := `
+WITH RECURSIVE roots AS (` + rootsQuery + `),
+tree AS (
+	SELECT p.id, p.topic_id, p.content, p.author_id, p.reply_to, p.created_at, p.updated_at, p.deleted_at, 0 AS depth
+	FROM posts p JOIN roots ON roots.id = p.id` + postTreeRecursion + postTreeSelect
+
+	nodes, err := r.queryTree(ctx, query, args...)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", getTreeOp).Int64("topic_id", topicID).Msg("Failed to get post tree")
+		return nil, fmt.Errorf("PostRepository - GetTree - %w", err)
+	}
+
+	return nodes, nil
+}
+
+// GetSubtree returns no nodes if the post does not exist or its topic is deleted.
+func (r *postRepository) GetSubtree(ctx context.Context, postID int64, maxDepth int) ([]entity.PostNode, error) {
+	query := `
+WITH RECURSIVE tree AS (
+	SELECT p.id, p.topic_id, p.content, p.author_id, p.reply_to, p.created_at, p.updated_at, p.deleted_at, 0 AS depth
+	FROM posts p JOIN topics t ON t.id = p.topic_id AND t.deleted_at IS NULL
+	WHERE p.id = $1` + postTreeRecursion + postTreeSelect
+
+	nodes, err := r.queryTree(ctx, query, postID, maxDepth)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", getSubtreeOp).Int64("post_id", postID).Msg("Failed to get post subtree")
+		return nil, fmt.Errorf("PostRepository - GetSubtree - %w", err)
+	}
+
+	return nodes, nil
+}
+
+func (r *postRepository) GetAncestors(ctx context.Context, postID int64) ([]entity.Post, error) {
+	rows, err := conn(ctx, r.pg).Query(ctx, `
+WITH RECURSIVE ancestors AS (
+	SELECT p.id, p.topic_id, p.content, p.author_id, p.reply_to, p.created_at, p.updated_at, p.deleted_at, 1 AS level
+	FROM posts p JOIN topics t ON t.id = p.topic_id AND t.deleted_at IS NULL
+	WHERE p.id = (SELECT reply_to FROM posts WHERE id = $1)
+	UNION ALL
+	SELECT p.id, p.topic_id, p.content, p.author_id, p.reply_to, p.created_at, p.updated_at, p.deleted_at, ancestors.level + 1
+	FROM posts p JOIN ancestors ON p.id = ancestors.reply_to
+)
+SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at, deleted_at FROM ancestors ORDER BY level DESC`, postID)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", getAncestorsOp).Int64("post_id", postID).Msg("Failed to get post ancestors")
+		return nil, fmt.Errorf("PostRepository - GetAncestors - pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []entity.Post
+	var p entity.Post
+	for rows.Next() {
+		if err := rows.Scan(&p.ID, &p.TopicID, &p.Content, &p.AuthorID, &p.ReplyTo, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt); err != nil {
+			r.log.Error().Err(err).Str("op", getAncestorsOp).Int64("post_id", postID).Msg("Failed to scan post")
+			return nil, fmt.Errorf("PostRepository - GetAncestors - rows.Next() - rows.Scan(): %w", err)
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, nil
+}
+
+func (r *postRepository) GetRevisions(ctx context.Context, postID int64) ([]entity.PostRevision, error) {
+	rows, err := conn(ctx, r.pg).Query(ctx, "SELECT id, post_id, content, editor_id, editor_role, created_at FROM post_revisions WHERE post_id = $1 ORDER BY id", postID)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", getRevisionsOp).Int64("post_id", postID).Msg("Failed to get post revisions")
+		return nil, fmt.Errorf("PostRepository - GetRevisions - pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []entity.PostRevision
+	var rev entity.PostRevision
+	for rows.Next() {
+		if err := rows.Scan(&rev.ID, &rev.PostID, &rev.Content, &rev.EditorID, &rev.EditorRole, &rev.CreatedAt); err != nil {
+			r.log.Error().Err(err).Str("op", getRevisionsOp).Int64("post_id", postID).Msg("Failed to scan post revision")
+			return nil, fmt.Errorf("PostRepository - GetRevisions - rows.Next() - rows.Scan(): %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, nil
+}
+
+func (r *postRepository) GetRevision(ctx context.Context, postID int64, revisionID int64) (*entity.PostRevision, error) {
+	row := conn(ctx, r.pg).QueryRow(ctx, "SELECT id, post_id, content, editor_id, editor_role, created_at FROM post_revisions WHERE post_id = $1 AND id = $2", postID, revisionID)
+
+	var rev entity.PostRevision
+	if err := row.Scan(&rev.ID, &rev.PostID, &rev.Content, &rev.EditorID, &rev.EditorRole, &rev.CreatedAt); err != nil {
+		r.log.Error().Err(err).Str("op", getRevisionOp).Int64("post_id", postID).Int64("revision_id", revisionID).Msg("Failed to get post revision")
+		return nil, fmt.Errorf("PostRepository - GetRevision - row.Scan(): %w", err)
+	}
+
+	return &rev, nil
+}
+
+func (r *postRepository) queryTree(ctx context.Context, query string, args ...any) ([]entity.PostNode, error) {
+	rows, err := conn(ctx, r.pg).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []entity.PostNode
+	var n entity.PostNode
+	for rows.Next() {
+		if err := rows.Scan(&n.ID, &n.TopicID, &n.Content, &n.AuthorID, &n.ReplyTo, &n.CreatedAt, &n.UpdatedAt, &n.DeletedAt, &n.Depth, &n.CollapsedCount); err != nil {
+			return nil, fmt.Errorf("rows.Next() - rows.Scan(): %w", err)
+		}
+		nodes = append(nodes, n)
+	}
+
+	return nodes, nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/go-common-forum/postgres"
 	"github.com/pashagolub/pgxmock/v4"
@@ -65,10 +66,10 @@ func TestPostRepository_GetByID(t *testing.T) {
 	id := int64(1)
 	authorID := int64(1)
 
-	expectedPost := &entity.Post{ID: 1, AuthorID: &authorID, Content: "test", ReplyTo: nil, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	expectedPost := &entity.Post{ID: 1, TopicID: 2, AuthorID: &authorID, Content: "test", ReplyTo: nil, CreatedAt: time.Now(), UpdatedAt: time.Now()}
 	t.Run("Success", func(t *testing.T) {
-		row := pgxmock.NewRows([]string{"id", "content", "author_id", "reply_to", "created_at", "updated_at"}).AddRow(expectedPost.ID, expectedPost.Content, expectedPost.AuthorID, expectedPost.ReplyTo, expectedPost.CreatedAt, expectedPost.UpdatedAt)
-		mockPool.ExpectQuery("SELECT id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE id").WithArgs(id).WillReturnRows(row)
+		row := pgxmock.NewRows([]string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at"}).AddRow(expectedPost.ID, expectedPost.TopicID, expectedPost.Content, expectedPost.AuthorID, expectedPost.ReplyTo, expectedPost.CreatedAt, expectedPost.UpdatedAt)
+		mockPool.ExpectQuery("SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE id = \\$1 AND deleted_at IS NULL").WithArgs(id).WillReturnRows(row)
 
 		post, err := repo.GetByID(ctx, id)
 		assert.NoError(t, err)
@@ -78,7 +79,7 @@ func TestPostRepository_GetByID(t *testing.T) {
 
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectQuery("SELECT id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE id").WithArgs(id).WillReturnError(dbErr)
+		mockPool.ExpectQuery("SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE id").WithArgs(id).WillReturnError(dbErr)
 
 		_, err := repo.GetByID(ctx, id)
 		assert.Error(t, err)
@@ -88,6 +89,41 @@ func TestPostRepository_GetByID(t *testing.T) {
 	})
 }
 
+func TestPostRepository_GetByIDForUpdate(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewPostRepository(pg, &logger)
+
+	id := int64(1)
+	authorID := int64(1)
+
+	expectedPost := &entity.Post{ID: 1, TopicID: 2, AuthorID: &authorID, Content: "test", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	t.Run("Success", func(t *testing.T) {
+		row := pgxmock.NewRows([]string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at"}).AddRow(expectedPost.ID, expectedPost.TopicID, expectedPost.Content, expectedPost.AuthorID, expectedPost.ReplyTo, expectedPost.CreatedAt, expectedPost.UpdatedAt)
+		mockPool.ExpectQuery("FROM posts WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE$").WithArgs(id).WillReturnRows(row)
+
+		post, err := repo.GetByIDForUpdate(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedPost, post)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockPool.ExpectQuery("FOR UPDATE").WithArgs(id).WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.GetByIDForUpdate(ctx, id)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.Contains(t, err.Error(), "PostRepository - GetByIDForUpdate - row.Scan()")
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
 func TestPostRepository_GetByTopic(t *testing.T) {
 	ctx := context.Background()
 	logger := zerolog.Nop()
@@ -101,17 +137,32 @@ func TestPostRepository_GetByTopic(t *testing.T) {
 
 	topicID := int64(1)
 	authorID := int64(1)
+	deletedAt := time.Now()
 	expectedPosts := []entity.Post{
 		{ID: 1, TopicID: topicID, Content: "test", AuthorID: &authorID, ReplyTo: nil, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{ID: 2, TopicID: topicID, Content: "test2", AuthorID: &authorID, ReplyTo: nil, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ID: 2, TopicID: topicID, Content: "test2", AuthorID: &authorID, ReplyTo: nil, CreatedAt: time.Now(), UpdatedAt: time.Now(), DeletedAt: &deletedAt},
 	}
+	page := entity.PageRequest{Limit: 20}
 
 	t.Run("Success", func(t *testing.T) {
-		rows := pgxmock.NewRows([]string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at"}).AddRow(expectedPosts[0].ID, expectedPosts[0].TopicID, expectedPosts[0].Content, expectedPosts[0].AuthorID, expectedPosts[0].ReplyTo, expectedPosts[0].CreatedAt, expectedPosts[0].UpdatedAt).
-			AddRow(expectedPosts[1].ID, expectedPosts[1].TopicID, expectedPosts[1].Content, expectedPosts[1].AuthorID, expectedPosts[1].ReplyTo, expectedPosts[1].CreatedAt, expectedPosts[1].UpdatedAt)
-		mockPool.ExpectQuery("SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE topic_id = \\$1 ORDER BY created_at").WithArgs(topicID).WillReturnRows(rows)
+		rows := pgxmock.NewRows([]string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at", "deleted_at"}).AddRow(expectedPosts[0].ID, expectedPosts[0].TopicID, expectedPosts[0].Content, expectedPosts[0].AuthorID, expectedPosts[0].ReplyTo, expectedPosts[0].CreatedAt, expectedPosts[0].UpdatedAt, expectedPosts[0].DeletedAt).
+			AddRow(expectedPosts[1].ID, expectedPosts[1].TopicID, expectedPosts[1].Content, expectedPosts[1].AuthorID, expectedPosts[1].ReplyTo, expectedPosts[1].CreatedAt, expectedPosts[1].UpdatedAt, expectedPosts[1].DeletedAt)
+		mockPool.ExpectQuery("SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at, deleted_at FROM posts WHERE topic_id = \\$1 ORDER BY created_at").WithArgs(topicID, page.Limit).WillReturnRows(rows)
+
+		posts, err := repo.GetByTopic(ctx, topicID, page)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedPosts, posts)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Before cursor", func(t *testing.T) {
+		cursor := &entity.Cursor{CreatedAt: time.Now(), ID: 5}
+		beforePage := entity.PageRequest{Limit: 20, Before: cursor}
+		rows := pgxmock.NewRows([]string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at", "deleted_at"}).AddRow(expectedPosts[1].ID, expectedPosts[1].TopicID, expectedPosts[1].Content, expectedPosts[1].AuthorID, expectedPosts[1].ReplyTo, expectedPosts[1].CreatedAt, expectedPosts[1].UpdatedAt, expectedPosts[1].DeletedAt).
+			AddRow(expectedPosts[0].ID, expectedPosts[0].TopicID, expectedPosts[0].Content, expectedPosts[0].AuthorID, expectedPosts[0].ReplyTo, expectedPosts[0].CreatedAt, expectedPosts[0].UpdatedAt, expectedPosts[0].DeletedAt)
+		mockPool.ExpectQuery("WHERE topic_id = \\$1 AND \\(created_at, id\\) < \\(\\$2, \\$3\\) ORDER BY created_at DESC, id DESC LIMIT \\$4").WithArgs(topicID, cursor.CreatedAt, cursor.ID, beforePage.Limit).WillReturnRows(rows)
 
-		posts, err := repo.GetByTopic(ctx, topicID)
+		posts, err := repo.GetByTopic(ctx, topicID, beforePage)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedPosts, posts)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
@@ -119,9 +170,9 @@ func TestPostRepository_GetByTopic(t *testing.T) {
 
 	t.Run("Query error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectQuery("SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE topic_id = \\$1 ORDER BY created_at").WithArgs(topicID).WillReturnError(dbErr)
+		mockPool.ExpectQuery("SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at, deleted_at FROM posts WHERE topic_id = \\$1 ORDER BY created_at").WithArgs(topicID, page.Limit).WillReturnError(dbErr)
 
-		_, err := repo.GetByTopic(ctx, topicID)
+		_, err := repo.GetByTopic(ctx, topicID, page)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "PostRepository - GetByTopic - pg.Pool.Query")
 		assert.ErrorIs(t, err, dbErr)
@@ -130,11 +181,11 @@ func TestPostRepository_GetByTopic(t *testing.T) {
 
 	t.Run("Scan error", func(t *testing.T) {
 		dbErr := errors.New("scan db error")
-		rows := pgxmock.NewRows([]string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at"}).AddRow(expectedPosts[0].ID, expectedPosts[0].TopicID, expectedPosts[0].Content, expectedPosts[0].AuthorID, expectedPosts[0].ReplyTo, expectedPosts[0].CreatedAt, expectedPosts[0].UpdatedAt).
+		rows := pgxmock.NewRows([]string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at", "deleted_at"}).AddRow(expectedPosts[0].ID, expectedPosts[0].TopicID, expectedPosts[0].Content, expectedPosts[0].AuthorID, expectedPosts[0].ReplyTo, expectedPosts[0].CreatedAt, expectedPosts[0].UpdatedAt, expectedPosts[0].DeletedAt).
 			RowError(0, dbErr)
-		mockPool.ExpectQuery("SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at FROM posts WHERE topic_id = \\$1 ORDER BY created_at").WithArgs(topicID).WillReturnRows(rows)
+		mockPool.ExpectQuery("SELECT id, topic_id, content, author_id, reply_to, created_at, updated_at, deleted_at FROM posts WHERE topic_id = \\$1 ORDER BY created_at").WithArgs(topicID, page.Limit).WillReturnRows(rows)
 
-		_, err := repo.GetByTopic(ctx, topicID)
+		_, err := repo.GetByTopic(ctx, topicID, page)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "PostRepository - GetByTopic - rows.Next() - rows.Scan()")
 		assert.ErrorIs(t, err, dbErr)
@@ -153,24 +204,35 @@ func TestPostRepository_Update(t *testing.T) {
 	pg := postgres.NewWithPool(mockPool)
 	repo := NewPostRepository(pg, &logger)
 
-	expectedSql := "UPDATE posts SET content = \\$1, updated_at = now\\(\\) WHERE id = \\$2"
+	expectedSql := "(?s)updated_at = \\$5\\) FOR UPDATE.*INSERT INTO post_revisions.*UPDATE posts SET content = \\$1, updated_at = now\\(\\) WHERE id = \\(SELECT id FROM old\\)"
 
 	id := int64(1)
 	content := "updated content"
+	editorID := int64(3)
+	editorRole := "admin"
 
 	t.Run("Success", func(t *testing.T) {
-		mockPool.ExpectExec(expectedSql).WithArgs(content, id).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mockPool.ExpectExec(expectedSql).WithArgs(content, id, editorID, editorRole, (*time.Time)(nil)).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := repo.Update(ctx, id, content)
+		err := repo.Update(ctx, id, content, editorID, editorRole, nil)
 		assert.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
 
+	t.Run("Stale version", func(t *testing.T) {
+		version := time.Now()
+		mockPool.ExpectExec(expectedSql).WithArgs(content, id, editorID, editorRole, &version).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.Update(ctx, id, content, editorID, editorRole, &version)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectExec(expectedSql).WithArgs(content, id).WillReturnError(dbErr)
+		mockPool.ExpectExec(expectedSql).WithArgs(content, id, editorID, editorRole, (*time.Time)(nil)).WillReturnError(dbErr)
 
-		err := repo.Update(ctx, id, content)
+		err := repo.Update(ctx, id, content, editorID, editorRole, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "PostRepository - Update - Exec")
 		assert.ErrorIs(t, err, dbErr)
@@ -190,23 +252,304 @@ func TestPostRepository_Delete(t *testing.T) {
 	repo := NewPostRepository(pg, &logger)
 
 	id := int64(1)
+	deletedBy := int64(2)
 
 	t.Run("Success", func(t *testing.T) {
-		mockPool.ExpectExec("DELETE FROM posts WHERE id").WithArgs(id).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mockPool.ExpectExec("UPDATE posts SET deleted_at = now\\(\\), deleted_by = \\$2 WHERE id = \\$1 AND deleted_at IS NULL").WithArgs(id, deletedBy).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := repo.Delete(ctx, id)
+		err := repo.Delete(ctx, id, deletedBy)
 		assert.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
 
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectExec("DELETE FROM posts WHERE id").WithArgs(id).WillReturnError(dbErr)
+		mockPool.ExpectExec("UPDATE posts SET deleted_at").WithArgs(id, deletedBy).WillReturnError(dbErr)
 
-		err := repo.Delete(ctx, id)
+		err := repo.Delete(ctx, id, deletedBy)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "PostRepository - Delete - pg.Pool.Exec()")
 		assert.ErrorIs(t, err, dbErr)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
 }
+
+func TestPostRepository_GetTree(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewPostRepository(pg, &logger)
+
+	topicID := int64(1)
+	rootID := int64(1)
+	page := entity.PageRequest{Limit: 20}
+	maxDepth := 2
+	expectedNodes := []entity.PostNode{
+		{Post: entity.Post{ID: 1, TopicID: topicID, Content: "root", CreatedAt: time.Now(), UpdatedAt: time.Now()}, Depth: 0},
+		{Post: entity.Post{ID: 2, TopicID: topicID, Content: "reply", ReplyTo: &rootID, CreatedAt: time.Now(), UpdatedAt: time.Now()}, Depth: 1},
+	}
+	columns := []string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at", "deleted_at", "depth", "collapsed_count"}
+
+	t.Run("Success", func(t *testing.T) {
+		rows := pgxmock.NewRows(columns)
+		for _, n := range expectedNodes {
+			rows.AddRow(n.ID, n.TopicID, n.Content, n.AuthorID, n.ReplyTo, n.CreatedAt, n.UpdatedAt, n.DeletedAt, n.Depth, n.CollapsedCount)
+		}
+		mockPool.ExpectQuery("WITH RECURSIVE roots AS \\(SELECT id FROM posts WHERE topic_id = \\$1 AND reply_to IS NULL ORDER BY created_at ASC, id ASC LIMIT \\$3\\)").WithArgs(topicID, maxDepth, page.Limit).WillReturnRows(rows)
+
+		nodes, err := repo.GetTree(ctx, topicID, page, maxDepth)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedNodes, nodes)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectQuery("WITH RECURSIVE roots").WithArgs(topicID, maxDepth, page.Limit).WillReturnError(dbErr)
+
+		_, err := repo.GetTree(ctx, topicID, page, maxDepth)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "PostRepository - GetTree - pg.Pool.Query")
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestPostRepository_GetSubtree(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewPostRepository(pg, &logger)
+
+	postID := int64(5)
+	maxDepth := 1
+	columns := []string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at", "deleted_at", "depth", "collapsed_count"}
+
+	t.Run("Success", func(t *testing.T) {
+		expected := []entity.PostNode{{Post: entity.Post{ID: postID, TopicID: 1, Content: "post", CreatedAt: time.Now(), UpdatedAt: time.Now()}, Depth: 0}}
+		rows := pgxmock.NewRows(columns).AddRow(expected[0].ID, expected[0].TopicID, expected[0].Content, expected[0].AuthorID, expected[0].ReplyTo, expected[0].CreatedAt, expected[0].UpdatedAt, expected[0].DeletedAt, 0, int64(0))
+		mockPool.ExpectQuery("FROM posts p JOIN topics t ON t.id = p.topic_id AND t.deleted_at IS NULL WHERE p.id = \\$1").WithArgs(postID, maxDepth).WillReturnRows(rows)
+
+		nodes, err := repo.GetSubtree(ctx, postID, maxDepth)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, nodes)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Scan error", func(t *testing.T) {
+		dbErr := errors.New("scan db error")
+		rows := pgxmock.NewRows(columns).AddRow(postID, int64(1), "post", nil, nil, time.Now(), time.Now(), nil, 0, int64(0)).RowError(0, dbErr)
+		mockPool.ExpectQuery("FROM posts p JOIN topics t ON t.id = p.topic_id AND t.deleted_at IS NULL WHERE p.id = \\$1").WithArgs(postID, maxDepth).WillReturnRows(rows)
+
+		_, err := repo.GetSubtree(ctx, postID, maxDepth)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "PostRepository - GetSubtree - rows.Next() - rows.Scan()")
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Topic deleted", func(t *testing.T) {
+		mockPool.ExpectQuery("FROM posts p JOIN topics t ON t.id = p.topic_id AND t.deleted_at IS NULL WHERE p.id = \\$1").WithArgs(postID, maxDepth).WillReturnRows(pgxmock.NewRows(columns))
+
+		nodes, err := repo.GetSubtree(ctx, postID, maxDepth)
+		assert.NoError(t, err)
+		assert.Empty(t, nodes)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestPostRepository_GetAncestors(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewPostRepository(pg, &logger)
+
+	postID := int64(3)
+	rootID := int64(1)
+	expectedPosts := []entity.Post{
+		{ID: 1, TopicID: 1, Content: "root", CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ID: 2, TopicID: 1, Content: "reply", ReplyTo: &rootID, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		rows := pgxmock.NewRows([]string{"id", "topic_id", "content", "author_id", "reply_to", "created_at", "updated_at", "deleted_at"})
+		for _, p := range expectedPosts {
+			rows.AddRow(p.ID, p.TopicID, p.Content, p.AuthorID, p.ReplyTo, p.CreatedAt, p.UpdatedAt, p.DeletedAt)
+		}
+		mockPool.ExpectQuery("WITH RECURSIVE ancestors .* JOIN topics t ON t.id = p.topic_id AND t.deleted_at IS NULL").WithArgs(postID).WillReturnRows(rows)
+
+		posts, err := repo.GetAncestors(ctx, postID)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedPosts, posts)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectQuery("WITH RECURSIVE ancestors").WithArgs(postID).WillReturnError(dbErr)
+
+		_, err := repo.GetAncestors(ctx, postID)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "PostRepository - GetAncestors - pg.Pool.Query")
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestPostRepository_GetRevisions(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewPostRepository(pg, &logger)
+
+	postID := int64(1)
+	editorID := int64(2)
+	columns := []string{"id", "post_id", "content", "editor_id", "editor_role", "created_at"}
+
+	t.Run("Success", func(t *testing.T) {
+		expected := []entity.PostRevision{
+			{ID: 1, PostID: postID, Content: "v1", EditorID: &editorID, EditorRole: "user", CreatedAt: time.Now()},
+			{ID: 2, PostID: postID, Content: "v2", EditorID: nil, EditorRole: "admin", CreatedAt: time.Now()},
+		}
+		rows := pgxmock.NewRows(columns)
+		for _, rev := range expected {
+			rows.AddRow(rev.ID, rev.PostID, rev.Content, rev.EditorID, rev.EditorRole, rev.CreatedAt)
+		}
+		mockPool.ExpectQuery("SELECT (.+) FROM post_revisions WHERE post_id = \\$1 ORDER BY id").WithArgs(postID).WillReturnRows(rows)
+
+		revisions, err := repo.GetRevisions(ctx, postID)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, revisions)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		dbErr := errors.New("db error")
+		mockPool.ExpectQuery("FROM post_revisions").WithArgs(postID).WillReturnError(dbErr)
+
+		revisions, err := repo.GetRevisions(ctx, postID)
+		assert.ErrorIs(t, err, dbErr)
+		assert.Nil(t, revisions)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestPostRepository_GetRevision(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewPostRepository(pg, &logger)
+
+	postID := int64(1)
+	revisionID := int64(5)
+	columns := []string{"id", "post_id", "content", "editor_id", "editor_role", "created_at"}
+
+	t.Run("Success", func(t *testing.T) {
+		expected := &entity.PostRevision{ID: revisionID, PostID: postID, Content: "old", EditorRole: "user", CreatedAt: time.Now()}
+		rows := pgxmock.NewRows(columns).AddRow(expected.ID, expected.PostID, expected.Content, expected.EditorID, expected.EditorRole, expected.CreatedAt)
+		mockPool.ExpectQuery("FROM post_revisions WHERE post_id = \\$1 AND id = \\$2").WithArgs(postID, revisionID).WillReturnRows(rows)
+
+		revision, err := repo.GetRevision(ctx, postID, revisionID)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, revision)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockPool.ExpectQuery("FROM post_revisions").WithArgs(postID, revisionID).WillReturnError(pgx.ErrNoRows)
+
+		revision, err := repo.GetRevision(ctx, postID, revisionID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.Nil(t, revision)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestPostRepository_Restore(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewPostRepository(pg, &logger)
+
+	id := int64(1)
+	expectedSql := "UPDATE posts SET deleted_at = NULL, deleted_by = NULL WHERE id = \\$1 AND deleted_at IS NOT NULL"
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec(expectedSql).WithArgs(id).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.Restore(ctx, id)
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Not deleted", func(t *testing.T) {
+		mockPool.ExpectExec(expectedSql).WithArgs(id).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.Restore(ctx, id)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestPostRepository_PurgeDeleted(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewPostRepository(pg, &logger)
+
+	before := time.Now().Add(-time.Hour)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec("DELETE FROM posts WHERE deleted_at < \\$1").WithArgs(before).WillReturnResult(pgxmock.NewResult("DELETE", 4))
+
+		purged, err := repo.PurgeDeleted(ctx, before)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), purged)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectExec("DELETE FROM posts WHERE deleted_at").WithArgs(before).WillReturnError(dbErr)
+
+		purged, err := repo.PurgeDeleted(ctx, before)
+		assert.ErrorIs(t, err, dbErr)
+		assert.Zero(t, purged)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
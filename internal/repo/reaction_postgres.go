@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/rs/zerolog"
+)
+
+type reactionRepository struct {
+	pg  *postgres.Postgres
+	log *zerolog.Logger
+}
+
+const (
+	addReactionOp     = "ReactionRepository.Add"
+	removeReactionOp  = "ReactionRepository.Remove"
+	getByPostsReactOp = "ReactionRepository.GetByPosts"
+)
+
+func NewReactionRepository(pg *postgres.Postgres, log *zerolog.Logger) ReactionRepository {
+	return &reactionRepository{pg, log}
+}
+
+func (r *reactionRepository) Add(ctx context.Context, postID int64, userID int64, reaction string) error {
+	if _, err := conn(ctx, r.pg).Exec(ctx, "INSERT INTO post_reactions (post_id, user_id, reaction) VALUES($1, $2, $3) ON CONFLICT DO NOTHING", postID, userID, reaction); err != nil {
+		r.log.Error().Err(err).Str("op", addReactionOp).Int64("post_id", postID).Int64("user_id", userID).Str("reaction", reaction).Msg("Failed to add reaction")
+		return fmt.Errorf("ReactionRepository - Add - pg.Pool.Exec(): %w", err)
+	}
+	return nil
+}
+
+func (r *reactionRepository) Remove(ctx context.Context, postID int64, userID int64, reaction string) error {
+	if _, err := conn(ctx, r.pg).Exec(ctx, "DELETE FROM post_reactions WHERE post_id = $1 AND user_id = $2 AND reaction = $3", postID, userID, reaction); err != nil {
+		r.log.Error().Err(err).Str("op", removeReactionOp).Int64("post_id", postID).Int64("user_id", userID).Str("reaction", reaction).Msg("Failed to remove reaction")
+		return fmt.Errorf("ReactionRepository - Remove - pg.Pool.Exec(): %w", err)
+	}
+	return nil
+}
+
+// GetByPosts loads reaction counts for a whole page of posts in one query.
+func (r *reactionRepository) GetByPosts(ctx context.Context, postIDs []int64, userID int64) ([]entity.ReactionCount, error) {
+	rows, err := conn(ctx, r.pg).Query(ctx, `
+	SELECT post_id, reaction, count(*), bool_or(user_id = $2)
+	FROM post_reactions
+	WHERE post_id = ANY($1)
+	GROUP BY post_id, reaction
+	ORDER BY post_id, reaction`, postIDs, userID)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", getByPostsReactOp).Msg("Failed to get reactions")
+		return nil, fmt.Errorf("ReactionRepository - GetByPosts - pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []entity.ReactionCount
+	var rc entity.ReactionCount
+	for rows.Next() {
+		if err := rows.Scan(&rc.PostID, &rc.Reaction, &rc.Count, &rc.ReactedByUser); err != nil {
+			r.log.Error().Err(err).Str("op", getByPostsReactOp).Msg("Failed to scan reaction")
+			return nil, fmt.Errorf("ReactionRepository - GetByPosts - rows.Next() - rows.Scan(): %w", err)
+		}
+		counts = append(counts, rc)
+	}
+
+	return counts, nil
+}
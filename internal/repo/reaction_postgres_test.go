@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactionRepository_Add(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewReactionRepository(pg, &logger)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec("INSERT INTO post_reactions").WithArgs(int64(1), int64(2), "like").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		err := repo.Add(ctx, 1, 2, "like")
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Already reacted", func(t *testing.T) {
+		mockPool.ExpectExec("INSERT INTO post_reactions").WithArgs(int64(1), int64(2), "like").WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+		err := repo.Add(ctx, 1, 2, "like")
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Exec error", func(t *testing.T) {
+		dbErr := errors.New("db error")
+		mockPool.ExpectExec("INSERT INTO post_reactions").WithArgs(int64(1), int64(2), "like").WillReturnError(dbErr)
+
+		err := repo.Add(ctx, 1, 2, "like")
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestReactionRepository_Remove(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewReactionRepository(pg, &logger)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec("DELETE FROM post_reactions").WithArgs(int64(1), int64(2), "like").WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+		err := repo.Remove(ctx, 1, 2, "like")
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Exec error", func(t *testing.T) {
+		dbErr := errors.New("db error")
+		mockPool.ExpectExec("DELETE FROM post_reactions").WithArgs(int64(1), int64(2), "like").WillReturnError(dbErr)
+
+		err := repo.Remove(ctx, 1, 2, "like")
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestReactionRepository_GetByPosts(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewReactionRepository(pg, &logger)
+
+	postIDs := []int64{1, 2}
+	userID := int64(5)
+	columns := []string{"post_id", "reaction", "count", "bool_or"}
+
+	t.Run("Success", func(t *testing.T) {
+		expected := []entity.ReactionCount{
+			{PostID: 1, Reaction: "like", Count: 3, ReactedByUser: true},
+			{PostID: 2, Reaction: "fire", Count: 1, ReactedByUser: false},
+		}
+		rows := pgxmock.NewRows(columns)
+		for _, rc := range expected {
+			rows.AddRow(rc.PostID, rc.Reaction, rc.Count, rc.ReactedByUser)
+		}
+		mockPool.ExpectQuery("FROM post_reactions").WithArgs(postIDs, userID).WillReturnRows(rows)
+
+		counts, err := repo.GetByPosts(ctx, postIDs, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, counts)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		dbErr := errors.New("db error")
+		mockPool.ExpectQuery("FROM post_reactions").WithArgs(postIDs, userID).WillReturnError(dbErr)
+
+		counts, err := repo.GetByPosts(ctx, postIDs, userID)
+		assert.ErrorIs(t, err, dbErr)
+		assert.Nil(t, counts)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
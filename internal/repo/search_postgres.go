@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/rs/zerolog"
+)
+
+type searchRepository struct {
+	pg  *postgres.Postgres
+	log *zerolog.Logger
+}
+
+const searchOp = "SearchRepository.Search"
+
+// Headlines are built only for the selected page, ts_headline is too expensive to run on every match.
+// The body is HTML-escaped before it, so the <mark> tags are the only markup in a snippet.
+// Matches in hidden categories and their subcategories are dropped unless $8 is set.
+const searchQuery = `
+WITH RECURSIVE hidden_categories AS (
+	SELECT id FROM categories WHERE hidden
+	UNION
+	SELECT c.id FROM categories c JOIN hidden_categories h ON c.parent_id = h.id
+),
+query AS (
+	SELECT websearch_to_tsquery('russian', $1) AS q
+),
+matches AS (
+	SELECT 'topic' AS type, t.id, t.id AS topic_id, t.category_id, t.title AS topic_title, t.author_id, t.title AS body, ts_rank(t.search_vector, query.q) AS rank, t.created_at
+	FROM topics t, query
+	WHERE t.search_vector @@ query.q AND t.deleted_at IS NULL
+	UNION ALL
+	SELECT 'post' AS type, p.id, p.topic_id, t.category_id, t.title AS topic_title, p.author_id, p.content AS body, ts_rank(p.search_vector, query.q) AS rank, p.created_at
+	FROM posts p JOIN topics t ON t.id = p.topic_id, query
+	WHERE p.search_vector @@ query.q AND p.deleted_at IS NULL AND t.deleted_at IS NULL
+),
+page AS (
+	SELECT * FROM matches
+	WHERE ($2::bigint IS NULL OR category_id = $2)
+		AND ($3::bigint IS NULL OR author_id = $3)
+		AND ($4::timestamptz IS NULL OR created_at >= $4)
+		AND ($5::timestamptz IS NULL OR created_at <= $5)
+		AND ($8 OR category_id NOT IN (SELECT id FROM hidden_categories))
+	ORDER BY rank DESC, created_at DESC, id DESC
+	LIMIT $6 OFFSET $7
+)
+SELECT page.type, page.id, page.topic_id, page.category_id, page.topic_title, page.author_id,
+	ts_headline('russian', replace(replace(replace(page.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), query.q, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10'),
+	page.rank, page.created_at
+FROM page, query
+ORDER BY page.rank DESC, page.created_at DESC, page.id DESC`
+
+func NewSearchRepository(pg *postgres.Postgres, log *zerolog.Logger) SearchRepository {
+	return &searchRepository{pg, log}
+}
+
+func (r *searchRepository) Search(ctx context.Context, query entity.SearchQuery) ([]entity.SearchResult, error) {
+	rows, err := conn(ctx, r.pg).Query(ctx, searchQuery, query.Query, query.CategoryID, query.AuthorID, query.From, query.To, query.Limit, query.Offset, query.IncludeHidden)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", searchOp).Str("query", query.Query).Msg("Failed to search")
+		return nil, fmt.Errorf("SearchRepository - Search - pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var results []entity.SearchResult
+	var s entity.SearchResult
+	for rows.Next() {
+		err := rows.Scan(&s.Type, &s.ID, &s.TopicID, &s.CategoryID, &s.TopicTitle, &s.AuthorID, &s.Snippet, &s.Rank, &s.CreatedAt)
+		if err != nil {
+			r.log.Error().Err(err).Str("op", searchOp).Str("query", query.Query).Msg("Failed to scan search result")
+			return nil, fmt.Errorf("SearchRepository - Search - rows.Next() - rows.Scan(): %w", err)
+		}
+		results = append(results, s)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchRepository_Search(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewSearchRepository(pg, &logger)
+
+	categoryID := int64(3)
+	authorID := int64(1)
+	query := entity.SearchQuery{Query: "экзамен", CategoryID: &categoryID, Limit: 20}
+	expectedResults := []entity.SearchResult{
+		{Type: entity.SearchResultTopic, ID: 1, TopicID: 1, CategoryID: categoryID, TopicTitle: "Экзамен по матану", AuthorID: &authorID, Snippet: "<mark>Экзамен</mark> по матану", Rank: 0.6, CreatedAt: time.Now()},
+		{Type: entity.SearchResultPost, ID: 7, TopicID: 1, CategoryID: categoryID, TopicTitle: "Экзамен по матану", AuthorID: nil, Snippet: "&lt;script&gt;alert(1)&lt;/script&gt; когда <mark>экзамен</mark>?", Rank: 0.3, CreatedAt: time.Now()},
+	}
+	columns := []string{"type", "id", "topic_id", "category_id", "topic_title", "author_id", "snippet", "rank", "created_at"}
+
+	t.Run("Success", func(t *testing.T) {
+		rows := pgxmock.NewRows(columns)
+		for _, r := range expectedResults {
+			rows.AddRow(r.Type, r.ID, r.TopicID, r.CategoryID, r.TopicTitle, r.AuthorID, r.Snippet, r.Rank, r.CreatedAt)
+		}
+		mockPool.ExpectQuery("websearch_to_tsquery\\('russian', \\$1\\).* AND \\(\\$8 OR category_id NOT IN \\(SELECT id FROM hidden_categories\\)\\).*ts_headline\\('russian', replace\\(replace\\(replace\\(page.body, '&', '&amp;'\\), '<', '&lt;'\\), '>', '&gt;'\\), query.q").WithArgs(query.Query, query.CategoryID, query.AuthorID, query.From, query.To, query.Limit, query.Offset, query.IncludeHidden).WillReturnRows(rows)
+
+		results, err := repo.Search(ctx, query)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedResults, results)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectQuery("websearch_to_tsquery").WithArgs(query.Query, query.CategoryID, query.AuthorID, query.From, query.To, query.Limit, query.Offset, query.IncludeHidden).WillReturnError(dbErr)
+
+		_, err := repo.Search(ctx, query)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "SearchRepository - Search - pg.Pool.Query")
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Scan error", func(t *testing.T) {
+		dbErr := errors.New("scan db error")
+		r := expectedResults[0]
+		rows := pgxmock.NewRows(columns).AddRow(r.Type, r.ID, r.TopicID, r.CategoryID, r.TopicTitle, r.AuthorID, r.Snippet, r.Rank, r.CreatedAt).RowError(0, dbErr)
+		mockPool.ExpectQuery("websearch_to_tsquery").WithArgs(query.Query, query.CategoryID, query.AuthorID, query.From, query.To, query.Limit, query.Offset, query.IncludeHidden).WillReturnRows(rows)
+
+		_, err := repo.Search(ctx, query)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "SearchRepository - Search - rows.Next() - rows.Scan()")
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
@@ -3,7 +3,10 @@ package repo
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/go-common-forum/postgres"
 	"github.com/rs/zerolog"
@@ -17,18 +20,28 @@ type topicRepository struct {
 const (
 	createTopicOp   = "TopicRepository.Create"
 	getByIdTopicOp  = "TopicRepository.GetById"
+	lockTopicOp     = "TopicRepository.GetByIDForUpdate"
 	getByCategoryOp = "TopicRepository.GetAll"
 	deleteTopicOp   = "TopicRepository.Delete"
 	updateTopicOp   = "TopicRepository.Update"
 	countTopicOp    = "TopicRepository.CountByCategory"
+	restoreTopicOp  = "TopicRepository.Restore"
+	updateStateOp   = "TopicRepository.UpdateState"
+	purgeTopicsOp   = "TopicRepository.PurgeDeleted"
 )
 
+// topicColumns selects a topic "t" with its reply stats; lp is the topic's last post.
+const topicColumns = `t.id, t.category_id, t.title, t.author_id, t.pinned, t.locked, t.created_at, t.updated_at,
+	t.reply_count, t.last_activity_at, lp.created_at, lp.author_id`
+
+const topicByIDQuery = "SELECT " + topicColumns + " FROM topics t LEFT JOIN posts lp ON lp.id = t.last_post_id WHERE t.id = $1 AND t.deleted_at IS NULL"
+
 func NewTopicRepository(pg *postgres.Postgres, log *zerolog.Logger) TopicRepository {
 	return &topicRepository{pg, log}
 }
 
 func (r *topicRepository) Create(ctx context.Context, topic entity.Topic) (int64, error) {
-	row := r.pg.Pool.QueryRow(ctx, "INSERT INTO topics (category_id, title, author_id) VALUES($1, $2, $3) RETURNING id", topic.CategoryID, topic.Title, topic.AuthorID)
+	row := conn(ctx, r.pg).QueryRow(ctx, "INSERT INTO topics (category_id, title, author_id) VALUES($1, $2, $3) RETURNING id", topic.CategoryID, topic.Title, topic.AuthorID)
 	var id int64
 	if err := row.Scan(&id); err != nil {
 		r.log.Error().Err(err).Str("op", createTopicOp).Any("topic", topic).Msg("Failed to insert topic")
@@ -39,10 +52,10 @@ func (r *topicRepository) Create(ctx context.Context, topic entity.Topic) (int64
 }
 
 func (r *topicRepository) GetByID(ctx context.Context, id int64) (*entity.Topic, error) {
-	row := r.pg.Pool.QueryRow(ctx, "SELECT id, category_id, title, author_id, created_at, updated_at FROM topics WHERE id = $1", id)
+	row := conn(ctx, r.pg).QueryRow(ctx, topicByIDQuery, id)
 
-	var t entity.Topic
-	if err := row.Scan(&t.ID, &t.CategoryID, &t.Title, &t.AuthorID, &t.CreatedAt, &t.UpdatedAt); err != nil {
+	t, err := scanTopic(row)
+	if err != nil {
 		r.log.Error().Err(err).Str("op", getByIdTopicOp).Int64("id", id).Msg("Failed to get topic")
 		return nil, fmt.Errorf("TopicRepository - GetByID - row.Scan(): %w", err)
 	}
@@ -50,8 +63,52 @@ func (r *topicRepository) GetByID(ctx context.Context, id int64) (*entity.Topic,
 	return &t, nil
 }
 
-func (r *topicRepository) GetByCategory(ctx context.Context, categoryID int64) ([]entity.Topic, error) {
-	rows, err := r.pg.Pool.Query(ctx, "SELECT id, category_id, title, author_id, created_at, updated_at FROM topics WHERE category_id = $1 ORDER BY created_at DESC", categoryID)
+// GetByIDForUpdate is GetByID that also locks the topic row until the surrounding
+// transaction ends, so a concurrent writer cannot change it between a check and a write.
+func (r *topicRepository) GetByIDForUpdate(ctx context.Context, id int64) (*entity.Topic, error) {
+	row := conn(ctx, r.pg).QueryRow(ctx, topicByIDQuery+" FOR UPDATE OF t", id)
+
+	t, err := scanTopic(row)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", lockTopicOp).Int64("id", id).Msg("Failed to get topic for update")
+		return nil, fmt.Errorf("TopicRepository - GetByIDForUpdate - row.Scan(): %w", err)
+	}
+
+	return &t, nil
+}
+
+// GetByCategory lists topics of a category in the given sort order. The reply counters and
+// last post reference are maintained by triggers on posts, so no posts are counted here.
+func (r *topicRepository) GetByCategory(ctx context.Context, categoryID int64, sort entity.TopicSort, page entity.PageRequest) ([]entity.Topic, error) {
+	query := "SELECT " + topicColumns + " FROM topics t LEFT JOIN posts lp ON lp.id = t.last_post_id WHERE t.category_id = $1 AND t.deleted_at IS NULL"
+	args := []any{categoryID}
+	order := "DESC"
+
+	sortColumn := "t.created_at"
+	cursorValue := func(c *entity.Cursor) any { return c.CreatedAt }
+	switch sort {
+	case entity.TopicSortLatestActivity:
+		sortColumn = "t.last_activity_at"
+	case entity.TopicSortMostReplies:
+		sortColumn = "t.reply_count"
+		cursorValue = func(c *entity.Cursor) any { return c.Replies }
+	}
+
+	// Pinned topics go first, pinned is part of the sort key so cursors keep working across the boundary.
+	switch {
+	case page.After != nil:
+		query += fmt.Sprintf(" AND (t.pinned, %s, t.id) < ($2, $3, $4)", sortColumn)
+		args = append(args, page.After.Pinned, cursorValue(page.After), page.After.ID)
+	case page.Before != nil:
+		query += fmt.Sprintf(" AND (t.pinned, %s, t.id) > ($2, $3, $4)", sortColumn)
+		args = append(args, page.Before.Pinned, cursorValue(page.Before), page.Before.ID)
+		order = "ASC"
+	}
+
+	query += fmt.Sprintf(" ORDER BY t.pinned %s, %s %s, t.id %s LIMIT $%d", order, sortColumn, order, order, len(args)+1)
+	args = append(args, page.Limit)
+
+	rows, err := conn(ctx, r.pg).Query(ctx, query, args...)
 	if err != nil {
 		r.log.Error().Err(err).Str("op", getByCategoryOp).Int64("category_id", categoryID).Msg("Failed to get topics")
 		return nil, fmt.Errorf("TopicRepository - GetByCategory - pg.Pool.Query: %w", err)
@@ -59,9 +116,8 @@ func (r *topicRepository) GetByCategory(ctx context.Context, categoryID int64) (
 	defer rows.Close()
 
 	var topics []entity.Topic
-	var t entity.Topic
 	for rows.Next() {
-		err := rows.Scan(&t.ID, &t.CategoryID, &t.Title, &t.AuthorID, &t.CreatedAt, &t.UpdatedAt)
+		t, err := scanTopic(rows)
 		if err != nil {
 			r.log.Error().Err(err).Str("op", getByCategoryOp).Int64("category_id", categoryID).Msg("Failed to scan topic")
 			return nil, fmt.Errorf("TopicRepository - GetByCategory - rows.Next() - rows.Scan(): %w", err)
@@ -69,21 +125,76 @@ func (r *topicRepository) GetByCategory(ctx context.Context, categoryID int64) (
 		topics = append(topics, t)
 	}
 
+	if page.Before != nil {
+		slices.Reverse(topics)
+	}
+
 	return topics, nil
 }
 
-func (r *topicRepository) Update(ctx context.Context, id int64, title string) error {
-	if _, err := r.pg.Pool.Exec(ctx, "UPDATE topics SET title = $1, updated_at = now() WHERE id = $2", title, id); err != nil {
+// Update renames a topic. A non-nil version makes the update conditional on updated_at
+// still being equal to it. Returns pgx.ErrNoRows if no topic was updated.
+func (r *topicRepository) Update(ctx context.Context, id int64, title string, version *time.Time) error {
+	tag, err := conn(ctx, r.pg).Exec(ctx, "UPDATE topics SET title = $1, updated_at = now() WHERE id = $2 AND ($3::timestamptz IS NULL OR updated_at = $3)", title, id, version)
+	if err != nil {
 		r.log.Error().Err(err).Str("op", updateTopicOp).Int64("id", id).Msg("Failed to update topic")
 		return fmt.Errorf("TopicRepository - Update - Exec: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("TopicRepository - Update: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+// UpdateState changes the pinned and locked flags, nil values are left as they are.
+// Returns pgx.ErrNoRows if the topic does not exist.
+func (r *topicRepository) UpdateState(ctx context.Context, id int64, pinned *bool, locked *bool) error {
+	tag, err := conn(ctx, r.pg).Exec(ctx, `UPDATE topics SET pinned = COALESCE($2, pinned), locked = COALESCE($3, locked) WHERE id = $1 AND deleted_at IS NULL`, id, pinned, locked)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", updateStateOp).Int64("id", id).Msg("Failed to update topic state")
+		return fmt.Errorf("TopicRepository - UpdateState - pg.Pool.Exec(): %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("TopicRepository - UpdateState: %w", pgx.ErrNoRows)
+	}
 	return nil
 }
 
-func (r *topicRepository) Delete(ctx context.Context, id int64) error {
-	if _, err := r.pg.Pool.Exec(ctx, `DELETE FROM topics WHERE id = $1`, id); err != nil {
+// Delete only marks the topic as deleted, its posts stay untouched so the whole
+// discussion comes back on Restore. The rows are removed for good by PurgeDeleted.
+func (r *topicRepository) Delete(ctx context.Context, id int64, deletedBy int64) error {
+	if _, err := conn(ctx, r.pg).Exec(ctx, `UPDATE topics SET deleted_at = now(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL`, id, deletedBy); err != nil {
 		r.log.Error().Err(err).Str("op", deleteTopicOp).Int64("id", id).Msg("Failed to delete topic")
 		return fmt.Errorf("TopicRepository - Delete - pg.Pool.Exec(): %w", err)
 	}
 	return nil
 }
+
+// Restore returns pgx.ErrNoRows if there is no deleted topic with the given id.
+func (r *topicRepository) Restore(ctx context.Context, id int64) error {
+	tag, err := conn(ctx, r.pg).Exec(ctx, `UPDATE topics SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", restoreTopicOp).Int64("id", id).Msg("Failed to restore topic")
+		return fmt.Errorf("TopicRepository - Restore - pg.Pool.Exec(): %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("TopicRepository - Restore: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *topicRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := conn(ctx, r.pg).Exec(ctx, `DELETE FROM topics WHERE deleted_at < $1`, before)
+	if err != nil {
+		r.log.Error().Err(err).Str("op", purgeTopicsOp).Time("before", before).Msg("Failed to purge topics")
+		return 0, fmt.Errorf("TopicRepository - PurgeDeleted - pg.Pool.Exec(): %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func scanTopic(row pgx.Row) (entity.Topic, error) {
+	var t entity.Topic
+	err := row.Scan(&t.ID, &t.CategoryID, &t.Title, &t.AuthorID, &t.Pinned, &t.Locked, &t.CreatedAt, &t.UpdatedAt,
+		&t.ReplyCount, &t.LastActivityAt, &t.LastPostAt, &t.LastPostAuthorID)
+	return t, err
+}
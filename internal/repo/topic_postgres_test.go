@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/go-common-forum/postgres"
 	"github.com/pashagolub/pgxmock/v4"
@@ -51,6 +52,14 @@ func TestTopicRepository_Create(t *testing.T) {
 	})
 }
 
+var topicRowColumns = []string{"id", "category_id", "title", "author_id", "pinned", "locked", "created_at", "updated_at",
+	"reply_count", "last_activity_at", "last_post_at", "last_post_author_id"}
+
+func addTopicRow(rows *pgxmock.Rows, t entity.Topic) *pgxmock.Rows {
+	return rows.AddRow(t.ID, t.CategoryID, t.Title, t.AuthorID, t.Pinned, t.Locked, t.CreatedAt, t.UpdatedAt,
+		t.ReplyCount, t.LastActivityAt, t.LastPostAt, t.LastPostAuthorID)
+}
+
 func TestTopicRepository_GetByID(t *testing.T) {
 	ctx := context.Background()
 	logger := zerolog.Nop()
@@ -64,12 +73,14 @@ func TestTopicRepository_GetByID(t *testing.T) {
 
 	id := int64(1)
 	authorID := int64(1)
+	lastPostAt := time.Now()
 
-	expectedTopic := &entity.Topic{ID: id, CategoryID: 1, Title: "test", AuthorID: &authorID, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	expectedTopic := &entity.Topic{ID: id, CategoryID: 1, Title: "test", AuthorID: &authorID, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		ReplyCount: 3, LastActivityAt: lastPostAt, LastPostAt: &lastPostAt, LastPostAuthorID: &authorID}
 
 	t.Run("Success", func(t *testing.T) {
-		row := pgxmock.NewRows([]string{"id", "category_id", "title", "author_id", "created_at", "updated_at"}).AddRow(expectedTopic.ID, expectedTopic.CategoryID, expectedTopic.Title, expectedTopic.AuthorID, expectedTopic.CreatedAt, expectedTopic.UpdatedAt)
-		mockPool.ExpectQuery("SELECT id, category_id, title, author_id, created_at, updated_at FROM topics WHERE id").WithArgs(id).WillReturnRows(row)
+		row := addTopicRow(pgxmock.NewRows(topicRowColumns), *expectedTopic)
+		mockPool.ExpectQuery("SELECT t.id, t.category_id, .* FROM topics t LEFT JOIN posts lp ON lp.id = t.last_post_id WHERE t.id = \\$1 AND t.deleted_at IS NULL").WithArgs(id).WillReturnRows(row)
 
 		topic, err := repo.GetByID(ctx, id)
 		assert.NoError(t, err)
@@ -79,7 +90,7 @@ func TestTopicRepository_GetByID(t *testing.T) {
 
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectQuery("SELECT id, category_id, title, author_id, created_at, updated_at FROM topics WHERE id").WithArgs(id).WillReturnError(dbErr)
+		mockPool.ExpectQuery("FROM topics t LEFT JOIN posts lp ON lp.id = t.last_post_id WHERE t.id").WithArgs(id).WillReturnError(dbErr)
 
 		_, err := repo.GetByID(ctx, id)
 		assert.Error(t, err)
@@ -89,6 +100,41 @@ func TestTopicRepository_GetByID(t *testing.T) {
 	})
 }
 
+func TestTopicRepository_GetByIDForUpdate(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewTopicRepository(pg, &logger)
+
+	id := int64(1)
+	authorID := int64(1)
+	expectedTopic := &entity.Topic{ID: id, CategoryID: 1, Title: "test", AuthorID: &authorID, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+
+	t.Run("Success", func(t *testing.T) {
+		row := addTopicRow(pgxmock.NewRows(topicRowColumns), *expectedTopic)
+		mockPool.ExpectQuery("FROM topics t LEFT JOIN posts lp ON lp.id = t.last_post_id WHERE t.id = \\$1 AND t.deleted_at IS NULL FOR UPDATE OF t$").WithArgs(id).WillReturnRows(row)
+
+		topic, err := repo.GetByIDForUpdate(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTopic, topic)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockPool.ExpectQuery("FOR UPDATE OF t").WithArgs(id).WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.GetByIDForUpdate(ctx, id)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.Contains(t, err.Error(), "TopicRepository - GetByIDForUpdate - row.Scan()")
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
 func TestTopicRepository_GetByCategory(t *testing.T) {
 	ctx := context.Background()
 	logger := zerolog.Nop()
@@ -102,27 +148,78 @@ func TestTopicRepository_GetByCategory(t *testing.T) {
 
 	categoryID := int64(1)
 	authorID := int64(1)
+	lastPostAt := time.Now()
 	expectedTopics := []entity.Topic{
-		{ID: 1, CategoryID: categoryID, Title: "test", AuthorID: &authorID, CreatedAt: time.Now(), UpdatedAt: time.Now()},
-		{ID: 2, CategoryID: categoryID, Title: "test2", AuthorID: &authorID, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ID: 1, CategoryID: categoryID, Title: "test", AuthorID: &authorID, CreatedAt: time.Now(), UpdatedAt: time.Now(), LastActivityAt: time.Now()},
+		{ID: 2, CategoryID: categoryID, Title: "test2", AuthorID: &authorID, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+			ReplyCount: 1, LastActivityAt: lastPostAt, LastPostAt: &lastPostAt, LastPostAuthorID: &authorID},
 	}
+	page := entity.PageRequest{Limit: 20}
+	listSql := "SELECT t.id, .* FROM topics t LEFT JOIN posts lp ON lp.id = t.last_post_id WHERE t.category_id"
 
 	t.Run("Success", func(t *testing.T) {
-		rows := pgxmock.NewRows([]string{"id", "category_id", "title", "author_id", "created_at", "updated_at"}).AddRow(expectedTopics[0].ID, expectedTopics[0].CategoryID, expectedTopics[0].Title, expectedTopics[0].AuthorID, expectedTopics[0].CreatedAt, expectedTopics[0].UpdatedAt).
-			AddRow(expectedTopics[1].ID, expectedTopics[1].CategoryID, expectedTopics[1].Title, expectedTopics[1].AuthorID, expectedTopics[1].CreatedAt, expectedTopics[1].UpdatedAt)
-		mockPool.ExpectQuery("SELECT id, category_id, title, author_id, created_at, updated_at FROM topics WHERE category_id").WithArgs(categoryID).WillReturnRows(rows)
+		rows := addTopicRow(addTopicRow(pgxmock.NewRows(topicRowColumns), expectedTopics[0]), expectedTopics[1])
+		mockPool.ExpectQuery(listSql+" = \\$1 AND t.deleted_at IS NULL ORDER BY t.pinned DESC, t.created_at DESC, t.id DESC LIMIT \\$2").WithArgs(categoryID, page.Limit).WillReturnRows(rows)
 
-		topics, err := repo.GetByCategory(ctx, categoryID)
+		topics, err := repo.GetByCategory(ctx, categoryID, entity.TopicSortNewest, page)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedTopics, topics)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
 
+	t.Run("After cursor", func(t *testing.T) {
+		cursor := &entity.Cursor{CreatedAt: time.Now(), ID: 5}
+		afterPage := entity.PageRequest{Limit: 20, After: cursor}
+		rows := addTopicRow(pgxmock.NewRows(topicRowColumns), expectedTopics[0])
+		mockPool.ExpectQuery("WHERE t.category_id = \\$1 AND t.deleted_at IS NULL AND \\(t.pinned, t.created_at, t.id\\) < \\(\\$2, \\$3, \\$4\\) ORDER BY t.pinned DESC, t.created_at DESC, t.id DESC LIMIT \\$5").WithArgs(categoryID, cursor.Pinned, cursor.CreatedAt, cursor.ID, afterPage.Limit).WillReturnRows(rows)
+
+		topics, err := repo.GetByCategory(ctx, categoryID, entity.TopicSortNewest, afterPage)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTopics[:1], topics)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Before cursor", func(t *testing.T) {
+		cursor := &entity.Cursor{CreatedAt: time.Now(), ID: 5}
+		beforePage := entity.PageRequest{Limit: 20, Before: cursor}
+		rows := addTopicRow(addTopicRow(pgxmock.NewRows(topicRowColumns), expectedTopics[1]), expectedTopics[0])
+		mockPool.ExpectQuery("WHERE t.category_id = \\$1 AND t.deleted_at IS NULL AND \\(t.pinned, t.created_at, t.id\\) > \\(\\$2, \\$3, \\$4\\) ORDER BY t.pinned ASC, t.created_at ASC, t.id ASC LIMIT \\$5").WithArgs(categoryID, cursor.Pinned, cursor.CreatedAt, cursor.ID, beforePage.Limit).WillReturnRows(rows)
+
+		topics, err := repo.GetByCategory(ctx, categoryID, entity.TopicSortNewest, beforePage)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTopics, topics)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Latest activity", func(t *testing.T) {
+		cursor := &entity.Cursor{CreatedAt: time.Now(), ID: 5}
+		afterPage := entity.PageRequest{Limit: 20, After: cursor}
+		rows := addTopicRow(pgxmock.NewRows(topicRowColumns), expectedTopics[1])
+		mockPool.ExpectQuery("AND \\(t.pinned, t.last_activity_at, t.id\\) < \\(\\$2, \\$3, \\$4\\) ORDER BY t.pinned DESC, t.last_activity_at DESC, t.id DESC").WithArgs(categoryID, cursor.Pinned, cursor.CreatedAt, cursor.ID, afterPage.Limit).WillReturnRows(rows)
+
+		topics, err := repo.GetByCategory(ctx, categoryID, entity.TopicSortLatestActivity, afterPage)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTopics[1:], topics)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Most replies", func(t *testing.T) {
+		cursor := &entity.Cursor{Replies: 4, ID: 5}
+		afterPage := entity.PageRequest{Limit: 20, After: cursor}
+		rows := addTopicRow(pgxmock.NewRows(topicRowColumns), expectedTopics[1])
+		mockPool.ExpectQuery("AND \\(t.pinned, t.reply_count, t.id\\) < \\(\\$2, \\$3, \\$4\\) ORDER BY t.pinned DESC, t.reply_count DESC, t.id DESC").WithArgs(categoryID, cursor.Pinned, cursor.Replies, cursor.ID, afterPage.Limit).WillReturnRows(rows)
+
+		topics, err := repo.GetByCategory(ctx, categoryID, entity.TopicSortMostReplies, afterPage)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTopics[1:], topics)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
 	t.Run("Query error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectQuery("SELECT id, category_id, title, author_id, created_at, updated_at FROM topics WHERE category_id").WithArgs(categoryID).WillReturnError(dbErr)
+		mockPool.ExpectQuery(listSql).WithArgs(categoryID, page.Limit).WillReturnError(dbErr)
 
-		_, err := repo.GetByCategory(ctx, categoryID)
+		_, err := repo.GetByCategory(ctx, categoryID, entity.TopicSortNewest, page)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "TopicRepository - GetByCategory - pg.Pool.Query")
 		assert.ErrorIs(t, err, dbErr)
@@ -132,11 +229,10 @@ func TestTopicRepository_GetByCategory(t *testing.T) {
 
 	t.Run("Scan error", func(t *testing.T) {
 		dbErr := errors.New("scan db error")
-		rows := pgxmock.NewRows([]string{"id", "category_id", "title", "author_id", "created_at", "updated_at"}).AddRow(expectedTopics[0].ID, expectedTopics[0].CategoryID, expectedTopics[0].Title, expectedTopics[0].AuthorID, expectedTopics[0].CreatedAt, expectedTopics[0].UpdatedAt).
-			RowError(0, dbErr)
-		mockPool.ExpectQuery("SELECT id, category_id, title, author_id, created_at, updated_at FROM topics WHERE category_id").WithArgs(categoryID).WillReturnRows(rows)
+		rows := addTopicRow(pgxmock.NewRows(topicRowColumns), expectedTopics[0]).RowError(0, dbErr)
+		mockPool.ExpectQuery(listSql).WithArgs(categoryID, page.Limit).WillReturnRows(rows)
 
-		_, err := repo.GetByCategory(ctx, categoryID)
+		_, err := repo.GetByCategory(ctx, categoryID, entity.TopicSortNewest, page)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "TopicRepository - GetByCategory - rows.Next() - rows.Scan()")
 		assert.ErrorIs(t, err, dbErr)
@@ -155,24 +251,33 @@ func TestTopicRepository_Update(t *testing.T) {
 	pg := postgres.NewWithPool(mockPool)
 	repo := NewTopicRepository(pg, &logger)
 
-	expectedSql := "UPDATE topics SET title = \\$1, updated_at = now\\(\\) WHERE id = \\$2"
+	expectedSql := "UPDATE topics SET title = \\$1, updated_at = now\\(\\) WHERE id = \\$2 AND \\(\\$3::timestamptz IS NULL OR updated_at = \\$3\\)"
 
 	id := int64(1)
 	title := "updated title"
 
 	t.Run("Success", func(t *testing.T) {
-		mockPool.ExpectExec(expectedSql).WithArgs(title, id).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mockPool.ExpectExec(expectedSql).WithArgs(title, id, (*time.Time)(nil)).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := repo.Update(ctx, id, title)
+		err := repo.Update(ctx, id, title, nil)
 		assert.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
 
+	t.Run("Stale version", func(t *testing.T) {
+		version := time.Now()
+		mockPool.ExpectExec(expectedSql).WithArgs(title, id, &version).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.Update(ctx, id, title, &version)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectExec(expectedSql).WithArgs(title, id).WillReturnError(dbErr)
+		mockPool.ExpectExec(expectedSql).WithArgs(title, id, (*time.Time)(nil)).WillReturnError(dbErr)
 
-		err := repo.Update(ctx, id, title)
+		err := repo.Update(ctx, id, title, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "TopicRepository - Update - Exec")
 		assert.ErrorIs(t, err, dbErr)
@@ -192,23 +297,130 @@ func TestTopicRepository_Delete(t *testing.T) {
 	repo := NewTopicRepository(pg, &logger)
 
 	id := int64(1)
+	deletedBy := int64(2)
 
 	t.Run("Success", func(t *testing.T) {
-		mockPool.ExpectExec("DELETE FROM topics WHERE id").WithArgs(id).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mockPool.ExpectExec("UPDATE topics SET deleted_at = now\\(\\), deleted_by = \\$2 WHERE id = \\$1 AND deleted_at IS NULL").WithArgs(id, deletedBy).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := repo.Delete(ctx, id)
+		err := repo.Delete(ctx, id, deletedBy)
 		assert.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
 
 	t.Run("DB error", func(t *testing.T) {
 		dbErr := errors.New("some db error")
-		mockPool.ExpectExec("DELETE FROM topics WHERE id").WithArgs(id).WillReturnError(dbErr)
+		mockPool.ExpectExec("UPDATE topics SET deleted_at").WithArgs(id, deletedBy).WillReturnError(dbErr)
 
-		err := repo.Delete(ctx, id)
+		err := repo.Delete(ctx, id, deletedBy)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "TopicRepository - Delete - pg.Pool.Exec()")
 		assert.ErrorIs(t, err, dbErr)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
 }
+
+func TestTopicRepository_Restore(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewTopicRepository(pg, &logger)
+
+	id := int64(1)
+	expectedSql := "UPDATE topics SET deleted_at = NULL, deleted_by = NULL WHERE id = \\$1 AND deleted_at IS NOT NULL"
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec(expectedSql).WithArgs(id).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.Restore(ctx, id)
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Not deleted", func(t *testing.T) {
+		mockPool.ExpectExec(expectedSql).WithArgs(id).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.Restore(ctx, id)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestTopicRepository_UpdateState(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewTopicRepository(pg, &logger)
+
+	id := int64(1)
+	pinned := true
+	var locked *bool
+	expectedSql := "UPDATE topics SET pinned = COALESCE\\(\\$2, pinned\\), locked = COALESCE\\(\\$3, locked\\) WHERE id = \\$1 AND deleted_at IS NULL"
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec(expectedSql).WithArgs(id, &pinned, locked).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.UpdateState(ctx, id, &pinned, locked)
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockPool.ExpectExec(expectedSql).WithArgs(id, &pinned, locked).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.UpdateState(ctx, id, &pinned, locked)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectExec("UPDATE topics SET pinned").WithArgs(id, &pinned, locked).WillReturnError(dbErr)
+
+		err := repo.UpdateState(ctx, id, &pinned, locked)
+		assert.ErrorIs(t, err, dbErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestTopicRepository_PurgeDeleted(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	repo := NewTopicRepository(pg, &logger)
+
+	before := time.Now().Add(-time.Hour)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPool.ExpectExec("DELETE FROM topics WHERE deleted_at < \\$1").WithArgs(before).WillReturnResult(pgxmock.NewResult("DELETE", 4))
+
+		purged, err := repo.PurgeDeleted(ctx, before)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), purged)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		dbErr := errors.New("some db error")
+		mockPool.ExpectExec("DELETE FROM topics WHERE deleted_at").WithArgs(before).WillReturnError(dbErr)
+
+		purged, err := repo.PurgeDeleted(ctx, before)
+		assert.ErrorIs(t, err, dbErr)
+		assert.Zero(t, purged)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/rs/zerolog"
+)
+
+const withinTxOp = "Transactor.WithinTx"
+
+type txKey struct{}
+
+// querier is the part of the pool API shared with pgx.Tx.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type transactor struct {
+	pg  *postgres.Postgres
+	log *zerolog.Logger
+}
+
+func NewTransactor(pg *postgres.Postgres, log *zerolog.Logger) Transactor {
+	return &transactor{pg, log}
+}
+
+func (t *transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.pg.Pool.Begin(ctx)
+	if err != nil {
+		t.log.Error().Err(err).Str("op", withinTxOp).Msg("Failed to begin transaction")
+		return fmt.Errorf("Transactor - WithinTx - pg.Pool.Begin(): %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		t.log.Error().Err(err).Str("op", withinTxOp).Msg("Failed to commit transaction")
+		return fmt.Errorf("Transactor - WithinTx - tx.Commit(): %w", err)
+	}
+	return nil
+}
+
+// conn returns the transaction started by WithinTx for ctx, or the pool outside of one.
+func conn(ctx context.Context, pg *postgres.Postgres) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pg.Pool
+}
@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/go-common-forum/postgres"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactor_WithinTx(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	pg := postgres.NewWithPool(mockPool)
+	transactor := NewTransactor(pg, &logger)
+	topicRepo := NewTopicRepository(pg, &logger)
+	postRepo := NewPostRepository(pg, &logger)
+
+	t.Run("Commit", func(t *testing.T) {
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery("INSERT INTO topics").WithArgs(int64(1), "title", (*int64)(nil)).WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(5)))
+		mockPool.ExpectQuery("INSERT INTO posts").WithArgs(int64(5), (*int64)(nil), "content", (*int64)(nil)).WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(9)))
+		mockPool.ExpectCommit()
+
+		err := transactor.WithinTx(ctx, func(ctx context.Context) error {
+			topicID, err := topicRepo.Create(ctx, entity.Topic{CategoryID: 1, Title: "title"})
+			if err != nil {
+				return err
+			}
+			_, err = postRepo.Create(ctx, entity.Post{TopicID: topicID, Content: "content"})
+			return err
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback on error", func(t *testing.T) {
+		fnErr := errors.New("post failed")
+		mockPool.ExpectBegin()
+		mockPool.ExpectRollback()
+
+		err := transactor.WithinTx(ctx, func(ctx context.Context) error {
+			return fnErr
+		})
+		assert.ErrorIs(t, err, fnErr)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Nested joins outer transaction", func(t *testing.T) {
+		mockPool.ExpectBegin()
+		mockPool.ExpectCommit()
+
+		err := transactor.WithinTx(ctx, func(ctx context.Context) error {
+			return transactor.WithinTx(ctx, func(ctx context.Context) error { return nil })
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Begin error", func(t *testing.T) {
+		dbErr := errors.New("begin failed")
+		mockPool.ExpectBegin().WillReturnError(dbErr)
+
+		err := transactor.WithinTx(ctx, func(ctx context.Context) error { return nil })
+		assert.ErrorIs(t, err, dbErr)
+		assert.Contains(t, err.Error(), "Transactor - WithinTx - pg.Pool.Begin()")
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Commit error", func(t *testing.T) {
+		mockPool.ExpectBegin()
+		mockPool.ExpectCommit().WillReturnError(pgx.ErrTxCommitRollback)
+
+		err := transactor.WithinTx(ctx, func(ctx context.Context) error { return nil })
+		assert.ErrorIs(t, err, pgx.ErrTxCommitRollback)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
@@ -2,75 +2,385 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/client"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/forum-service-sstu-forum/internal/repo"
 	"github.com/rs/zerolog"
 )
 
 const (
-	createOp  = "CategoryUsecase.Create"
-	getByIdOp = "CategoryUsecase.GetByID"
-	getAllOp  = "CategoryUsecase.GetAll"
-	deleteOp  = "CategoryUsecase.Delete"
-	updateOp  = "CategoryUsecase.Update"
+	createOp            = "CategoryUsecase.Create"
+	getByIdOp           = "CategoryUsecase.GetByID"
+	getAllOp            = "CategoryUsecase.GetAll"
+	getTreeCategoriesOp = "CategoryUsecase.GetTree"
+	deleteOp            = "CategoryUsecase.Delete"
+	updateOp            = "CategoryUsecase.Update"
+	reorderOp           = "CategoryUsecase.Reorder"
+
+	getModeratorsOp   = "CategoryUsecase.GetModerators"
+	addModeratorOp    = "CategoryUsecase.AddModerator"
+	removeModeratorOp = "CategoryUsecase.RemoveModerator"
 )
 
 type categoryUsecase struct {
-	repo repo.CategoryRepository
-	log  *zerolog.Logger
+	repo          repo.CategoryRepository
+	moderatorRepo repo.ModeratorRepository
+	transactor    repo.Transactor
+	userClient    client.UserClient
+	cache         ResponseCache
+	log           *zerolog.Logger
 }
 
-func NewCategoryUsecase(repo repo.CategoryRepository, log *zerolog.Logger) CategoryUsecase {
-	return &categoryUsecase{repo, log}
+func NewCategoryUsecase(repo repo.CategoryRepository, moderatorRepo repo.ModeratorRepository, transactor repo.Transactor, userClient client.UserClient, cache ResponseCache, log *zerolog.Logger) CategoryUsecase {
+	return &categoryUsecase{repo, moderatorRepo, transactor, userClient, cache, log}
 }
 
 func (u *categoryUsecase) Create(ctx context.Context, category entity.Category) (int64, error) {
+	if category.ParentID != nil {
+		if err := u.checkParent(ctx, 0, *category.ParentID); err != nil {
+			u.log.Warn().Err(err).Str("op", createOp).Int64("parent_id", *category.ParentID).Msg("Invalid parent category")
+			return 0, err
+		}
+	}
+
 	id, err := u.repo.Create(ctx, category)
 	if err != nil {
 		u.log.Error().Err(err).Str("op", createOp).Any("category", category).Msg("Failed to create category in repository")
 		return 0, fmt.Errorf("ForumService - CategoryUsecase - Create - repo.Create(): %w", err)
 	}
+	u.cache.InvalidateCategory(id)
+
 	u.log.Info().Str("op", createOp).Any("category", category).Msg("Category created successfully")
 	return id, nil
 }
 
-func (u *categoryUsecase) GetByID(ctx context.Context, id int64) (*entity.Category, error) {
+// GetByID returns a category with its breadcrumbs. Unless includeHidden is set, a category
+// that is hidden itself or through one of its ancestors is reported as not found.
+// degraded is set when the last post author got a placeholder name.
+func (u *categoryUsecase) GetByID(ctx context.Context, id int64, includeHidden bool) (*entity.Category, bool, error) {
 	category, err := u.repo.GetByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, fmt.Errorf("ForumService - CategoryUsecase - GetByID - repo.GetByID(): %w", ErrCategoryNotFound)
+		}
 		u.log.Error().Err(err).Str("op", getByIdOp).Int64("id", id).Msg("Failed to get category in repository")
-		return nil, fmt.Errorf("ForumService - CategoryUsecase - GetByID - repo.GetByID(): %w", err)
+		return nil, false, fmt.Errorf("ForumService - CategoryUsecase - GetByID - repo.GetByID(): %w", err)
+	}
+
+	if !includeHidden {
+		hidden, err := u.repo.IsHidden(ctx, id)
+		if err != nil {
+			u.log.Error().Err(err).Str("op", getByIdOp).Int64("id", id).Msg("Failed to check category visibility in repository")
+			return nil, false, fmt.Errorf("ForumService - CategoryUsecase - GetByID - repo.IsHidden(): %w", err)
+		}
+		if hidden {
+			return nil, false, fmt.Errorf("ForumService - CategoryUsecase - GetByID: %w", ErrCategoryNotFound)
+		}
 	}
 
+	path, err := u.repo.GetPath(ctx, id)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", getByIdOp).Int64("id", id).Msg("Failed to get category path in repository")
+		return nil, false, fmt.Errorf("ForumService - CategoryUsecase - GetByID - repo.GetPath(): %w", err)
+	}
+	if len(path) > 0 {
+		category.Breadcrumbs = path[:len(path)-1]
+	}
+
+	degraded := u.setLastPostUsernames(ctx, getByIdOp, []*entity.Category{category})
+
 	u.log.Info().Str("op", getByIdOp).Int64("id", id).Msg("Category taken successfully")
-	return category, nil
+	return category, degraded, nil
 }
 
-func (u *categoryUsecase) GetAll(ctx context.Context) ([]entity.Category, error) {
-	categories, err := u.repo.GetAll(ctx)
+// GetAll returns categories in display order. Hidden categories and their subcategories
+// are only included when includeHidden is set. degraded is set when last post authors got
+// a placeholder name.
+func (u *categoryUsecase) GetAll(ctx context.Context, includeHidden bool) ([]entity.Category, bool, error) {
+	categories, err := u.repo.GetAll(ctx, includeHidden)
 	if err != nil {
 		u.log.Error().Err(err).Str("op", getAllOp).Msg("Failed to get categories in repository")
-		return nil, fmt.Errorf("ForumService - CategoryUsecase - GetAll - repo.GetAll(): %w", err)
+		return nil, false, fmt.Errorf("ForumService - CategoryUsecase - GetAll - repo.GetAll(): %w", err)
 	}
+
+	refs := make([]*entity.Category, len(categories))
+	for i := range categories {
+		refs[i] = &categories[i]
+	}
+	degraded := u.setLastPostUsernames(ctx, getAllOp, refs)
 	u.log.Info().Str("op", getAllOp).Msg("All categories succesfully taken")
-	return categories, nil
+	return categories, degraded, nil
 }
 
-func (u *categoryUsecase) Update(ctx context.Context, id int64, title, description string) error {
-	if err := u.repo.Update(ctx, id, title, description); err != nil {
-		u.log.Error().Err(err).Str("op", updateOp).Int64("id", id).Msg("Failed to update category in repository")
-		return fmt.Errorf("ForumService - CategoryUsecase - Update - repo.Update(): %w", err)
+// GetTree returns root categories with their subcategories nested under them.
+// degraded is set when last post authors got a placeholder name.
+func (u *categoryUsecase) GetTree(ctx context.Context, includeHidden bool) ([]*entity.CategoryNode, bool, error) {
+	categories, err := u.repo.GetAll(ctx, includeHidden)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", getTreeCategoriesOp).Msg("Failed to get categories in repository")
+		return nil, false, fmt.Errorf("ForumService - CategoryUsecase - GetTree - repo.GetAll(): %w", err)
+	}
+
+	nodes := make(map[int64]*entity.CategoryNode, len(categories))
+	refs := make([]*entity.Category, 0, len(categories))
+	for _, c := range categories {
+		node := &entity.CategoryNode{Category: c, Children: []*entity.CategoryNode{}}
+		nodes[c.ID] = node
+		refs = append(refs, &node.Category)
 	}
+	degraded := u.setLastPostUsernames(ctx, getTreeCategoriesOp, refs)
+
+	roots := []*entity.CategoryNode{}
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	u.log.Info().Str("op", getTreeCategoriesOp).Msg("Category tree succesfully built")
+	return roots, degraded, nil
+}
+
+// Update changes the title and description of a category. A non-nil parentID also moves it:
+// 0 makes the category a root, any other value puts it under that category. A non-nil hidden
+// shows or hides the category. All changes are applied in one transaction.
+// A non-nil version is the updated_at the caller last saw; if the category has changed
+// since or no longer exists, ErrVersionConflict is returned.
+func (u *categoryUsecase) Update(ctx context.Context, id int64, title, description *string, parentID *int64, hidden *bool, version *time.Time) error {
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var newParent *int64
+		if parentID != nil && *parentID != 0 {
+			// Without the lock two concurrent moves could each pass the check and form a cycle together.
+			if err := u.repo.LockTree(ctx); err != nil {
+				u.log.Error().Err(err).Str("op", updateOp).Int64("id", id).Msg("Failed to lock category tree")
+				return fmt.Errorf("ForumService - CategoryUsecase - Update - repo.LockTree(): %w", err)
+			}
+			if err := u.checkParent(ctx, id, *parentID); err != nil {
+				u.log.Warn().Err(err).Str("op", updateOp).Int64("id", id).Int64("parent_id", *parentID).Msg("Invalid parent category")
+				return err
+			}
+			newParent = parentID
+		}
+
+		if err := u.repo.Update(ctx, id, title, description, hidden, version); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				if version != nil {
+					u.log.Warn().Str("op", updateOp).Int64("id", id).Msg("Category version conflict")
+					return fmt.Errorf("ForumService - CategoryUsecase - Update - repo.Update(): %w", ErrVersionConflict)
+				}
+				return fmt.Errorf("ForumService - CategoryUsecase - Update - repo.Update(): %w", ErrCategoryNotFound)
+			}
+			u.log.Error().Err(err).Str("op", updateOp).Int64("id", id).Msg("Failed to update category in repository")
+			return fmt.Errorf("ForumService - CategoryUsecase - Update - repo.Update(): %w", err)
+		}
+
+		if parentID != nil {
+			if err := u.repo.SetParent(ctx, id, newParent); err != nil {
+				u.log.Error().Err(err).Str("op", updateOp).Int64("id", id).Msg("Failed to move category in repository")
+				return fmt.Errorf("ForumService - CategoryUsecase - Update - repo.SetParent(): %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Moving or hiding a category also changes what its subcategories show.
+	if parentID != nil || hidden != nil {
+		u.cache.Invalidate()
+	} else {
+		u.cache.InvalidateCategory(id)
+	}
+
 	u.log.Info().Str("op", updateOp).Int64("id", id).Msg("Category updated successfully")
 	return nil
 }
 
+// Reorder sets the display order of categories to the order of ids. Nothing is changed
+// if any of the categories does not exist.
+func (u *categoryUsecase) Reorder(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return ErrInvalidCategoryOrder
+	}
+	seen := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			return ErrInvalidCategoryOrder
+		}
+		seen[id] = struct{}{}
+	}
+
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		return u.repo.Reorder(ctx, ids)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("ForumService - CategoryUsecase - Reorder - repo.Reorder(): %w", ErrCategoryNotFound)
+		}
+		u.log.Error().Err(err).Str("op", reorderOp).Ints64("ids", ids).Msg("Failed to reorder categories in repository")
+		return fmt.Errorf("ForumService - CategoryUsecase - Reorder - repo.Reorder(): %w", err)
+	}
+	u.cache.Invalidate()
+
+	u.log.Info().Str("op", reorderOp).Ints64("ids", ids).Msg("Categories reordered successfully")
+	return nil
+}
+
+// Delete removes a category with its topics. Categories that still have subcategories
+// are not deleted, they have to be moved or deleted first.
 func (u *categoryUsecase) Delete(ctx context.Context, id int64) error {
-	if err := u.repo.Delete(ctx, id); err != nil {
-		u.log.Error().Err(err).Str("op", deleteOp).Int64("id", id).Msg("Failed to delete category in repository")
-		return fmt.Errorf("ForumService - CategoryUsecase - Delete - repo.Delete(): %w", err)
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.LockTree(ctx); err != nil {
+			u.log.Error().Err(err).Str("op", deleteOp).Int64("id", id).Msg("Failed to lock category tree")
+			return fmt.Errorf("ForumService - CategoryUsecase - Delete - repo.LockTree(): %w", err)
+		}
+
+		hasChildren, err := u.repo.HasChildren(ctx, id)
+		if err != nil {
+			u.log.Error().Err(err).Str("op", deleteOp).Int64("id", id).Msg("Failed to check subcategories in repository")
+			return fmt.Errorf("ForumService - CategoryUsecase - Delete - repo.HasChildren(): %w", err)
+		}
+		if hasChildren {
+			u.log.Warn().Str("op", deleteOp).Int64("id", id).Msg("Category has subcategories")
+			return fmt.Errorf("ForumService - CategoryUsecase - Delete: %w", ErrCategoryHasChildren)
+		}
+
+		if err := u.repo.Delete(ctx, id); err != nil {
+			u.log.Error().Err(err).Str("op", deleteOp).Int64("id", id).Msg("Failed to delete category in repository")
+			return fmt.Errorf("ForumService - CategoryUsecase - Delete - repo.Delete(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	u.cache.Invalidate()
+
 	u.log.Info().Str("op", deleteOp).Int64("id", id).Msg("Category deleted successfully")
 	return nil
 }
+
+func (u *categoryUsecase) GetModerators(ctx context.Context, categoryID int64) ([]entity.CategoryModerator, error) {
+	if err := u.checkCategory(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	moderators, err := u.moderatorRepo.GetByCategory(ctx, categoryID)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", getModeratorsOp).Int64("category_id", categoryID).Msg("Failed to get moderators in repository")
+		return nil, fmt.Errorf("ForumService - CategoryUsecase - GetModerators - moderatorRepo.GetByCategory(): %w", err)
+	}
+
+	u.log.Info().Str("op", getModeratorsOp).Int64("category_id", categoryID).Msg("Moderators taken successfully")
+	return moderators, nil
+}
+
+func (u *categoryUsecase) AddModerator(ctx context.Context, categoryID int64, userID int64) error {
+	if err := u.checkCategory(ctx, categoryID); err != nil {
+		return err
+	}
+
+	if err := u.moderatorRepo.Add(ctx, categoryID, userID); err != nil {
+		u.log.Error().Err(err).Str("op", addModeratorOp).Int64("category_id", categoryID).Int64("user_id", userID).Msg("Failed to add moderator in repository")
+		return fmt.Errorf("ForumService - CategoryUsecase - AddModerator - moderatorRepo.Add(): %w", err)
+	}
+
+	u.log.Info().Str("op", addModeratorOp).Int64("category_id", categoryID).Int64("user_id", userID).Msg("Moderator added successfully")
+	return nil
+}
+
+func (u *categoryUsecase) RemoveModerator(ctx context.Context, categoryID int64, userID int64) error {
+	if err := u.moderatorRepo.Remove(ctx, categoryID, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("ForumService - CategoryUsecase - RemoveModerator - moderatorRepo.Remove(): %w", ErrModeratorNotFound)
+		}
+		u.log.Error().Err(err).Str("op", removeModeratorOp).Int64("category_id", categoryID).Int64("user_id", userID).Msg("Failed to remove moderator in repository")
+		return fmt.Errorf("ForumService - CategoryUsecase - RemoveModerator - moderatorRepo.Remove(): %w", err)
+	}
+
+	u.log.Info().Str("op", removeModeratorOp).Int64("category_id", categoryID).Int64("user_id", userID).Msg("Moderator removed successfully")
+	return nil
+}
+
+func (u *categoryUsecase) checkCategory(ctx context.Context, categoryID int64) error {
+	if _, err := u.repo.GetByID(ctx, categoryID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("ForumService - CategoryUsecase - checkCategory - repo.GetByID(): %w", ErrCategoryNotFound)
+		}
+		return fmt.Errorf("ForumService - CategoryUsecase - checkCategory - repo.GetByID(): %w", err)
+	}
+
+	return nil
+}
+
+// setLastPostUsernames resolves the authors of the categories' last posts in one batch.
+// While the user service is down the authors get a placeholder name and degraded is set.
+func (u *categoryUsecase) setLastPostUsernames(ctx context.Context, op string, categories []*entity.Category) (degraded bool) {
+	var authorIDs []int64
+	authorIDSet := make(map[int64]bool)
+	for _, c := range categories {
+		if c.LastPost != nil && c.LastPost.AuthorID != nil && !authorIDSet[*c.LastPost.AuthorID] {
+			authorIDs = append(authorIDs, *c.LastPost.AuthorID)
+			authorIDSet[*c.LastPost.AuthorID] = true
+		}
+	}
+
+	var usernames map[int64]string
+	if len(authorIDs) > 0 {
+		var err error
+		usernames, err = u.userClient.GetUsernames(ctx, authorIDs)
+		if err != nil {
+			u.log.Warn().Err(err).Str("op", op).Msg("Failed to get usernames, serving placeholders")
+			degraded = true
+		}
+	}
+
+	for _, c := range categories {
+		if c.LastPost == nil {
+			continue
+		}
+		c.LastPost.Username = deletedUsername
+		if c.LastPost.AuthorID == nil {
+			continue
+		}
+		if degraded {
+			c.LastPost.Username = unavailableUsername
+			continue
+		}
+		if username, exists := usernames[*c.LastPost.AuthorID]; exists {
+			c.LastPost.Username = username
+		}
+	}
+	return degraded
+}
+
+// checkParent makes sure parentID exists and is not the category itself or one of its
+// descendants, so moving the category cannot create a cycle. id is 0 for a new category.
+func (u *categoryUsecase) checkParent(ctx context.Context, id int64, parentID int64) error {
+	path, err := u.repo.GetPath(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("ForumService - CategoryUsecase - checkParent - repo.GetPath(): %w", err)
+	}
+	if len(path) == 0 {
+		return fmt.Errorf("ForumService - CategoryUsecase - checkParent: %w", ErrParentCategoryNotFound)
+	}
+
+	for _, b := range path {
+		if b.ID == id {
+			return fmt.Errorf("ForumService - CategoryUsecase - checkParent: %w", ErrCategoryCycle)
+		}
+	}
+
+	return nil
+}
@@ -3,27 +3,42 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/forum-service-sstu-forum/mocks"
 	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
 type CategoryUsecaseSuite struct {
 	suite.Suite
-	usecase  CategoryUsecase
-	repoMock *mocks.CategoryRepository
-	log      *zerolog.Logger
+	usecase        CategoryUsecase
+	repoMock       *mocks.CategoryRepository
+	moderatorRepo  *mocks.ModeratorRepository
+	transactorMock *mocks.Transactor
+	userClient     *mocks.UserClient
+	cacheMock      *mocks.ResponseCache
+	log            *zerolog.Logger
 }
 
 func (s *CategoryUsecaseSuite) SetupTest() {
 	s.repoMock = mocks.NewCategoryRepository(s.T())
+	s.moderatorRepo = mocks.NewModeratorRepository(s.T())
+	s.transactorMock = mocks.NewTransactor(s.T())
+	s.userClient = mocks.NewUserClient(s.T())
+	s.cacheMock = mocks.NewResponseCache(s.T())
+	s.cacheMock.On("Invalidate").Maybe()
+	s.cacheMock.On("InvalidateCategory", mock.Anything).Maybe()
+	s.cacheMock.On("InvalidateTopic", mock.Anything, mock.Anything).Maybe()
+	s.cacheMock.On("InvalidatePosts", mock.Anything).Maybe()
 	logger := zerolog.Nop()
 	s.log = &logger
-	s.usecase = NewCategoryUsecase(s.repoMock, s.log)
+	s.usecase = NewCategoryUsecase(s.repoMock, s.moderatorRepo, s.transactorMock, s.userClient, s.cacheMock, s.log)
 }
 
 func TestCategoryUsecaseSuite(t *testing.T) {
@@ -31,6 +46,13 @@ func TestCategoryUsecaseSuite(t *testing.T) {
 }
 
 // Create
+// runInTx makes the transactor mock call the function it is given, like a real transaction would.
+func (s *CategoryUsecaseSuite) runInTx(ctx context.Context) {
+	s.transactorMock.On("WithinTx", ctx, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).Once()
+}
+
 func (s *CategoryUsecaseSuite) TestCreateCategory_Success() {
 	ctx := context.Background()
 	category := entity.Category{Title: "New Category", Description: "Description"}
@@ -66,14 +88,18 @@ func (s *CategoryUsecaseSuite) TestGetByIDCategory_Success() {
 	ctx := context.Background()
 	categoryID := int64(1)
 	expectedCategory := &entity.Category{ID: categoryID, Title: "Test Category", Description: "Test Description", CreatedAt: time.Now()}
+	path := []entity.Breadcrumb{{ID: 7, Title: "Faculty"}, {ID: categoryID, Title: "Test Category"}}
 
 	s.repoMock.On("GetByID", ctx, categoryID).Return(expectedCategory, nil).Once()
+	s.repoMock.On("IsHidden", ctx, categoryID).Return(false, nil).Once()
+	s.repoMock.On("GetPath", ctx, categoryID).Return(path, nil).Once()
 
-	category, err := s.usecase.GetByID(ctx, categoryID)
+	category, _, err := s.usecase.GetByID(ctx, categoryID, false)
 
 	s.NoError(err)
 	s.NotNil(category)
 	s.Equal(expectedCategory, category)
+	s.Equal([]entity.Breadcrumb{{ID: 7, Title: "Faculty"}}, category.Breadcrumbs)
 	s.repoMock.AssertExpectations(s.T())
 }
 
@@ -84,7 +110,7 @@ func (s *CategoryUsecaseSuite) TestGetByIDCategory_RepoError() {
 
 	s.repoMock.On("GetByID", ctx, categoryID).Return(nil, expectedError).Once()
 
-	category, err := s.usecase.GetByID(ctx, categoryID)
+	category, _, err := s.usecase.GetByID(ctx, categoryID, false)
 
 	s.Error(err)
 	s.Nil(category)
@@ -93,6 +119,49 @@ func (s *CategoryUsecaseSuite) TestGetByIDCategory_RepoError() {
 	s.repoMock.AssertExpectations(s.T())
 }
 
+func (s *CategoryUsecaseSuite) TestGetByIDCategory_NotFound() {
+	ctx := context.Background()
+	categoryID := int64(1)
+
+	s.repoMock.On("GetByID", ctx, categoryID).Return(nil, pgx.ErrNoRows).Once()
+
+	category, _, err := s.usecase.GetByID(ctx, categoryID, true)
+
+	s.ErrorIs(err, ErrCategoryNotFound)
+	s.Nil(category)
+	s.repoMock.AssertExpectations(s.T())
+}
+
+func (s *CategoryUsecaseSuite) TestGetByIDCategory_Hidden() {
+	ctx := context.Background()
+	categoryID := int64(1)
+
+	s.repoMock.On("GetByID", ctx, categoryID).Return(&entity.Category{ID: categoryID}, nil).Once()
+	s.repoMock.On("IsHidden", ctx, categoryID).Return(true, nil).Once()
+
+	category, _, err := s.usecase.GetByID(ctx, categoryID, false)
+
+	s.ErrorIs(err, ErrCategoryNotFound)
+	s.Nil(category)
+	s.repoMock.AssertExpectations(s.T())
+	s.repoMock.AssertNotCalled(s.T(), "GetPath", mock.Anything, mock.Anything)
+}
+
+func (s *CategoryUsecaseSuite) TestGetByIDCategory_HiddenForAdmin() {
+	ctx := context.Background()
+	categoryID := int64(1)
+	hidden := &entity.Category{ID: categoryID, Hidden: true}
+
+	s.repoMock.On("GetByID", ctx, categoryID).Return(hidden, nil).Once()
+	s.repoMock.On("GetPath", ctx, categoryID).Return([]entity.Breadcrumb{{ID: categoryID}}, nil).Once()
+
+	category, _, err := s.usecase.GetByID(ctx, categoryID, true)
+
+	s.NoError(err)
+	s.Equal(hidden, category)
+	s.repoMock.AssertNotCalled(s.T(), "IsHidden", mock.Anything, mock.Anything)
+}
+
 // GetAll
 func (s *CategoryUsecaseSuite) TestGetAllCategories_Success() {
 	ctx := context.Background()
@@ -101,9 +170,9 @@ func (s *CategoryUsecaseSuite) TestGetAllCategories_Success() {
 		{ID: 2, Title: "Category 2", Description: "Desc 2", CreatedAt: time.Now()},
 	}
 
-	s.repoMock.On("GetAll", ctx).Return(expectedCategories, nil).Once()
+	s.repoMock.On("GetAll", ctx, false).Return(expectedCategories, nil).Once()
 
-	categories, err := s.usecase.GetAll(ctx)
+	categories, _, err := s.usecase.GetAll(ctx, false)
 
 	s.NoError(err)
 	s.NotNil(categories)
@@ -111,13 +180,69 @@ func (s *CategoryUsecaseSuite) TestGetAllCategories_Success() {
 	s.repoMock.AssertExpectations(s.T())
 }
 
+func (s *CategoryUsecaseSuite) TestGetAllCategories_LastPostAuthors() {
+	ctx := context.Background()
+	authorID := int64(5)
+	categories := []entity.Category{
+		{ID: 1, Title: "Category 1", PostCount: 3, LastPost: &entity.LastPost{ID: 10, TopicID: 2, TopicTitle: "Topic", AuthorID: &authorID}},
+		{ID: 2, Title: "Category 2", PostCount: 1, LastPost: &entity.LastPost{ID: 11, TopicID: 4, TopicTitle: "Orphan"}},
+		{ID: 3, Title: "Empty"},
+	}
+
+	s.repoMock.On("GetAll", ctx, false).Return(categories, nil).Once()
+	s.userClient.On("GetUsernames", ctx, []int64{authorID}).Return(map[int64]string{authorID: "alice"}, nil).Once()
+
+	result, _, err := s.usecase.GetAll(ctx, false)
+
+	s.NoError(err)
+	s.Equal("alice", result[0].LastPost.Username)
+	s.Equal(deletedUsername, result[1].LastPost.Username)
+	s.Nil(result[2].LastPost)
+	s.userClient.AssertExpectations(s.T())
+}
+
+func (s *CategoryUsecaseSuite) TestGetAllCategories_UserClientError() {
+	ctx := context.Background()
+	authorID := int64(5)
+	categories := []entity.Category{{ID: 1, LastPost: &entity.LastPost{ID: 10, AuthorID: &authorID}}}
+	expectedError := errors.New("grpc error")
+
+	s.repoMock.On("GetAll", ctx, false).Return(categories, nil).Once()
+	s.userClient.On("GetUsernames", ctx, []int64{authorID}).Return(nil, expectedError).Once()
+
+	result, degraded, err := s.usecase.GetAll(ctx, false)
+
+	s.NoError(err)
+	s.True(degraded)
+	s.Require().Len(result, 1)
+	s.Equal(unavailableUsername, result[0].LastPost.Username)
+}
+
+func (s *CategoryUsecaseSuite) TestGetByIDCategory_UserClientError() {
+	ctx := context.Background()
+	categoryID := int64(1)
+	authorID := int64(5)
+	category := &entity.Category{ID: categoryID, LastPost: &entity.LastPost{ID: 10, AuthorID: &authorID}}
+
+	s.repoMock.On("GetByID", ctx, categoryID).Return(category, nil).Once()
+	s.repoMock.On("IsHidden", ctx, categoryID).Return(false, nil).Once()
+	s.repoMock.On("GetPath", ctx, categoryID).Return([]entity.Breadcrumb{{ID: categoryID}}, nil).Once()
+	s.userClient.On("GetUsernames", ctx, []int64{authorID}).Return(nil, errors.New("grpc error")).Once()
+
+	result, degraded, err := s.usecase.GetByID(ctx, categoryID, false)
+
+	s.NoError(err)
+	s.True(degraded)
+	s.Equal(unavailableUsername, result.LastPost.Username)
+}
+
 func (s *CategoryUsecaseSuite) TestGetAllCategories_RepoError() {
 	ctx := context.Background()
 	expectedError := errors.New("repository error")
 
-	s.repoMock.On("GetAll", ctx).Return(nil, expectedError).Once()
+	s.repoMock.On("GetAll", ctx, false).Return(nil, expectedError).Once()
 
-	categories, err := s.usecase.GetAll(ctx)
+	categories, _, err := s.usecase.GetAll(ctx, false)
 
 	s.Error(err)
 	s.Nil(categories)
@@ -129,28 +254,32 @@ func (s *CategoryUsecaseSuite) TestGetAllCategories_RepoError() {
 // Update
 func (s *CategoryUsecaseSuite) TestUpdateCategory_Success() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	categoryID := int64(1)
 	title := "Updated Title"
 	description := "Updated Description"
 
-	s.repoMock.On("Update", ctx, categoryID, title, description).Return(nil).Once()
+	s.repoMock.On("Update", ctx, categoryID, &title, &description, (*bool)(nil), (*time.Time)(nil)).Return(nil).Once()
 
-	err := s.usecase.Update(ctx, categoryID, title, description)
+	err := s.usecase.Update(ctx, categoryID, &title, &description, nil, nil, nil)
 
 	s.NoError(err)
 	s.repoMock.AssertExpectations(s.T())
+	s.cacheMock.AssertCalled(s.T(), "InvalidateCategory", categoryID)
+	s.cacheMock.AssertNotCalled(s.T(), "Invalidate")
 }
 
 func (s *CategoryUsecaseSuite) TestUpdateCategory_RepoError() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	categoryID := int64(1)
 	title := "Updated Title"
 	description := "Updated Description"
 	expectedError := errors.New("repository error")
 
-	s.repoMock.On("Update", ctx, categoryID, title, description).Return(expectedError).Once()
+	s.repoMock.On("Update", ctx, categoryID, &title, &description, (*bool)(nil), (*time.Time)(nil)).Return(expectedError).Once()
 
-	err := s.usecase.Update(ctx, categoryID, title, description)
+	err := s.usecase.Update(ctx, categoryID, &title, &description, nil, nil, nil)
 
 	s.Error(err)
 	s.Contains(err.Error(), "ForumService - CategoryUsecase - Update - repo.Update()")
@@ -158,11 +287,89 @@ func (s *CategoryUsecaseSuite) TestUpdateCategory_RepoError() {
 	s.repoMock.AssertExpectations(s.T())
 }
 
+func (s *CategoryUsecaseSuite) TestUpdateCategory_VersionConflict() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	categoryID := int64(1)
+	version := time.Now().Add(-time.Minute)
+
+	s.repoMock.On("Update", ctx, categoryID, (*string)(nil), (*string)(nil), (*bool)(nil), &version).Return(fmt.Errorf("CategoryRepository - Update: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.Update(ctx, categoryID, nil, nil, nil, nil, &version)
+
+	s.ErrorIs(err, ErrVersionConflict)
+}
+
+func (s *CategoryUsecaseSuite) TestUpdateCategory_NotFound() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	categoryID := int64(1)
+
+	s.repoMock.On("Update", ctx, categoryID, (*string)(nil), (*string)(nil), (*bool)(nil), (*time.Time)(nil)).Return(fmt.Errorf("CategoryRepository - Update: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.Update(ctx, categoryID, nil, nil, nil, nil, nil)
+
+	s.ErrorIs(err, ErrCategoryNotFound)
+}
+
+func (s *CategoryUsecaseSuite) TestUpdateCategory_Hide() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	categoryID := int64(1)
+	hidden := true
+
+	s.repoMock.On("Update", ctx, categoryID, (*string)(nil), (*string)(nil), &hidden, (*time.Time)(nil)).Return(nil).Once()
+
+	err := s.usecase.Update(ctx, categoryID, nil, nil, nil, &hidden, nil)
+
+	s.NoError(err)
+	s.repoMock.AssertExpectations(s.T())
+	s.cacheMock.AssertCalled(s.T(), "Invalidate")
+}
+
+// Reorder
+func (s *CategoryUsecaseSuite) TestReorder_Success() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	ids := []int64{3, 1, 2}
+
+	s.repoMock.On("Reorder", ctx, ids).Return(nil).Once()
+
+	err := s.usecase.Reorder(ctx, ids)
+
+	s.NoError(err)
+	s.repoMock.AssertExpectations(s.T())
+}
+
+func (s *CategoryUsecaseSuite) TestReorder_InvalidOrder() {
+	ctx := context.Background()
+
+	s.ErrorIs(s.usecase.Reorder(ctx, nil), ErrInvalidCategoryOrder)
+	s.ErrorIs(s.usecase.Reorder(ctx, []int64{1, 2, 1}), ErrInvalidCategoryOrder)
+	s.repoMock.AssertNotCalled(s.T(), "Reorder", mock.Anything, mock.Anything)
+}
+
+func (s *CategoryUsecaseSuite) TestReorder_UnknownCategory() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	ids := []int64{1, 42}
+
+	s.repoMock.On("Reorder", ctx, ids).Return(fmt.Errorf("wrapped: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.Reorder(ctx, ids)
+
+	s.ErrorIs(err, ErrCategoryNotFound)
+	s.repoMock.AssertExpectations(s.T())
+}
+
 // Delete
 func (s *CategoryUsecaseSuite) TestDeleteCategory_Success() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	categoryID := int64(1)
 
+	s.repoMock.On("LockTree", ctx).Return(nil).Once()
+	s.repoMock.On("HasChildren", ctx, categoryID).Return(false, nil).Once()
 	s.repoMock.On("Delete", ctx, categoryID).Return(nil).Once()
 
 	err := s.usecase.Delete(ctx, categoryID)
@@ -173,9 +380,12 @@ func (s *CategoryUsecaseSuite) TestDeleteCategory_Success() {
 
 func (s *CategoryUsecaseSuite) TestDeleteCategory_RepoError() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	categoryID := int64(1)
 	expectedError := errors.New("repository error")
 
+	s.repoMock.On("LockTree", ctx).Return(nil).Once()
+	s.repoMock.On("HasChildren", ctx, categoryID).Return(false, nil).Once()
 	s.repoMock.On("Delete", ctx, categoryID).Return(expectedError).Once()
 
 	err := s.usecase.Delete(ctx, categoryID)
@@ -185,3 +395,205 @@ func (s *CategoryUsecaseSuite) TestDeleteCategory_RepoError() {
 	s.ErrorIs(err, expectedError)
 	s.repoMock.AssertExpectations(s.T())
 }
+
+func (s *CategoryUsecaseSuite) TestDeleteCategory_HasChildren() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	categoryID := int64(1)
+
+	s.repoMock.On("LockTree", ctx).Return(nil).Once()
+	s.repoMock.On("HasChildren", ctx, categoryID).Return(true, nil).Once()
+
+	err := s.usecase.Delete(ctx, categoryID)
+
+	s.ErrorIs(err, ErrCategoryHasChildren)
+	s.repoMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+	s.cacheMock.AssertNotCalled(s.T(), "Invalidate")
+}
+
+// Tree
+func (s *CategoryUsecaseSuite) TestGetTree_Success() {
+	ctx := context.Background()
+	faculty, department := int64(1), int64(2)
+	categories := []entity.Category{
+		{ID: faculty, Title: "Faculty"},
+		{ID: department, ParentID: &faculty, Title: "Department"},
+		{ID: 3, ParentID: &department, Title: "Course"},
+		{ID: 4, Title: "Offtopic"},
+	}
+
+	s.repoMock.On("GetAll", ctx, false).Return(categories, nil).Once()
+
+	tree, _, err := s.usecase.GetTree(ctx, false)
+
+	s.NoError(err)
+	s.Require().Len(tree, 2)
+	s.Equal(faculty, tree[0].ID)
+	s.Equal(int64(4), tree[1].ID)
+	s.Require().Len(tree[0].Children, 1)
+	s.Equal(department, tree[0].Children[0].ID)
+	s.Require().Len(tree[0].Children[0].Children, 1)
+	s.Equal(int64(3), tree[0].Children[0].Children[0].ID)
+	s.Empty(tree[1].Children)
+}
+
+func (s *CategoryUsecaseSuite) TestGetTree_UserClientError() {
+	ctx := context.Background()
+	authorID := int64(5)
+	categories := []entity.Category{
+		{ID: 1, LastPost: &entity.LastPost{ID: 10, AuthorID: &authorID}},
+		{ID: 2, LastPost: &entity.LastPost{ID: 11}},
+	}
+
+	s.repoMock.On("GetAll", ctx, false).Return(categories, nil).Once()
+	s.userClient.On("GetUsernames", ctx, []int64{authorID}).Return(nil, errors.New("grpc error")).Once()
+
+	tree, degraded, err := s.usecase.GetTree(ctx, false)
+
+	s.NoError(err)
+	s.True(degraded)
+	s.Require().Len(tree, 2)
+	s.Equal(unavailableUsername, tree[0].LastPost.Username)
+	s.Equal(deletedUsername, tree[1].LastPost.Username)
+}
+
+// Move
+func (s *CategoryUsecaseSuite) TestUpdateCategory_MoveUnderParent() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	categoryID, parentID := int64(3), int64(2)
+
+	s.repoMock.On("LockTree", ctx).Return(nil).Once()
+	s.repoMock.On("GetPath", ctx, parentID).Return([]entity.Breadcrumb{{ID: 1}, {ID: parentID}}, nil).Once()
+	s.repoMock.On("Update", ctx, categoryID, (*string)(nil), (*string)(nil), (*bool)(nil), (*time.Time)(nil)).Return(nil).Once()
+	s.repoMock.On("SetParent", ctx, categoryID, &parentID).Return(nil).Once()
+
+	err := s.usecase.Update(ctx, categoryID, nil, nil, &parentID, nil, nil)
+
+	s.NoError(err)
+	s.repoMock.AssertExpectations(s.T())
+}
+
+func (s *CategoryUsecaseSuite) TestUpdateCategory_MoveToRoot() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	categoryID := int64(3)
+	root := int64(0)
+
+	s.repoMock.On("Update", ctx, categoryID, (*string)(nil), (*string)(nil), (*bool)(nil), (*time.Time)(nil)).Return(nil).Once()
+	s.repoMock.On("SetParent", ctx, categoryID, (*int64)(nil)).Return(nil).Once()
+
+	err := s.usecase.Update(ctx, categoryID, nil, nil, &root, nil, nil)
+
+	s.NoError(err)
+	s.repoMock.AssertNotCalled(s.T(), "GetPath", mock.Anything, mock.Anything)
+}
+
+func (s *CategoryUsecaseSuite) TestUpdateCategory_MoveUnderDescendant() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	categoryID, childID := int64(1), int64(3)
+
+	s.repoMock.On("LockTree", ctx).Return(nil).Once()
+	s.repoMock.On("GetPath", ctx, childID).Return([]entity.Breadcrumb{{ID: categoryID}, {ID: 2}, {ID: childID}}, nil).Once()
+
+	err := s.usecase.Update(ctx, categoryID, nil, nil, &childID, nil, nil)
+
+	s.ErrorIs(err, ErrCategoryCycle)
+	s.repoMock.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	s.repoMock.AssertNotCalled(s.T(), "SetParent", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *CategoryUsecaseSuite) TestUpdateCategory_MoveUnderItself() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	categoryID := int64(1)
+
+	s.repoMock.On("LockTree", ctx).Return(nil).Once()
+	s.repoMock.On("GetPath", ctx, categoryID).Return([]entity.Breadcrumb{{ID: categoryID}}, nil).Once()
+
+	err := s.usecase.Update(ctx, categoryID, nil, nil, &categoryID, nil, nil)
+
+	s.ErrorIs(err, ErrCategoryCycle)
+}
+
+func (s *CategoryUsecaseSuite) TestUpdateCategory_MoveLockError() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	categoryID, parentID := int64(3), int64(2)
+	expectedError := errors.New("lock error")
+
+	s.repoMock.On("LockTree", ctx).Return(expectedError).Once()
+
+	err := s.usecase.Update(ctx, categoryID, nil, nil, &parentID, nil, nil)
+
+	s.ErrorIs(err, expectedError)
+	s.repoMock.AssertNotCalled(s.T(), "GetPath", mock.Anything, mock.Anything)
+}
+
+func (s *CategoryUsecaseSuite) TestCreateCategory_ParentNotFound() {
+	ctx := context.Background()
+	parentID := int64(42)
+	category := entity.Category{ParentID: &parentID, Title: "Course"}
+
+	s.repoMock.On("GetPath", ctx, parentID).Return(nil, nil).Once()
+
+	id, err := s.usecase.Create(ctx, category)
+
+	s.ErrorIs(err, ErrParentCategoryNotFound)
+	s.Zero(id)
+	s.repoMock.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+// Moderators
+func (s *CategoryUsecaseSuite) TestAddModerator_Success() {
+	ctx := context.Background()
+	categoryID := int64(1)
+	userID := int64(5)
+
+	s.repoMock.On("GetByID", ctx, categoryID).Return(&entity.Category{ID: categoryID}, nil).Once()
+	s.moderatorRepo.On("Add", ctx, categoryID, userID).Return(nil).Once()
+
+	err := s.usecase.AddModerator(ctx, categoryID, userID)
+
+	s.NoError(err)
+	s.moderatorRepo.AssertExpectations(s.T())
+}
+
+func (s *CategoryUsecaseSuite) TestAddModerator_CategoryNotFound() {
+	ctx := context.Background()
+	categoryID := int64(1)
+
+	s.repoMock.On("GetByID", ctx, categoryID).Return(nil, pgx.ErrNoRows).Once()
+
+	err := s.usecase.AddModerator(ctx, categoryID, 5)
+
+	s.ErrorIs(err, ErrCategoryNotFound)
+	s.moderatorRepo.AssertNotCalled(s.T(), "Add", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *CategoryUsecaseSuite) TestGetModerators_Success() {
+	ctx := context.Background()
+	categoryID := int64(1)
+	expected := []entity.CategoryModerator{{CategoryID: categoryID, UserID: 5}}
+
+	s.repoMock.On("GetByID", ctx, categoryID).Return(&entity.Category{ID: categoryID}, nil).Once()
+	s.moderatorRepo.On("GetByCategory", ctx, categoryID).Return(expected, nil).Once()
+
+	moderators, err := s.usecase.GetModerators(ctx, categoryID)
+
+	s.NoError(err)
+	s.Equal(expected, moderators)
+}
+
+func (s *CategoryUsecaseSuite) TestRemoveModerator_NotModerator() {
+	ctx := context.Background()
+	categoryID := int64(1)
+	userID := int64(5)
+
+	s.moderatorRepo.On("Remove", ctx, categoryID, userID).Return(fmt.Errorf("ModeratorRepository - Remove: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.RemoveModerator(ctx, categoryID, userID)
+
+	s.ErrorIs(err, ErrModeratorNotFound)
+}
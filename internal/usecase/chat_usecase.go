@@ -2,49 +2,380 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/client"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/forum-service-sstu-forum/internal/repo"
 	"github.com/rs/zerolog"
 )
 
 type chatUsecase struct {
-	chatRepo repo.ChatRepository
-	log      *zerolog.Logger
+	chatRepo         repo.ChatRepository
+	conversationRepo repo.ConversationRepository
+	transactor       repo.Transactor
+	userClient       client.UserClient
+	editWindow       time.Duration
+	log              *zerolog.Logger
 }
 
-func NewChatUsecase(chatRepo repo.ChatRepository, log *zerolog.Logger) ChatUsecase {
+func NewChatUsecase(chatRepo repo.ChatRepository, conversationRepo repo.ConversationRepository, transactor repo.Transactor, userClient client.UserClient, editWindow time.Duration, log *zerolog.Logger) ChatUsecase {
 	return &chatUsecase{
-		chatRepo: chatRepo,
-		log:      log,
+		chatRepo:         chatRepo,
+		conversationRepo: conversationRepo,
+		transactor:       transactor,
+		userClient:       userClient,
+		editWindow:       editWindow,
+		log:              log,
 	}
 }
 
-func (u *chatUsecase) GetMessageHistory(ctx context.Context, limit int64) ([]entity.ChatMessage, error) {
-	messages, err := u.chatRepo.GetMessages(ctx, limit)
+// GetMessageHistory returns a page of messages of a room older than before, the latest
+// page if before is 0.
+func (u *chatUsecase) GetMessageHistory(ctx context.Context, roomID int64, before int64, limit int64) ([]entity.ChatMessage, error) {
+	messages, err := u.chatRepo.GetMessages(ctx, roomID, before, limit)
 	if err != nil {
-		u.log.Error().Err(err).Str("op", "ChatUsecase.GetMessageHistory").Msg("Failed to get message history")
+		u.log.Error().Err(err).Str("op", "ChatUsecase.GetMessageHistory").Int64("room_id", roomID).Msg("Failed to get message history")
 		return nil, fmt.Errorf("ChatUsecase - GetMessageHistory - u.chatRepo.GetMessages(): %w", err)
 	}
-	u.log.Info().Int64("limit", limit).Int64("total_messages", int64(len(messages))).Msg("Message history retrieved")
+	u.log.Info().Int64("room_id", roomID).Int64("before", before).Int64("limit", limit).Int64("total_messages", int64(len(messages))).Msg("Message history retrieved")
 	return messages, nil
 }
 
-func (u *chatUsecase) SaveMessage(ctx context.Context, userID int64, username string, content string) (*entity.ChatMessage, error) {
+// GetMissedMessages returns the messages of a room sent after the message with id since,
+// along with those sent shortly before it that may have been committed after it, so
+// callers dedupe by id. If more than limit messages have ids after since,
+// ErrHistoryGapTooLarge is returned instead; the ones sent shortly before do not count.
+func (u *chatUsecase) GetMissedMessages(ctx context.Context, roomID int64, since int64, limit int64) ([]entity.ChatMessage, error) {
+	messages, err := u.chatRepo.GetMessagesSince(ctx, roomID, since, limit+1)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", "ChatUsecase.GetMissedMessages").Int64("room_id", roomID).Msg("Failed to get missed messages")
+		return nil, fmt.Errorf("ChatUsecase - GetMissedMessages - u.chatRepo.GetMessagesSince(): %w", err)
+	}
+
+	var missed int64
+	for _, message := range messages {
+		if message.ID > since {
+			missed++
+		}
+	}
+	if missed > limit {
+		return nil, fmt.Errorf("ChatUsecase - GetMissedMessages: %w", ErrHistoryGapTooLarge)
+	}
+
+	return messages, nil
+}
+
+func (u *chatUsecase) SaveMessage(ctx context.Context, roomID int64, userID int64, username string, content string) (*entity.ChatMessage, error) {
 	message := &entity.ChatMessage{
+		RoomID:    roomID,
 		UserID:    userID,
 		Username:  username,
 		Content:   content,
 		CreatedAt: time.Now(),
 	}
 
-	if _, err := u.chatRepo.SaveMessage(ctx, message); err != nil {
+	id, err := u.chatRepo.SaveMessage(ctx, message)
+	if err != nil {
 		u.log.Error().Err(err).Str("op", "ChatUsecase.SaveMessage").Msg("Failed to save message")
 		return nil, fmt.Errorf("ChatUsecase - SaveMessage - u.chatRepo.SaveMessage(): %w", err)
 	}
 
-	u.log.Info().Int64("user_id", message.UserID).Str("username", message.Username).Msg("Message saved successfully")
+	message.ID = id
+
+	u.log.Info().Int64("room_id", roomID).Int64("user_id", message.UserID).Str("username", message.Username).Msg("Message saved successfully")
+	return message, nil
+}
+
+// EditMessage replaces the content of a chat message and returns the edited message.
+// The access check and the update run in one transaction. Blank content is rejected
+// with ErrEmptyChatMessage, messages are deleted with DeleteMessage.
+func (u *chatUsecase) EditMessage(ctx context.Context, messageID int64, userID int64, role string, content string) (*entity.ChatMessage, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("ChatUsecase - EditMessage: %w", ErrEmptyChatMessage)
+	}
+
+	var message *entity.ChatMessage
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if message, err = u.getModifiableMessage(ctx, messageID, userID, role); err != nil {
+			return err
+		}
+
+		editedAt, err := u.chatRepo.UpdateMessage(ctx, messageID, content)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("ChatUsecase - EditMessage - u.chatRepo.UpdateMessage(): %w", ErrChatMessageNotFound)
+			}
+			u.log.Error().Err(err).Str("op", "ChatUsecase.EditMessage").Int64("message_id", messageID).Msg("Failed to update message")
+			return fmt.Errorf("ChatUsecase - EditMessage - u.chatRepo.UpdateMessage(): %w", err)
+		}
+		message.Content = content
+		message.EditedAt = &editedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.log.Info().Int64("message_id", messageID).Int64("user_id", userID).Msg("Message edited successfully")
+	return message, nil
+}
+
+// DeleteMessage deletes a chat message and returns it with its content cleared.
+func (u *chatUsecase) DeleteMessage(ctx context.Context, messageID int64, userID int64, role string) (*entity.ChatMessage, error) {
+	var message *entity.ChatMessage
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if message, err = u.getModifiableMessage(ctx, messageID, userID, role); err != nil {
+			return err
+		}
+
+		deletedAt, err := u.chatRepo.DeleteMessage(ctx, messageID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("ChatUsecase - DeleteMessage - u.chatRepo.DeleteMessage(): %w", ErrChatMessageNotFound)
+			}
+			u.log.Error().Err(err).Str("op", "ChatUsecase.DeleteMessage").Int64("message_id", messageID).Msg("Failed to delete message")
+			return fmt.Errorf("ChatUsecase - DeleteMessage - u.chatRepo.DeleteMessage(): %w", err)
+		}
+		message.Content = ""
+		message.DeletedAt = &deletedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.log.Info().Int64("message_id", messageID).Int64("user_id", userID).Msg("Message deleted successfully")
+	return message, nil
+}
+
+// getModifiableMessage returns ErrChatMessageNotFound for missing and deleted messages
+// and ErrForbidden if the user may not change the message.
+func (u *chatUsecase) getModifiableMessage(ctx context.Context, messageID int64, userID int64, role string) (*entity.ChatMessage, error) {
+	message, err := u.chatRepo.GetMessage(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ChatUsecase - getModifiableMessage - u.chatRepo.GetMessage(): %w", ErrChatMessageNotFound)
+		}
+		u.log.Error().Err(err).Str("op", "ChatUsecase.getModifiableMessage").Int64("message_id", messageID).Msg("Failed to get message")
+		return nil, fmt.Errorf("ChatUsecase - getModifiableMessage - u.chatRepo.GetMessage(): %w", err)
+	}
+	if message.DeletedAt != nil {
+		return nil, fmt.Errorf("ChatUsecase - getModifiableMessage: %w", ErrChatMessageNotFound)
+	}
+	if !policy.CanModifyChatMessage(userID, role, message, u.editWindow) {
+		u.log.Warn().Str("op", "ChatUsecase.getModifiableMessage").Int64("message_id", messageID).Int64("user_id", userID).Msg("Access denied")
+		return nil, fmt.Errorf("ChatUsecase - getModifiableMessage: %w", ErrForbidden)
+	}
+
+	return message, nil
+}
+
+// GetRooms lists the chat rooms, rooms of hidden categories only for admins.
+func (u *chatUsecase) GetRooms(ctx context.Context, role string) ([]entity.ChatRoom, error) {
+	rooms, err := u.chatRepo.GetRooms(ctx)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", "ChatUsecase.GetRooms").Msg("Failed to get rooms")
+		return nil, fmt.Errorf("ChatUsecase - GetRooms - u.chatRepo.GetRooms(): %w", err)
+	}
+
+	visible := make([]entity.ChatRoom, 0, len(rooms))
+	for _, room := range rooms {
+		if !room.Hidden || policy.IsAdmin(role) {
+			visible = append(visible, room)
+		}
+	}
+
+	return visible, nil
+}
+
+// GetRoom returns ErrChatRoomNotFound for rooms the role is not allowed to see.
+func (u *chatUsecase) GetRoom(ctx context.Context, roomID int64, role string) (*entity.ChatRoom, error) {
+	room, err := u.chatRepo.GetRoom(ctx, roomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ChatUsecase - GetRoom - u.chatRepo.GetRoom(): %w", ErrChatRoomNotFound)
+		}
+		u.log.Error().Err(err).Str("op", "ChatUsecase.GetRoom").Int64("room_id", roomID).Msg("Failed to get room")
+		return nil, fmt.Errorf("ChatUsecase - GetRoom - u.chatRepo.GetRoom(): %w", err)
+	}
+	if room.Hidden && !policy.IsAdmin(role) {
+		return nil, fmt.Errorf("ChatUsecase - GetRoom: %w", ErrChatRoomNotFound)
+	}
+
+	return room, nil
+}
+
+func (u *chatUsecase) CreateRoom(ctx context.Context, userID int64, name string) (*entity.ChatRoom, error) {
+	room := &entity.ChatRoom{Kind: entity.ChatRoomCustom, Name: name, CreatedBy: &userID}
+
+	if _, err := u.chatRepo.CreateRoom(ctx, room); err != nil {
+		u.log.Error().Err(err).Str("op", "ChatUsecase.CreateRoom").Str("name", name).Msg("Failed to create room")
+		return nil, fmt.Errorf("ChatUsecase - CreateRoom - u.chatRepo.CreateRoom(): %w", err)
+	}
+
+	u.log.Info().Int64("room_id", room.ID).Int64("user_id", userID).Msg("Room created successfully")
+	return room, nil
+}
+
+// DeleteRoom deletes a room created by an admin. The general and category rooms
+// cannot be deleted, ErrChatRoomNotFound is returned for them.
+func (u *chatUsecase) DeleteRoom(ctx context.Context, roomID int64) error {
+	if err := u.chatRepo.DeleteRoom(ctx, roomID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("ChatUsecase - DeleteRoom - u.chatRepo.DeleteRoom(): %w", ErrChatRoomNotFound)
+		}
+		u.log.Error().Err(err).Str("op", "ChatUsecase.DeleteRoom").Int64("room_id", roomID).Msg("Failed to delete room")
+		return fmt.Errorf("ChatUsecase - DeleteRoom - u.chatRepo.DeleteRoom(): %w", err)
+	}
+
+	u.log.Info().Int64("room_id", roomID).Msg("Room deleted successfully")
+	return nil
+}
+
+// GetConversations lists the private conversations of a user. Peers the user service
+// cannot resolve right now get a placeholder name instead of failing the listing.
+func (u *chatUsecase) GetConversations(ctx context.Context, userID int64) ([]entity.Conversation, error) {
+	conversations, err := u.conversationRepo.GetByUser(ctx, userID)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", "ChatUsecase.GetConversations").Int64("user_id", userID).Msg("Failed to get conversations")
+		return nil, fmt.Errorf("ChatUsecase - GetConversations - u.conversationRepo.GetByUser(): %w", err)
+	}
+
+	peerIDs := make([]int64, 0, len(conversations))
+	for _, c := range conversations {
+		peerIDs = append(peerIDs, c.PeerID)
+	}
+
+	usernames, err := u.userClient.GetUsernames(ctx, peerIDs)
+	if err != nil {
+		u.log.Warn().Err(err).Str("op", "ChatUsecase.GetConversations").Msg("Failed to get usernames, serving placeholders")
+	}
+	for i := range conversations {
+		c := &conversations[i]
+		if err != nil {
+			c.PeerUsername = unavailableUsername
+			continue
+		}
+		c.PeerUsername = deletedUsername
+		if username, exists := usernames[c.PeerID]; exists {
+			c.PeerUsername = username
+		}
+	}
+
+	return conversations, nil
+}
+
+// GetConversationMessages returns a page of messages older than before, the latest page
+// if before is 0, with the usernames of the sender and the recipient. Loading the latest
+// page marks the conversation as read. Users outside the conversation get
+// ErrConversationNotFound.
+func (u *chatUsecase) GetConversationMessages(ctx context.Context, conversationID int64, userID int64, before int64, limit int64) ([]entity.DirectMessage, error) {
+	peerID, err := u.conversationRepo.GetPeer(ctx, conversationID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ChatUsecase - GetConversationMessages - u.conversationRepo.GetPeer(): %w", ErrConversationNotFound)
+		}
+		u.log.Error().Err(err).Str("op", "ChatUsecase.GetConversationMessages").Int64("conversation_id", conversationID).Msg("Failed to get conversation")
+		return nil, fmt.Errorf("ChatUsecase - GetConversationMessages - u.conversationRepo.GetPeer(): %w", err)
+	}
+
+	messages, err := u.conversationRepo.GetMessages(ctx, conversationID, before, limit)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", "ChatUsecase.GetConversationMessages").Int64("conversation_id", conversationID).Msg("Failed to get messages")
+		return nil, fmt.Errorf("ChatUsecase - GetConversationMessages - u.conversationRepo.GetMessages(): %w", err)
+	}
+
+	if before == 0 && len(messages) > 0 {
+		if err := u.conversationRepo.MarkRead(ctx, conversationID, userID, messages[len(messages)-1].ID); err != nil {
+			u.log.Error().Err(err).Str("op", "ChatUsecase.GetConversationMessages").Int64("conversation_id", conversationID).Msg("Failed to mark conversation read")
+			return nil, fmt.Errorf("ChatUsecase - GetConversationMessages - u.conversationRepo.MarkRead(): %w", err)
+		}
+	}
+
+	u.setDirectUsernames(ctx, messages, userID, peerID)
+	return messages, nil
+}
+
+// setDirectUsernames fills the recipient and both usernames of messages between userID
+// and peerID. Users the user service cannot resolve right now get a placeholder name.
+func (u *chatUsecase) setDirectUsernames(ctx context.Context, messages []entity.DirectMessage, userID int64, peerID int64) {
+	if len(messages) == 0 {
+		return
+	}
+
+	usernames, err := u.userClient.GetUsernames(ctx, []int64{userID, peerID})
+	if err != nil {
+		u.log.Warn().Err(err).Str("op", "ChatUsecase.GetConversationMessages").Msg("Failed to get usernames, serving placeholders")
+	}
+	username := func(id int64) string {
+		if err != nil {
+			return unavailableUsername
+		}
+		if name, exists := usernames[id]; exists {
+			return name
+		}
+		return deletedUsername
+	}
+
+	for i := range messages {
+		m := &messages[i]
+		m.RecipientID = peerID
+		if m.SenderID == peerID {
+			m.RecipientID = userID
+		}
+		m.SenderUsername = username(m.SenderID)
+		m.RecipientUsername = username(m.RecipientID)
+	}
+}
+
+// SendDirectMessage saves a private message, starting the conversation with its first
+// message. The returned message carries both usernames for delivery.
+func (u *chatUsecase) SendDirectMessage(ctx context.Context, senderID int64, senderUsername string, recipientID int64, content string) (*entity.DirectMessage, error) {
+	if recipientID == senderID {
+		return nil, fmt.Errorf("ChatUsecase - SendDirectMessage: %w", ErrInvalidRecipient)
+	}
+
+	recipientUsername, err := u.userClient.GetUsername(ctx, recipientID)
+	if err != nil {
+		if errors.Is(err, client.ErrUserNotFound) {
+			return nil, fmt.Errorf("ChatUsecase - SendDirectMessage - u.userClient.GetUsername(): %w", ErrRecipientNotFound)
+		}
+		u.log.Error().Err(err).Str("op", "ChatUsecase.SendDirectMessage").Int64("recipient_id", recipientID).Msg("Failed to get recipient username")
+		return nil, fmt.Errorf("ChatUsecase - SendDirectMessage - u.userClient.GetUsername(): %w", err)
+	}
+
+	message := &entity.DirectMessage{
+		SenderID:          senderID,
+		SenderUsername:    senderUsername,
+		RecipientID:       recipientID,
+		RecipientUsername: recipientUsername,
+		Content:           content,
+		CreatedAt:         time.Now(),
+	}
+
+	err = u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		conversationID, err := u.conversationRepo.GetOrCreate(ctx, senderID, recipientID)
+		if err != nil {
+			return err
+		}
+		message.ConversationID = conversationID
+
+		message.ID, err = u.conversationRepo.SaveMessage(ctx, message)
+		return err
+	})
+	if err != nil {
+		u.log.Error().Err(err).Str("op", "ChatUsecase.SendDirectMessage").Int64("sender_id", senderID).Int64("recipient_id", recipientID).Msg("Failed to save direct message")
+		return nil, fmt.Errorf("ChatUsecase - SendDirectMessage - u.transactor.WithinTx(): %w", err)
+	}
+
+	u.log.Info().Int64("conversation_id", message.ConversationID).Int64("sender_id", senderID).Int64("recipient_id", recipientID).Msg("Direct message saved successfully")
 	return message, nil
 }
@@ -3,9 +3,12 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/client"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	"github.com/keshvan/forum-service-sstu-forum/mocks"
 	"github.com/rs/zerolog"
@@ -16,16 +19,31 @@ import (
 
 type ChatUsecaseSuite struct {
 	suite.Suite
-	usecase      ChatUsecase
-	chatRepoMock *mocks.ChatRepository
-	log          *zerolog.Logger
+	usecase              ChatUsecase
+	chatRepoMock         *mocks.ChatRepository
+	conversationRepoMock *mocks.ConversationRepository
+	transactorMock       *mocks.Transactor
+	userClientMock       *mocks.UserClient
+	log                  *zerolog.Logger
 }
 
 func (s *ChatUsecaseSuite) SetupTest() {
 	s.chatRepoMock = mocks.NewChatRepository(s.T())
+	s.conversationRepoMock = mocks.NewConversationRepository(s.T())
+	s.transactorMock = mocks.NewTransactor(s.T())
+	s.userClientMock = mocks.NewUserClient(s.T())
 	logger := zerolog.Nop()
 	s.log = &logger
-	s.usecase = NewChatUsecase(s.chatRepoMock, s.log)
+	s.usecase = NewChatUsecase(s.chatRepoMock, s.conversationRepoMock, s.transactorMock, s.userClientMock, chatEditWindow, s.log)
+}
+
+const chatEditWindow = 15 * time.Minute
+
+// runInTx makes the transactor mock call the function it is given, like a real transaction would.
+func (s *ChatUsecaseSuite) runInTx(ctx context.Context) {
+	s.transactorMock.On("WithinTx", ctx, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).Once()
 }
 
 func TestChatUsecaseSuite(t *testing.T) {
@@ -41,9 +59,9 @@ func (s *ChatUsecaseSuite) TestGetMessageHistory_Success() {
 		{ID: 2, UserID: 2, Username: "user2", Content: "msg2", CreatedAt: time.Now()},
 	}
 
-	s.chatRepoMock.On("GetMessages", ctx, limit).Return(expectedMessages, nil).Once()
+	s.chatRepoMock.On("GetMessages", ctx, entity.GeneralChatRoomID, int64(0), limit).Return(expectedMessages, nil).Once()
 
-	messages, err := s.usecase.GetMessageHistory(ctx, limit)
+	messages, err := s.usecase.GetMessageHistory(ctx, entity.GeneralChatRoomID, 0, limit)
 
 	s.NoError(err)
 	s.NotNil(messages)
@@ -56,9 +74,9 @@ func (s *ChatUsecaseSuite) TestGetMessageHistory_RepoError() {
 	limit := int64(50)
 	expectedError := errors.New("repository error")
 
-	s.chatRepoMock.On("GetMessages", ctx, limit).Return(nil, expectedError).Once()
+	s.chatRepoMock.On("GetMessages", ctx, entity.GeneralChatRoomID, int64(0), limit).Return(nil, expectedError).Once()
 
-	messages, err := s.usecase.GetMessageHistory(ctx, limit)
+	messages, err := s.usecase.GetMessageHistory(ctx, entity.GeneralChatRoomID, 0, limit)
 
 	s.Error(err)
 	s.Nil(messages)
@@ -67,9 +85,34 @@ func (s *ChatUsecaseSuite) TestGetMessageHistory_RepoError() {
 	s.chatRepoMock.AssertExpectations(s.T())
 }
 
+// GetMissedMessages
+func (s *ChatUsecaseSuite) TestGetMissedMessages() {
+	ctx := context.Background()
+	missed := []entity.ChatMessage{{ID: 11}, {ID: 12}}
+	s.chatRepoMock.On("GetMessagesSince", ctx, entity.GeneralChatRoomID, int64(10), int64(3)).Return(missed, nil).Once()
+
+	messages, err := s.usecase.GetMissedMessages(ctx, entity.GeneralChatRoomID, 10, 2)
+	s.NoError(err)
+	s.Equal(missed, messages)
+
+	s.chatRepoMock.On("GetMessagesSince", ctx, entity.GeneralChatRoomID, int64(10), int64(2)).Return(missed, nil).Once()
+
+	_, err = s.usecase.GetMissedMessages(ctx, entity.GeneralChatRoomID, 10, 1)
+	s.ErrorIs(err, ErrHistoryGapTooLarge)
+
+	// Messages from the overlap before since do not count against the limit.
+	withOverlap := []entity.ChatMessage{{ID: 8}, {ID: 9}, {ID: 11}}
+	s.chatRepoMock.On("GetMessagesSince", ctx, entity.GeneralChatRoomID, int64(10), int64(2)).Return(withOverlap, nil).Once()
+
+	messages, err = s.usecase.GetMissedMessages(ctx, entity.GeneralChatRoomID, 10, 1)
+	s.NoError(err)
+	s.Equal(withOverlap, messages)
+}
+
 // SaveMessage
 func (s *ChatUsecaseSuite) TestSaveMessage_Success() {
 	ctx := context.Background()
+	roomID := int64(3)
 	userID := int64(1)
 	username := "TestUser"
 	content := "This is a test message."
@@ -78,13 +121,15 @@ func (s *ChatUsecaseSuite) TestSaveMessage_Success() {
 	var capturedMessage *entity.ChatMessage
 	s.chatRepoMock.On("SaveMessage", ctx, mock.MatchedBy(func(msg *entity.ChatMessage) bool {
 		capturedMessage = msg
-		return msg.UserID == userID && msg.Username == username && msg.Content == content
+		return msg.RoomID == roomID && msg.UserID == userID && msg.Username == username && msg.Content == content
 	})).Return(expectedMessageID, nil).Once()
 
-	savedMessage, err := s.usecase.SaveMessage(ctx, userID, username, content)
+	savedMessage, err := s.usecase.SaveMessage(ctx, roomID, userID, username, content)
 
 	s.NoError(err)
 	s.NotNil(savedMessage)
+	s.Equal(expectedMessageID, savedMessage.ID)
+	s.Equal(roomID, savedMessage.RoomID)
 	s.Equal(userID, savedMessage.UserID)
 	s.Equal(username, savedMessage.Username)
 	s.Equal(content, savedMessage.Content)
@@ -110,7 +155,7 @@ func (s *ChatUsecaseSuite) TestSaveMessage_RepoError() {
 		return msg.UserID == userID && msg.Username == username && msg.Content == content
 	})).Return(int64(0), expectedError).Once()
 
-	savedMessage, err := s.usecase.SaveMessage(ctx, userID, username, content)
+	savedMessage, err := s.usecase.SaveMessage(ctx, entity.GeneralChatRoomID, userID, username, content)
 
 	s.Error(err)
 	s.Nil(savedMessage)
@@ -118,3 +163,239 @@ func (s *ChatUsecaseSuite) TestSaveMessage_RepoError() {
 	s.ErrorIs(err, expectedError)
 	s.chatRepoMock.AssertExpectations(s.T())
 }
+
+// Rooms
+func (s *ChatUsecaseSuite) TestGetRooms_HiddenOnlyForAdmins() {
+	ctx := context.Background()
+	rooms := []entity.ChatRoom{
+		{ID: 1, Kind: entity.ChatRoomGeneral, Name: "Общий чат"},
+		{ID: 2, Kind: entity.ChatRoomCategory, Name: "Модераторская", Hidden: true},
+	}
+	s.chatRepoMock.On("GetRooms", ctx).Return(rooms, nil).Twice()
+
+	visible, err := s.usecase.GetRooms(ctx, "user")
+	s.NoError(err)
+	s.Equal(rooms[:1], visible)
+
+	visible, err = s.usecase.GetRooms(ctx, "admin")
+	s.NoError(err)
+	s.Equal(rooms, visible)
+}
+
+func (s *ChatUsecaseSuite) TestGetRoom_NotFound() {
+	ctx := context.Background()
+	s.chatRepoMock.On("GetRoom", ctx, int64(9)).Return(nil, pgx.ErrNoRows).Once()
+	s.chatRepoMock.On("GetRoom", ctx, int64(2)).Return(&entity.ChatRoom{ID: 2, Hidden: true}, nil).Twice()
+
+	_, err := s.usecase.GetRoom(ctx, 9, "admin")
+	s.ErrorIs(err, ErrChatRoomNotFound)
+
+	_, err = s.usecase.GetRoom(ctx, 2, "user")
+	s.ErrorIs(err, ErrChatRoomNotFound)
+
+	room, err := s.usecase.GetRoom(ctx, 2, "admin")
+	s.NoError(err)
+	s.Equal(int64(2), room.ID)
+}
+
+func (s *ChatUsecaseSuite) TestCreateRoom() {
+	ctx := context.Background()
+	userID := int64(1)
+	s.chatRepoMock.On("CreateRoom", ctx, mock.MatchedBy(func(room *entity.ChatRoom) bool {
+		room.ID = 5
+		return room.Kind == entity.ChatRoomCustom && room.Name == "Экзамены" && *room.CreatedBy == userID
+	})).Return(int64(5), nil).Once()
+
+	room, err := s.usecase.CreateRoom(ctx, userID, "Экзамены")
+
+	s.NoError(err)
+	s.Equal(int64(5), room.ID)
+}
+
+func (s *ChatUsecaseSuite) TestDeleteRoom_NotFound() {
+	ctx := context.Background()
+	s.chatRepoMock.On("DeleteRoom", ctx, entity.GeneralChatRoomID).Return(fmt.Errorf("ChatRepository - DeleteRoom: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.DeleteRoom(ctx, entity.GeneralChatRoomID)
+
+	s.ErrorIs(err, ErrChatRoomNotFound)
+}
+
+// GetConversations
+func (s *ChatUsecaseSuite) TestGetConversations_ResolvesPeers() {
+	ctx := context.Background()
+	s.conversationRepoMock.On("GetByUser", ctx, int64(1)).Return([]entity.Conversation{{ID: 3, PeerID: 2}, {ID: 4, PeerID: 5}}, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{2, 5}).Return(map[int64]string{2: "bob"}, nil).Once()
+
+	conversations, err := s.usecase.GetConversations(ctx, 1)
+
+	s.NoError(err)
+	s.Equal("bob", conversations[0].PeerUsername)
+	s.Equal(deletedUsername, conversations[1].PeerUsername)
+}
+
+func (s *ChatUsecaseSuite) TestGetConversations_UserServiceDown() {
+	ctx := context.Background()
+	s.conversationRepoMock.On("GetByUser", ctx, int64(1)).Return([]entity.Conversation{{ID: 3, PeerID: 2}}, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{2}).Return(nil, errors.New("unavailable")).Once()
+
+	conversations, err := s.usecase.GetConversations(ctx, 1)
+
+	s.NoError(err)
+	s.Equal(unavailableUsername, conversations[0].PeerUsername)
+}
+
+// GetConversationMessages
+func (s *ChatUsecaseSuite) TestGetConversationMessages_LatestPageMarksRead() {
+	ctx := context.Background()
+	messages := []entity.DirectMessage{{ID: 8, ConversationID: 3, SenderID: 1}, {ID: 9, ConversationID: 3, SenderID: 2}}
+	s.conversationRepoMock.On("GetPeer", ctx, int64(3), int64(1)).Return(int64(2), nil).Once()
+	s.conversationRepoMock.On("GetMessages", ctx, int64(3), int64(0), int64(50)).Return(messages, nil).Once()
+	s.conversationRepoMock.On("MarkRead", ctx, int64(3), int64(1), int64(9)).Return(nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{1, 2}).Return(map[int64]string{1: "alice", 2: "bob"}, nil).Once()
+
+	result, err := s.usecase.GetConversationMessages(ctx, 3, 1, 0, 50)
+
+	s.NoError(err)
+	s.Equal([]entity.DirectMessage{
+		{ID: 8, ConversationID: 3, SenderID: 1, SenderUsername: "alice", RecipientID: 2, RecipientUsername: "bob"},
+		{ID: 9, ConversationID: 3, SenderID: 2, SenderUsername: "bob", RecipientID: 1, RecipientUsername: "alice"},
+	}, result)
+}
+
+func (s *ChatUsecaseSuite) TestGetConversationMessages_OlderPageKeepsUnread() {
+	ctx := context.Background()
+	s.conversationRepoMock.On("GetPeer", ctx, int64(3), int64(1)).Return(int64(2), nil).Once()
+	s.conversationRepoMock.On("GetMessages", ctx, int64(3), int64(8), int64(50)).Return([]entity.DirectMessage{{ID: 7, SenderID: 2}}, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{1, 2}).Return(map[int64]string{1: "alice", 2: "bob"}, nil).Once()
+
+	_, err := s.usecase.GetConversationMessages(ctx, 3, 1, 8, 50)
+
+	s.NoError(err)
+}
+
+func (s *ChatUsecaseSuite) TestGetConversationMessages_UsernamePlaceholders() {
+	ctx := context.Background()
+	s.conversationRepoMock.On("GetPeer", ctx, int64(3), int64(1)).Return(int64(2), nil).Twice()
+	s.conversationRepoMock.On("GetMessages", ctx, int64(3), int64(8), int64(50)).Return([]entity.DirectMessage{{ID: 7, SenderID: 2}}, nil).Twice()
+
+	// The peer has deleted their account.
+	s.userClientMock.On("GetUsernames", ctx, []int64{1, 2}).Return(map[int64]string{1: "alice"}, nil).Once()
+	result, err := s.usecase.GetConversationMessages(ctx, 3, 1, 8, 50)
+	s.NoError(err)
+	s.Equal(deletedUsername, result[0].SenderUsername)
+	s.Equal("alice", result[0].RecipientUsername)
+
+	s.userClientMock.On("GetUsernames", ctx, []int64{1, 2}).Return(nil, errors.New("unavailable")).Once()
+	result, err = s.usecase.GetConversationMessages(ctx, 3, 1, 8, 50)
+	s.NoError(err)
+	s.Equal(unavailableUsername, result[0].SenderUsername)
+	s.Equal(unavailableUsername, result[0].RecipientUsername)
+}
+
+func (s *ChatUsecaseSuite) TestGetConversationMessages_NotAParticipant() {
+	ctx := context.Background()
+	s.conversationRepoMock.On("GetPeer", ctx, int64(3), int64(9)).Return(int64(0), fmt.Errorf("ConversationRepository - GetPeer: %w", pgx.ErrNoRows)).Once()
+
+	_, err := s.usecase.GetConversationMessages(ctx, 3, 9, 0, 50)
+
+	s.ErrorIs(err, ErrConversationNotFound)
+}
+
+// SendDirectMessage
+func (s *ChatUsecaseSuite) TestSendDirectMessage_Success() {
+	ctx := context.Background()
+	s.userClientMock.On("GetUsername", ctx, int64(2)).Return("bob", nil).Once()
+	s.runInTx(ctx)
+	s.conversationRepoMock.On("GetOrCreate", ctx, int64(1), int64(2)).Return(int64(3), nil).Once()
+	s.conversationRepoMock.On("SaveMessage", ctx, mock.MatchedBy(func(m *entity.DirectMessage) bool {
+		return m.ConversationID == 3 && m.SenderID == 1 && m.Content == "hi"
+	})).Return(int64(10), nil).Once()
+
+	message, err := s.usecase.SendDirectMessage(ctx, 1, "alice", 2, "hi")
+
+	s.NoError(err)
+	s.Equal(int64(10), message.ID)
+	s.Equal(int64(3), message.ConversationID)
+	s.Equal("alice", message.SenderUsername)
+	s.Equal("bob", message.RecipientUsername)
+}
+
+func (s *ChatUsecaseSuite) TestSendDirectMessage_InvalidRecipient() {
+	ctx := context.Background()
+
+	_, err := s.usecase.SendDirectMessage(ctx, 1, "alice", 1, "hi")
+	s.ErrorIs(err, ErrInvalidRecipient)
+
+	s.userClientMock.On("GetUsername", ctx, int64(9)).Return("", fmt.Errorf("client: %w", client.ErrUserNotFound)).Once()
+	_, err = s.usecase.SendDirectMessage(ctx, 1, "alice", 9, "hi")
+	s.ErrorIs(err, ErrRecipientNotFound)
+}
+
+// EditMessage
+func (s *ChatUsecaseSuite) TestEditMessage_AuthorWithinWindow() {
+	ctx := context.Background()
+	editedAt := time.Now()
+	s.runInTx(ctx)
+	s.chatRepoMock.On("GetMessage", ctx, int64(7)).Return(&entity.ChatMessage{ID: 7, RoomID: 3, UserID: 1, Content: "helo", CreatedAt: time.Now().Add(-time.Minute)}, nil).Once()
+	s.chatRepoMock.On("UpdateMessage", ctx, int64(7), "hello").Return(editedAt, nil).Once()
+
+	message, err := s.usecase.EditMessage(ctx, 7, 1, "user", "hello")
+
+	s.NoError(err)
+	s.Equal("hello", message.Content)
+	s.Equal(int64(3), message.RoomID)
+	s.Equal(&editedAt, message.EditedAt)
+}
+
+func (s *ChatUsecaseSuite) TestEditMessage_Forbidden() {
+	ctx := context.Background()
+	old := &entity.ChatMessage{ID: 7, UserID: 1, CreatedAt: time.Now().Add(-chatEditWindow - time.Minute)}
+
+	s.runInTx(ctx)
+	s.chatRepoMock.On("GetMessage", ctx, int64(7)).Return(old, nil).Once()
+	_, err := s.usecase.EditMessage(ctx, 7, 1, "user", "hello")
+	s.ErrorIs(err, ErrForbidden)
+
+	s.runInTx(ctx)
+	s.chatRepoMock.On("GetMessage", ctx, int64(7)).Return(&entity.ChatMessage{ID: 7, UserID: 1, CreatedAt: time.Now()}, nil).Once()
+	_, err = s.usecase.EditMessage(ctx, 7, 2, "user", "hello")
+	s.ErrorIs(err, ErrForbidden)
+}
+
+func (s *ChatUsecaseSuite) TestEditMessage_EmptyContent() {
+	ctx := context.Background()
+
+	_, err := s.usecase.EditMessage(ctx, 7, 1, "user", " \n\t")
+	s.ErrorIs(err, ErrEmptyChatMessage)
+}
+
+// DeleteMessage
+func (s *ChatUsecaseSuite) TestDeleteMessage_AdminAfterWindow() {
+	ctx := context.Background()
+	deletedAt := time.Now()
+	s.runInTx(ctx)
+	s.chatRepoMock.On("GetMessage", ctx, int64(7)).Return(&entity.ChatMessage{ID: 7, RoomID: 3, UserID: 1, Content: "spam", CreatedAt: time.Now().Add(-24 * time.Hour)}, nil).Once()
+	s.chatRepoMock.On("DeleteMessage", ctx, int64(7)).Return(deletedAt, nil).Once()
+
+	message, err := s.usecase.DeleteMessage(ctx, 7, 2, "admin")
+
+	s.NoError(err)
+	s.Empty(message.Content)
+	s.Equal(&deletedAt, message.DeletedAt)
+}
+
+func (s *ChatUsecaseSuite) TestDeleteMessage_NotFound() {
+	ctx := context.Background()
+	deletedAt := time.Now()
+
+	s.runInTx(ctx)
+	s.chatRepoMock.On("GetMessage", ctx, int64(7)).Return(nil, fmt.Errorf("ChatRepository - GetMessage: %w", pgx.ErrNoRows)).Once()
+	_, err := s.usecase.DeleteMessage(ctx, 7, 1, "admin")
+	s.ErrorIs(err, ErrChatMessageNotFound)
+
+	s.runInTx(ctx)
+	s.chatRepoMock.On("GetMessage", ctx, int64(8)).Return(&entity.ChatMessage{ID: 8, UserID: 1, CreatedAt: time.Now(), DeletedAt: &deletedAt}, nil).Once()
+	_, err = s.usecase.DeleteMessage(ctx, 8, 1, "user")
+	s.ErrorIs(err, ErrChatMessageNotFound)
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
 )
@@ -9,29 +10,73 @@ import (
 type (
 	CategoryUsecase interface {
 		Create(context.Context, entity.Category) (int64, error)
-		GetByID(ctx context.Context, id int64) (*entity.Category, error)
-		GetAll(context.Context) ([]entity.Category, error)
-		Update(ctx context.Context, id int64, title, description string) error
+		GetByID(ctx context.Context, id int64, includeHidden bool) (*entity.Category, bool, error)
+		GetAll(ctx context.Context, includeHidden bool) ([]entity.Category, bool, error)
+		GetTree(ctx context.Context, includeHidden bool) ([]*entity.CategoryNode, bool, error)
+		Update(ctx context.Context, id int64, title, description *string, parentID *int64, hidden *bool, version *time.Time) error
+		Reorder(ctx context.Context, ids []int64) error
 		Delete(ctx context.Context, id int64) error
+		GetModerators(ctx context.Context, categoryID int64) ([]entity.CategoryModerator, error)
+		AddModerator(ctx context.Context, categoryID int64, userID int64) error
+		RemoveModerator(ctx context.Context, categoryID int64, userID int64) error
 	}
 
 	PostUsecase interface {
 		Create(context.Context, entity.Post) (int64, error)
-		GetByTopic(ctx context.Context, topicID int64) ([]entity.Post, error)
-		Update(ctx context.Context, postID int64, userID int64, role string, content string) error
+		GetByTopic(ctx context.Context, topicID int64, viewerID int64, includeHidden bool, page entity.PageRequest) ([]entity.Post, entity.PageInfo, error)
+		GetTree(ctx context.Context, topicID int64, viewerID int64, includeHidden bool, page entity.PageRequest, maxDepth int) ([]*entity.PostNode, entity.PageInfo, error)
+		GetByID(ctx context.Context, postID int64, includeHidden bool) (*entity.Post, bool, error)
+		GetThread(ctx context.Context, postID int64, maxDepth int) (*entity.PostThread, bool, error)
+		Update(ctx context.Context, postID int64, userID int64, role string, content string, version *time.Time) error
 		Delete(ctx context.Context, postID int64, userID int64, role string) error
+		Restore(ctx context.Context, postID int64) error
+		GetRevisions(ctx context.Context, postID int64, userID int64, role string) ([]entity.PostRevision, error)
+		DiffRevisions(ctx context.Context, postID int64, userID int64, role string, from int64, to *int64) (*entity.RevisionDiff, error)
+		AddReaction(ctx context.Context, postID int64, userID int64, reaction string) error
+		RemoveReaction(ctx context.Context, postID int64, userID int64, reaction string) error
 	}
 
 	TopicUsecase interface {
-		Create(context.Context, entity.Topic) (int64, error)
-		GetByID(ctx context.Context, id int64) (*entity.Topic, error)
-		GetByCategory(ct context.Context, categoryID int64) ([]entity.Topic, error)
-		Update(ctx context.Context, topicID int64, userID int64, role string, title string) error
+		Create(ctx context.Context, topic entity.Topic, content string) (int64, error)
+		GetByID(ctx context.Context, id int64) (*entity.Topic, bool, error)
+		GetByCategory(ctx context.Context, categoryID int64, includeHidden bool, sort entity.TopicSort, page entity.PageRequest) ([]entity.Topic, entity.PageInfo, error)
+		Update(ctx context.Context, topicID int64, userID int64, role string, title string, version *time.Time) error
 		Delete(ctx context.Context, topicID int64, userID int64, role string) error
+		Restore(ctx context.Context, topicID int64) error
+		SetState(ctx context.Context, topicID int64, userID int64, role string, pinned *bool, locked *bool) error
+	}
+
+	// ResponseCache evicts cached responses that show an entity. The usecases call it once
+	// a write has committed.
+	ResponseCache interface {
+		Invalidate()
+		InvalidateCategory(categoryID int64)
+		InvalidateTopic(categoryID, topicID int64)
+		InvalidatePosts(topicID int64)
+	}
+
+	// PostFeed receives every new post, including opening posts of topics, once it
+	// has committed.
+	PostFeed interface {
+		Publish(post entity.Post)
+	}
+
+	SearchUsecase interface {
+		Search(ctx context.Context, query entity.SearchQuery) ([]entity.SearchResult, bool, error)
 	}
 
 	ChatUsecase interface {
-		GetMessageHistory(ctx context.Context, limit int64) ([]entity.ChatMessage, error)
-		SaveMessage(ctx context.Context, userID int64, username string, content string) (*entity.ChatMessage, error)
+		GetMessageHistory(ctx context.Context, roomID int64, before int64, limit int64) ([]entity.ChatMessage, error)
+		GetMissedMessages(ctx context.Context, roomID int64, since int64, limit int64) ([]entity.ChatMessage, error)
+		SaveMessage(ctx context.Context, roomID int64, userID int64, username string, content string) (*entity.ChatMessage, error)
+		EditMessage(ctx context.Context, messageID int64, userID int64, role string, content string) (*entity.ChatMessage, error)
+		DeleteMessage(ctx context.Context, messageID int64, userID int64, role string) (*entity.ChatMessage, error)
+		GetRooms(ctx context.Context, role string) ([]entity.ChatRoom, error)
+		GetRoom(ctx context.Context, roomID int64, role string) (*entity.ChatRoom, error)
+		CreateRoom(ctx context.Context, userID int64, name string) (*entity.ChatRoom, error)
+		DeleteRoom(ctx context.Context, roomID int64) error
+		GetConversations(ctx context.Context, userID int64) ([]entity.Conversation, error)
+		GetConversationMessages(ctx context.Context, conversationID int64, userID int64, before int64, limit int64) ([]entity.DirectMessage, error)
+		SendDirectMessage(ctx context.Context, senderID int64, senderUsername string, recipientID int64, content string) (*entity.DirectMessage, error)
 	}
 )
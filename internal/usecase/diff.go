@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+)
+
+// diffLines builds a line-level diff of two texts from their longest common subsequence.
+// The common prefix and suffix are cut off first, so typical small edits stay cheap.
+func diffLines(from, to string) []entity.DiffLine {
+	a := strings.Split(from, "\n")
+	b := strings.Split(to, "\n")
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]entity.DiffLine, 0, len(a)+len(b))
+	for _, l := range a[:prefix] {
+		lines = append(lines, entity.DiffLine{Op: entity.DiffEqual, Text: l})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	// lcs[i][j] is the LCS length of midA[i:] and midB[j:].
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(midA) && j < len(midB) {
+		switch {
+		case midA[i] == midB[j]:
+			lines = append(lines, entity.DiffLine{Op: entity.DiffEqual, Text: midA[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, entity.DiffLine{Op: entity.DiffDelete, Text: midA[i]})
+			i++
+		default:
+			lines = append(lines, entity.DiffLine{Op: entity.DiffInsert, Text: midB[j]})
+			j++
+		}
+	}
+	for ; i < len(midA); i++ {
+		lines = append(lines, entity.DiffLine{Op: entity.DiffDelete, Text: midA[i]})
+	}
+	for ; j < len(midB); j++ {
+		lines = append(lines, entity.DiffLine{Op: entity.DiffInsert, Text: midB[j]})
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		lines = append(lines, entity.DiffLine{Op: entity.DiffEqual, Text: l})
+	}
+
+	return lines
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import "github.com/keshvan/forum-service-sstu-forum/internal/entity"
+
+func normalizePage(page entity.PageRequest) entity.PageRequest {
+	if page.Limit <= 0 {
+		page.Limit = entity.DefaultPageLimit
+	}
+	if page.Limit > entity.MaxPageLimit {
+		page.Limit = entity.MaxPageLimit
+	}
+	return page
+}
+
+// repoPage asks the repository for one extra row so paginate can tell whether
+// another page exists in the requested direction.
+func repoPage(page entity.PageRequest) entity.PageRequest {
+	page.Limit++
+	return page
+}
+
+func paginate[T any](items []T, page entity.PageRequest, cursorOf func(T) entity.Cursor) ([]T, entity.PageInfo) {
+	var info entity.PageInfo
+	hasMore := int64(len(items)) > page.Limit
+
+	if page.Before != nil {
+		if hasMore {
+			items = items[int64(len(items))-page.Limit:]
+		}
+		if len(items) == 0 {
+			return items, info
+		}
+		if hasMore {
+			info.PrevCursor = cursorOf(items[0]).Encode()
+		}
+		info.NextCursor = cursorOf(items[len(items)-1]).Encode()
+		return items, info
+	}
+
+	if hasMore {
+		items = items[:page.Limit]
+	}
+	if len(items) == 0 {
+		return items, info
+	}
+	if hasMore {
+		info.NextCursor = cursorOf(items[len(items)-1]).Encode()
+	}
+	if page.After != nil {
+		info.PrevCursor = cursorOf(items[0]).Encode()
+	}
+	return items, info
+}
+
+// topicCursor returns the cursor function for a topic listing in the given sort order.
+func topicCursor(sort entity.TopicSort) func(entity.Topic) entity.Cursor {
+	switch sort {
+	case entity.TopicSortLatestActivity:
+		return func(t entity.Topic) entity.Cursor {
+			return entity.Cursor{Pinned: t.Pinned, CreatedAt: t.LastActivityAt, ID: t.ID}
+		}
+	case entity.TopicSortMostReplies:
+		return func(t entity.Topic) entity.Cursor {
+			return entity.Cursor{Pinned: t.Pinned, Replies: t.ReplyCount, ID: t.ID}
+		}
+	default:
+		return func(t entity.Topic) entity.Cursor {
+			return entity.Cursor{Pinned: t.Pinned, CreatedAt: t.CreatedAt, ID: t.ID}
+		}
+	}
+}
+
+func postCursor(p entity.Post) entity.Cursor {
+	return entity.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
@@ -4,19 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/client"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/forum-service-sstu-forum/internal/repo"
 	"github.com/rs/zerolog"
 )
 
 type postUsecase struct {
-	postRepo   repo.PostRepository
-	topicRepo  repo.TopicRepository
-	userClient client.UserClient
-	log        *zerolog.Logger
+	postRepo     repo.PostRepository
+	topicRepo    repo.TopicRepository
+	categoryRepo repo.CategoryRepository
+	reactionRepo repo.ReactionRepository
+	transactor   repo.Transactor
+	userClient   client.UserClient
+	policy       *policy.Policy
+	cache        ResponseCache
+	feed         PostFeed
+	log          *zerolog.Logger
 }
 
 const (
@@ -24,142 +32,617 @@ const (
 	getByTopicOp = "PostUsecase.GetByTopic"
 	deletePostOp = "PostUsecase.Delete"
 	updatePostOp = "PostUsecase.Update"
+	getTreeOp    = "PostUsecase.GetTree"
+	getThreadOp  = "PostUsecase.GetThread"
+	getPostOp    = "PostUsecase.GetByID"
+
+	addReactionOp    = "PostUsecase.AddReaction"
+	removeReactionOp = "PostUsecase.RemoveReaction"
+
+	getRevisionsOp  = "PostUsecase.GetRevisions"
+	diffRevisionsOp = "PostUsecase.DiffRevisions"
+
+	restorePostOp = "PostUsecase.Restore"
+)
+
+const (
+	deletedUsername     = "Удаленный пользователь"
+	unavailableUsername = "Пользователь"
+	deletedPostContent  = "Сообщение удалено"
 )
 
-func NewPostUsecase(postRepo repo.PostRepository, topicRepo repo.TopicRepository, userClient client.UserClient, log *zerolog.Logger) PostUsecase {
-	return &postUsecase{postRepo: postRepo, topicRepo: topicRepo, userClient: userClient, log: log}
+func NewPostUsecase(postRepo repo.PostRepository, topicRepo repo.TopicRepository, categoryRepo repo.CategoryRepository, reactionRepo repo.ReactionRepository, transactor repo.Transactor, userClient client.UserClient, policy *policy.Policy, cache ResponseCache, feed PostFeed, log *zerolog.Logger) PostUsecase {
+	return &postUsecase{postRepo: postRepo, topicRepo: topicRepo, categoryRepo: categoryRepo, reactionRepo: reactionRepo, transactor: transactor, userClient: userClient, policy: policy, cache: cache, feed: feed, log: log}
 }
 
 func (u *postUsecase) Create(ctx context.Context, post entity.Post) (int64, error) {
-	if err := u.checkTopic(ctx, post.TopicID); err != nil {
+	topic, err := u.checkTopic(ctx, post.TopicID)
+	if err != nil {
 		u.log.Error().Err(err).Str("op", createPostOp).Int64("topic_id", post.TopicID).Msg("Topic not found")
 		return 0, err
 	}
 
+	if topic.Locked {
+		u.log.Warn().Str("op", createPostOp).Int64("topic_id", post.TopicID).Msg("Topic is locked")
+		return 0, fmt.Errorf("ForumService - PostUsecase - Create: %w", ErrTopicLocked)
+	}
+
+	if post.ReplyTo != nil {
+		if err := u.checkReplyTarget(ctx, post.TopicID, *post.ReplyTo); err != nil {
+			u.log.Warn().Err(err).Str("op", createPostOp).Int64("topic_id", post.TopicID).Int64("reply_to", *post.ReplyTo).Msg("Invalid reply target")
+			return 0, err
+		}
+	}
+
 	id, err := u.postRepo.Create(ctx, post)
 	if err != nil {
 		u.log.Error().Err(err).Str("op", createPostOp).Any("post", post).Msg("Failed to create post in repository")
 		return 0, fmt.Errorf("ForumService - PostUsecase - Create - postRepo.Create(): %w", err)
 	}
+	u.cache.InvalidateTopic(topic.CategoryID, post.TopicID)
+	post.ID = id
+	u.feed.Publish(post)
 
 	u.log.Info().Str("op", createPostOp).Any("post", post).Msg("Post successfully created")
 	return id, nil
 }
 
-/*func (u *postUsecase) GetByID(ctx context.Context, id int64) (*entity.Post, error) {
-	post, err := u.postRepo.GetByID(ctx, id)
+// GetByTopic returns a page of the posts of a topic. Unless includeHidden is set, a topic
+// in a hidden category is reported as not found.
+func (u *postUsecase) GetByTopic(ctx context.Context, topicID int64, viewerID int64, includeHidden bool, page entity.PageRequest) ([]entity.Post, entity.PageInfo, error) {
+	if _, err := u.checkVisibleTopic(ctx, topicID, includeHidden); err != nil {
+		u.log.Error().Err(err).Str("op", getByTopicOp).Int64("topic_id", topicID).Msg("Topic not found")
+		return nil, entity.PageInfo{}, err
+	}
+
+	page = normalizePage(page)
+	posts, err := u.postRepo.GetByTopic(ctx, topicID, repoPage(page))
+	if err != nil {
+		u.log.Error().Err(err).Str("op", getByTopicOp).Int64("topic_id", topicID).Msg("Failed to get posts")
+		return nil, entity.PageInfo{}, fmt.Errorf("ForumService - PostUsecase - GetByTopic - postRepo.GetByTopic(): %w", err)
+	}
+
+	posts, pageInfo := paginate(posts, page, postCursor)
+
+	postRefs := make([]*entity.Post, len(posts))
+	for i := range posts {
+		postRefs[i] = &posts[i]
+	}
+	pageInfo.Degraded = u.setUsernamesOrPlaceholder(ctx, getByTopicOp, postRefs)
+	maskDeleted(postRefs)
+	if err := u.setReactions(ctx, postRefs, viewerID); err != nil {
+		return nil, entity.PageInfo{}, fmt.Errorf("ForumService - PostUsecase - GetByTopic - %w", err)
+	}
+
+	u.log.Info().Str("op", getByTopicOp).Int64("topic_id", topicID).Msg("Posts by topic succesfully taken")
+	return posts, pageInfo, nil
+}
+
+func (u *postUsecase) GetTree(ctx context.Context, topicID int64, viewerID int64, includeHidden bool, page entity.PageRequest, maxDepth int) ([]*entity.PostNode, entity.PageInfo, error) {
+	if _, err := u.checkVisibleTopic(ctx, topicID, includeHidden); err != nil {
+		u.log.Error().Err(err).Str("op", getTreeOp).Int64("topic_id", topicID).Msg("Topic not found")
+		return nil, entity.PageInfo{}, err
+	}
+
+	page = normalizePage(page)
+	nodes, err := u.postRepo.GetTree(ctx, topicID, repoPage(page), normalizeDepth(maxDepth))
+	if err != nil {
+		u.log.Error().Err(err).Str("op", getTreeOp).Int64("topic_id", topicID).Msg("Failed to get post tree")
+		return nil, entity.PageInfo{}, fmt.Errorf("ForumService - PostUsecase - GetTree - postRepo.GetTree(): %w", err)
+	}
+
+	posts := make([]*entity.Post, len(nodes))
+	for i := range nodes {
+		posts[i] = &nodes[i].Post
+	}
+	degraded := u.setUsernamesOrPlaceholder(ctx, getTreeOp, posts)
+	if err := u.setReactions(ctx, posts, viewerID); err != nil {
+		return nil, entity.PageInfo{}, fmt.Errorf("ForumService - PostUsecase - GetTree - %w", err)
+	}
+	maskDeleted(posts)
+
+	roots, pageInfo := paginate(buildPostTree(nodes), page, func(n *entity.PostNode) entity.Cursor {
+		return postCursor(n.Post)
+	})
+	pageInfo.Degraded = degraded
+
+	u.log.Info().Str("op", getTreeOp).Int64("topic_id", topicID).Msg("Post tree succesfully taken")
+	return roots, pageInfo, nil
+}
+
+// GetByID returns a single post, the one a client loads before editing it. Posts of
+// deleted topics, and unless includeHidden is set of topics in hidden categories, are
+// reported as not found. degraded is set when the author got a placeholder name.
+func (u *postUsecase) GetByID(ctx context.Context, postID int64, includeHidden bool) (*entity.Post, bool, error) {
+	post, err := u.postRepo.GetByID(ctx, postID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			u.log.Error().Err(err).Str("op", get).Any("post", post).Msg("Failed to create post in repository")
-			return nil, fmt.Errorf("ForumService - PostUsecase - GetByID - postRepo.GetByID(): %w", ErrPostNotFound)
+			return nil, false, fmt.Errorf("ForumService - PostUsecase - GetByID - postRepo.GetByID(): %w", ErrPostNotFound)
 		}
-		return nil, fmt.Errorf("ForumService - PostUsecase - GetByID - postRepo.GetByID(): %w", err)
+		u.log.Error().Err(err).Str("op", getPostOp).Int64("post_id", postID).Msg("Failed to get post in repository")
+		return nil, false, fmt.Errorf("ForumService - PostUsecase - GetByID - postRepo.GetByID(): %w", err)
 	}
-	return post, nil
-}*/
 
-func (u *postUsecase) GetByTopic(ctx context.Context, topicID int64) ([]entity.Post, error) {
-	if err := u.checkTopic(ctx, topicID); err != nil {
-		u.log.Error().Err(err).Str("op", getByTopicOp).Int64("topic_id", topicID).Msg("Topic not found")
-		return nil, err
+	if _, err := u.checkVisibleTopic(ctx, post.TopicID, includeHidden); err != nil {
+		if errors.Is(err, ErrTopicNotFound) {
+			return nil, false, fmt.Errorf("ForumService - PostUsecase - GetByID: %w", ErrPostNotFound)
+		}
+		u.log.Error().Err(err).Str("op", getPostOp).Int64("post_id", postID).Msg("Failed to get topic of post")
+		return nil, false, fmt.Errorf("ForumService - PostUsecase - GetByID - %w", err)
 	}
-	posts, err := u.postRepo.GetByTopic(ctx, topicID)
+
+	degraded := u.setUsernamesOrPlaceholder(ctx, getPostOp, []*entity.Post{post})
+
+	u.log.Info().Str("op", getPostOp).Int64("post_id", postID).Msg("Post taken successfully")
+	return post, degraded, nil
+}
+
+// GetThread returns a post with its replies and the posts above it.
+// degraded is set when authors got a placeholder name.
+func (u *postUsecase) GetThread(ctx context.Context, postID int64, maxDepth int) (*entity.PostThread, bool, error) {
+	nodes, err := u.postRepo.GetSubtree(ctx, postID, normalizeDepth(maxDepth))
 	if err != nil {
-		u.log.Error().Err(err).Str("op", getByTopicOp).Int64("topic_id", topicID).Msg("Failed to get posts")
-		return nil, fmt.Errorf("ForumService - PostUsecase - GetByTopic - postRepo.GetByTopic(): %w", err)
+		u.log.Error().Err(err).Str("op", getThreadOp).Int64("post_id", postID).Msg("Failed to get post subtree")
+		return nil, false, fmt.Errorf("ForumService - PostUsecase - GetThread - postRepo.GetSubtree(): %w", err)
+	}
+	if len(nodes) == 0 {
+		return nil, false, fmt.Errorf("ForumService - PostUsecase - GetThread - postRepo.GetSubtree(): %w", ErrPostNotFound)
 	}
 
-	var authorIDs []int64
-	authorIDSet := make(map[int64]bool)
-	for i := range posts {
-		if posts[i].AuthorID != nil {
-			if _, exists := authorIDSet[*posts[i].AuthorID]; !exists {
-				authorIDs = append(authorIDs, *posts[i].AuthorID)
-				authorIDSet[*posts[i].AuthorID] = true
+	ancestors, err := u.postRepo.GetAncestors(ctx, postID)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", getThreadOp).Int64("post_id", postID).Msg("Failed to get post ancestors")
+		return nil, false, fmt.Errorf("ForumService - PostUsecase - GetThread - postRepo.GetAncestors(): %w", err)
+	}
+
+	posts := make([]*entity.Post, 0, len(nodes)+len(ancestors))
+	for i := range ancestors {
+		posts = append(posts, &ancestors[i])
+	}
+	for i := range nodes {
+		posts = append(posts, &nodes[i].Post)
+	}
+	degraded := u.setUsernamesOrPlaceholder(ctx, getThreadOp, posts)
+	maskDeleted(posts)
+
+	if ancestors == nil {
+		ancestors = []entity.Post{}
+	}
+
+	u.log.Info().Str("op", getThreadOp).Int64("post_id", postID).Msg("Post thread succesfully taken")
+	return &entity.PostThread{Ancestors: ancestors, Post: buildPostTree(nodes)[0]}, degraded, nil
+}
+
+// Update edits a post. The access check and the update run in one transaction.
+// A non-nil version is the updated_at the caller last saw; if the post has changed
+// since, ErrVersionConflict is returned.
+func (u *postUsecase) Update(ctx context.Context, postID int64, userID int64, role string, content string, version *time.Time) error {
+	var post *entity.Post
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if post, err = u.lockForWrite(ctx, postID, userID, role); err != nil {
+			u.log.Warn().Err(err).Str("op", updatePostOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Access denied")
+			return err
+		}
+
+		if err := u.postRepo.Update(ctx, postID, content, userID, role, version); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				if version != nil {
+					u.log.Warn().Str("op", updatePostOp).Int64("post_id", postID).Msg("Post version conflict")
+					return fmt.Errorf("ForumService - PostUsecase - Update - postRepo.Update(): %w", ErrVersionConflict)
+				}
+				return fmt.Errorf("ForumService - PostUsecase - Update - postRepo.Update(): %w", ErrPostNotFound)
 			}
+			u.log.Error().Err(err).Str("op", updatePostOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Failed to update post in repository")
+			return fmt.Errorf("ForumService - PostUsecase - Update - postRepo.Update(): %w", err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	u.cache.InvalidatePosts(post.TopicID)
 
-	usernames, err := u.userClient.GetUsernames(ctx, authorIDs)
+	u.log.Info().Str("op", updatePostOp).Int64("post_id", postID).Msg("Post updated successfully")
+	return nil
+}
+
+// Delete soft-deletes a post. The access check and the delete run in one transaction.
+func (u *postUsecase) Delete(ctx context.Context, postID int64, userID int64, role string) error {
+	var post *entity.Post
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if post, err = u.lockForWrite(ctx, postID, userID, role); err != nil {
+			u.log.Warn().Err(err).Str("op", deletePostOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Access denied")
+			return err
+		}
+
+		if err := u.postRepo.Delete(ctx, postID, userID); err != nil {
+			u.log.Error().Err(err).Str("op", deletePostOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Failed to delete post in repository")
+			return fmt.Errorf("ForumService - PostUsecase - Delete - postRepo.delete(): %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("ForumService - TopicUsecase  - GetByCategory - userClient.GetUsernames(): %w", err)
+		return err
 	}
+	u.invalidateTopic(ctx, post.TopicID)
 
-	for i := range posts {
-		if posts[i].AuthorID == nil {
-			posts[i].Username = "Удаленный пользователь"
+	u.log.Info().Str("op", updatePostOp).Int64("post_id", postID).Msg("Post deleted successfully")
+	return nil
+}
+
+func (u *postUsecase) Restore(ctx context.Context, postID int64) error {
+	if err := u.postRepo.Restore(ctx, postID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("ForumService - PostUsecase - Restore - postRepo.Restore(): %w", ErrPostNotFound)
+		}
+		u.log.Error().Err(err).Str("op", restorePostOp).Int64("post_id", postID).Msg("Failed to restore post in repository")
+		return fmt.Errorf("ForumService - PostUsecase - Restore - postRepo.Restore(): %w", err)
+	}
+	// Restores are rare admin actions, the topic of the post is not looked up for them.
+	u.cache.Invalidate()
+
+	u.log.Info().Str("op", restorePostOp).Int64("post_id", postID).Msg("Post restored successfully")
+	return nil
+}
+
+func (u *postUsecase) GetRevisions(ctx context.Context, postID int64, userID int64, role string) ([]entity.PostRevision, error) {
+	if _, err := u.checkAccess(ctx, postID, userID, role); err != nil {
+		u.log.Warn().Err(err).Str("op", getRevisionsOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Access denied")
+		return nil, err
+	}
+
+	revisions, err := u.postRepo.GetRevisions(ctx, postID)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", getRevisionsOp).Int64("post_id", postID).Msg("Failed to get post revisions")
+		return nil, fmt.Errorf("ForumService - PostUsecase - GetRevisions - postRepo.GetRevisions(): %w", err)
+	}
+
+	var editorIDs []int64
+	editorIDSet := make(map[int64]bool)
+	for i := range revisions {
+		if revisions[i].EditorID != nil && !editorIDSet[*revisions[i].EditorID] {
+			editorIDs = append(editorIDs, *revisions[i].EditorID)
+			editorIDSet[*revisions[i].EditorID] = true
+		}
+	}
+
+	usernames, err := u.userClient.GetUsernames(ctx, editorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ForumService - PostUsecase - GetRevisions - userClient.GetUsernames(): %w", err)
+	}
+
+	for i := range revisions {
+		revisions[i].EditorUsername = deletedUsername
+		if revisions[i].EditorID == nil {
 			continue
 		}
+		if username, exists := usernames[*revisions[i].EditorID]; exists {
+			revisions[i].EditorUsername = username
+		}
+	}
+
+	if revisions == nil {
+		revisions = []entity.PostRevision{}
+	}
+
+	u.log.Info().Str("op", getRevisionsOp).Int64("post_id", postID).Int("count", len(revisions)).Msg("Post revisions succesfully taken")
+	return revisions, nil
+}
+
+// DiffRevisions compares two revisions of a post line by line.
+// A nil to compares against the current content.
+func (u *postUsecase) DiffRevisions(ctx context.Context, postID int64, userID int64, role string, from int64, to *int64) (*entity.RevisionDiff, error) {
+	post, err := u.checkAccess(ctx, postID, userID, role)
+	if err != nil {
+		u.log.Warn().Err(err).Str("op", diffRevisionsOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Access denied")
+		return nil, err
+	}
 
-		if username, exists := usernames[*posts[i].AuthorID]; exists {
-			posts[i].Username = username
-		} else {
-			posts[i].Username = "Удаленный пользователь"
+	fromContent, err := u.revisionContent(ctx, postID, from)
+	if err != nil {
+		return nil, fmt.Errorf("ForumService - PostUsecase - DiffRevisions - %w", err)
+	}
+
+	toContent := post.Content
+	if to != nil {
+		toContent, err = u.revisionContent(ctx, postID, *to)
+		if err != nil {
+			return nil, fmt.Errorf("ForumService - PostUsecase - DiffRevisions - %w", err)
 		}
 	}
 
-	u.log.Info().Str("op", getByTopicOp).Int64("topic_id", topicID).Msg("Posts by topic succesfully taken")
-	return posts, nil
+	u.log.Info().Str("op", diffRevisionsOp).Int64("post_id", postID).Int64("from", from).Msg("Post revisions succesfully compared")
+	return &entity.RevisionDiff{PostID: postID, From: from, To: to, Lines: diffLines(fromContent, toContent)}, nil
 }
 
-func (u *postUsecase) Update(ctx context.Context, postID int64, userID int64, role string, content string) error {
-	if err := u.checkAccess(ctx, postID, userID, role); err != nil {
-		u.log.Warn().Err(err).Str("op", updatePostOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Access denied")
+func (u *postUsecase) revisionContent(ctx context.Context, postID int64, revisionID int64) (string, error) {
+	rev, err := u.postRepo.GetRevision(ctx, postID, revisionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("postRepo.GetRevision(): %w", ErrRevisionNotFound)
+		}
+		return "", fmt.Errorf("postRepo.GetRevision(): %w", err)
+	}
+	return rev.Content, nil
+}
+
+func (u *postUsecase) AddReaction(ctx context.Context, postID int64, userID int64, reaction string) error {
+	post, err := u.checkReaction(ctx, postID, reaction)
+	if err != nil {
+		u.log.Warn().Err(err).Str("op", addReactionOp).Int64("post_id", postID).Str("reaction", reaction).Msg("Invalid reaction")
 		return err
 	}
 
-	if err := u.postRepo.Update(ctx, postID, content); err != nil {
-		u.log.Error().Err(err).Str("op", updatePostOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Failed to update post in repository")
-		return fmt.Errorf("ForumService - PostUsecase - Update - postRepo.Update(): %w", err)
+	if err := u.reactionRepo.Add(ctx, postID, userID, reaction); err != nil {
+		u.log.Error().Err(err).Str("op", addReactionOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Failed to add reaction in repository")
+		return fmt.Errorf("ForumService - PostUsecase - AddReaction - reactionRepo.Add(): %w", err)
 	}
+	u.cache.InvalidatePosts(post.TopicID)
 
-	u.log.Info().Str("op", updatePostOp).Int64("post_id", postID).Msg("Post updated successfully")
+	u.log.Info().Str("op", addReactionOp).Int64("post_id", postID).Int64("user_id", userID).Str("reaction", reaction).Msg("Reaction added successfully")
 	return nil
 }
 
-func (u *postUsecase) Delete(ctx context.Context, postID int64, userID int64, role string) error {
-	fmt.Printf("USER_ID: %d ,  POST_ID: %d , ROLE: %s", userID, postID, role)
-	if err := u.checkAccess(ctx, postID, userID, role); err != nil {
-		u.log.Warn().Err(err).Str("op", deletePostOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Access denied")
+func (u *postUsecase) RemoveReaction(ctx context.Context, postID int64, userID int64, reaction string) error {
+	post, err := u.checkReaction(ctx, postID, reaction)
+	if err != nil {
+		u.log.Warn().Err(err).Str("op", removeReactionOp).Int64("post_id", postID).Str("reaction", reaction).Msg("Invalid reaction")
 		return err
 	}
 
-	if err := u.postRepo.Delete(ctx, postID); err != nil {
-		u.log.Error().Err(err).Str("op", deletePostOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Failed to delete post in repository")
-		return fmt.Errorf("ForumService - PostUsecase - Delete - postRepo.delete(): %w", err)
+	if err := u.reactionRepo.Remove(ctx, postID, userID, reaction); err != nil {
+		u.log.Error().Err(err).Str("op", removeReactionOp).Int64("post_id", postID).Int64("user_id", userID).Msg("Failed to remove reaction in repository")
+		return fmt.Errorf("ForumService - PostUsecase - RemoveReaction - reactionRepo.Remove(): %w", err)
 	}
+	u.cache.InvalidatePosts(post.TopicID)
 
-	u.log.Info().Str("op", updatePostOp).Int64("post_id", postID).Msg("Post deleted successfully")
+	u.log.Info().Str("op", removeReactionOp).Int64("post_id", postID).Int64("user_id", userID).Str("reaction", reaction).Msg("Reaction removed successfully")
 	return nil
 }
 
-func (u *postUsecase) checkTopic(ctx context.Context, topicID int64) error {
-	if _, err := u.topicRepo.GetByID(ctx, topicID); err != nil {
+func (u *postUsecase) checkReaction(ctx context.Context, postID int64, reaction string) (*entity.Post, error) {
+	if !entity.AllowedReactions[reaction] {
+		return nil, fmt.Errorf("ForumService - PostUsecase - checkReaction: %w", ErrInvalidReaction)
+	}
+
+	post, err := u.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ForumService - PostUsecase - checkReaction - postRepo.GetByID(): %w", ErrPostNotFound)
+		}
+		return nil, fmt.Errorf("ForumService - PostUsecase - checkReaction - postRepo.GetByID(): %w", err)
+	}
+
+	return post, nil
+}
+
+func (u *postUsecase) checkTopic(ctx context.Context, topicID int64) (*entity.Topic, error) {
+	topic, err := u.topicRepo.GetByID(ctx, topicID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ForumService - PostUsecase - checkTopic - topicRepo.GetByID(): %w", ErrTopicNotFound)
+		}
+		return nil, fmt.Errorf("ForumService - PostUsecase - checkTopic - topicRepo.GetByID(): %w", err)
+	}
+
+	return topic, nil
+}
+
+// checkVisibleTopic is checkTopic that also reports a topic in a hidden category as not
+// found, unless includeHidden is set.
+func (u *postUsecase) checkVisibleTopic(ctx context.Context, topicID int64, includeHidden bool) (*entity.Topic, error) {
+	topic, err := u.checkTopic(ctx, topicID)
+	if err != nil || includeHidden {
+		return topic, err
+	}
+
+	hidden, err := u.categoryRepo.IsHidden(ctx, topic.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("ForumService - PostUsecase - checkVisibleTopic - categoryRepo.IsHidden(): %w", err)
+	}
+	if hidden {
+		return nil, fmt.Errorf("ForumService - PostUsecase - checkVisibleTopic: %w", ErrTopicNotFound)
+	}
+
+	return topic, nil
+}
+
+func (u *postUsecase) checkReplyTarget(ctx context.Context, topicID int64, replyTo int64) error {
+	parent, err := u.postRepo.GetByID(ctx, replyTo)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("ForumService - PostUsecase - checkTopic - topicRepo.GetByID(): %w", ErrTopicNotFound)
+			return fmt.Errorf("ForumService - PostUsecase - checkReplyTarget - postRepo.GetByID(): %w", ErrInvalidReplyTarget)
 		}
-		return fmt.Errorf("ForumService - PostUsecase - checkTopic - topicRepo.GetByID(): %w", err)
+		return fmt.Errorf("ForumService - PostUsecase - checkReplyTarget - postRepo.GetByID(): %w", err)
+	}
+
+	if parent.TopicID != topicID {
+		return fmt.Errorf("ForumService - PostUsecase - checkReplyTarget: %w", ErrInvalidReplyTarget)
 	}
 
 	return nil
 }
 
-func (u *postUsecase) checkAccess(ctx context.Context, postID int64, userID int64, role string) error {
+func (u *postUsecase) checkAccess(ctx context.Context, postID int64, userID int64, role string) (*entity.Post, error) {
 	post, err := u.postRepo.GetByID(ctx, postID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("ForumService - PostUsecase - checkAccess - postRepo.GetByID(): %w", ErrPostNotFound)
+			return nil, fmt.Errorf("ForumService - PostUsecase - checkAccess - postRepo.GetByID(): %w", ErrPostNotFound)
+		}
+		return nil, fmt.Errorf("ForumService - PostUsecase - checkAccess  - postRepo.GetByID(): %w", err)
+	}
+
+	if err := u.canModify(ctx, post, userID, role); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// lockForWrite locks the post and checks that the user may modify it. It must run inside
+// the transaction of the write it guards.
+func (u *postUsecase) lockForWrite(ctx context.Context, postID int64, userID int64, role string) (*entity.Post, error) {
+	post, err := u.postRepo.GetByIDForUpdate(ctx, postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ForumService - PostUsecase - lockForWrite - postRepo.GetByIDForUpdate(): %w", ErrPostNotFound)
+		}
+		return nil, fmt.Errorf("ForumService - PostUsecase - lockForWrite - postRepo.GetByIDForUpdate(): %w", err)
+	}
+
+	if err := u.canModify(ctx, post, userID, role); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// invalidateTopic evicts the cached responses showing the counters of a topic. If the
+// topic cannot be read, the whole cache is dropped rather than serving stale counters.
+func (u *postUsecase) invalidateTopic(ctx context.Context, topicID int64) {
+	topic, err := u.topicRepo.GetByID(ctx, topicID)
+	if err != nil {
+		u.log.Warn().Err(err).Int64("topic_id", topicID).Msg("Failed to get topic, invalidating the whole response cache")
+		u.cache.Invalidate()
+		return
+	}
+
+	u.cache.InvalidateTopic(topic.CategoryID, topicID)
+}
+
+func (u *postUsecase) canModify(ctx context.Context, post *entity.Post, userID int64, role string) error {
+	ok, err := u.policy.CanModifyPost(ctx, userID, role, post)
+	if err != nil {
+		return fmt.Errorf("ForumService - PostUsecase - canModify - policy.CanModifyPost(): %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("ForumService - PostUsecase - canModify: %w", ErrForbidden)
+	}
+
+	return nil
+}
+
+func (u *postUsecase) setUsernames(ctx context.Context, posts []*entity.Post) error {
+	var authorIDs []int64
+	authorIDSet := make(map[int64]bool)
+	for _, p := range posts {
+		if p.AuthorID != nil && !authorIDSet[*p.AuthorID] {
+			authorIDs = append(authorIDs, *p.AuthorID)
+			authorIDSet[*p.AuthorID] = true
+		}
+	}
+
+	usernames, err := u.userClient.GetUsernames(ctx, authorIDs)
+	if err != nil {
+		return fmt.Errorf("userClient.GetUsernames(): %w", err)
+	}
+
+	for _, p := range posts {
+		p.Username = deletedUsername
+		if p.AuthorID == nil {
+			continue
+		}
+		if username, exists := usernames[*p.AuthorID]; exists {
+			p.Username = username
+		}
+	}
+
+	return nil
+}
+
+// setUsernamesOrPlaceholder is setUsernames for listings, which stay readable while the
+// user service is down: authors then get a placeholder name and true is returned.
+func (u *postUsecase) setUsernamesOrPlaceholder(ctx context.Context, op string, posts []*entity.Post) (degraded bool) {
+	if err := u.setUsernames(ctx, posts); err != nil {
+		u.log.Warn().Err(err).Str("op", op).Msg("Failed to get usernames, serving placeholders")
+		for _, p := range posts {
+			p.Username = deletedUsername
+			if p.AuthorID != nil {
+				p.Username = unavailableUsername
+			}
 		}
-		return fmt.Errorf("ForumService - PostUsecase - checkAccess  - postRepo.GetByID(): %w", err)
+		return true
 	}
+	return false
+}
 
-	if role == "admin" {
+// setReactions fills reaction counts with a single query for all given posts.
+// viewerID is 0 for anonymous requests.
+func (u *postUsecase) setReactions(ctx context.Context, posts []*entity.Post, viewerID int64) error {
+	if len(posts) == 0 {
 		return nil
 	}
 
-	if post.AuthorID == nil || (*post.AuthorID != userID) {
-		return fmt.Errorf("ForumService - PostUsecase - checkAccess  - postRepo.Update(): %w", ErrForbidden)
+	postIDs := make([]int64, len(posts))
+	byID := make(map[int64]*entity.Post, len(posts))
+	for i, p := range posts {
+		postIDs[i] = p.ID
+		byID[p.ID] = p
+	}
+
+	counts, err := u.reactionRepo.GetByPosts(ctx, postIDs, viewerID)
+	if err != nil {
+		return fmt.Errorf("reactionRepo.GetByPosts(): %w", err)
+	}
+
+	for _, rc := range counts {
+		p, ok := byID[rc.PostID]
+		if !ok {
+			continue
+		}
+		if p.Reactions == nil {
+			p.Reactions = make(map[string]int64)
+		}
+		p.Reactions[rc.Reaction] = rc.Count
+		if rc.ReactedByUser {
+			p.MyReactions = append(p.MyReactions, rc.Reaction)
+		}
 	}
 
 	return nil
 }
+
+// maskDeleted replaces the content of soft-deleted posts with a placeholder,
+// the posts themselves stay in listings to keep reply chains readable.
+func maskDeleted(posts []*entity.Post) {
+	for _, p := range posts {
+		if p.DeletedAt != nil {
+			p.Content = deletedPostContent
+		}
+	}
+}
+
+func normalizeDepth(depth int) int {
+	if depth <= 0 {
+		return entity.DefaultTreeDepth
+	}
+	if depth > entity.MaxTreeDepth {
+		return entity.MaxTreeDepth
+	}
+	return depth
+}
+
+// buildPostTree nests flat nodes under their parents. Nodes must be ordered by depth,
+// so a parent is always seen before its replies. Returns the depth 0 nodes.
+func buildPostTree(nodes []entity.PostNode) []*entity.PostNode {
+	byID := make(map[int64]*entity.PostNode, len(nodes))
+	roots := make([]*entity.PostNode, 0)
+
+	for i := range nodes {
+		node := &nodes[i]
+		node.Replies = make([]*entity.PostNode, 0)
+		byID[node.ID] = node
+
+		if node.Depth == 0 || node.ReplyTo == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := byID[*node.ReplyTo]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+
+	return roots
+}
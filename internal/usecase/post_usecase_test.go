@@ -3,11 +3,13 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/forum-service-sstu-forum/mocks" // Используем сгенерированные моки
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/mock"
@@ -19,7 +21,13 @@ type PostUsecaseSuite struct {
 	usecase         PostUsecase
 	postRepoMock    *mocks.PostRepository
 	topicRepoMock   *mocks.TopicRepository
+	categoryRepo    *mocks.CategoryRepository
+	reactionRepo    *mocks.ReactionRepository
+	transactorMock  *mocks.Transactor
 	userClientMock  *mocks.UserClient
+	moderatorRepo   *mocks.ModeratorRepository
+	cacheMock       *mocks.ResponseCache
+	feedMock        *mocks.PostFeed
 	log             *zerolog.Logger
 	defaultAuthorID int64
 }
@@ -27,11 +35,22 @@ type PostUsecaseSuite struct {
 func (s *PostUsecaseSuite) SetupTest() {
 	s.postRepoMock = mocks.NewPostRepository(s.T())
 	s.topicRepoMock = mocks.NewTopicRepository(s.T())
+	s.categoryRepo = mocks.NewCategoryRepository(s.T())
+	s.reactionRepo = mocks.NewReactionRepository(s.T())
+	s.transactorMock = mocks.NewTransactor(s.T())
 	s.userClientMock = mocks.NewUserClient(s.T())
+	s.moderatorRepo = mocks.NewModeratorRepository(s.T())
+	s.cacheMock = mocks.NewResponseCache(s.T())
+	s.cacheMock.On("Invalidate").Maybe()
+	s.cacheMock.On("InvalidateCategory", mock.Anything).Maybe()
+	s.cacheMock.On("InvalidateTopic", mock.Anything, mock.Anything).Maybe()
+	s.cacheMock.On("InvalidatePosts", mock.Anything).Maybe()
+	s.feedMock = mocks.NewPostFeed(s.T())
+	s.feedMock.On("Publish", mock.Anything).Maybe()
 	logger := zerolog.Nop()
 	s.log = &logger
 	s.defaultAuthorID = int64(1)
-	s.usecase = NewPostUsecase(s.postRepoMock, s.topicRepoMock, s.userClientMock, s.log)
+	s.usecase = NewPostUsecase(s.postRepoMock, s.topicRepoMock, s.categoryRepo, s.reactionRepo, s.transactorMock, s.userClientMock, policy.New(s.moderatorRepo, s.topicRepoMock), s.cacheMock, s.feedMock, s.log)
 }
 
 func TestPostUsecaseSuite(t *testing.T) {
@@ -54,6 +73,8 @@ func (s *PostUsecaseSuite) TestCreatePost_Success() {
 	s.Equal(expectedPostID, id)
 	s.topicRepoMock.AssertExpectations(s.T())
 	s.postRepoMock.AssertExpectations(s.T())
+	post.ID = expectedPostID
+	s.feedMock.AssertCalled(s.T(), "Publish", post)
 }
 
 func (s *PostUsecaseSuite) TestCreatePost_TopicNotFound() {
@@ -72,6 +93,21 @@ func (s *PostUsecaseSuite) TestCreatePost_TopicNotFound() {
 	s.postRepoMock.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
 }
 
+func (s *PostUsecaseSuite) TestCreatePost_TopicLocked() {
+	ctx := context.Background()
+	post := entity.Post{TopicID: 1, AuthorID: &s.defaultAuthorID, Content: "content"}
+	topic := &entity.Topic{ID: post.TopicID, Title: "Locked Topic", Locked: true}
+
+	s.topicRepoMock.On("GetByID", ctx, post.TopicID).Return(topic, nil).Once()
+
+	id, err := s.usecase.Create(ctx, post)
+
+	s.ErrorIs(err, ErrTopicLocked)
+	s.Equal(int64(0), id)
+	s.topicRepoMock.AssertExpectations(s.T())
+	s.postRepoMock.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
 /*
 func (s *PostUsecaseSuite) TestCreatePost_TopicRepoError_OtherThanNotFound() {
 	ctx := context.Background()
@@ -89,6 +125,56 @@ func (s *PostUsecaseSuite) TestCreatePost_TopicRepoError_OtherThanNotFound() {
 	s.postRepoMock.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
 }*/
 
+func (s *PostUsecaseSuite) TestCreatePost_ReplySameTopic() {
+	ctx := context.Background()
+	replyTo := int64(5)
+	post := entity.Post{TopicID: 1, AuthorID: &s.defaultAuthorID, Content: "content", ReplyTo: &replyTo}
+	topic := &entity.Topic{ID: post.TopicID, Title: "Existing Topic"}
+	parent := &entity.Post{ID: replyTo, TopicID: post.TopicID}
+
+	s.topicRepoMock.On("GetByID", ctx, post.TopicID).Return(topic, nil).Once()
+	s.postRepoMock.On("GetByID", ctx, replyTo).Return(parent, nil).Once()
+	s.postRepoMock.On("Create", ctx, post).Return(int64(6), nil).Once()
+
+	id, err := s.usecase.Create(ctx, post)
+
+	s.NoError(err)
+	s.Equal(int64(6), id)
+}
+
+func (s *PostUsecaseSuite) TestCreatePost_ReplyTargetNotFound() {
+	ctx := context.Background()
+	replyTo := int64(5)
+	post := entity.Post{TopicID: 1, AuthorID: &s.defaultAuthorID, Content: "content", ReplyTo: &replyTo}
+	topic := &entity.Topic{ID: post.TopicID, Title: "Existing Topic"}
+
+	s.topicRepoMock.On("GetByID", ctx, post.TopicID).Return(topic, nil).Once()
+	s.postRepoMock.On("GetByID", ctx, replyTo).Return(nil, pgx.ErrNoRows).Once()
+
+	id, err := s.usecase.Create(ctx, post)
+
+	s.ErrorIs(err, ErrInvalidReplyTarget)
+	s.Equal(int64(0), id)
+	s.postRepoMock.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *PostUsecaseSuite) TestCreatePost_ReplyTargetInOtherTopic() {
+	ctx := context.Background()
+	replyTo := int64(5)
+	post := entity.Post{TopicID: 1, AuthorID: &s.defaultAuthorID, Content: "content", ReplyTo: &replyTo}
+	topic := &entity.Topic{ID: post.TopicID, Title: "Existing Topic"}
+	parent := &entity.Post{ID: replyTo, TopicID: 2}
+
+	s.topicRepoMock.On("GetByID", ctx, post.TopicID).Return(topic, nil).Once()
+	s.postRepoMock.On("GetByID", ctx, replyTo).Return(parent, nil).Once()
+
+	id, err := s.usecase.Create(ctx, post)
+
+	s.ErrorIs(err, ErrInvalidReplyTarget)
+	s.Equal(int64(0), id)
+	s.postRepoMock.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
 func (s *PostUsecaseSuite) TestCreatePost_RepoError() {
 	ctx := context.Background()
 	post := entity.Post{TopicID: 1, AuthorID: &s.defaultAuthorID, Content: "content"}
@@ -123,20 +209,25 @@ func (s *PostUsecaseSuite) TestGetByTopic_Success() {
 		authorID1: "UserOne",
 		authorID2: "UserTwo",
 	}
+	reactionsFromRepo := []entity.ReactionCount{
+		{PostID: 1, Reaction: "heart", Count: 1},
+		{PostID: 1, Reaction: "like", Count: 2, ReactedByUser: true},
+	}
 	expectedPosts := []entity.Post{
-		{ID: 1, TopicID: topicID, AuthorID: &authorID1, Username: "UserOne", Content: "Post 1", CreatedAt: postsFromRepo[0].CreatedAt},
+		{ID: 1, TopicID: topicID, AuthorID: &authorID1, Username: "UserOne", Content: "Post 1", CreatedAt: postsFromRepo[0].CreatedAt, Reactions: map[string]int64{"heart": 1, "like": 2}, MyReactions: []string{"like"}},
 		{ID: 2, TopicID: topicID, AuthorID: &authorID2, Username: "UserTwo", Content: "Post 2", CreatedAt: postsFromRepo[1].CreatedAt},
 		{ID: 3, TopicID: topicID, AuthorID: nil, Username: "Удаленный пользователь", Content: "Post 3 - Deleted User", CreatedAt: postsFromRepo[2].CreatedAt},
 	}
 	topic := &entity.Topic{ID: topicID, Title: "Existing Topic"}
 
 	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topic, nil).Once()
-	s.postRepoMock.On("GetByTopic", ctx, topicID).Return(postsFromRepo, nil).Once()
+	s.postRepoMock.On("GetByTopic", ctx, topicID, entity.PageRequest{Limit: entity.DefaultPageLimit + 1}).Return(postsFromRepo, nil).Once()
 	s.userClientMock.On("GetUsernames", ctx, mock.MatchedBy(func(ids []int64) bool {
 		return len(ids) == 2 && ((ids[0] == authorID1 && ids[1] == authorID2) || (ids[0] == authorID2 && ids[1] == authorID1))
 	})).Return(usernamesFromClient, nil).Once()
+	s.reactionRepo.On("GetByPosts", ctx, []int64{1, 2, 3}, authorID2).Return(reactionsFromRepo, nil).Once()
 
-	posts, err := s.usecase.GetByTopic(ctx, topicID)
+	posts, _, err := s.usecase.GetByTopic(ctx, topicID, authorID2, true, entity.PageRequest{})
 
 	s.NoError(err)
 	s.NotNil(posts)
@@ -144,6 +235,21 @@ func (s *PostUsecaseSuite) TestGetByTopic_Success() {
 	s.topicRepoMock.AssertExpectations(s.T())
 	s.postRepoMock.AssertExpectations(s.T())
 	s.userClientMock.AssertExpectations(s.T())
+	s.reactionRepo.AssertExpectations(s.T())
+}
+
+func (s *PostUsecaseSuite) TestGetByTopic_HiddenCategory() {
+	ctx := context.Background()
+	topicID := int64(1)
+
+	s.topicRepoMock.On("GetByID", ctx, topicID).Return(&entity.Topic{ID: topicID, CategoryID: 3}, nil).Once()
+	s.categoryRepo.On("IsHidden", ctx, int64(3)).Return(true, nil).Once()
+
+	posts, _, err := s.usecase.GetByTopic(ctx, topicID, 0, false, entity.PageRequest{})
+
+	s.ErrorIs(err, ErrTopicNotFound)
+	s.Nil(posts)
+	s.postRepoMock.AssertNotCalled(s.T(), "GetByTopic", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *PostUsecaseSuite) TestGetByTopic_RepoError() {
@@ -153,9 +259,9 @@ func (s *PostUsecaseSuite) TestGetByTopic_RepoError() {
 	topic := &entity.Topic{ID: topicID, Title: "Existing Topic"}
 
 	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topic, nil).Once()
-	s.postRepoMock.On("GetByTopic", ctx, topicID).Return(nil, expectedError).Once()
+	s.postRepoMock.On("GetByTopic", ctx, topicID, entity.PageRequest{Limit: entity.DefaultPageLimit + 1}).Return(nil, expectedError).Once()
 
-	posts, err := s.usecase.GetByTopic(ctx, topicID)
+	posts, _, err := s.usecase.GetByTopic(ctx, topicID, 0, true, entity.PageRequest{})
 
 	s.Error(err)
 	s.Nil(posts)
@@ -172,20 +278,22 @@ func (s *PostUsecaseSuite) TestGetByTopic_UserClientError() {
 	authorID1 := int64(10)
 	postsFromRepo := []entity.Post{
 		{ID: 1, TopicID: topicID, AuthorID: &authorID1, Content: "Post 1"},
+		{ID: 2, TopicID: topicID, AuthorID: nil, Content: "Post 2"},
 	}
-	expectedError := errors.New("user client error")
 	topic := &entity.Topic{ID: topicID, Title: "Existing Topic"}
 
 	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topic, nil).Once()
-	s.postRepoMock.On("GetByTopic", ctx, topicID).Return(postsFromRepo, nil).Once()
-	s.userClientMock.On("GetUsernames", ctx, []int64{authorID1}).Return(nil, expectedError).Once()
+	s.postRepoMock.On("GetByTopic", ctx, topicID, entity.PageRequest{Limit: entity.DefaultPageLimit + 1}).Return(postsFromRepo, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID1}).Return(nil, errors.New("user client error")).Once()
+	s.reactionRepo.On("GetByPosts", ctx, []int64{1, 2}, int64(0)).Return(nil, nil).Once()
 
-	posts, err := s.usecase.GetByTopic(ctx, topicID)
+	posts, pageInfo, err := s.usecase.GetByTopic(ctx, topicID, 0, true, entity.PageRequest{})
 
-	s.Error(err)
-	s.Nil(posts)                                                                                        // В текущей реализации возвращается nil при ошибке клиента
-	s.Contains(err.Error(), "ForumService - TopicUsecase  - GetByCategory - userClient.GetUsernames()") // Ошибка из TopicUsecase, т.к. логика похожа
-	s.ErrorIs(err, expectedError)
+	s.NoError(err)
+	s.True(pageInfo.Degraded)
+	s.Require().Len(posts, 2)
+	s.Equal(unavailableUsername, posts[0].Username)
+	s.Equal(deletedUsername, posts[1].Username)
 	s.topicRepoMock.AssertExpectations(s.T())
 	s.postRepoMock.AssertExpectations(s.T())
 	s.userClientMock.AssertExpectations(s.T())
@@ -198,29 +306,260 @@ func (s *PostUsecaseSuite) TestGetByTopic_CheckTopicError() {
 
 	s.topicRepoMock.On("GetByID", ctx, topicID).Return(nil, pgx.ErrNoRows).Once() // Ошибка в checkTopic
 
-	posts, err := s.usecase.GetByTopic(ctx, topicID)
+	posts, _, err := s.usecase.GetByTopic(ctx, topicID, 0, true, entity.PageRequest{})
 
 	s.Error(err)
 	s.Nil(posts)
 	s.ErrorIs(err, expectedError)
 	s.topicRepoMock.AssertExpectations(s.T())
-	s.postRepoMock.AssertNotCalled(s.T(), "GetByTopic", mock.Anything, mock.Anything)
+	s.postRepoMock.AssertNotCalled(s.T(), "GetByTopic", mock.Anything, mock.Anything, mock.Anything)
+	s.userClientMock.AssertNotCalled(s.T(), "GetUsernames", mock.Anything, mock.Anything)
+}
+
+func (s *PostUsecaseSuite) TestGetByTopic_ReactionRepoError() {
+	ctx := context.Background()
+	topicID := int64(1)
+	postsFromRepo := []entity.Post{{ID: 1, TopicID: topicID, Content: "Post 1"}}
+	expectedError := errors.New("reaction repository error")
+	topic := &entity.Topic{ID: topicID, Title: "Existing Topic"}
+
+	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topic, nil).Once()
+	s.postRepoMock.On("GetByTopic", ctx, topicID, entity.PageRequest{Limit: entity.DefaultPageLimit + 1}).Return(postsFromRepo, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64(nil)).Return(map[int64]string{}, nil).Once()
+	s.reactionRepo.On("GetByPosts", ctx, []int64{1}, int64(0)).Return(nil, expectedError).Once()
+
+	posts, _, err := s.usecase.GetByTopic(ctx, topicID, 0, true, entity.PageRequest{})
+
+	s.Nil(posts)
+	s.ErrorIs(err, expectedError)
+	s.Contains(err.Error(), "ForumService - PostUsecase - GetByTopic - reactionRepo.GetByPosts()")
+}
+
+func (s *PostUsecaseSuite) TestGetByTopic_DeletedPostPlaceholder() {
+	ctx := context.Background()
+	topicID := int64(1)
+	deletedAt := time.Now()
+	postsFromRepo := []entity.Post{
+		{ID: 1, TopicID: topicID, Content: "visible"},
+		{ID: 2, TopicID: topicID, Content: "secret", DeletedAt: &deletedAt},
+	}
+	topic := &entity.Topic{ID: topicID, Title: "Existing Topic"}
+
+	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topic, nil).Once()
+	s.postRepoMock.On("GetByTopic", ctx, topicID, entity.PageRequest{Limit: entity.DefaultPageLimit + 1}).Return(postsFromRepo, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64(nil)).Return(map[int64]string{}, nil).Once()
+	s.reactionRepo.On("GetByPosts", ctx, []int64{1, 2}, int64(0)).Return(nil, nil).Once()
+
+	posts, _, err := s.usecase.GetByTopic(ctx, topicID, 0, true, entity.PageRequest{})
+
+	s.NoError(err)
+	s.Require().Len(posts, 2)
+	s.Equal("visible", posts[0].Content)
+	s.Equal("Сообщение удалено", posts[1].Content)
+	s.NotNil(posts[1].DeletedAt)
+}
+
+// GetTree
+func (s *PostUsecaseSuite) TestGetTree_Success() {
+	ctx := context.Background()
+	topicID := int64(1)
+	authorID := int64(10)
+	rootID := int64(1)
+	replyID := int64(2)
+	nodesFromRepo := []entity.PostNode{
+		{Post: entity.Post{ID: rootID, TopicID: topicID, AuthorID: &authorID, Content: "root"}, Depth: 0},
+		{Post: entity.Post{ID: 3, TopicID: topicID, Content: "second root"}, Depth: 0},
+		{Post: entity.Post{ID: replyID, TopicID: topicID, AuthorID: &authorID, Content: "reply", ReplyTo: &rootID}, Depth: 1},
+		{Post: entity.Post{ID: 4, TopicID: topicID, Content: "nested", ReplyTo: &replyID}, Depth: 2, CollapsedCount: 3},
+	}
+	topic := &entity.Topic{ID: topicID, Title: "Existing Topic"}
+
+	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topic, nil).Once()
+	s.postRepoMock.On("GetTree", ctx, topicID, entity.PageRequest{Limit: entity.DefaultPageLimit + 1}, entity.DefaultTreeDepth).Return(nodesFromRepo, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID}).Return(map[int64]string{authorID: "UserOne"}, nil).Once()
+	s.reactionRepo.On("GetByPosts", ctx, []int64{rootID, 3, replyID, 4}, int64(0)).Return([]entity.ReactionCount{{PostID: replyID, Reaction: "fire", Count: 1}}, nil).Once()
+
+	roots, pageInfo, err := s.usecase.GetTree(ctx, topicID, 0, true, entity.PageRequest{}, 0)
+
+	s.NoError(err)
+	s.Empty(pageInfo.NextCursor)
+	s.Len(roots, 2)
+	s.Equal("UserOne", roots[0].Username)
+	s.Equal("Удаленный пользователь", roots[1].Username)
+	s.Empty(roots[1].Replies)
+	s.Require().Len(roots[0].Replies, 1)
+	s.Equal(replyID, roots[0].Replies[0].ID)
+	s.Equal(map[string]int64{"fire": 1}, roots[0].Replies[0].Reactions)
+	s.Empty(roots[0].Replies[0].MyReactions)
+	s.Require().Len(roots[0].Replies[0].Replies, 1)
+	s.Equal(int64(3), roots[0].Replies[0].Replies[0].CollapsedCount)
+}
+
+func (s *PostUsecaseSuite) TestGetTree_TopicNotFound() {
+	ctx := context.Background()
+	topicID := int64(1)
+
+	s.topicRepoMock.On("GetByID", ctx, topicID).Return(nil, pgx.ErrNoRows).Once()
+
+	roots, _, err := s.usecase.GetTree(ctx, topicID, 0, true, entity.PageRequest{}, 3)
+
+	s.ErrorIs(err, ErrTopicNotFound)
+	s.Nil(roots)
+	s.postRepoMock.AssertNotCalled(s.T(), "GetTree", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// GetByID
+func (s *PostUsecaseSuite) TestGetByID_Success() {
+	ctx := context.Background()
+	postID := int64(2)
+	authorID := int64(10)
+	post := &entity.Post{ID: postID, TopicID: 1, AuthorID: &authorID, Content: "post"}
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(post, nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(1)).Return(&entity.Topic{ID: 1}, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID}).Return(map[int64]string{authorID: "Author"}, nil).Once()
+
+	got, _, err := s.usecase.GetByID(ctx, postID, true)
+
+	s.NoError(err)
+	s.Equal("Author", got.Username)
+}
+
+func (s *PostUsecaseSuite) TestGetByID_UserClientError() {
+	ctx := context.Background()
+	postID := int64(2)
+	authorID := int64(10)
+	post := &entity.Post{ID: postID, TopicID: 1, AuthorID: &authorID, Content: "post"}
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(post, nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(1)).Return(&entity.Topic{ID: 1}, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID}).Return(nil, errors.New("user client error")).Once()
+
+	got, degraded, err := s.usecase.GetByID(ctx, postID, true)
+
+	s.NoError(err)
+	s.True(degraded)
+	s.Equal(unavailableUsername, got.Username)
+}
+
+func (s *PostUsecaseSuite) TestGetByID_TopicDeleted() {
+	ctx := context.Background()
+	postID := int64(2)
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(&entity.Post{ID: postID, TopicID: 1}, nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(1)).Return(nil, pgx.ErrNoRows).Once()
+
+	got, _, err := s.usecase.GetByID(ctx, postID, true)
+
+	s.ErrorIs(err, ErrPostNotFound)
+	s.Nil(got)
+}
+
+func (s *PostUsecaseSuite) TestGetByID_HiddenCategory() {
+	ctx := context.Background()
+	postID := int64(2)
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(&entity.Post{ID: postID, TopicID: 1}, nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(1)).Return(&entity.Topic{ID: 1, CategoryID: 3}, nil).Once()
+	s.categoryRepo.On("IsHidden", ctx, int64(3)).Return(true, nil).Once()
+
+	got, _, err := s.usecase.GetByID(ctx, postID, false)
+
+	s.ErrorIs(err, ErrPostNotFound)
+	s.Nil(got)
+}
+
+// GetThread
+func (s *PostUsecaseSuite) TestGetThread_Success() {
+	ctx := context.Background()
+	postID := int64(2)
+	rootID := int64(1)
+	authorID := int64(10)
+	ancestors := []entity.Post{{ID: rootID, TopicID: 1, AuthorID: &authorID, Content: "root"}}
+	subtree := []entity.PostNode{
+		{Post: entity.Post{ID: postID, TopicID: 1, Content: "post", ReplyTo: &rootID}, Depth: 0},
+		{Post: entity.Post{ID: 3, TopicID: 1, AuthorID: &authorID, Content: "reply", ReplyTo: &postID}, Depth: 1},
+	}
+
+	s.postRepoMock.On("GetSubtree", ctx, postID, entity.MaxTreeDepth).Return(subtree, nil).Once()
+	s.postRepoMock.On("GetAncestors", ctx, postID).Return(ancestors, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID}).Return(map[int64]string{authorID: "UserOne"}, nil).Once()
+
+	thread, _, err := s.usecase.GetThread(ctx, postID, 100)
+
+	s.NoError(err)
+	s.Require().Len(thread.Ancestors, 1)
+	s.Equal("UserOne", thread.Ancestors[0].Username)
+	s.Equal(postID, thread.Post.ID)
+	s.Require().Len(thread.Post.Replies, 1)
+	s.Equal("UserOne", thread.Post.Replies[0].Username)
+}
+
+func (s *PostUsecaseSuite) TestGetThread_UserClientError() {
+	ctx := context.Background()
+	postID := int64(2)
+	authorID := int64(10)
+	subtree := []entity.PostNode{{Post: entity.Post{ID: postID, TopicID: 1, AuthorID: &authorID, Content: "post"}}}
+
+	s.postRepoMock.On("GetSubtree", ctx, postID, entity.DefaultTreeDepth).Return(subtree, nil).Once()
+	s.postRepoMock.On("GetAncestors", ctx, postID).Return(nil, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID}).Return(nil, errors.New("unavailable")).Once()
+
+	thread, degraded, err := s.usecase.GetThread(ctx, postID, 0)
+
+	s.NoError(err)
+	s.True(degraded)
+	s.Equal(unavailableUsername, thread.Post.Username)
+}
+
+func (s *PostUsecaseSuite) TestGetThread_PostNotFound() {
+	ctx := context.Background()
+	postID := int64(2)
+
+	s.postRepoMock.On("GetSubtree", ctx, postID, entity.DefaultTreeDepth).Return(nil, nil).Once()
+
+	thread, _, err := s.usecase.GetThread(ctx, postID, 0)
+
+	s.ErrorIs(err, ErrPostNotFound)
+	s.Nil(thread)
+	s.postRepoMock.AssertNotCalled(s.T(), "GetAncestors", mock.Anything, mock.Anything)
+}
+
+func (s *PostUsecaseSuite) TestGetThread_TopicDeleted() {
+	ctx := context.Background()
+	postID := int64(2)
+
+	// The subtree query skips posts of deleted topics.
+	s.postRepoMock.On("GetSubtree", ctx, postID, entity.DefaultTreeDepth).Return([]entity.PostNode{}, nil).Once()
+
+	thread, _, err := s.usecase.GetThread(ctx, postID, 0)
+
+	s.ErrorIs(err, ErrPostNotFound)
+	s.Nil(thread)
 	s.userClientMock.AssertNotCalled(s.T(), "GetUsernames", mock.Anything, mock.Anything)
 }
 
 // Update
+// runInTx makes the transactor mock call the function it is given, like a real transaction would.
+func (s *PostUsecaseSuite) runInTx(ctx context.Context) {
+	s.transactorMock.On("WithinTx", ctx, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).Once()
+}
+
 func (s *PostUsecaseSuite) TestUpdatePost_Success_Author() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
 	content := "updated content"
 	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID, Content: "old content"}
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
-	s.postRepoMock.On("Update", ctx, postID, content).Return(nil).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("Update", ctx, postID, content, userID, role, (*time.Time)(nil)).Return(nil).Once()
 
-	err := s.usecase.Update(ctx, postID, userID, role, content)
+	err := s.usecase.Update(ctx, postID, userID, role, content, nil)
 
 	s.NoError(err)
 	s.postRepoMock.AssertExpectations(s.T())
@@ -228,6 +567,7 @@ func (s *PostUsecaseSuite) TestUpdatePost_Success_Author() {
 
 func (s *PostUsecaseSuite) TestUpdatePost_Success_Admin() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	adminID := int64(999)
 	otherUserID := s.defaultAuthorID
@@ -235,10 +575,10 @@ func (s *PostUsecaseSuite) TestUpdatePost_Success_Admin() {
 	content := "updated content by admin"
 	postFromRepo := &entity.Post{ID: postID, AuthorID: &otherUserID, Content: "old content"}
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
-	s.postRepoMock.On("Update", ctx, postID, content).Return(nil).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("Update", ctx, postID, content, adminID, role, (*time.Time)(nil)).Return(nil).Once()
 
-	err := s.usecase.Update(ctx, postID, adminID, role, content)
+	err := s.usecase.Update(ctx, postID, adminID, role, content, nil)
 
 	s.NoError(err)
 	s.postRepoMock.AssertExpectations(s.T())
@@ -246,6 +586,7 @@ func (s *PostUsecaseSuite) TestUpdatePost_Success_Admin() {
 
 func (s *PostUsecaseSuite) TestUpdatePost_AccessDenied_NotAuthorNotAdmin() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	anotherUserID := int64(555)
 	authorID := s.defaultAuthorID
@@ -254,36 +595,40 @@ func (s *PostUsecaseSuite) TestUpdatePost_AccessDenied_NotAuthorNotAdmin() {
 	postFromRepo := &entity.Post{ID: postID, AuthorID: &authorID, Content: "old content"}
 	expectedError := ErrForbidden
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(0)).Return(&entity.Topic{CategoryID: 3}, nil).Once()
+	s.moderatorRepo.On("IsModerator", ctx, int64(3), anotherUserID).Return(false, nil).Once()
 
-	err := s.usecase.Update(ctx, postID, anotherUserID, role, content)
+	err := s.usecase.Update(ctx, postID, anotherUserID, role, content, nil)
 
 	s.Error(err)
 	s.ErrorIs(err, expectedError)
 	s.postRepoMock.AssertExpectations(s.T())
-	s.postRepoMock.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+	s.postRepoMock.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *PostUsecaseSuite) TestUpdatePost_PostNotFound_OnCheckAccess() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
 	content := "updated content"
 	expectedError := ErrPostNotFound
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(nil, pgx.ErrNoRows).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(nil, pgx.ErrNoRows).Once()
 
-	err := s.usecase.Update(ctx, postID, userID, role, content)
+	err := s.usecase.Update(ctx, postID, userID, role, content, nil)
 
 	s.Error(err)
 	s.ErrorIs(err, expectedError)
 	s.postRepoMock.AssertExpectations(s.T())
-	s.postRepoMock.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+	s.postRepoMock.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *PostUsecaseSuite) TestUpdatePost_RepoUpdateError() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
@@ -291,10 +636,10 @@ func (s *PostUsecaseSuite) TestUpdatePost_RepoUpdateError() {
 	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID, Content: "old content"}
 	repoError := errors.New("repo update error")
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
-	s.postRepoMock.On("Update", ctx, postID, content).Return(repoError).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("Update", ctx, postID, content, userID, role, (*time.Time)(nil)).Return(repoError).Once()
 
-	err := s.usecase.Update(ctx, postID, userID, role, content)
+	err := s.usecase.Update(ctx, postID, userID, role, content, nil)
 
 	s.Error(err)
 	s.ErrorIs(err, repoError)
@@ -302,42 +647,82 @@ func (s *PostUsecaseSuite) TestUpdatePost_RepoUpdateError() {
 	s.postRepoMock.AssertExpectations(s.T())
 }
 
+func (s *PostUsecaseSuite) TestUpdatePost_VersionConflict() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	postID := int64(1)
+	content := "updated content"
+	version := time.Now().Add(-time.Minute)
+	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID, Content: "old content", UpdatedAt: time.Now()}
+
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("Update", ctx, postID, content, s.defaultAuthorID, "user", &version).Return(fmt.Errorf("PostRepository - Update: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.Update(ctx, postID, s.defaultAuthorID, "user", content, &version)
+
+	s.ErrorIs(err, ErrVersionConflict)
+}
+
+func (s *PostUsecaseSuite) TestUpdatePost_NotFoundWithoutVersion() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	postID := int64(1)
+	content := "updated content"
+	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID, Content: "old content"}
+
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("Update", ctx, postID, content, s.defaultAuthorID, "user", (*time.Time)(nil)).Return(fmt.Errorf("PostRepository - Update: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.Update(ctx, postID, s.defaultAuthorID, "user", content, nil)
+
+	s.ErrorIs(err, ErrPostNotFound)
+	s.NotErrorIs(err, ErrVersionConflict)
+}
+
 // Delete
 func (s *PostUsecaseSuite) TestDeletePost_Success_Author() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
-	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID}
+	postFromRepo := &entity.Post{ID: postID, TopicID: 7, AuthorID: &s.defaultAuthorID}
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
-	s.postRepoMock.On("Delete", ctx, postID).Return(nil).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("Delete", ctx, postID, userID).Return(nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(7)).Return(&entity.Topic{ID: 7, CategoryID: 3}, nil).Once()
 
 	err := s.usecase.Delete(ctx, postID, userID, role)
 
 	s.NoError(err)
 	s.postRepoMock.AssertExpectations(s.T())
+	s.cacheMock.AssertCalled(s.T(), "InvalidateTopic", int64(3), int64(7))
+	s.cacheMock.AssertNotCalled(s.T(), "Invalidate")
 }
 
 func (s *PostUsecaseSuite) TestDeletePost_Success_Admin() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	adminID := int64(999)
 	otherUserID := s.defaultAuthorID
 	role := "admin"
-	postFromRepo := &entity.Post{ID: postID, AuthorID: &otherUserID}
+	postFromRepo := &entity.Post{ID: postID, TopicID: 7, AuthorID: &otherUserID}
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
-	s.postRepoMock.On("Delete", ctx, postID).Return(nil).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("Delete", ctx, postID, adminID).Return(nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(7)).Return(nil, pgx.ErrNoRows).Once()
 
 	err := s.usecase.Delete(ctx, postID, adminID, role)
 
 	s.NoError(err)
 	s.postRepoMock.AssertExpectations(s.T())
+	s.cacheMock.AssertCalled(s.T(), "Invalidate")
 }
 
 func (s *PostUsecaseSuite) TestDeletePost_AccessDenied_NotAuthorNotAdmin() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	anotherUserID := int64(555)
 	authorID := s.defaultAuthorID
@@ -345,44 +730,68 @@ func (s *PostUsecaseSuite) TestDeletePost_AccessDenied_NotAuthorNotAdmin() {
 	postFromRepo := &entity.Post{ID: postID, AuthorID: &authorID}
 	expectedError := ErrForbidden
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(0)).Return(&entity.Topic{CategoryID: 3}, nil).Once()
+	s.moderatorRepo.On("IsModerator", ctx, int64(3), anotherUserID).Return(false, nil).Once()
 
 	err := s.usecase.Delete(ctx, postID, anotherUserID, role)
 
 	s.Error(err)
 	s.ErrorIs(err, expectedError)
 	s.postRepoMock.AssertExpectations(s.T())
-	s.postRepoMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+	s.postRepoMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *PostUsecaseSuite) TestDeletePost_Success_Moderator() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	postID := int64(1)
+	moderatorID := int64(50)
+	postFromRepo := &entity.Post{ID: postID, TopicID: 7, AuthorID: &s.defaultAuthorID}
+	topic := &entity.Topic{ID: 7, CategoryID: 3}
+
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(7)).Return(topic, nil).Twice()
+	s.moderatorRepo.On("IsModerator", ctx, int64(3), moderatorID).Return(true, nil).Once()
+	s.postRepoMock.On("Delete", ctx, postID, moderatorID).Return(nil).Once()
+
+	err := s.usecase.Delete(ctx, postID, moderatorID, "user")
+
+	s.NoError(err)
+	s.postRepoMock.AssertExpectations(s.T())
+	s.moderatorRepo.AssertExpectations(s.T())
 }
 
 func (s *PostUsecaseSuite) TestDeletePost_PostNotFound_OnCheckAccess() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
 	expectedError := ErrPostNotFound
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(nil, pgx.ErrNoRows).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(nil, pgx.ErrNoRows).Once()
 
 	err := s.usecase.Delete(ctx, postID, userID, role)
 
 	s.Error(err)
 	s.ErrorIs(err, expectedError)
 	s.postRepoMock.AssertExpectations(s.T())
-	s.postRepoMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+	s.postRepoMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *PostUsecaseSuite) TestDeletePost_RepoError() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	postID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
 	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID}
 	repoError := errors.New("repo delete error")
 
-	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("GetByIDForUpdate", ctx, postID).Return(postFromRepo, nil).Once()
 
-	s.postRepoMock.On("Delete", ctx, postID).Return(repoError).Once()
+	s.postRepoMock.On("Delete", ctx, postID, userID).Return(repoError).Once()
 
 	err := s.usecase.Delete(ctx, postID, userID, role)
 
@@ -391,3 +800,200 @@ func (s *PostUsecaseSuite) TestDeletePost_RepoError() {
 	s.Contains(err.Error(), "ForumService - PostUsecase - Delete - postRepo.delete()")
 	s.postRepoMock.AssertExpectations(s.T())
 }
+
+// Revisions
+func (s *PostUsecaseSuite) TestGetRevisions_Success_Author() {
+	ctx := context.Background()
+	postID := int64(1)
+	adminID := int64(99)
+	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID}
+	revisionsFromRepo := []entity.PostRevision{
+		{ID: 1, PostID: postID, Content: "v1", EditorID: &s.defaultAuthorID, EditorRole: "user"},
+		{ID: 2, PostID: postID, Content: "v2", EditorID: &adminID, EditorRole: "admin"},
+		{ID: 3, PostID: postID, Content: "v3", EditorID: nil, EditorRole: "user"},
+	}
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("GetRevisions", ctx, postID).Return(revisionsFromRepo, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{s.defaultAuthorID, adminID}).Return(map[int64]string{s.defaultAuthorID: "author"}, nil).Once()
+
+	revisions, err := s.usecase.GetRevisions(ctx, postID, s.defaultAuthorID, "user")
+
+	s.NoError(err)
+	s.Require().Len(revisions, 3)
+	s.Equal("author", revisions[0].EditorUsername)
+	s.Equal("Удаленный пользователь", revisions[1].EditorUsername)
+	s.Equal("Удаленный пользователь", revisions[2].EditorUsername)
+	s.postRepoMock.AssertExpectations(s.T())
+	s.userClientMock.AssertExpectations(s.T())
+}
+
+func (s *PostUsecaseSuite) TestGetRevisions_Forbidden() {
+	ctx := context.Background()
+	postID := int64(1)
+	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID}
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
+	s.topicRepoMock.On("GetByID", ctx, int64(0)).Return(&entity.Topic{CategoryID: 3}, nil).Once()
+	s.moderatorRepo.On("IsModerator", ctx, int64(3), int64(42)).Return(false, nil).Once()
+
+	revisions, err := s.usecase.GetRevisions(ctx, postID, int64(42), "user")
+
+	s.ErrorIs(err, ErrForbidden)
+	s.Nil(revisions)
+	s.postRepoMock.AssertNotCalled(s.T(), "GetRevisions", mock.Anything, mock.Anything)
+}
+
+func (s *PostUsecaseSuite) TestDiffRevisions_AgainstCurrent() {
+	ctx := context.Background()
+	postID := int64(1)
+	adminID := int64(99)
+	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID, Content: "first\nsecond edited\nthird"}
+	revision := &entity.PostRevision{ID: 5, PostID: postID, Content: "first\nsecond\nthird"}
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("GetRevision", ctx, postID, revision.ID).Return(revision, nil).Once()
+
+	diff, err := s.usecase.DiffRevisions(ctx, postID, adminID, "admin", revision.ID, nil)
+
+	s.NoError(err)
+	s.Nil(diff.To)
+	s.Equal([]entity.DiffLine{
+		{Op: entity.DiffEqual, Text: "first"},
+		{Op: entity.DiffDelete, Text: "second"},
+		{Op: entity.DiffInsert, Text: "second edited"},
+		{Op: entity.DiffEqual, Text: "third"},
+	}, diff.Lines)
+	s.postRepoMock.AssertExpectations(s.T())
+}
+
+func (s *PostUsecaseSuite) TestDiffRevisions_BetweenRevisions() {
+	ctx := context.Background()
+	postID := int64(1)
+	to := int64(6)
+	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID, Content: "current"}
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("GetRevision", ctx, postID, int64(5)).Return(&entity.PostRevision{ID: 5, Content: "a\nb\nc"}, nil).Once()
+	s.postRepoMock.On("GetRevision", ctx, postID, to).Return(&entity.PostRevision{ID: to, Content: "a\nc\nd"}, nil).Once()
+
+	diff, err := s.usecase.DiffRevisions(ctx, postID, s.defaultAuthorID, "user", 5, &to)
+
+	s.NoError(err)
+	s.Equal(&to, diff.To)
+	s.Equal([]entity.DiffLine{
+		{Op: entity.DiffEqual, Text: "a"},
+		{Op: entity.DiffDelete, Text: "b"},
+		{Op: entity.DiffEqual, Text: "c"},
+		{Op: entity.DiffInsert, Text: "d"},
+	}, diff.Lines)
+}
+
+func (s *PostUsecaseSuite) TestDiffRevisions_RevisionNotFound() {
+	ctx := context.Background()
+	postID := int64(1)
+	postFromRepo := &entity.Post{ID: postID, AuthorID: &s.defaultAuthorID}
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(postFromRepo, nil).Once()
+	s.postRepoMock.On("GetRevision", ctx, postID, int64(5)).Return(nil, pgx.ErrNoRows).Once()
+
+	diff, err := s.usecase.DiffRevisions(ctx, postID, s.defaultAuthorID, "user", 5, nil)
+
+	s.ErrorIs(err, ErrRevisionNotFound)
+	s.Nil(diff)
+}
+
+func (s *PostUsecaseSuite) TestDiffLines_Identical() {
+	s.Equal([]entity.DiffLine{{Op: entity.DiffEqual, Text: "same"}}, diffLines("same", "same"))
+}
+
+// Reactions
+func (s *PostUsecaseSuite) TestAddReaction_Success() {
+	ctx := context.Background()
+	postID := int64(1)
+	userID := int64(2)
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(&entity.Post{ID: postID}, nil).Once()
+	s.reactionRepo.On("Add", ctx, postID, userID, "like").Return(nil).Once()
+
+	err := s.usecase.AddReaction(ctx, postID, userID, "like")
+
+	s.NoError(err)
+	s.postRepoMock.AssertExpectations(s.T())
+	s.reactionRepo.AssertExpectations(s.T())
+}
+
+func (s *PostUsecaseSuite) TestAddReaction_UnknownReaction() {
+	ctx := context.Background()
+
+	err := s.usecase.AddReaction(ctx, 1, 2, "poop")
+
+	s.ErrorIs(err, ErrInvalidReaction)
+	s.postRepoMock.AssertNotCalled(s.T(), "GetByID", mock.Anything, mock.Anything)
+	s.reactionRepo.AssertNotCalled(s.T(), "Add", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *PostUsecaseSuite) TestAddReaction_PostNotFound() {
+	ctx := context.Background()
+	postID := int64(1)
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(nil, pgx.ErrNoRows).Once()
+
+	err := s.usecase.AddReaction(ctx, postID, 2, "like")
+
+	s.ErrorIs(err, ErrPostNotFound)
+	s.reactionRepo.AssertNotCalled(s.T(), "Add", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *PostUsecaseSuite) TestRemoveReaction_Success() {
+	ctx := context.Background()
+	postID := int64(1)
+	userID := int64(2)
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(&entity.Post{ID: postID}, nil).Once()
+	s.reactionRepo.On("Remove", ctx, postID, userID, "heart").Return(nil).Once()
+
+	err := s.usecase.RemoveReaction(ctx, postID, userID, "heart")
+
+	s.NoError(err)
+	s.reactionRepo.AssertExpectations(s.T())
+}
+
+func (s *PostUsecaseSuite) TestRemoveReaction_RepoError() {
+	ctx := context.Background()
+	postID := int64(1)
+	userID := int64(2)
+	repoError := errors.New("repo remove error")
+
+	s.postRepoMock.On("GetByID", ctx, postID).Return(&entity.Post{ID: postID}, nil).Once()
+	s.reactionRepo.On("Remove", ctx, postID, userID, "heart").Return(repoError).Once()
+
+	err := s.usecase.RemoveReaction(ctx, postID, userID, "heart")
+
+	s.ErrorIs(err, repoError)
+	s.Contains(err.Error(), "ForumService - PostUsecase - RemoveReaction - reactionRepo.Remove()")
+}
+
+// Restore
+func (s *PostUsecaseSuite) TestRestorePost_Success() {
+	ctx := context.Background()
+	postID := int64(1)
+
+	s.postRepoMock.On("Restore", ctx, postID).Return(nil).Once()
+
+	err := s.usecase.Restore(ctx, postID)
+
+	s.NoError(err)
+	s.postRepoMock.AssertExpectations(s.T())
+}
+
+func (s *PostUsecaseSuite) TestRestorePost_NotDeleted() {
+	ctx := context.Background()
+	postID := int64(1)
+
+	s.postRepoMock.On("Restore", ctx, postID).Return(fmt.Errorf("PostRepository - Restore: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.Restore(ctx, postID)
+
+	s.ErrorIs(err, ErrPostNotFound)
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/client"
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/repo"
+	"github.com/rs/zerolog"
+)
+
+type searchUsecase struct {
+	searchRepo repo.SearchRepository
+	userClient client.UserClient
+	log        *zerolog.Logger
+}
+
+const searchOp = "SearchUsecase.Search"
+
+func NewSearchUsecase(searchRepo repo.SearchRepository, userClient client.UserClient, log *zerolog.Logger) SearchUsecase {
+	return &searchUsecase{searchRepo: searchRepo, userClient: userClient, log: log}
+}
+
+// Search runs a full-text search. degraded is set when authors got a placeholder name.
+func (u *searchUsecase) Search(ctx context.Context, query entity.SearchQuery) ([]entity.SearchResult, bool, error) {
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, false, fmt.Errorf("ForumService - SearchUsecase - Search: %w", ErrEmptySearchQuery)
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = entity.DefaultPageLimit
+	}
+	if query.Limit > entity.MaxPageLimit {
+		query.Limit = entity.MaxPageLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	results, err := u.searchRepo.Search(ctx, query)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", searchOp).Str("query", query.Query).Msg("Failed to search in repository")
+		return nil, false, fmt.Errorf("ForumService - SearchUsecase - Search - searchRepo.Search(): %w", err)
+	}
+
+	var authorIDs []int64
+	authorIDSet := make(map[int64]bool)
+	for i := range results {
+		if results[i].AuthorID != nil {
+			if _, exists := authorIDSet[*results[i].AuthorID]; !exists {
+				authorIDs = append(authorIDs, *results[i].AuthorID)
+				authorIDSet[*results[i].AuthorID] = true
+			}
+		}
+	}
+
+	// Search results stay readable while the user service is down.
+	usernames, err := u.userClient.GetUsernames(ctx, authorIDs)
+	degraded := err != nil
+	if degraded {
+		u.log.Warn().Err(err).Str("op", searchOp).Str("query", query.Query).Msg("Failed to get usernames, serving placeholders")
+	}
+
+	for i := range results {
+		if results[i].AuthorID == nil {
+			results[i].Username = deletedUsername
+			continue
+		}
+		if degraded {
+			results[i].Username = unavailableUsername
+			continue
+		}
+
+		if username, exists := usernames[*results[i].AuthorID]; exists {
+			results[i].Username = username
+		} else {
+			results[i].Username = deletedUsername
+		}
+	}
+
+	u.log.Info().Str("op", searchOp).Str("query", query.Query).Int("total_results", len(results)).Msg("Search completed successfully")
+	return results, degraded, nil
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type SearchUsecaseSuite struct {
+	suite.Suite
+	usecase        SearchUsecase
+	searchRepoMock *mocks.SearchRepository
+	userClientMock *mocks.UserClient
+	log            *zerolog.Logger
+}
+
+func (s *SearchUsecaseSuite) SetupTest() {
+	s.searchRepoMock = mocks.NewSearchRepository(s.T())
+	s.userClientMock = mocks.NewUserClient(s.T())
+	logger := zerolog.Nop()
+	s.log = &logger
+	s.usecase = NewSearchUsecase(s.searchRepoMock, s.userClientMock, s.log)
+}
+
+func TestSearchUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(SearchUsecaseSuite))
+}
+
+func (s *SearchUsecaseSuite) TestSearch_Success() {
+	ctx := context.Background()
+	authorID := int64(10)
+	resultsFromRepo := []entity.SearchResult{
+		{Type: entity.SearchResultTopic, ID: 1, TopicID: 1, AuthorID: &authorID, Snippet: "<mark>сессия</mark>"},
+		{Type: entity.SearchResultPost, ID: 5, TopicID: 1, AuthorID: nil, Snippet: "про <mark>сессию</mark>"},
+	}
+
+	s.searchRepoMock.On("Search", ctx, entity.SearchQuery{Query: "сессия", Limit: entity.DefaultPageLimit}).Return(resultsFromRepo, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID}).Return(map[int64]string{authorID: "UserOne"}, nil).Once()
+
+	results, _, err := s.usecase.Search(ctx, entity.SearchQuery{Query: "  сессия "})
+
+	s.NoError(err)
+	s.Len(results, 2)
+	s.Equal("UserOne", results[0].Username)
+	s.Equal("Удаленный пользователь", results[1].Username)
+}
+
+func (s *SearchUsecaseSuite) TestSearch_EmptyQuery() {
+	ctx := context.Background()
+
+	results, _, err := s.usecase.Search(ctx, entity.SearchQuery{Query: "   "})
+
+	s.Error(err)
+	s.Nil(results)
+	s.ErrorIs(err, ErrEmptySearchQuery)
+	s.searchRepoMock.AssertNotCalled(s.T(), "Search", mock.Anything, mock.Anything)
+}
+
+func (s *SearchUsecaseSuite) TestSearch_LimitClamped() {
+	ctx := context.Background()
+
+	s.searchRepoMock.On("Search", ctx, entity.SearchQuery{Query: "q", Limit: entity.MaxPageLimit}).Return(nil, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64(nil)).Return(map[int64]string{}, nil).Once()
+
+	results, _, err := s.usecase.Search(ctx, entity.SearchQuery{Query: "q", Limit: 1000})
+
+	s.NoError(err)
+	s.Empty(results)
+}
+
+func (s *SearchUsecaseSuite) TestSearch_RepoError() {
+	ctx := context.Background()
+	expectedError := errors.New("search repo error")
+
+	s.searchRepoMock.On("Search", ctx, mock.Anything).Return(nil, expectedError).Once()
+
+	results, _, err := s.usecase.Search(ctx, entity.SearchQuery{Query: "q"})
+
+	s.Error(err)
+	s.Nil(results)
+	s.Contains(err.Error(), "ForumService - SearchUsecase - Search - searchRepo.Search()")
+	s.ErrorIs(err, expectedError)
+	s.userClientMock.AssertNotCalled(s.T(), "GetUsernames", mock.Anything, mock.Anything)
+}
+
+func (s *SearchUsecaseSuite) TestSearch_UserClientError() {
+	ctx := context.Background()
+	authorID := int64(10)
+	expectedError := errors.New("user client error")
+
+	s.searchRepoMock.On("Search", ctx, mock.Anything).Return([]entity.SearchResult{{ID: 1, AuthorID: &authorID}}, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID}).Return(nil, expectedError).Once()
+
+	results, degraded, err := s.usecase.Search(ctx, entity.SearchQuery{Query: "q"})
+
+	s.NoError(err)
+	s.True(degraded)
+	s.Require().Len(results, 1)
+	s.Equal(unavailableUsername, results[0].Username)
+}
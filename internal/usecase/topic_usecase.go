@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/keshvan/forum-service-sstu-forum/internal/client"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/forum-service-sstu-forum/internal/repo"
 	"github.com/rs/zerolog"
 )
@@ -15,7 +17,12 @@ import (
 type topicUsecase struct {
 	topicRepo    repo.TopicRepository
 	categoryRepo repo.CategoryRepository
+	postRepo     repo.PostRepository
+	transactor   repo.Transactor
 	userClient   client.UserClient
+	policy       *policy.Policy
+	cache        ResponseCache
+	feed         PostFeed
 	log          *zerolog.Logger
 }
 
@@ -25,150 +32,284 @@ const (
 	deleteTopicOp   = "TopicUsecase.Delete"
 	updateTopicOp   = "TopicUsecase.Update"
 	getByIdTopicOp  = "TopicUsecase.GetByID"
+	restoreTopicOp  = "TopicUsecase.Restore"
+	setStateOp      = "TopicUsecase.SetState"
 )
 
-func NewTopicUsecase(topicRepo repo.TopicRepository, categoryRepo repo.CategoryRepository, userClient client.UserClient, log *zerolog.Logger) TopicUsecase {
-	return &topicUsecase{topicRepo: topicRepo, categoryRepo: categoryRepo, userClient: userClient, log: log}
+func NewTopicUsecase(topicRepo repo.TopicRepository, categoryRepo repo.CategoryRepository, postRepo repo.PostRepository, transactor repo.Transactor, userClient client.UserClient, policy *policy.Policy, cache ResponseCache, feed PostFeed, log *zerolog.Logger) TopicUsecase {
+	return &topicUsecase{topicRepo: topicRepo, categoryRepo: categoryRepo, postRepo: postRepo, transactor: transactor, userClient: userClient, policy: policy, cache: cache, feed: feed, log: log}
 }
 
-func (u *topicUsecase) Create(ctx context.Context, topic entity.Topic) (int64, error) {
+// Create inserts the topic together with its opening post in one transaction,
+// so a failed post never leaves an empty topic behind.
+func (u *topicUsecase) Create(ctx context.Context, topic entity.Topic, content string) (int64, error) {
 	if err := u.checkCategory(ctx, topic.CategoryID); err != nil {
 		u.log.Error().Err(err).Str("op", createTopicOp).Int64("category_id", topic.CategoryID).Msg("Category not found")
 		return 0, err
 	}
 
-	id, err := u.topicRepo.Create(ctx, topic)
+	var id int64
+	var post entity.Post
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = u.topicRepo.Create(ctx, topic)
+		if err != nil {
+			return fmt.Errorf("topicRepo.Create(): %w", err)
+		}
+
+		post = entity.Post{TopicID: id, AuthorID: topic.AuthorID, Content: content}
+		if post.ID, err = u.postRepo.Create(ctx, post); err != nil {
+			return fmt.Errorf("postRepo.Create(): %w", err)
+		}
+		return nil
+	})
 	if err != nil {
 		u.log.Error().Err(err).Str("op", createTopicOp).Any("topic", topic).Msg("Failed to create topic in repository")
-		return 0, fmt.Errorf("ForumService - TopicUsecase - Create - topicRepo.Create(): %w", err)
+		return 0, fmt.Errorf("ForumService - TopicUsecase - Create - %w", err)
 	}
+	u.cache.InvalidateCategory(topic.CategoryID)
+	u.feed.Publish(post)
 
 	u.log.Info().Str("op", createTopicOp).Any("topic", topic).Msg("Topic created successfully")
 	return id, nil
 }
 
-func (u *topicUsecase) GetByID(ctx context.Context, id int64) (*entity.Topic, error) {
+// GetByID returns a topic with its breadcrumbs. degraded is set when the author got a
+// placeholder name.
+func (u *topicUsecase) GetByID(ctx context.Context, id int64) (*entity.Topic, bool, error) {
 	topic, err := u.topicRepo.GetByID(ctx, id)
 	if err != nil {
 		u.log.Error().Err(err).Str("op", getByIdTopicOp).Int64("id", id).Msg("Failed to get topic in repository")
-		return nil, fmt.Errorf("ForumService - TopicUsecase - GetByID - repo.GetByID(): %w", err)
+		return nil, false, fmt.Errorf("ForumService - TopicUsecase - GetByID - repo.GetByID(): %w", err)
 	}
 
-	var username string
-
-	if topic.AuthorID == nil {
-		username = "Удаленный пользователь"
-	} else {
-		if uname, err := u.userClient.GetUsername(ctx, *topic.AuthorID); err != nil {
-			return nil, fmt.Errorf("ForumService - TopicUsecase - GetById - userClient.GetUsername(): %w", err)
-		} else {
-			username = uname
+	degraded := false
+	topic.Username = deletedUsername
+	if topic.AuthorID != nil {
+		username, err := u.userClient.GetUsername(ctx, *topic.AuthorID)
+		switch {
+		case err == nil:
+			topic.Username = username
+		case errors.Is(err, client.ErrUserNotFound):
+		default:
+			// The topic stays readable while the user service is down.
+			u.log.Warn().Err(err).Str("op", getByIdTopicOp).Int64("id", id).Msg("Failed to get username, serving placeholder")
+			topic.Username = unavailableUsername
+			degraded = true
 		}
 	}
 
-	topic.Username = username
+	breadcrumbs, err := u.categoryRepo.GetPath(ctx, topic.CategoryID)
+	if err != nil {
+		u.log.Error().Err(err).Str("op", getByIdTopicOp).Int64("id", id).Msg("Failed to get category path in repository")
+		return nil, false, fmt.Errorf("ForumService - TopicUsecase - GetByID - categoryRepo.GetPath(): %w", err)
+	}
+	topic.Breadcrumbs = breadcrumbs
 
 	u.log.Info().Str("op", getByIdTopicOp).Int64("id", id).Msg("Topic taken successfully")
-	return topic, nil
+	return topic, degraded, nil
 }
 
-func (u *topicUsecase) GetByCategory(ctx context.Context, categoryID int64) ([]entity.Topic, error) {
-	if err := u.checkCategory(ctx, categoryID); err != nil {
+// GetByCategory lists topics of a category. An empty sort means entity.TopicSortNewest.
+// Unless includeHidden is set, a hidden category is reported as not found.
+func (u *topicUsecase) GetByCategory(ctx context.Context, categoryID int64, includeHidden bool, sort entity.TopicSort, page entity.PageRequest) ([]entity.Topic, entity.PageInfo, error) {
+	if err := u.checkVisibleCategory(ctx, categoryID, includeHidden); err != nil {
 		u.log.Error().Err(err).Str("op", getByCategoryOp).Int64("category_id", categoryID).Msg("Category not found")
-		return nil, err
+		return nil, entity.PageInfo{}, err
 	}
 
-	topics, err := u.topicRepo.GetByCategory(ctx, categoryID)
+	if sort == "" {
+		sort = entity.TopicSortNewest
+	}
+
+	page = normalizePage(page)
+	topics, err := u.topicRepo.GetByCategory(ctx, categoryID, sort, repoPage(page))
 	if err != nil {
 		u.log.Error().Err(err).Str("op", getByCategoryOp).Int64("category_id", categoryID).Msg("Failed to get topics in repository")
-		return nil, fmt.Errorf("ForumService - TopicUsecase  - GetByCategory - topicRepo.GetByCategory(): %w", err)
+		return nil, entity.PageInfo{}, fmt.Errorf("ForumService - TopicUsecase  - GetByCategory - topicRepo.GetByCategory(): %w", err)
 	}
 
+	topics, pageInfo := paginate(topics, page, topicCursor(sort))
+
+	// Topic authors and last post authors are resolved in a single call.
 	var authorIDs []int64
 	authorIDSet := make(map[int64]bool)
 	for i := range topics {
-		if topics[i].AuthorID != nil {
-			if _, exists := authorIDSet[*topics[i].AuthorID]; !exists {
-				authorIDs = append(authorIDs, *topics[i].AuthorID)
-				authorIDSet[*topics[i].AuthorID] = true
+		for _, id := range []*int64{topics[i].AuthorID, topics[i].LastPostAuthorID} {
+			if id != nil && !authorIDSet[*id] {
+				authorIDs = append(authorIDs, *id)
+				authorIDSet[*id] = true
 			}
 		}
 	}
 
+	// The listing stays readable while the user service is down.
 	usernames, err := u.userClient.GetUsernames(ctx, authorIDs)
 	if err != nil {
-		return nil, fmt.Errorf("ForumService - TopicUsecase  - GetByCategory - userClient.GetUsernames(): %w", err)
+		u.log.Warn().Err(err).Str("op", getByCategoryOp).Int64("category_id", categoryID).Msg("Failed to get usernames, serving placeholders")
+		usernames = make(map[int64]string, len(authorIDs))
+		for _, id := range authorIDs {
+			usernames[id] = unavailableUsername
+		}
+		pageInfo.Degraded = true
 	}
 
 	for i := range topics {
-		if topics[i].AuthorID == nil {
-			topics[i].Username = "Удаленный пользователь"
-			continue
-		}
-
-		if username, exists := usernames[*topics[i].AuthorID]; exists {
-			topics[i].Username = username
-		} else {
-			topics[i].Username = "Удаленный пользователь"
+		topics[i].Username = usernameOrDeleted(usernames, topics[i].AuthorID)
+		if topics[i].LastPostAt != nil {
+			topics[i].LastPostAuthor = usernameOrDeleted(usernames, topics[i].LastPostAuthorID)
 		}
 	}
 
 	u.log.Info().Str("op", getByCategoryOp).Int64("category_id", categoryID).Msg("Topics by category succesfully taken")
-	return topics, nil
+	return topics, pageInfo, nil
 }
 
-func (u *topicUsecase) Update(ctx context.Context, topicID int64, userID int64, role string, title string) error {
-	if err := u.checkAccess(ctx, topicID, userID, role); err != nil {
-		u.log.Warn().Err(err).Str("op", updateTopicOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Access denied")
-		return err
-	}
+// Update renames a topic. The access check and the update run in one transaction.
+// A non-nil version is the updated_at the caller last saw; if the topic has changed
+// since, ErrVersionConflict is returned.
+func (u *topicUsecase) Update(ctx context.Context, topicID int64, userID int64, role string, title string, version *time.Time) error {
+	var topic *entity.Topic
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if topic, err = u.checkAccess(ctx, topicID, userID, role); err != nil {
+			u.log.Warn().Err(err).Str("op", updateTopicOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Access denied")
+			return err
+		}
 
-	if err := u.topicRepo.Update(ctx, topicID, title); err != nil {
-		u.log.Error().Err(err).Str("op", updateTopicOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Failed to update topic in repository")
-		return fmt.Errorf("ForumService - TopicUsecase - Update - topicRepo.Update(): %w", err)
+		if err := u.topicRepo.Update(ctx, topicID, title, version); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				if version != nil {
+					u.log.Warn().Str("op", updateTopicOp).Int64("topic_id", topicID).Msg("Topic version conflict")
+					return fmt.Errorf("ForumService - TopicUsecase - Update - topicRepo.Update(): %w", ErrVersionConflict)
+				}
+				return fmt.Errorf("ForumService - TopicUsecase - Update - topicRepo.Update(): %w", ErrTopicNotFound)
+			}
+			u.log.Error().Err(err).Str("op", updateTopicOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Failed to update topic in repository")
+			return fmt.Errorf("ForumService - TopicUsecase - Update - topicRepo.Update(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	u.cache.InvalidateTopic(topic.CategoryID, topicID)
 
 	u.log.Info().Str("op", updateTopicOp).Int64("topic_id", topicID).Msg("Topic updated successfully")
 	return nil
 }
 
+// Delete soft-deletes a topic. The access check and the delete run in one transaction.
 func (u *topicUsecase) Delete(ctx context.Context, topicID int64, userID int64, role string) error {
-	if err := u.checkAccess(ctx, topicID, userID, role); err != nil {
-		u.log.Warn().Err(err).Str("op", deleteTopicOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Access denied")
-		return err
-	}
+	var topic *entity.Topic
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if topic, err = u.checkAccess(ctx, topicID, userID, role); err != nil {
+			u.log.Warn().Err(err).Str("op", deleteTopicOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Access denied")
+			return err
+		}
 
-	if err := u.topicRepo.Delete(ctx, topicID); err != nil {
-		u.log.Error().Err(err).Str("op", deleteTopicOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Access denied")
-		return fmt.Errorf("ForumService - TopicUsecase - Delete - topicRepo.Delete(): %w", err)
+		if err := u.topicRepo.Delete(ctx, topicID, userID); err != nil {
+			u.log.Error().Err(err).Str("op", deleteTopicOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Access denied")
+			return fmt.Errorf("ForumService - TopicUsecase - Delete - topicRepo.Delete(): %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	u.cache.InvalidateTopic(topic.CategoryID, topicID)
 
 	u.log.Info().Str("op", deleteTopicOp).Int64("topic_id", topicID).Msg("Topic deleted successfully")
 	return nil
 }
 
-func (u *topicUsecase) checkAccess(ctx context.Context, topicID int64, userID int64, role string) error {
-	post, err := u.topicRepo.GetByID(ctx, topicID)
-	if err != nil {
+func (u *topicUsecase) Restore(ctx context.Context, topicID int64) error {
+	if err := u.topicRepo.Restore(ctx, topicID); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("ForumService - TopicUsecase - checkAccess - topicRepo.GetByID(): %w", ErrTopicNotFound)
+			return fmt.Errorf("ForumService - TopicUsecase - Restore - topicRepo.Restore(): %w", ErrTopicNotFound)
 		}
-		return fmt.Errorf("ForumService - TopicUsecase - checkAccess  - topicRepo.GetByID(): %w", err)
+		u.log.Error().Err(err).Str("op", restoreTopicOp).Int64("topic_id", topicID).Msg("Failed to restore topic in repository")
+		return fmt.Errorf("ForumService - TopicUsecase - Restore - topicRepo.Restore(): %w", err)
 	}
+	// Restores are rare admin actions, the category of the topic is not looked up for them.
+	u.cache.Invalidate()
+
+	u.log.Info().Str("op", restoreTopicOp).Int64("topic_id", topicID).Msg("Topic restored successfully")
+	return nil
+}
+
+// SetState pins/unpins and locks/unlocks a topic, nil flags are left unchanged.
+// Only admins and moderators of the topic's category may change the state.
+func (u *topicUsecase) SetState(ctx context.Context, topicID int64, userID int64, role string, pinned *bool, locked *bool) error {
+	var topic *entity.Topic
+	err := u.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		topic, err = u.lockTopic(ctx, topicID)
+		if err != nil {
+			return err
+		}
+
+		ok, err := u.policy.CanModerate(ctx, userID, role, topic.CategoryID)
+		if err != nil {
+			u.log.Error().Err(err).Str("op", setStateOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Failed to check permissions")
+			return fmt.Errorf("ForumService - TopicUsecase - SetState - policy.CanModerate(): %w", err)
+		}
+		if !ok {
+			u.log.Warn().Str("op", setStateOp).Int64("topic_id", topicID).Int64("user_id", userID).Msg("Access denied")
+			return fmt.Errorf("ForumService - TopicUsecase - SetState: %w", ErrForbidden)
+		}
 
-	if role == "admin" {
+		if err := u.topicRepo.UpdateState(ctx, topicID, pinned, locked); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("ForumService - TopicUsecase - SetState - topicRepo.UpdateState(): %w", ErrTopicNotFound)
+			}
+			u.log.Error().Err(err).Str("op", setStateOp).Int64("topic_id", topicID).Msg("Failed to update topic state in repository")
+			return fmt.Errorf("ForumService - TopicUsecase - SetState - topicRepo.UpdateState(): %w", err)
+		}
 		return nil
+	})
+	if err != nil {
+		return err
 	}
+	u.cache.InvalidateTopic(topic.CategoryID, topicID)
+
+	u.log.Info().Str("op", setStateOp).Int64("topic_id", topicID).Msg("Topic state updated successfully")
+	return nil
+}
 
-	if post.AuthorID == nil || (*post.AuthorID != userID) {
-		return fmt.Errorf("ForumService - TopicUsecase - checkAccess  - topicRepo.Update(): %w", ErrForbidden)
+// checkAccess locks the topic and checks that the user may modify it. It must run inside
+// the transaction of the write it guards.
+func (u *topicUsecase) checkAccess(ctx context.Context, topicID int64, userID int64, role string) (*entity.Topic, error) {
+	topic, err := u.lockTopic(ctx, topicID)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	ok, err := u.policy.CanModifyTopic(ctx, userID, role, topic)
+	if err != nil {
+		return nil, fmt.Errorf("ForumService - TopicUsecase - checkAccess - policy.CanModifyTopic(): %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("ForumService - TopicUsecase - checkAccess: %w", ErrForbidden)
+	}
+
+	return topic, nil
+}
+
+func (u *topicUsecase) lockTopic(ctx context.Context, topicID int64) (*entity.Topic, error) {
+	topic, err := u.topicRepo.GetByIDForUpdate(ctx, topicID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("ForumService - TopicUsecase - lockTopic - topicRepo.GetByIDForUpdate(): %w", ErrTopicNotFound)
+		}
+		return nil, fmt.Errorf("ForumService - TopicUsecase - lockTopic - topicRepo.GetByIDForUpdate(): %w", err)
+	}
+
+	return topic, nil
 }
 
 func (u *topicUsecase) checkCategory(ctx context.Context, categoryID int64) error {
-	fmt.Println("checkCategory", categoryID)
 	if _, err := u.categoryRepo.GetByID(ctx, categoryID); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("ForumService - TopicUsecase - checkCategory - categoryRepo.GetByID(): %w", ErrCategoryNotFound)
@@ -178,3 +319,32 @@ func (u *topicUsecase) checkCategory(ctx context.Context, categoryID int64) erro
 
 	return nil
 }
+
+func (u *topicUsecase) checkVisibleCategory(ctx context.Context, categoryID int64, includeHidden bool) error {
+	if err := u.checkCategory(ctx, categoryID); err != nil {
+		return err
+	}
+	if includeHidden {
+		return nil
+	}
+
+	hidden, err := u.categoryRepo.IsHidden(ctx, categoryID)
+	if err != nil {
+		return fmt.Errorf("ForumService - TopicUsecase - checkVisibleCategory - categoryRepo.IsHidden(): %w", err)
+	}
+	if hidden {
+		return fmt.Errorf("ForumService - TopicUsecase - checkVisibleCategory: %w", ErrCategoryNotFound)
+	}
+
+	return nil
+}
+
+func usernameOrDeleted(usernames map[int64]string, id *int64) string {
+	if id == nil {
+		return deletedUsername
+	}
+	if username, exists := usernames[*id]; exists {
+		return username
+	}
+	return deletedUsername
+}
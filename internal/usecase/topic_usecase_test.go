@@ -3,11 +3,14 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/keshvan/forum-service-sstu-forum/internal/client"
 	"github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	"github.com/keshvan/forum-service-sstu-forum/internal/policy"
 	"github.com/keshvan/forum-service-sstu-forum/mocks"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/mock"
@@ -19,7 +22,12 @@ type TopicUsecaseSuite struct {
 	usecase           TopicUsecase
 	topicRepoMock     *mocks.TopicRepository
 	categoryRepoMock  *mocks.CategoryRepository
+	postRepoMock      *mocks.PostRepository
+	transactorMock    *mocks.Transactor
 	userClientMock    *mocks.UserClient
+	moderatorRepo     *mocks.ModeratorRepository
+	cacheMock         *mocks.ResponseCache
+	feedMock          *mocks.PostFeed
 	log               *zerolog.Logger
 	defaultAuthorID   int64
 	defaultCategoryID int64
@@ -28,13 +36,23 @@ type TopicUsecaseSuite struct {
 func (s *TopicUsecaseSuite) SetupTest() {
 	s.topicRepoMock = mocks.NewTopicRepository(s.T())
 	s.categoryRepoMock = mocks.NewCategoryRepository(s.T())
+	s.postRepoMock = mocks.NewPostRepository(s.T())
+	s.transactorMock = mocks.NewTransactor(s.T())
 	s.userClientMock = mocks.NewUserClient(s.T())
+	s.moderatorRepo = mocks.NewModeratorRepository(s.T())
+	s.cacheMock = mocks.NewResponseCache(s.T())
+	s.cacheMock.On("Invalidate").Maybe()
+	s.cacheMock.On("InvalidateCategory", mock.Anything).Maybe()
+	s.cacheMock.On("InvalidateTopic", mock.Anything, mock.Anything).Maybe()
+	s.cacheMock.On("InvalidatePosts", mock.Anything).Maybe()
+	s.feedMock = mocks.NewPostFeed(s.T())
+	s.feedMock.On("Publish", mock.Anything).Maybe()
 	logger := zerolog.Nop()
 	s.log = &logger
 	s.defaultAuthorID = int64(123)
 	s.defaultCategoryID = int64(1)
 
-	s.usecase = NewTopicUsecase(s.topicRepoMock, s.categoryRepoMock, s.userClientMock, s.log)
+	s.usecase = NewTopicUsecase(s.topicRepoMock, s.categoryRepoMock, s.postRepoMock, s.transactorMock, s.userClientMock, policy.New(s.moderatorRepo, s.topicRepoMock), s.cacheMock, s.feedMock, s.log)
 }
 
 func TestTopicUsecaseSuite(t *testing.T) {
@@ -42,21 +60,52 @@ func TestTopicUsecaseSuite(t *testing.T) {
 }
 
 // Create
+// runInTx makes the transactor mock call the function it is given, like a real transaction would.
+func (s *TopicUsecaseSuite) runInTx(ctx context.Context) {
+	s.transactorMock.On("WithinTx", ctx, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).Once()
+}
+
 func (s *TopicUsecaseSuite) TestCreateTopic_Success() {
 	ctx := context.Background()
 	topic := entity.Topic{CategoryID: s.defaultCategoryID, AuthorID: &s.defaultAuthorID, Title: "topic title"}
 	expectedTopicID := int64(1)
 	category := &entity.Category{ID: s.defaultCategoryID, Title: "Existing category"}
+	firstPost := entity.Post{TopicID: expectedTopicID, AuthorID: &s.defaultAuthorID, Content: "opening post"}
 
 	s.categoryRepoMock.On("GetByID", ctx, s.defaultCategoryID).Return(category, nil).Once()
+	s.runInTx(ctx)
 	s.topicRepoMock.On("Create", ctx, topic).Return(expectedTopicID, nil).Once()
+	s.postRepoMock.On("Create", ctx, firstPost).Return(int64(10), nil).Once()
 
-	id, err := s.usecase.Create(ctx, topic)
+	id, err := s.usecase.Create(ctx, topic, "opening post")
 
 	s.NoError(err)
 	s.Equal(expectedTopicID, id)
 	s.categoryRepoMock.AssertExpectations(s.T())
 	s.topicRepoMock.AssertExpectations(s.T())
+	s.postRepoMock.AssertExpectations(s.T())
+	firstPost.ID = 10
+	s.feedMock.AssertCalled(s.T(), "Publish", firstPost)
+}
+
+func (s *TopicUsecaseSuite) TestCreateTopic_PostError() {
+	ctx := context.Background()
+	topic := entity.Topic{CategoryID: s.defaultCategoryID, AuthorID: &s.defaultAuthorID, Title: "topic title"}
+	category := &entity.Category{ID: s.defaultCategoryID, Title: "Existing category"}
+	expectedError := errors.New("post repository create error")
+
+	s.categoryRepoMock.On("GetByID", ctx, s.defaultCategoryID).Return(category, nil).Once()
+	s.runInTx(ctx)
+	s.topicRepoMock.On("Create", ctx, topic).Return(int64(1), nil).Once()
+	s.postRepoMock.On("Create", ctx, mock.Anything).Return(int64(0), expectedError).Once()
+
+	id, err := s.usecase.Create(ctx, topic, "opening post")
+
+	s.Zero(id)
+	s.Contains(err.Error(), "ForumService - TopicUsecase - Create - postRepo.Create()")
+	s.ErrorIs(err, expectedError)
 }
 
 func (s *TopicUsecaseSuite) TestCreateTopic_CategoryNotFound() {
@@ -66,7 +115,7 @@ func (s *TopicUsecaseSuite) TestCreateTopic_CategoryNotFound() {
 
 	s.categoryRepoMock.On("GetByID", ctx, s.defaultCategoryID).Return(nil, pgx.ErrNoRows).Once()
 
-	id, err := s.usecase.Create(ctx, topic)
+	id, err := s.usecase.Create(ctx, topic, "opening post")
 
 	s.Error(err)
 	s.Equal(int64(0), id)
@@ -83,7 +132,7 @@ func (s *TopicUsecaseSuite) TestCreateTopic_CategoryRepoError_OtherThanNotFound(
 
 	s.categoryRepoMock.On("GetByID", ctx, s.defaultCategoryID).Return(nil, repoError).Once()
 
-	id, err := s.usecase.Create(ctx, topic)
+	id, err := s.usecase.Create(ctx, topic, "opening post")
 
 	s.Error(err)
 	s.Equal(int64(0), id)
@@ -99,9 +148,10 @@ func (s *TopicUsecaseSuite) TestCreateTopic_RepoError() {
 	category := &entity.Category{ID: s.defaultCategoryID, Title: "Existing category"}
 
 	s.categoryRepoMock.On("GetByID", ctx, s.defaultCategoryID).Return(category, nil).Once()
+	s.runInTx(ctx)
 	s.topicRepoMock.On("Create", ctx, topic).Return(int64(0), expectedError).Once()
 
-	id, err := s.usecase.Create(ctx, topic)
+	id, err := s.usecase.Create(ctx, topic, "opening post")
 
 	s.Error(err)
 	s.Equal(int64(0), id)
@@ -118,12 +168,14 @@ func (s *TopicUsecaseSuite) TestGetByIDTopic_Success_WithAuthor() {
 	authorID := s.defaultAuthorID
 	expectedUsername := "TestUser"
 	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &authorID, Title: "Test Topic", CategoryID: s.defaultCategoryID, CreatedAt: time.Now()}
-	expectedTopic := &entity.Topic{ID: topicID, AuthorID: &authorID, Title: "Test Topic", CategoryID: s.defaultCategoryID, Username: expectedUsername, CreatedAt: topicFromRepo.CreatedAt}
+	breadcrumbs := []entity.Breadcrumb{{ID: 9, Title: "Faculty"}, {ID: s.defaultCategoryID, Title: "Department"}}
+	expectedTopic := &entity.Topic{ID: topicID, AuthorID: &authorID, Title: "Test Topic", CategoryID: s.defaultCategoryID, Username: expectedUsername, CreatedAt: topicFromRepo.CreatedAt, Breadcrumbs: breadcrumbs}
 
 	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
 	s.userClientMock.On("GetUsername", ctx, authorID).Return(expectedUsername, nil).Once()
+	s.categoryRepoMock.On("GetPath", ctx, s.defaultCategoryID).Return(breadcrumbs, nil).Once()
 
-	topic, err := s.usecase.GetByID(ctx, topicID)
+	topic, _, err := s.usecase.GetByID(ctx, topicID)
 
 	s.NoError(err)
 	s.NotNil(topic)
@@ -136,11 +188,13 @@ func (s *TopicUsecaseSuite) TestGetByIDTopic_Success_AuthorNil() {
 	ctx := context.Background()
 	topicID := int64(1)
 	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: nil, Title: "Test Topic", CategoryID: s.defaultCategoryID, CreatedAt: time.Now()}
-	expectedTopic := &entity.Topic{ID: topicID, AuthorID: nil, Title: "Test Topic", CategoryID: s.defaultCategoryID, Username: "Удаленный пользователь", CreatedAt: topicFromRepo.CreatedAt}
+	breadcrumbs := []entity.Breadcrumb{{ID: s.defaultCategoryID, Title: "Category"}}
+	expectedTopic := &entity.Topic{ID: topicID, AuthorID: nil, Title: "Test Topic", CategoryID: s.defaultCategoryID, Username: "Удаленный пользователь", CreatedAt: topicFromRepo.CreatedAt, Breadcrumbs: breadcrumbs}
 
 	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.categoryRepoMock.On("GetPath", ctx, s.defaultCategoryID).Return(breadcrumbs, nil).Once()
 
-	topic, err := s.usecase.GetByID(ctx, topicID)
+	topic, _, err := s.usecase.GetByID(ctx, topicID)
 
 	s.NoError(err)
 	s.NotNil(topic)
@@ -156,7 +210,7 @@ func (s *TopicUsecaseSuite) TestGetByIDTopic_RepoError() {
 
 	s.topicRepoMock.On("GetByID", ctx, topicID).Return(nil, expectedError).Once()
 
-	topic, err := s.usecase.GetByID(ctx, topicID)
+	topic, _, err := s.usecase.GetByID(ctx, topicID)
 
 	s.Error(err)
 	s.Nil(topic)
@@ -175,17 +229,33 @@ func (s *TopicUsecaseSuite) TestGetByIDTopic_UserClientError() {
 
 	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
 	s.userClientMock.On("GetUsername", ctx, authorID).Return("", expectedError).Once()
+	s.categoryRepoMock.On("GetPath", ctx, int64(0)).Return([]entity.Breadcrumb{}, nil).Once()
 
-	topic, err := s.usecase.GetByID(ctx, topicID)
+	topic, degraded, err := s.usecase.GetByID(ctx, topicID)
 
-	s.Error(err)
-	s.Nil(topic)
-	s.Contains(err.Error(), "ForumService - TopicUsecase - GetById - userClient.GetUsername()")
-	s.ErrorIs(err, expectedError)
+	s.NoError(err)
+	s.True(degraded)
+	s.Equal(unavailableUsername, topic.Username)
 	s.topicRepoMock.AssertExpectations(s.T())
 	s.userClientMock.AssertExpectations(s.T())
 }
 
+func (s *TopicUsecaseSuite) TestGetByIDTopic_AuthorNotFound() {
+	ctx := context.Background()
+	topicID := int64(1)
+	authorID := s.defaultAuthorID
+	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &authorID, Title: "Test Topic"}
+
+	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.userClientMock.On("GetUsername", ctx, authorID).Return("", fmt.Errorf("client: %w", client.ErrUserNotFound)).Once()
+	s.categoryRepoMock.On("GetPath", ctx, int64(0)).Return([]entity.Breadcrumb{}, nil).Once()
+
+	topic, _, err := s.usecase.GetByID(ctx, topicID)
+
+	s.NoError(err)
+	s.Equal(deletedUsername, topic.Username)
+}
+
 // GetByCategory
 func (s *TopicUsecaseSuite) TestGetByCategory_Success() {
 	ctx := context.Background()
@@ -209,13 +279,14 @@ func (s *TopicUsecaseSuite) TestGetByCategory_Success() {
 	category := &entity.Category{ID: categoryID, Title: "Existing category"}
 
 	s.categoryRepoMock.On("GetByID", ctx, categoryID).Return(category, nil).Once()
-	s.topicRepoMock.On("GetByCategory", ctx, categoryID).Return(topicsFromRepo, nil).Once()
+	s.categoryRepoMock.On("IsHidden", ctx, categoryID).Return(false, nil).Once()
+	s.topicRepoMock.On("GetByCategory", ctx, categoryID, entity.TopicSortNewest, entity.PageRequest{Limit: entity.DefaultPageLimit + 1}).Return(topicsFromRepo, nil).Once()
 	s.userClientMock.On("GetUsernames", ctx, mock.MatchedBy(func(ids []int64) bool {
 		s.ElementsMatch([]int64{authorID1, authorID2}, ids)
 		return true
 	})).Return(usernamesFromClient, nil).Once()
 
-	topics, err := s.usecase.GetByCategory(ctx, categoryID)
+	topics, _, err := s.usecase.GetByCategory(ctx, categoryID, false, "", entity.PageRequest{})
 
 	s.NoError(err)
 	s.NotNil(topics)
@@ -225,6 +296,58 @@ func (s *TopicUsecaseSuite) TestGetByCategory_Success() {
 	s.userClientMock.AssertExpectations(s.T())
 }
 
+func (s *TopicUsecaseSuite) TestGetByCategory_NextPage() {
+	ctx := context.Background()
+	categoryID := s.defaultCategoryID
+	authorID := int64(10)
+	now := time.Now()
+	topicsFromRepo := []entity.Topic{
+		{ID: 3, CategoryID: categoryID, AuthorID: &authorID, Title: "Topic 3", CreatedAt: now},
+		{ID: 2, CategoryID: categoryID, AuthorID: &authorID, Title: "Topic 2", CreatedAt: now.Add(-time.Minute)},
+		{ID: 1, CategoryID: categoryID, AuthorID: &authorID, Title: "Topic 1", CreatedAt: now.Add(-2 * time.Minute)},
+	}
+	after := &entity.Cursor{CreatedAt: now.Add(time.Minute), ID: 4}
+	category := &entity.Category{ID: categoryID, Title: "Existing category"}
+
+	s.categoryRepoMock.On("GetByID", ctx, categoryID).Return(category, nil).Once()
+	s.topicRepoMock.On("GetByCategory", ctx, categoryID, entity.TopicSortNewest, entity.PageRequest{Limit: 3, After: after}).Return(topicsFromRepo, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID}).Return(map[int64]string{authorID: "UserOne"}, nil).Once()
+
+	topics, pageInfo, err := s.usecase.GetByCategory(ctx, categoryID, true, "", entity.PageRequest{Limit: 2, After: after})
+
+	s.NoError(err)
+	s.Len(topics, 2)
+	s.Equal(int64(3), topics[0].ID)
+	s.Equal(int64(2), topics[1].ID)
+	s.Equal(entity.Cursor{CreatedAt: topics[1].CreatedAt, ID: 2}.Encode(), pageInfo.NextCursor)
+	s.Equal(entity.Cursor{CreatedAt: topics[0].CreatedAt, ID: 3}.Encode(), pageInfo.PrevCursor)
+}
+
+func (s *TopicUsecaseSuite) TestGetByCategory_LastPostAuthors() {
+	ctx := context.Background()
+	categoryID := s.defaultCategoryID
+	authorID, replierID := int64(10), int64(30)
+	lastPostAt := time.Now()
+	topicsFromRepo := []entity.Topic{
+		{ID: 2, CategoryID: categoryID, AuthorID: &authorID, Title: "Busy", ReplyCount: 5, LastActivityAt: lastPostAt, LastPostAt: &lastPostAt, LastPostAuthorID: &replierID},
+		{ID: 1, CategoryID: categoryID, AuthorID: &authorID, Title: "Quiet", ReplyCount: 1, LastActivityAt: lastPostAt, LastPostAt: &lastPostAt},
+	}
+	category := &entity.Category{ID: categoryID, Title: "Existing category"}
+
+	s.categoryRepoMock.On("GetByID", ctx, categoryID).Return(category, nil).Once()
+	s.topicRepoMock.On("GetByCategory", ctx, categoryID, entity.TopicSortMostReplies, entity.PageRequest{Limit: 2}).Return(topicsFromRepo, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID, replierID}).Return(map[int64]string{authorID: "Author", replierID: "Replier"}, nil).Once()
+
+	topics, pageInfo, err := s.usecase.GetByCategory(ctx, categoryID, true, entity.TopicSortMostReplies, entity.PageRequest{Limit: 1})
+
+	s.NoError(err)
+	s.Len(topics, 1)
+	s.Equal("Author", topics[0].Username)
+	s.Equal("Replier", topics[0].LastPostAuthor)
+	s.Equal(entity.Cursor{Replies: 5, ID: 2}.Encode(), pageInfo.NextCursor)
+	s.userClientMock.AssertExpectations(s.T())
+}
+
 func (s *TopicUsecaseSuite) TestGetByCategory_CheckCategoryError_NotFound() {
 	ctx := context.Background()
 	categoryID := s.defaultCategoryID
@@ -232,16 +355,31 @@ func (s *TopicUsecaseSuite) TestGetByCategory_CheckCategoryError_NotFound() {
 
 	s.categoryRepoMock.On("GetByID", ctx, categoryID).Return(nil, pgx.ErrNoRows).Once()
 
-	topics, err := s.usecase.GetByCategory(ctx, categoryID)
+	topics, _, err := s.usecase.GetByCategory(ctx, categoryID, true, "", entity.PageRequest{})
 
 	s.Error(err)
 	s.Nil(topics)
 	s.ErrorIs(err, expectedError)
 	s.categoryRepoMock.AssertExpectations(s.T())
-	s.topicRepoMock.AssertNotCalled(s.T(), "GetByCategory", mock.Anything, mock.Anything)
+	s.topicRepoMock.AssertNotCalled(s.T(), "GetByCategory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	s.userClientMock.AssertNotCalled(s.T(), "GetUsernames", mock.Anything, mock.Anything)
 }
 
+func (s *TopicUsecaseSuite) TestGetByCategory_HiddenCategory() {
+	ctx := context.Background()
+	categoryID := s.defaultCategoryID
+
+	s.categoryRepoMock.On("GetByID", ctx, categoryID).Return(&entity.Category{ID: categoryID}, nil).Once()
+	s.categoryRepoMock.On("IsHidden", ctx, categoryID).Return(true, nil).Once()
+
+	topics, _, err := s.usecase.GetByCategory(ctx, categoryID, false, "", entity.PageRequest{})
+
+	s.ErrorIs(err, ErrCategoryNotFound)
+	s.Nil(topics)
+	s.categoryRepoMock.AssertExpectations(s.T())
+	s.topicRepoMock.AssertNotCalled(s.T(), "GetByCategory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (s *TopicUsecaseSuite) TestGetByCategory_TopicRepoError() {
 	ctx := context.Background()
 	categoryID := s.defaultCategoryID
@@ -249,9 +387,9 @@ func (s *TopicUsecaseSuite) TestGetByCategory_TopicRepoError() {
 	category := &entity.Category{ID: categoryID, Title: "Existing category"}
 
 	s.categoryRepoMock.On("GetByID", ctx, categoryID).Return(category, nil).Once()
-	s.topicRepoMock.On("GetByCategory", ctx, categoryID).Return(nil, expectedError).Once()
+	s.topicRepoMock.On("GetByCategory", ctx, categoryID, entity.TopicSortNewest, entity.PageRequest{Limit: entity.DefaultPageLimit + 1}).Return(nil, expectedError).Once()
 
-	topics, err := s.usecase.GetByCategory(ctx, categoryID)
+	topics, _, err := s.usecase.GetByCategory(ctx, categoryID, true, "", entity.PageRequest{})
 
 	s.Error(err)
 	s.Nil(topics)
@@ -268,20 +406,21 @@ func (s *TopicUsecaseSuite) TestGetByCategory_UserClientError() {
 	authorID1 := int64(10)
 	topicsFromRepo := []entity.Topic{
 		{ID: 1, CategoryID: categoryID, AuthorID: &authorID1, Title: "Topic 1"},
+		{ID: 2, CategoryID: categoryID, AuthorID: nil, Title: "Topic 2"},
 	}
-	expectedError := errors.New("user client GetUsernames error")
 	category := &entity.Category{ID: categoryID, Title: "Existing category"}
 
 	s.categoryRepoMock.On("GetByID", ctx, categoryID).Return(category, nil).Once()
-	s.topicRepoMock.On("GetByCategory", ctx, categoryID).Return(topicsFromRepo, nil).Once()
-	s.userClientMock.On("GetUsernames", ctx, []int64{authorID1}).Return(nil, expectedError).Once()
+	s.topicRepoMock.On("GetByCategory", ctx, categoryID, entity.TopicSortNewest, entity.PageRequest{Limit: entity.DefaultPageLimit + 1}).Return(topicsFromRepo, nil).Once()
+	s.userClientMock.On("GetUsernames", ctx, []int64{authorID1}).Return(nil, errors.New("user client GetUsernames error")).Once()
 
-	topics, err := s.usecase.GetByCategory(ctx, categoryID)
+	topics, pageInfo, err := s.usecase.GetByCategory(ctx, categoryID, true, "", entity.PageRequest{})
 
-	s.Error(err)
-	s.Nil(topics)
-	s.Contains(err.Error(), "ForumService - TopicUsecase  - GetByCategory - userClient.GetUsernames()")
-	s.ErrorIs(err, expectedError)
+	s.NoError(err)
+	s.True(pageInfo.Degraded)
+	s.Require().Len(topics, 2)
+	s.Equal(unavailableUsername, topics[0].Username)
+	s.Equal(deletedUsername, topics[1].Username)
 	s.categoryRepoMock.AssertExpectations(s.T())
 	s.topicRepoMock.AssertExpectations(s.T())
 	s.userClientMock.AssertExpectations(s.T())
@@ -290,23 +429,26 @@ func (s *TopicUsecaseSuite) TestGetByCategory_UserClientError() {
 // Update
 func (s *TopicUsecaseSuite) TestUpdateTopic_Success_Author() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
 	title := "updated title"
-	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &s.defaultAuthorID, Title: "Old title"}
+	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &s.defaultAuthorID, CategoryID: 4, Title: "Old title"}
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
-	s.topicRepoMock.On("Update", ctx, topicID, title).Return(nil).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("Update", ctx, topicID, title, (*time.Time)(nil)).Return(nil).Once()
 
-	err := s.usecase.Update(ctx, topicID, userID, role, title)
+	err := s.usecase.Update(ctx, topicID, userID, role, title, nil)
 
 	s.NoError(err)
 	s.topicRepoMock.AssertExpectations(s.T())
+	s.cacheMock.AssertCalled(s.T(), "InvalidateTopic", int64(4), topicID)
 }
 
 func (s *TopicUsecaseSuite) TestUpdateTopic_Success_Admin() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	adminID := int64(555)
 	authorID := s.defaultAuthorID
@@ -314,10 +456,10 @@ func (s *TopicUsecaseSuite) TestUpdateTopic_Success_Admin() {
 	title := "Updated by Admin"
 	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &authorID, Title: "Old title"}
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
-	s.topicRepoMock.On("Update", ctx, topicID, title).Return(nil).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("Update", ctx, topicID, title, (*time.Time)(nil)).Return(nil).Once()
 
-	err := s.usecase.Update(ctx, topicID, adminID, role, title)
+	err := s.usecase.Update(ctx, topicID, adminID, role, title, nil)
 
 	s.NoError(err)
 	s.topicRepoMock.AssertExpectations(s.T())
@@ -325,44 +467,67 @@ func (s *TopicUsecaseSuite) TestUpdateTopic_Success_Admin() {
 
 func (s *TopicUsecaseSuite) TestUpdateTopic_AccessDenied_NotAuthorNotAdmin() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	nonAuthorID := int64(555)
 	authorID := s.defaultAuthorID
 	role := "user"
 	title := "Attempted Update"
-	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &authorID, Title: "Old title"}
+	topicFromRepo := &entity.Topic{ID: topicID, CategoryID: s.defaultCategoryID, AuthorID: &authorID, Title: "Old title"}
 	expectedError := ErrForbidden
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.moderatorRepo.On("IsModerator", ctx, s.defaultCategoryID, nonAuthorID).Return(false, nil).Once()
 
-	err := s.usecase.Update(ctx, topicID, nonAuthorID, role, title)
+	err := s.usecase.Update(ctx, topicID, nonAuthorID, role, title, nil)
 
 	s.Error(err)
 	s.ErrorIs(err, expectedError)
-	s.topicRepoMock.AssertCalled(s.T(), "GetByID", ctx, topicID)
-	s.topicRepoMock.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+	s.topicRepoMock.AssertCalled(s.T(), "GetByIDForUpdate", ctx, topicID)
+	s.topicRepoMock.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TopicUsecaseSuite) TestUpdateTopic_Success_Moderator() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	topicID := int64(1)
+	moderatorID := int64(50)
+	title := "Moderated title"
+	topicFromRepo := &entity.Topic{ID: topicID, CategoryID: s.defaultCategoryID, AuthorID: &s.defaultAuthorID, Title: "Old title"}
+
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.moderatorRepo.On("IsModerator", ctx, s.defaultCategoryID, moderatorID).Return(true, nil).Once()
+	s.topicRepoMock.On("Update", ctx, topicID, title, (*time.Time)(nil)).Return(nil).Once()
+
+	err := s.usecase.Update(ctx, topicID, moderatorID, "user", title, nil)
+
+	s.NoError(err)
+	s.topicRepoMock.AssertExpectations(s.T())
+	s.moderatorRepo.AssertExpectations(s.T())
 }
 
 func (s *TopicUsecaseSuite) TestUpdateTopic_TopicNotFound_OnCheckAccess() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
 	title := "updated title"
 	expectedError := ErrTopicNotFound
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(nil, pgx.ErrNoRows).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(nil, pgx.ErrNoRows).Once()
 
-	err := s.usecase.Update(ctx, topicID, userID, role, title)
+	err := s.usecase.Update(ctx, topicID, userID, role, title, nil)
 
 	s.Error(err)
 	s.ErrorIs(err, expectedError)
 	s.topicRepoMock.AssertExpectations(s.T())
-	s.topicRepoMock.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+	s.topicRepoMock.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *TopicUsecaseSuite) TestUpdateTopic_RepoUpdateError() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
@@ -370,10 +535,10 @@ func (s *TopicUsecaseSuite) TestUpdateTopic_RepoUpdateError() {
 	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &s.defaultAuthorID, Title: "Old title"}
 	repoError := errors.New("repo update error")
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
-	s.topicRepoMock.On("Update", ctx, topicID, title).Return(repoError).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("Update", ctx, topicID, title, (*time.Time)(nil)).Return(repoError).Once()
 
-	err := s.usecase.Update(ctx, topicID, userID, role, title)
+	err := s.usecase.Update(ctx, topicID, userID, role, title, nil)
 
 	s.Error(err)
 	s.ErrorIs(err, repoError)
@@ -381,16 +546,49 @@ func (s *TopicUsecaseSuite) TestUpdateTopic_RepoUpdateError() {
 	s.topicRepoMock.AssertExpectations(s.T())
 }
 
+func (s *TopicUsecaseSuite) TestUpdateTopic_VersionConflict() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	topicID := int64(1)
+	title := "updated title"
+	version := time.Now().Add(-time.Minute)
+	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &s.defaultAuthorID, Title: "Old title", UpdatedAt: time.Now()}
+
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("Update", ctx, topicID, title, &version).Return(fmt.Errorf("TopicRepository - Update: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.Update(ctx, topicID, s.defaultAuthorID, "user", title, &version)
+
+	s.ErrorIs(err, ErrVersionConflict)
+}
+
+func (s *TopicUsecaseSuite) TestUpdateTopic_NotFoundWithoutVersion() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	topicID := int64(1)
+	title := "updated title"
+	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &s.defaultAuthorID, Title: "Old title"}
+
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("Update", ctx, topicID, title, (*time.Time)(nil)).Return(fmt.Errorf("TopicRepository - Update: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.Update(ctx, topicID, s.defaultAuthorID, "user", title, nil)
+
+	s.ErrorIs(err, ErrTopicNotFound)
+	s.NotErrorIs(err, ErrVersionConflict)
+}
+
 // Delete
 func (s *TopicUsecaseSuite) TestDeleteTopic_Success_Author() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
 	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &s.defaultAuthorID}
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
-	s.topicRepoMock.On("Delete", ctx, topicID).Return(nil).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("Delete", ctx, topicID, userID).Return(nil).Once()
 
 	err := s.usecase.Delete(ctx, topicID, userID, role)
 
@@ -400,14 +598,15 @@ func (s *TopicUsecaseSuite) TestDeleteTopic_Success_Author() {
 
 func (s *TopicUsecaseSuite) TestDeleteTopic_Success_Admin() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	adminID := int64(999)
 	authorID := s.defaultAuthorID
 	role := "admin"
 	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &authorID}
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
-	s.topicRepoMock.On("Delete", ctx, topicID).Return(nil).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("Delete", ctx, topicID, adminID).Return(nil).Once()
 
 	err := s.usecase.Delete(ctx, topicID, adminID, role)
 
@@ -417,50 +616,54 @@ func (s *TopicUsecaseSuite) TestDeleteTopic_Success_Admin() {
 
 func (s *TopicUsecaseSuite) TestDeleteTopic_AccessDenied_NotAuthorNotAdmin() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	nonAuthorID := int64(555)
 	authorID := s.defaultAuthorID
 	role := "user"
-	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &authorID}
+	topicFromRepo := &entity.Topic{ID: topicID, CategoryID: s.defaultCategoryID, AuthorID: &authorID}
 	expectedError := ErrForbidden
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.moderatorRepo.On("IsModerator", ctx, s.defaultCategoryID, nonAuthorID).Return(false, nil).Once()
 
 	err := s.usecase.Delete(ctx, topicID, nonAuthorID, role)
 
 	s.Error(err)
 	s.ErrorIs(err, expectedError)
-	s.topicRepoMock.AssertCalled(s.T(), "GetByID", ctx, topicID)
-	s.topicRepoMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+	s.topicRepoMock.AssertCalled(s.T(), "GetByIDForUpdate", ctx, topicID)
+	s.topicRepoMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *TopicUsecaseSuite) TestDeleteTopic_TopicNotFound_OnCheckAccess() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
 	expectedError := ErrTopicNotFound
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(nil, pgx.ErrNoRows).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(nil, pgx.ErrNoRows).Once()
 
 	err := s.usecase.Delete(ctx, topicID, userID, role)
 
 	s.Error(err)
 	s.ErrorIs(err, expectedError)
 	s.topicRepoMock.AssertExpectations(s.T())
-	s.topicRepoMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+	s.topicRepoMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *TopicUsecaseSuite) TestDeleteTopic_RepoDeleteError() {
 	ctx := context.Background()
+	s.runInTx(ctx)
 	topicID := int64(1)
 	userID := s.defaultAuthorID
 	role := "user"
 	topicFromRepo := &entity.Topic{ID: topicID, AuthorID: &s.defaultAuthorID}
 	repoError := errors.New("repo delete error")
 
-	s.topicRepoMock.On("GetByID", ctx, topicID).Return(topicFromRepo, nil).Once()
-	s.topicRepoMock.On("Delete", ctx, topicID).Return(repoError).Once()
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topicFromRepo, nil).Once()
+	s.topicRepoMock.On("Delete", ctx, topicID, userID).Return(repoError).Once()
 
 	err := s.usecase.Delete(ctx, topicID, userID, role)
 
@@ -469,3 +672,96 @@ func (s *TopicUsecaseSuite) TestDeleteTopic_RepoDeleteError() {
 	s.Contains(err.Error(), "ForumService - TopicUsecase - Delete - topicRepo.Delete()")
 	s.topicRepoMock.AssertExpectations(s.T())
 }
+
+// Restore
+func (s *TopicUsecaseSuite) TestRestoreTopic_Success() {
+	ctx := context.Background()
+	topicID := int64(1)
+
+	s.topicRepoMock.On("Restore", ctx, topicID).Return(nil).Once()
+
+	err := s.usecase.Restore(ctx, topicID)
+
+	s.NoError(err)
+	s.topicRepoMock.AssertExpectations(s.T())
+}
+
+func (s *TopicUsecaseSuite) TestRestoreTopic_NotDeleted() {
+	ctx := context.Background()
+	topicID := int64(1)
+
+	s.topicRepoMock.On("Restore", ctx, topicID).Return(fmt.Errorf("TopicRepository - Restore: %w", pgx.ErrNoRows)).Once()
+
+	err := s.usecase.Restore(ctx, topicID)
+
+	s.ErrorIs(err, ErrTopicNotFound)
+}
+
+// SetState
+func (s *TopicUsecaseSuite) TestSetState_Success_Admin() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	topicID := int64(1)
+	adminID := int64(999)
+	locked := true
+	topic := &entity.Topic{ID: topicID, CategoryID: s.defaultCategoryID, AuthorID: &s.defaultAuthorID}
+
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topic, nil).Once()
+	s.topicRepoMock.On("UpdateState", ctx, topicID, (*bool)(nil), &locked).Return(nil).Once()
+
+	err := s.usecase.SetState(ctx, topicID, adminID, "admin", nil, &locked)
+
+	s.NoError(err)
+	s.topicRepoMock.AssertExpectations(s.T())
+	s.moderatorRepo.AssertNotCalled(s.T(), "IsModerator", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TopicUsecaseSuite) TestSetState_Success_Moderator() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	topicID := int64(1)
+	moderatorID := int64(50)
+	pinned := true
+	topic := &entity.Topic{ID: topicID, CategoryID: s.defaultCategoryID, AuthorID: &s.defaultAuthorID}
+
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topic, nil).Once()
+	s.moderatorRepo.On("IsModerator", ctx, s.defaultCategoryID, moderatorID).Return(true, nil).Once()
+	s.topicRepoMock.On("UpdateState", ctx, topicID, &pinned, (*bool)(nil)).Return(nil).Once()
+
+	err := s.usecase.SetState(ctx, topicID, moderatorID, "user", &pinned, nil)
+
+	s.NoError(err)
+	s.topicRepoMock.AssertExpectations(s.T())
+	s.moderatorRepo.AssertExpectations(s.T())
+}
+
+func (s *TopicUsecaseSuite) TestSetState_Forbidden_ModeratorOfOtherCategory() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	topicID := int64(1)
+	moderatorID := int64(50)
+	pinned := true
+	topic := &entity.Topic{ID: topicID, CategoryID: s.defaultCategoryID, AuthorID: &moderatorID}
+
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(topic, nil).Once()
+	s.moderatorRepo.On("IsModerator", ctx, s.defaultCategoryID, moderatorID).Return(false, nil).Once()
+
+	err := s.usecase.SetState(ctx, topicID, moderatorID, "user", &pinned, nil)
+
+	s.ErrorIs(err, ErrForbidden)
+	s.topicRepoMock.AssertNotCalled(s.T(), "UpdateState", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TopicUsecaseSuite) TestSetState_TopicNotFound() {
+	ctx := context.Background()
+	s.runInTx(ctx)
+	topicID := int64(1)
+	pinned := true
+
+	s.topicRepoMock.On("GetByIDForUpdate", ctx, topicID).Return(nil, pgx.ErrNoRows).Once()
+
+	err := s.usecase.SetState(ctx, topicID, 1, "admin", &pinned, nil)
+
+	s.ErrorIs(err, ErrTopicNotFound)
+	s.topicRepoMock.AssertNotCalled(s.T(), "UpdateState", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -7,4 +7,25 @@ var (
 	ErrTopicNotFound    = errors.New("topic not found")
 	ErrPostNotFound     = errors.New("post not found")
 	ErrForbidden        = errors.New("forbidden")
+	ErrVersionConflict  = errors.New("resource has been modified since it was read")
+	ErrEmptySearchQuery = errors.New("search query is empty")
+
+	ErrInvalidReplyTarget = errors.New("reply target must be an existing post in the same topic")
+	ErrInvalidReaction    = errors.New("unknown reaction")
+	ErrRevisionNotFound   = errors.New("revision not found")
+	ErrTopicLocked        = errors.New("topic is locked")
+	ErrModeratorNotFound  = errors.New("moderator not found")
+
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("category cannot be moved under itself or its subcategory")
+	ErrInvalidCategoryOrder   = errors.New("category order must list each category id once")
+	ErrCategoryHasChildren    = errors.New("category has subcategories")
+
+	ErrChatRoomNotFound     = errors.New("chat room not found")
+	ErrChatMessageNotFound  = errors.New("chat message not found")
+	ErrEmptyChatMessage     = errors.New("chat message is empty")
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrInvalidRecipient     = errors.New("cannot send a message to yourself")
+	ErrRecipientNotFound    = errors.New("recipient not found")
+	ErrHistoryGapTooLarge   = errors.New("too many messages were missed")
 )
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_posts_deleted_at;
+DROP INDEX IF EXISTS idx_topics_deleted_at;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS deleted_by, DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE topics DROP COLUMN IF EXISTS deleted_by, DROP COLUMN IF EXISTS deleted_at;
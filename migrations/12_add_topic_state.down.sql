@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_topics_category_pinned_created_id;
+CREATE INDEX IF NOT EXISTS idx_topics_category_created_id ON public.topics(category_id, created_at DESC, id DESC);
+
+ALTER TABLE topics DROP COLUMN IF EXISTS locked, DROP COLUMN IF EXISTS pinned;
@@ -0,0 +1,6 @@
+ALTER TABLE topics
+    ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT FALSE;
+
+DROP INDEX IF EXISTS idx_topics_category_created_id;
+CREATE INDEX IF NOT EXISTS idx_topics_category_pinned_created_id ON public.topics(category_id, pinned DESC, created_at DESC, id DESC);
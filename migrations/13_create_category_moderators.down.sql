@@ -0,0 +1 @@
+DROP TABLE IF EXISTS category_moderators;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS category_moderators (
+    category_id INT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (category_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_moderators_user_id ON public.category_moderators(user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES categories(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON public.categories(parent_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_categories_parent_position;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS hidden;
+ALTER TABLE categories DROP COLUMN IF EXISTS position;
@@ -0,0 +1,6 @@
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS position INT NOT NULL DEFAULT 0;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE categories SET position = id;
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_position ON public.categories(parent_id, position);
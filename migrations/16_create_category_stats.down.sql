@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS trg_posts_category_stats ON posts;
+DROP TRIGGER IF EXISTS trg_topics_category_stats ON topics;
+DROP TRIGGER IF EXISTS trg_categories_stats ON categories;
+
+DROP FUNCTION IF EXISTS category_stats_on_post();
+DROP FUNCTION IF EXISTS category_stats_on_topic();
+DROP FUNCTION IF EXISTS category_stats_on_category();
+DROP FUNCTION IF EXISTS refresh_category_stats(INT);
+
+DROP TABLE IF EXISTS category_stats;
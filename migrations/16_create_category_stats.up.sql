@@ -0,0 +1,74 @@
+CREATE TABLE IF NOT EXISTS category_stats (
+    category_id INT PRIMARY KEY REFERENCES categories(id) ON DELETE CASCADE,
+    topic_count INT NOT NULL DEFAULT 0,
+    post_count INT NOT NULL DEFAULT 0,
+    last_post_id INT REFERENCES posts(id) ON DELETE SET NULL
+);
+
+-- refresh_category_stats recounts a single category. Inserts only bump the counters,
+-- the full recount is left for the rarer soft delete, restore and purge paths.
+CREATE OR REPLACE FUNCTION refresh_category_stats(cat INT) RETURNS VOID AS $$
+    UPDATE category_stats SET
+        topic_count = (SELECT COUNT(*) FROM topics WHERE category_id = cat AND deleted_at IS NULL),
+        post_count = (
+            SELECT COUNT(*) FROM posts p JOIN topics t ON t.id = p.topic_id
+            WHERE t.category_id = cat AND t.deleted_at IS NULL AND p.deleted_at IS NULL
+        ),
+        last_post_id = (
+            SELECT MAX(p.id) FROM posts p JOIN topics t ON t.id = p.topic_id
+            WHERE t.category_id = cat AND t.deleted_at IS NULL AND p.deleted_at IS NULL
+        )
+    WHERE category_id = cat;
+$$ LANGUAGE SQL;
+
+CREATE OR REPLACE FUNCTION category_stats_on_category() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO category_stats (category_id) VALUES (NEW.id) ON CONFLICT DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION category_stats_on_topic() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        IF NEW.deleted_at IS NULL THEN
+            UPDATE category_stats SET topic_count = topic_count + 1 WHERE category_id = NEW.category_id;
+        END IF;
+    ELSIF TG_OP = 'UPDATE' THEN
+        PERFORM refresh_category_stats(NEW.category_id);
+        IF OLD.category_id <> NEW.category_id THEN
+            PERFORM refresh_category_stats(OLD.category_id);
+        END IF;
+    ELSIF OLD.deleted_at IS NULL THEN
+        PERFORM refresh_category_stats(OLD.category_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION category_stats_on_post() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        IF NEW.deleted_at IS NULL THEN
+            UPDATE category_stats s SET post_count = s.post_count + 1, last_post_id = NEW.id
+            FROM topics t
+            WHERE t.id = NEW.topic_id AND t.deleted_at IS NULL AND s.category_id = t.category_id;
+        END IF;
+    ELSIF TG_OP = 'UPDATE' OR OLD.deleted_at IS NULL THEN
+        PERFORM refresh_category_stats(t.category_id) FROM topics t WHERE t.id = OLD.topic_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_categories_stats AFTER INSERT ON categories
+    FOR EACH ROW EXECUTE FUNCTION category_stats_on_category();
+
+CREATE TRIGGER trg_topics_category_stats AFTER INSERT OR DELETE OR UPDATE OF deleted_at, category_id ON topics
+    FOR EACH ROW EXECUTE FUNCTION category_stats_on_topic();
+
+CREATE TRIGGER trg_posts_category_stats AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON posts
+    FOR EACH ROW EXECUTE FUNCTION category_stats_on_post();
+
+INSERT INTO category_stats (category_id) SELECT id FROM categories ON CONFLICT DO NOTHING;
+SELECT refresh_category_stats(id) FROM categories;
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_topics_category_pinned_replies_id;
+DROP INDEX IF EXISTS idx_topics_category_pinned_activity_id;
+
+DROP TRIGGER IF EXISTS trg_posts_topic_stats ON posts;
+DROP FUNCTION IF EXISTS topic_stats_on_post();
+DROP FUNCTION IF EXISTS refresh_topic_stats(INT);
+
+ALTER TABLE topics
+    DROP COLUMN IF EXISTS last_activity_at,
+    DROP COLUMN IF EXISTS last_post_id,
+    DROP COLUMN IF EXISTS reply_count;
@@ -0,0 +1,42 @@
+ALTER TABLE topics
+    ADD COLUMN IF NOT EXISTS reply_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_post_id INT REFERENCES posts(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS last_activity_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- refresh_topic_stats recounts a single topic after a post is soft deleted, restored or purged.
+-- New posts only bump the counter.
+CREATE OR REPLACE FUNCTION refresh_topic_stats(tid INT) RETURNS VOID AS $$
+    UPDATE topics t SET
+        reply_count = (SELECT COUNT(*) FROM posts WHERE topic_id = tid AND deleted_at IS NULL),
+        last_post_id = (
+            SELECT id FROM posts WHERE topic_id = tid AND deleted_at IS NULL
+            ORDER BY created_at DESC, id DESC LIMIT 1
+        ),
+        last_activity_at = COALESCE(
+            (SELECT MAX(created_at) FROM posts WHERE topic_id = tid AND deleted_at IS NULL),
+            t.created_at
+        )
+    WHERE t.id = tid;
+$$ LANGUAGE SQL;
+
+CREATE OR REPLACE FUNCTION topic_stats_on_post() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        IF NEW.deleted_at IS NULL THEN
+            UPDATE topics SET reply_count = reply_count + 1, last_post_id = NEW.id, last_activity_at = NEW.created_at
+            WHERE id = NEW.topic_id;
+        END IF;
+    ELSIF TG_OP = 'UPDATE' OR OLD.deleted_at IS NULL THEN
+        PERFORM refresh_topic_stats(OLD.topic_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_posts_topic_stats AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON posts
+    FOR EACH ROW EXECUTE FUNCTION topic_stats_on_post();
+
+SELECT refresh_topic_stats(id) FROM topics;
+
+CREATE INDEX IF NOT EXISTS idx_topics_category_pinned_activity_id ON public.topics(category_id, pinned DESC, last_activity_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_topics_category_pinned_replies_id ON public.topics(category_id, pinned DESC, reply_count DESC, id DESC);
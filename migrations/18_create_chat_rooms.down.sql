@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_messages_room_created;
+ALTER TABLE messages DROP COLUMN IF EXISTS room_id;
+
+DROP TRIGGER IF EXISTS trg_categories_chat_room ON categories;
+DROP FUNCTION IF EXISTS chat_room_on_category();
+
+DROP TABLE IF EXISTS chat_rooms;
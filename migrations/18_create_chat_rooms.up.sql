@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS chat_rooms (
+    id SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('general', 'category', 'custom')),
+    -- Category rooms are named after their category.
+    name TEXT,
+    category_id INT UNIQUE REFERENCES categories(id) ON DELETE CASCADE,
+    created_by INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((kind = 'category') = (category_id IS NOT NULL)),
+    CHECK (kind = 'category' OR name IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_rooms_general ON public.chat_rooms(kind) WHERE kind = 'general';
+
+-- Existing messages were sent to the single global chat, which becomes the general room.
+INSERT INTO chat_rooms (id, kind, name) VALUES (1, 'general', 'Общий чат') ON CONFLICT DO NOTHING;
+SELECT setval(pg_get_serial_sequence('chat_rooms', 'id'), GREATEST((SELECT MAX(id) FROM chat_rooms), 1));
+
+CREATE OR REPLACE FUNCTION chat_room_on_category() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO chat_rooms (kind, category_id) VALUES ('category', NEW.id) ON CONFLICT DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_categories_chat_room AFTER INSERT ON categories
+    FOR EACH ROW EXECUTE FUNCTION chat_room_on_category();
+
+INSERT INTO chat_rooms (kind, category_id) SELECT 'category', id FROM categories ON CONFLICT DO NOTHING;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS room_id INT NOT NULL DEFAULT 1 REFERENCES chat_rooms(id) ON DELETE CASCADE;
+ALTER TABLE messages ALTER COLUMN room_id DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_messages_room_created ON public.messages(room_id, created_at);
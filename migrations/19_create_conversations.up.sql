@@ -0,0 +1,24 @@
+-- A conversation is between two users, stored with the smaller user id first.
+-- last_read holds the id of the last message each participant has read.
+CREATE TABLE IF NOT EXISTS conversations (
+    id SERIAL PRIMARY KEY,
+    user_low INT NOT NULL,
+    user_high INT NOT NULL,
+    user_low_last_read INT NOT NULL DEFAULT 0,
+    user_high_last_read INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_low, user_high),
+    CHECK (user_low < user_high)
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversations_user_high ON public.conversations(user_high);
+
+CREATE TABLE IF NOT EXISTS direct_messages (
+    id SERIAL PRIMARY KEY,
+    conversation_id INT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id INT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_direct_messages_conversation_created_id ON public.direct_messages(conversation_id, created_at DESC, id DESC);
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_messages_room_created ON public.messages(room_id, created_at);
+DROP INDEX IF EXISTS idx_messages_room_created_id;
+ALTER TABLE messages ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE messages ALTER COLUMN created_at DROP DEFAULT;
@@ -0,0 +1,11 @@
+-- History pages are read with a keyset on (created_at, id) within a room, which skips
+-- rows without created_at. Those get the time of the message before them.
+UPDATE messages SET created_at = COALESCE(
+    (SELECT max(prev.created_at) FROM messages prev WHERE prev.id < messages.id),
+    'epoch'
+) WHERE created_at IS NULL;
+ALTER TABLE messages ALTER COLUMN created_at SET DEFAULT now();
+ALTER TABLE messages ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_messages_room_created_id ON public.messages(room_id, created_at, id);
+DROP INDEX IF EXISTS idx_messages_room_created;
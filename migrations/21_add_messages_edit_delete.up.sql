@@ -0,0 +1,4 @@
+-- Deleted messages keep their row so that history pages and replays stay in order,
+-- their content is cleared.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,29 @@
+CREATE OR REPLACE FUNCTION refresh_topic_stats(tid INT) RETURNS VOID AS $$
+    UPDATE topics t SET
+        reply_count = (SELECT COUNT(*) FROM posts WHERE topic_id = tid AND deleted_at IS NULL),
+        last_post_id = (
+            SELECT id FROM posts WHERE topic_id = tid AND deleted_at IS NULL
+            ORDER BY created_at DESC, id DESC LIMIT 1
+        ),
+        last_activity_at = COALESCE(
+            (SELECT MAX(created_at) FROM posts WHERE topic_id = tid AND deleted_at IS NULL),
+            t.created_at
+        )
+    WHERE t.id = tid;
+$$ LANGUAGE SQL;
+
+CREATE OR REPLACE FUNCTION topic_stats_on_post() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        IF NEW.deleted_at IS NULL THEN
+            UPDATE topics SET reply_count = reply_count + 1, last_post_id = NEW.id, last_activity_at = NEW.created_at
+            WHERE id = NEW.topic_id;
+        END IF;
+    ELSIF TG_OP = 'UPDATE' OR OLD.deleted_at IS NULL THEN
+        PERFORM refresh_topic_stats(OLD.topic_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT refresh_topic_stats(id) FROM topics;
@@ -0,0 +1,4 @@
+-- Deleting a category no longer deletes its subcategories, it fails while they exist.
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_id_fkey;
+ALTER TABLE categories ADD CONSTRAINT categories_parent_id_fkey
+    FOREIGN KEY (parent_id) REFERENCES categories(id) ON DELETE RESTRICT;
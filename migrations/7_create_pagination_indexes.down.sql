@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_topics_category_created_id;
+
+DROP INDEX IF EXISTS idx_posts_topic_created_id;
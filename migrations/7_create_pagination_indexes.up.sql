@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_topics_category_created_id ON public.topics(category_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_posts_topic_created_id ON public.posts(topic_id, created_at, id);
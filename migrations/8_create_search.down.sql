@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_topics_search_vector;
+
+DROP INDEX IF EXISTS idx_posts_search_vector;
+
+ALTER TABLE topics DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS search_vector;
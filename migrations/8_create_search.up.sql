@@ -0,0 +1,9 @@
+ALTER TABLE topics ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', coalesce(title, ''))) STORED;
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', coalesce(content, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_topics_search_vector ON public.topics USING GIN(search_vector);
+
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON public.posts USING GIN(search_vector);
@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // CategoryRepository is an autogenerated mock type for the CategoryRepository type
@@ -60,9 +62,9 @@ func (_m *CategoryRepository) Delete(ctx context.Context, id int64) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: _a0
-func (_m *CategoryRepository) GetAll(_a0 context.Context) ([]entity.Category, error) {
-	ret := _m.Called(_a0)
+// GetAll provides a mock function with given fields: ctx, includeHidden
+func (_m *CategoryRepository) GetAll(ctx context.Context, includeHidden bool) ([]entity.Category, error) {
+	ret := _m.Called(ctx, includeHidden)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAll")
@@ -70,19 +72,19 @@ func (_m *CategoryRepository) GetAll(_a0 context.Context) ([]entity.Category, er
 
 	var r0 []entity.Category
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]entity.Category, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]entity.Category, error)); ok {
+		return rf(ctx, includeHidden)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []entity.Category); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []entity.Category); ok {
+		r0 = rf(ctx, includeHidden)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.Category)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, includeHidden)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -120,17 +122,157 @@ func (_m *CategoryRepository) GetByID(_a0 context.Context, _a1 int64) (*entity.C
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: ctx, id, title, description
-func (_m *CategoryRepository) Update(ctx context.Context, id int64, title string, description string) error {
-	ret := _m.Called(ctx, id, title, description)
+// GetPath provides a mock function with given fields: ctx, id
+func (_m *CategoryRepository) GetPath(ctx context.Context, id int64) ([]entity.Breadcrumb, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPath")
+	}
+
+	var r0 []entity.Breadcrumb
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.Breadcrumb, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.Breadcrumb); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Breadcrumb)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HasChildren provides a mock function with given fields: ctx, id
+func (_m *CategoryRepository) HasChildren(ctx context.Context, id int64) (bool, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasChildren")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (bool, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bool); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsHidden provides a mock function with given fields: ctx, id
+func (_m *CategoryRepository) IsHidden(ctx context.Context, id int64) (bool, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsHidden")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (bool, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bool); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockTree provides a mock function with given fields: ctx
+func (_m *CategoryRepository) LockTree(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockTree")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reorder provides a mock function with given fields: ctx, ids
+func (_m *CategoryRepository) Reorder(ctx context.Context, ids []int64) error {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reorder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetParent provides a mock function with given fields: ctx, id, parentID
+func (_m *CategoryRepository) SetParent(ctx context.Context, id int64, parentID *int64) error {
+	ret := _m.Called(ctx, id, parentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetParent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *int64) error); ok {
+		r0 = rf(ctx, id, parentID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, id, title, description, hidden, version
+func (_m *CategoryRepository) Update(ctx context.Context, id int64, title *string, description *string, hidden *bool, version *time.Time) error {
+	ret := _m.Called(ctx, id, title, description, hidden, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) error); ok {
-		r0 = rf(ctx, id, title, description)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *string, *string, *bool, *time.Time) error); ok {
+		r0 = rf(ctx, id, title, description, hidden, version)
 	} else {
 		r0 = ret.Error(0)
 	}
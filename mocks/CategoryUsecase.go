@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // CategoryUsecase is an autogenerated mock type for the CategoryUsecase type
@@ -14,6 +16,24 @@ type CategoryUsecase struct {
 	mock.Mock
 }
 
+// AddModerator provides a mock function with given fields: ctx, categoryID, userID
+func (_m *CategoryUsecase) AddModerator(ctx context.Context, categoryID int64, userID int64) error {
+	ret := _m.Called(ctx, categoryID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddModerator")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, categoryID, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Create provides a mock function with given fields: _a0, _a1
 func (_m *CategoryUsecase) Create(_a0 context.Context, _a1 entity.Category) (int64, error) {
 	ret := _m.Called(_a0, _a1)
@@ -60,59 +80,103 @@ func (_m *CategoryUsecase) Delete(ctx context.Context, id int64) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: _a0
-func (_m *CategoryUsecase) GetAll(_a0 context.Context) ([]entity.Category, error) {
-	ret := _m.Called(_a0)
+// GetAll provides a mock function with given fields: ctx, includeHidden
+func (_m *CategoryUsecase) GetAll(ctx context.Context, includeHidden bool) ([]entity.Category, bool, error) {
+	ret := _m.Called(ctx, includeHidden)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAll")
 	}
 
 	var r0 []entity.Category
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]entity.Category, error)); ok {
-		return rf(_a0)
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]entity.Category, bool, error)); ok {
+		return rf(ctx, includeHidden)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []entity.Category); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []entity.Category); ok {
+		r0 = rf(ctx, includeHidden)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.Category)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, bool) bool); ok {
+		r1 = rf(ctx, includeHidden)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(bool)
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(context.Context, bool) error); ok {
+		r2 = rf(ctx, includeHidden)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
-// GetByID provides a mock function with given fields: ctx, id
-func (_m *CategoryUsecase) GetByID(ctx context.Context, id int64) (*entity.Category, error) {
-	ret := _m.Called(ctx, id)
+// GetByID provides a mock function with given fields: ctx, id, includeHidden
+func (_m *CategoryUsecase) GetByID(ctx context.Context, id int64, includeHidden bool) (*entity.Category, bool, error) {
+	ret := _m.Called(ctx, id, includeHidden)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByID")
 	}
 
 	var r0 *entity.Category
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) (*entity.Category, error)); ok {
-		return rf(ctx, id)
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) (*entity.Category, bool, error)); ok {
+		return rf(ctx, id, includeHidden)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.Category); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) *entity.Category); ok {
+		r0 = rf(ctx, id, includeHidden)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.Category)
 		}
 	}
 
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bool) bool); ok {
+		r1 = rf(ctx, id, includeHidden)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64, bool) error); ok {
+		r2 = rf(ctx, id, includeHidden)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetModerators provides a mock function with given fields: ctx, categoryID
+func (_m *CategoryUsecase) GetModerators(ctx context.Context, categoryID int64) ([]entity.CategoryModerator, error) {
+	ret := _m.Called(ctx, categoryID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetModerators")
+	}
+
+	var r0 []entity.CategoryModerator
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.CategoryModerator, error)); ok {
+		return rf(ctx, categoryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.CategoryModerator); ok {
+		r0 = rf(ctx, categoryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.CategoryModerator)
+		}
+	}
+
 	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, id)
+		r1 = rf(ctx, categoryID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -120,17 +184,90 @@ func (_m *CategoryUsecase) GetByID(ctx context.Context, id int64) (*entity.Categ
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: ctx, id, title, description
-func (_m *CategoryUsecase) Update(ctx context.Context, id int64, title string, description string) error {
-	ret := _m.Called(ctx, id, title, description)
+// GetTree provides a mock function with given fields: ctx, includeHidden
+func (_m *CategoryUsecase) GetTree(ctx context.Context, includeHidden bool) ([]*entity.CategoryNode, bool, error) {
+	ret := _m.Called(ctx, includeHidden)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTree")
+	}
+
+	var r0 []*entity.CategoryNode
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]*entity.CategoryNode, bool, error)); ok {
+		return rf(ctx, includeHidden)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []*entity.CategoryNode); ok {
+		r0 = rf(ctx, includeHidden)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.CategoryNode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool) bool); ok {
+		r1 = rf(ctx, includeHidden)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, bool) error); ok {
+		r2 = rf(ctx, includeHidden)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RemoveModerator provides a mock function with given fields: ctx, categoryID, userID
+func (_m *CategoryUsecase) RemoveModerator(ctx context.Context, categoryID int64, userID int64) error {
+	ret := _m.Called(ctx, categoryID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveModerator")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, categoryID, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reorder provides a mock function with given fields: ctx, ids
+func (_m *CategoryUsecase) Reorder(ctx context.Context, ids []int64) error {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reorder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, id, title, description, parentID, hidden, version
+func (_m *CategoryUsecase) Update(ctx context.Context, id int64, title *string, description *string, parentID *int64, hidden *bool, version *time.Time) error {
+	ret := _m.Called(ctx, id, title, description, parentID, hidden, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) error); ok {
-		r0 = rf(ctx, id, title, description)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *string, *string, *int64, *bool, *time.Time) error); ok {
+		r0 = rf(ctx, id, title, description, parentID, hidden, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // ChatRepository is an autogenerated mock type for the ChatRepository type
@@ -14,9 +16,113 @@ type ChatRepository struct {
 	mock.Mock
 }
 
-// GetMessages provides a mock function with given fields: ctx, limit
-func (_m *ChatRepository) GetMessages(ctx context.Context, limit int64) ([]entity.ChatMessage, error) {
-	ret := _m.Called(ctx, limit)
+// CreateRoom provides a mock function with given fields: ctx, room
+func (_m *ChatRepository) CreateRoom(ctx context.Context, room *entity.ChatRoom) (int64, error) {
+	ret := _m.Called(ctx, room)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRoom")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.ChatRoom) (int64, error)); ok {
+		return rf(ctx, room)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.ChatRoom) int64); ok {
+		r0 = rf(ctx, room)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *entity.ChatRoom) error); ok {
+		r1 = rf(ctx, room)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteMessage provides a mock function with given fields: ctx, id
+func (_m *ChatRepository) DeleteMessage(ctx context.Context, id int64) (time.Time, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMessage")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (time.Time, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) time.Time); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteRoom provides a mock function with given fields: ctx, id
+func (_m *ChatRepository) DeleteRoom(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteRoom")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetMessage provides a mock function with given fields: ctx, id
+func (_m *ChatRepository) GetMessage(ctx context.Context, id int64) (*entity.ChatMessage, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMessage")
+	}
+
+	var r0 *entity.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*entity.ChatMessage, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.ChatMessage); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ChatMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessages provides a mock function with given fields: ctx, roomID, before, limit
+func (_m *ChatRepository) GetMessages(ctx context.Context, roomID int64, before int64, limit int64) ([]entity.ChatMessage, error) {
+	ret := _m.Called(ctx, roomID, before, limit)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetMessages")
@@ -24,19 +130,109 @@ func (_m *ChatRepository) GetMessages(ctx context.Context, limit int64) ([]entit
 
 	var r0 []entity.ChatMessage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.ChatMessage, error)); ok {
-		return rf(ctx, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) ([]entity.ChatMessage, error)); ok {
+		return rf(ctx, roomID, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) []entity.ChatMessage); ok {
+		r0 = rf(ctx, roomID, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.ChatMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, roomID, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessagesSince provides a mock function with given fields: ctx, roomID, since, limit
+func (_m *ChatRepository) GetMessagesSince(ctx context.Context, roomID int64, since int64, limit int64) ([]entity.ChatMessage, error) {
+	ret := _m.Called(ctx, roomID, since, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMessagesSince")
+	}
+
+	var r0 []entity.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) ([]entity.ChatMessage, error)); ok {
+		return rf(ctx, roomID, since, limit)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.ChatMessage); ok {
-		r0 = rf(ctx, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) []entity.ChatMessage); ok {
+		r0 = rf(ctx, roomID, since, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.ChatMessage)
 		}
 	}
 
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, roomID, since, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRoom provides a mock function with given fields: ctx, id
+func (_m *ChatRepository) GetRoom(ctx context.Context, id int64) (*entity.ChatRoom, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRoom")
+	}
+
+	var r0 *entity.ChatRoom
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*entity.ChatRoom, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.ChatRoom); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ChatRoom)
+		}
+	}
+
 	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, limit)
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRooms provides a mock function with given fields: ctx
+func (_m *ChatRepository) GetRooms(ctx context.Context) ([]entity.ChatRoom, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRooms")
+	}
+
+	var r0 []entity.ChatRoom
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]entity.ChatRoom, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []entity.ChatRoom); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.ChatRoom)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,6 +268,34 @@ func (_m *ChatRepository) SaveMessage(ctx context.Context, message *entity.ChatM
 	return r0, r1
 }
 
+// UpdateMessage provides a mock function with given fields: ctx, id, content
+func (_m *ChatRepository) UpdateMessage(ctx context.Context, id int64, content string) (time.Time, error) {
+	ret := _m.Called(ctx, id, content)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMessage")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (time.Time, error)); ok {
+		return rf(ctx, id, content)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) time.Time); ok {
+		r0 = rf(ctx, id, content)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, id, content)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewChatRepository creates a new instance of ChatRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewChatRepository(t interface {
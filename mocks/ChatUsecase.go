@@ -14,9 +14,177 @@ type ChatUsecase struct {
 	mock.Mock
 }
 
-// GetMessageHistory provides a mock function with given fields: ctx, limit
-func (_m *ChatUsecase) GetMessageHistory(ctx context.Context, limit int64) ([]entity.ChatMessage, error) {
-	ret := _m.Called(ctx, limit)
+// CreateRoom provides a mock function with given fields: ctx, userID, name
+func (_m *ChatUsecase) CreateRoom(ctx context.Context, userID int64, name string) (*entity.ChatRoom, error) {
+	ret := _m.Called(ctx, userID, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRoom")
+	}
+
+	var r0 *entity.ChatRoom
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (*entity.ChatRoom, error)); ok {
+		return rf(ctx, userID, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *entity.ChatRoom); ok {
+		r0 = rf(ctx, userID, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ChatRoom)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, userID, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteMessage provides a mock function with given fields: ctx, messageID, userID, role
+func (_m *ChatUsecase) DeleteMessage(ctx context.Context, messageID int64, userID int64, role string) (*entity.ChatMessage, error) {
+	ret := _m.Called(ctx, messageID, userID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMessage")
+	}
+
+	var r0 *entity.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) (*entity.ChatMessage, error)); ok {
+		return rf(ctx, messageID, userID, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) *entity.ChatMessage); ok {
+		r0 = rf(ctx, messageID, userID, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ChatMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string) error); ok {
+		r1 = rf(ctx, messageID, userID, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteRoom provides a mock function with given fields: ctx, roomID
+func (_m *ChatUsecase) DeleteRoom(ctx context.Context, roomID int64) error {
+	ret := _m.Called(ctx, roomID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteRoom")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, roomID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EditMessage provides a mock function with given fields: ctx, messageID, userID, role, content
+func (_m *ChatUsecase) EditMessage(ctx context.Context, messageID int64, userID int64, role string, content string) (*entity.ChatMessage, error) {
+	ret := _m.Called(ctx, messageID, userID, role, content)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EditMessage")
+	}
+
+	var r0 *entity.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) (*entity.ChatMessage, error)); ok {
+		return rf(ctx, messageID, userID, role, content)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) *entity.ChatMessage); ok {
+		r0 = rf(ctx, messageID, userID, role, content)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ChatMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string) error); ok {
+		r1 = rf(ctx, messageID, userID, role, content)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConversationMessages provides a mock function with given fields: ctx, conversationID, userID, before, limit
+func (_m *ChatUsecase) GetConversationMessages(ctx context.Context, conversationID int64, userID int64, before int64, limit int64) ([]entity.DirectMessage, error) {
+	ret := _m.Called(ctx, conversationID, userID, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetConversationMessages")
+	}
+
+	var r0 []entity.DirectMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64, int64) ([]entity.DirectMessage, error)); ok {
+		return rf(ctx, conversationID, userID, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64, int64) []entity.DirectMessage); ok {
+		r0 = rf(ctx, conversationID, userID, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.DirectMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64, int64) error); ok {
+		r1 = rf(ctx, conversationID, userID, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConversations provides a mock function with given fields: ctx, userID
+func (_m *ChatUsecase) GetConversations(ctx context.Context, userID int64) ([]entity.Conversation, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetConversations")
+	}
+
+	var r0 []entity.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.Conversation, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.Conversation); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Conversation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessageHistory provides a mock function with given fields: ctx, roomID, before, limit
+func (_m *ChatUsecase) GetMessageHistory(ctx context.Context, roomID int64, before int64, limit int64) ([]entity.ChatMessage, error) {
+	ret := _m.Called(ctx, roomID, before, limit)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetMessageHistory")
@@ -24,19 +192,19 @@ func (_m *ChatUsecase) GetMessageHistory(ctx context.Context, limit int64) ([]en
 
 	var r0 []entity.ChatMessage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.ChatMessage, error)); ok {
-		return rf(ctx, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) ([]entity.ChatMessage, error)); ok {
+		return rf(ctx, roomID, before, limit)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.ChatMessage); ok {
-		r0 = rf(ctx, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) []entity.ChatMessage); ok {
+		r0 = rf(ctx, roomID, before, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.ChatMessage)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, roomID, before, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,9 +212,99 @@ func (_m *ChatUsecase) GetMessageHistory(ctx context.Context, limit int64) ([]en
 	return r0, r1
 }
 
-// SaveMessage provides a mock function with given fields: ctx, userID, username, content
-func (_m *ChatUsecase) SaveMessage(ctx context.Context, userID int64, username string, content string) (*entity.ChatMessage, error) {
-	ret := _m.Called(ctx, userID, username, content)
+// GetMissedMessages provides a mock function with given fields: ctx, roomID, since, limit
+func (_m *ChatUsecase) GetMissedMessages(ctx context.Context, roomID int64, since int64, limit int64) ([]entity.ChatMessage, error) {
+	ret := _m.Called(ctx, roomID, since, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMissedMessages")
+	}
+
+	var r0 []entity.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) ([]entity.ChatMessage, error)); ok {
+		return rf(ctx, roomID, since, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) []entity.ChatMessage); ok {
+		r0 = rf(ctx, roomID, since, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.ChatMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, roomID, since, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRoom provides a mock function with given fields: ctx, roomID, role
+func (_m *ChatUsecase) GetRoom(ctx context.Context, roomID int64, role string) (*entity.ChatRoom, error) {
+	ret := _m.Called(ctx, roomID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRoom")
+	}
+
+	var r0 *entity.ChatRoom
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (*entity.ChatRoom, error)); ok {
+		return rf(ctx, roomID, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *entity.ChatRoom); ok {
+		r0 = rf(ctx, roomID, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.ChatRoom)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, roomID, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRooms provides a mock function with given fields: ctx, role
+func (_m *ChatUsecase) GetRooms(ctx context.Context, role string) ([]entity.ChatRoom, error) {
+	ret := _m.Called(ctx, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRooms")
+	}
+
+	var r0 []entity.ChatRoom
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]entity.ChatRoom, error)); ok {
+		return rf(ctx, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []entity.ChatRoom); ok {
+		r0 = rf(ctx, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.ChatRoom)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveMessage provides a mock function with given fields: ctx, roomID, userID, username, content
+func (_m *ChatUsecase) SaveMessage(ctx context.Context, roomID int64, userID int64, username string, content string) (*entity.ChatMessage, error) {
+	ret := _m.Called(ctx, roomID, userID, username, content)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveMessage")
@@ -54,19 +312,49 @@ func (_m *ChatUsecase) SaveMessage(ctx context.Context, userID int64, username s
 
 	var r0 *entity.ChatMessage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) (*entity.ChatMessage, error)); ok {
-		return rf(ctx, userID, username, content)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) (*entity.ChatMessage, error)); ok {
+		return rf(ctx, roomID, userID, username, content)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) *entity.ChatMessage); ok {
-		r0 = rf(ctx, userID, username, content)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) *entity.ChatMessage); ok {
+		r0 = rf(ctx, roomID, userID, username, content)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.ChatMessage)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string) error); ok {
-		r1 = rf(ctx, userID, username, content)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string) error); ok {
+		r1 = rf(ctx, roomID, userID, username, content)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendDirectMessage provides a mock function with given fields: ctx, senderID, senderUsername, recipientID, content
+func (_m *ChatUsecase) SendDirectMessage(ctx context.Context, senderID int64, senderUsername string, recipientID int64, content string) (*entity.DirectMessage, error) {
+	ret := _m.Called(ctx, senderID, senderUsername, recipientID, content)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendDirectMessage")
+	}
+
+	var r0 *entity.DirectMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, int64, string) (*entity.DirectMessage, error)); ok {
+		return rf(ctx, senderID, senderUsername, recipientID, content)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, int64, string) *entity.DirectMessage); ok {
+		r0 = rf(ctx, senderID, senderUsername, recipientID, content)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.DirectMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, int64, string) error); ok {
+		r1 = rf(ctx, senderID, senderUsername, recipientID, content)
 	} else {
 		r1 = ret.Error(1)
 	}
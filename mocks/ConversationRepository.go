@@ -0,0 +1,191 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ConversationRepository is an autogenerated mock type for the ConversationRepository type
+type ConversationRepository struct {
+	mock.Mock
+}
+
+// GetByUser provides a mock function with given fields: ctx, userID
+func (_m *ConversationRepository) GetByUser(ctx context.Context, userID int64) ([]entity.Conversation, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByUser")
+	}
+
+	var r0 []entity.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.Conversation, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.Conversation); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Conversation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessages provides a mock function with given fields: ctx, conversationID, before, limit
+func (_m *ConversationRepository) GetMessages(ctx context.Context, conversationID int64, before int64, limit int64) ([]entity.DirectMessage, error) {
+	ret := _m.Called(ctx, conversationID, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMessages")
+	}
+
+	var r0 []entity.DirectMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) ([]entity.DirectMessage, error)); ok {
+		return rf(ctx, conversationID, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) []entity.DirectMessage); ok {
+		r0 = rf(ctx, conversationID, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.DirectMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, conversationID, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrCreate provides a mock function with given fields: ctx, userID, peerID
+func (_m *ConversationRepository) GetOrCreate(ctx context.Context, userID int64, peerID int64) (int64, error) {
+	ret := _m.Called(ctx, userID, peerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrCreate")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (int64, error)); ok {
+		return rf(ctx, userID, peerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) int64); ok {
+		r0 = rf(ctx, userID, peerID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, peerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPeer provides a mock function with given fields: ctx, conversationID, userID
+func (_m *ConversationRepository) GetPeer(ctx context.Context, conversationID int64, userID int64) (int64, error) {
+	ret := _m.Called(ctx, conversationID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPeer")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (int64, error)); ok {
+		return rf(ctx, conversationID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) int64); ok {
+		r0 = rf(ctx, conversationID, userID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, conversationID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRead provides a mock function with given fields: ctx, conversationID, userID, messageID
+func (_m *ConversationRepository) MarkRead(ctx context.Context, conversationID int64, userID int64, messageID int64) error {
+	ret := _m.Called(ctx, conversationID, userID, messageID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkRead")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) error); ok {
+		r0 = rf(ctx, conversationID, userID, messageID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveMessage provides a mock function with given fields: ctx, message
+func (_m *ConversationRepository) SaveMessage(ctx context.Context, message *entity.DirectMessage) (int64, error) {
+	ret := _m.Called(ctx, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveMessage")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.DirectMessage) (int64, error)); ok {
+		return rf(ctx, message)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.DirectMessage) int64); ok {
+		r0 = rf(ctx, message)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *entity.DirectMessage) error); ok {
+		r1 = rf(ctx, message)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewConversationRepository creates a new instance of ConversationRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewConversationRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ConversationRepository {
+	mock := &ConversationRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
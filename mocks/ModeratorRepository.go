@@ -0,0 +1,123 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ModeratorRepository is an autogenerated mock type for the ModeratorRepository type
+type ModeratorRepository struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, categoryID, userID
+func (_m *ModeratorRepository) Add(ctx context.Context, categoryID int64, userID int64) error {
+	ret := _m.Called(ctx, categoryID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Add")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, categoryID, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByCategory provides a mock function with given fields: ctx, categoryID
+func (_m *ModeratorRepository) GetByCategory(ctx context.Context, categoryID int64) ([]entity.CategoryModerator, error) {
+	ret := _m.Called(ctx, categoryID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByCategory")
+	}
+
+	var r0 []entity.CategoryModerator
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.CategoryModerator, error)); ok {
+		return rf(ctx, categoryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.CategoryModerator); ok {
+		r0 = rf(ctx, categoryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.CategoryModerator)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, categoryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsModerator provides a mock function with given fields: ctx, categoryID, userID
+func (_m *ModeratorRepository) IsModerator(ctx context.Context, categoryID int64, userID int64) (bool, error) {
+	ret := _m.Called(ctx, categoryID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsModerator")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (bool, error)); ok {
+		return rf(ctx, categoryID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) bool); ok {
+		r0 = rf(ctx, categoryID, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, categoryID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Remove provides a mock function with given fields: ctx, categoryID, userID
+func (_m *ModeratorRepository) Remove(ctx context.Context, categoryID int64, userID int64) error {
+	ret := _m.Called(ctx, categoryID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Remove")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, categoryID, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewModeratorRepository creates a new instance of ModeratorRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewModeratorRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ModeratorRepository {
+	mock := &ModeratorRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,32 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PostFeed is an autogenerated mock type for the PostFeed type
+type PostFeed struct {
+	mock.Mock
+}
+
+// Publish provides a mock function with given fields: post
+func (_m *PostFeed) Publish(post entity.Post) {
+	_m.Called(post)
+}
+
+// NewPostFeed creates a new instance of PostFeed. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPostFeed(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PostFeed {
+	mock := &PostFeed{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
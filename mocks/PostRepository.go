@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // PostRepository is an autogenerated mock type for the PostRepository type
@@ -42,17 +44,17 @@ func (_m *PostRepository) Create(_a0 context.Context, _a1 entity.Post) (int64, e
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: ctx, id
-func (_m *PostRepository) Delete(ctx context.Context, id int64) error {
-	ret := _m.Called(ctx, id)
+// Delete provides a mock function with given fields: ctx, id, deletedBy
+func (_m *PostRepository) Delete(ctx context.Context, id int64, deletedBy int64) error {
+	ret := _m.Called(ctx, id, deletedBy)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, id, deletedBy)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -60,6 +62,36 @@ func (_m *PostRepository) Delete(ctx context.Context, id int64) error {
 	return r0
 }
 
+// GetAncestors provides a mock function with given fields: ctx, postID
+func (_m *PostRepository) GetAncestors(ctx context.Context, postID int64) ([]entity.Post, error) {
+	ret := _m.Called(ctx, postID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAncestors")
+	}
+
+	var r0 []entity.Post
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.Post, error)); ok {
+		return rf(ctx, postID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.Post); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, postID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByID provides a mock function with given fields: _a0, _a1
 func (_m *PostRepository) GetByID(_a0 context.Context, _a1 int64) (*entity.Post, error) {
 	ret := _m.Called(_a0, _a1)
@@ -90,9 +122,39 @@ func (_m *PostRepository) GetByID(_a0 context.Context, _a1 int64) (*entity.Post,
 	return r0, r1
 }
 
-// GetByTopic provides a mock function with given fields: ctx, topicID
-func (_m *PostRepository) GetByTopic(ctx context.Context, topicID int64) ([]entity.Post, error) {
-	ret := _m.Called(ctx, topicID)
+// GetByIDForUpdate provides a mock function with given fields: _a0, _a1
+func (_m *PostRepository) GetByIDForUpdate(_a0 context.Context, _a1 int64) (*entity.Post, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByIDForUpdate")
+	}
+
+	var r0 *entity.Post
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*entity.Post, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.Post); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByTopic provides a mock function with given fields: ctx, topicID, page
+func (_m *PostRepository) GetByTopic(ctx context.Context, topicID int64, page entity.PageRequest) ([]entity.Post, error) {
+	ret := _m.Called(ctx, topicID, page)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByTopic")
@@ -100,19 +162,167 @@ func (_m *PostRepository) GetByTopic(ctx context.Context, topicID int64) ([]enti
 
 	var r0 []entity.Post
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.Post, error)); ok {
-		return rf(ctx, topicID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, entity.PageRequest) ([]entity.Post, error)); ok {
+		return rf(ctx, topicID, page)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.Post); ok {
-		r0 = rf(ctx, topicID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, entity.PageRequest) []entity.Post); ok {
+		r0 = rf(ctx, topicID, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.Post)
 		}
 	}
 
+	if rf, ok := ret.Get(1).(func(context.Context, int64, entity.PageRequest) error); ok {
+		r1 = rf(ctx, topicID, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRevision provides a mock function with given fields: ctx, postID, revisionID
+func (_m *PostRepository) GetRevision(ctx context.Context, postID int64, revisionID int64) (*entity.PostRevision, error) {
+	ret := _m.Called(ctx, postID, revisionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRevision")
+	}
+
+	var r0 *entity.PostRevision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*entity.PostRevision, error)); ok {
+		return rf(ctx, postID, revisionID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *entity.PostRevision); ok {
+		r0 = rf(ctx, postID, revisionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.PostRevision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, postID, revisionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRevisions provides a mock function with given fields: ctx, postID
+func (_m *PostRepository) GetRevisions(ctx context.Context, postID int64) ([]entity.PostRevision, error) {
+	ret := _m.Called(ctx, postID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRevisions")
+	}
+
+	var r0 []entity.PostRevision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.PostRevision, error)); ok {
+		return rf(ctx, postID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.PostRevision); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.PostRevision)
+		}
+	}
+
 	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, topicID)
+		r1 = rf(ctx, postID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubtree provides a mock function with given fields: ctx, postID, maxDepth
+func (_m *PostRepository) GetSubtree(ctx context.Context, postID int64, maxDepth int) ([]entity.PostNode, error) {
+	ret := _m.Called(ctx, postID, maxDepth)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubtree")
+	}
+
+	var r0 []entity.PostNode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) ([]entity.PostNode, error)); ok {
+		return rf(ctx, postID, maxDepth)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) []entity.PostNode); ok {
+		r0 = rf(ctx, postID, maxDepth)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.PostNode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int) error); ok {
+		r1 = rf(ctx, postID, maxDepth)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTree provides a mock function with given fields: ctx, topicID, page, maxDepth
+func (_m *PostRepository) GetTree(ctx context.Context, topicID int64, page entity.PageRequest, maxDepth int) ([]entity.PostNode, error) {
+	ret := _m.Called(ctx, topicID, page, maxDepth)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTree")
+	}
+
+	var r0 []entity.PostNode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, entity.PageRequest, int) ([]entity.PostNode, error)); ok {
+		return rf(ctx, topicID, page, maxDepth)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, entity.PageRequest, int) []entity.PostNode); ok {
+		r0 = rf(ctx, topicID, page, maxDepth)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.PostNode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, entity.PageRequest, int) error); ok {
+		r1 = rf(ctx, topicID, page, maxDepth)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx, before
+func (_m *PostRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeDeleted")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -120,17 +330,35 @@ func (_m *PostRepository) GetByTopic(ctx context.Context, topicID int64) ([]enti
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: ctx, id, content
-func (_m *PostRepository) Update(ctx context.Context, id int64, content string) error {
-	ret := _m.Called(ctx, id, content)
+// Restore provides a mock function with given fields: ctx, id
+func (_m *PostRepository) Restore(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, id, content, editorID, editorRole, version
+func (_m *PostRepository) Update(ctx context.Context, id int64, content string, editorID int64, editorRole string, version *time.Time) error {
+	ret := _m.Called(ctx, id, content, editorID, editorRole, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
-		r0 = rf(ctx, id, content)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, int64, string, *time.Time) error); ok {
+		r0 = rf(ctx, id, content, editorID, editorRole, version)
 	} else {
 		r0 = ret.Error(0)
 	}
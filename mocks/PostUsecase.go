@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // PostUsecase is an autogenerated mock type for the PostUsecase type
@@ -14,6 +16,24 @@ type PostUsecase struct {
 	mock.Mock
 }
 
+// AddReaction provides a mock function with given fields: ctx, postID, userID, reaction
+func (_m *PostUsecase) AddReaction(ctx context.Context, postID int64, userID int64, reaction string) error {
+	ret := _m.Called(ctx, postID, userID, reaction)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddReaction")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) error); ok {
+		r0 = rf(ctx, postID, userID, reaction)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Create provides a mock function with given fields: _a0, _a1
 func (_m *PostUsecase) Create(_a0 context.Context, _a1 entity.Post) (int64, error) {
 	ret := _m.Called(_a0, _a1)
@@ -60,29 +80,133 @@ func (_m *PostUsecase) Delete(ctx context.Context, postID int64, userID int64, r
 	return r0
 }
 
-// GetByTopic provides a mock function with given fields: ctx, topicID
-func (_m *PostUsecase) GetByTopic(ctx context.Context, topicID int64) ([]entity.Post, error) {
-	ret := _m.Called(ctx, topicID)
+// DiffRevisions provides a mock function with given fields: ctx, postID, userID, role, from, to
+func (_m *PostUsecase) DiffRevisions(ctx context.Context, postID int64, userID int64, role string, from int64, to *int64) (*entity.RevisionDiff, error) {
+	ret := _m.Called(ctx, postID, userID, role, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DiffRevisions")
+	}
+
+	var r0 *entity.RevisionDiff
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, int64, *int64) (*entity.RevisionDiff, error)); ok {
+		return rf(ctx, postID, userID, role, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, int64, *int64) *entity.RevisionDiff); ok {
+		r0 = rf(ctx, postID, userID, role, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RevisionDiff)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, int64, *int64) error); ok {
+		r1 = rf(ctx, postID, userID, role, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByID provides a mock function with given fields: ctx, postID, includeHidden
+func (_m *PostUsecase) GetByID(ctx context.Context, postID int64, includeHidden bool) (*entity.Post, bool, error) {
+	ret := _m.Called(ctx, postID, includeHidden)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByID")
+	}
+
+	var r0 *entity.Post
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) (*entity.Post, bool, error)); ok {
+		return rf(ctx, postID, includeHidden)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) *entity.Post); ok {
+		r0 = rf(ctx, postID, includeHidden)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bool) bool); ok {
+		r1 = rf(ctx, postID, includeHidden)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64, bool) error); ok {
+		r2 = rf(ctx, postID, includeHidden)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetByTopic provides a mock function with given fields: ctx, topicID, viewerID, includeHidden, page
+func (_m *PostUsecase) GetByTopic(ctx context.Context, topicID int64, viewerID int64, includeHidden bool, page entity.PageRequest) ([]entity.Post, entity.PageInfo, error) {
+	ret := _m.Called(ctx, topicID, viewerID, includeHidden, page)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByTopic")
 	}
 
 	var r0 []entity.Post
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.Post, error)); ok {
-		return rf(ctx, topicID)
+	var r1 entity.PageInfo
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool, entity.PageRequest) ([]entity.Post, entity.PageInfo, error)); ok {
+		return rf(ctx, topicID, viewerID, includeHidden, page)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.Post); ok {
-		r0 = rf(ctx, topicID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool, entity.PageRequest) []entity.Post); ok {
+		r0 = rf(ctx, topicID, viewerID, includeHidden, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.Post)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, topicID)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, bool, entity.PageRequest) entity.PageInfo); ok {
+		r1 = rf(ctx, topicID, viewerID, includeHidden, page)
+	} else {
+		r1 = ret.Get(1).(entity.PageInfo)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64, int64, bool, entity.PageRequest) error); ok {
+		r2 = rf(ctx, topicID, viewerID, includeHidden, page)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetRevisions provides a mock function with given fields: ctx, postID, userID, role
+func (_m *PostUsecase) GetRevisions(ctx context.Context, postID int64, userID int64, role string) ([]entity.PostRevision, error) {
+	ret := _m.Called(ctx, postID, userID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRevisions")
+	}
+
+	var r0 []entity.PostRevision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) ([]entity.PostRevision, error)); ok {
+		return rf(ctx, postID, userID, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) []entity.PostRevision); ok {
+		r0 = rf(ctx, postID, userID, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.PostRevision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string) error); ok {
+		r1 = rf(ctx, postID, userID, role)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,17 +214,127 @@ func (_m *PostUsecase) GetByTopic(ctx context.Context, topicID int64) ([]entity.
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: ctx, postID, userID, role, content
-func (_m *PostUsecase) Update(ctx context.Context, postID int64, userID int64, role string, content string) error {
-	ret := _m.Called(ctx, postID, userID, role, content)
+// GetThread provides a mock function with given fields: ctx, postID, maxDepth
+func (_m *PostUsecase) GetThread(ctx context.Context, postID int64, maxDepth int) (*entity.PostThread, bool, error) {
+	ret := _m.Called(ctx, postID, maxDepth)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetThread")
+	}
+
+	var r0 *entity.PostThread
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) (*entity.PostThread, bool, error)); ok {
+		return rf(ctx, postID, maxDepth)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) *entity.PostThread); ok {
+		r0 = rf(ctx, postID, maxDepth)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.PostThread)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int) bool); ok {
+		r1 = rf(ctx, postID, maxDepth)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64, int) error); ok {
+		r2 = rf(ctx, postID, maxDepth)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetTree provides a mock function with given fields: ctx, topicID, viewerID, includeHidden, page, maxDepth
+func (_m *PostUsecase) GetTree(ctx context.Context, topicID int64, viewerID int64, includeHidden bool, page entity.PageRequest, maxDepth int) ([]*entity.PostNode, entity.PageInfo, error) {
+	ret := _m.Called(ctx, topicID, viewerID, includeHidden, page, maxDepth)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTree")
+	}
+
+	var r0 []*entity.PostNode
+	var r1 entity.PageInfo
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool, entity.PageRequest, int) ([]*entity.PostNode, entity.PageInfo, error)); ok {
+		return rf(ctx, topicID, viewerID, includeHidden, page, maxDepth)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool, entity.PageRequest, int) []*entity.PostNode); ok {
+		r0 = rf(ctx, topicID, viewerID, includeHidden, page, maxDepth)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.PostNode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, bool, entity.PageRequest, int) entity.PageInfo); ok {
+		r1 = rf(ctx, topicID, viewerID, includeHidden, page, maxDepth)
+	} else {
+		r1 = ret.Get(1).(entity.PageInfo)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64, int64, bool, entity.PageRequest, int) error); ok {
+		r2 = rf(ctx, topicID, viewerID, includeHidden, page, maxDepth)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RemoveReaction provides a mock function with given fields: ctx, postID, userID, reaction
+func (_m *PostUsecase) RemoveReaction(ctx context.Context, postID int64, userID int64, reaction string) error {
+	ret := _m.Called(ctx, postID, userID, reaction)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveReaction")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) error); ok {
+		r0 = rf(ctx, postID, userID, reaction)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Restore provides a mock function with given fields: ctx, postID
+func (_m *PostUsecase) Restore(ctx context.Context, postID int64) error {
+	ret := _m.Called(ctx, postID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, postID, userID, role, content, version
+func (_m *PostUsecase) Update(ctx context.Context, postID int64, userID int64, role string, content string, version *time.Time) error {
+	ret := _m.Called(ctx, postID, userID, role, content, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) error); ok {
-		r0 = rf(ctx, postID, userID, role, content)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string, *time.Time) error); ok {
+		r0 = rf(ctx, postID, userID, role, content, version)
 	} else {
 		r0 = ret.Error(0)
 	}
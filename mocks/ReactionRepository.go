@@ -0,0 +1,95 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ReactionRepository is an autogenerated mock type for the ReactionRepository type
+type ReactionRepository struct {
+	mock.Mock
+}
+
+// Add provides a mock function with given fields: ctx, postID, userID, reaction
+func (_m *ReactionRepository) Add(ctx context.Context, postID int64, userID int64, reaction string) error {
+	ret := _m.Called(ctx, postID, userID, reaction)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Add")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) error); ok {
+		r0 = rf(ctx, postID, userID, reaction)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByPosts provides a mock function with given fields: ctx, postIDs, userID
+func (_m *ReactionRepository) GetByPosts(ctx context.Context, postIDs []int64, userID int64) ([]entity.ReactionCount, error) {
+	ret := _m.Called(ctx, postIDs, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByPosts")
+	}
+
+	var r0 []entity.ReactionCount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64, int64) ([]entity.ReactionCount, error)); ok {
+		return rf(ctx, postIDs, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int64, int64) []entity.ReactionCount); ok {
+		r0 = rf(ctx, postIDs, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.ReactionCount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int64, int64) error); ok {
+		r1 = rf(ctx, postIDs, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Remove provides a mock function with given fields: ctx, postID, userID, reaction
+func (_m *ReactionRepository) Remove(ctx context.Context, postID int64, userID int64, reaction string) error {
+	ret := _m.Called(ctx, postID, userID, reaction)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Remove")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) error); ok {
+		r0 = rf(ctx, postID, userID, reaction)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewReactionRepository creates a new instance of ReactionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReactionRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReactionRepository {
+	mock := &ReactionRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,44 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// ResponseCache is an autogenerated mock type for the ResponseCache type
+type ResponseCache struct {
+	mock.Mock
+}
+
+// Invalidate provides a mock function with no fields
+func (_m *ResponseCache) Invalidate() {
+	_m.Called()
+}
+
+// InvalidateCategory provides a mock function with given fields: categoryID
+func (_m *ResponseCache) InvalidateCategory(categoryID int64) {
+	_m.Called(categoryID)
+}
+
+// InvalidatePosts provides a mock function with given fields: topicID
+func (_m *ResponseCache) InvalidatePosts(topicID int64) {
+	_m.Called(topicID)
+}
+
+// InvalidateTopic provides a mock function with given fields: categoryID, topicID
+func (_m *ResponseCache) InvalidateTopic(categoryID int64, topicID int64) {
+	_m.Called(categoryID, topicID)
+}
+
+// NewResponseCache creates a new instance of ResponseCache. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewResponseCache(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ResponseCache {
+	mock := &ResponseCache{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,66 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SearchUsecase is an autogenerated mock type for the SearchUsecase type
+type SearchUsecase struct {
+	mock.Mock
+}
+
+// Search provides a mock function with given fields: ctx, query
+func (_m *SearchUsecase) Search(ctx context.Context, query entity.SearchQuery) ([]entity.SearchResult, bool, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []entity.SearchResult
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.SearchQuery) ([]entity.SearchResult, bool, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.SearchQuery) []entity.SearchResult); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.SearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.SearchQuery) bool); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, entity.SearchQuery) error); ok {
+		r2 = rf(ctx, query)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// NewSearchUsecase creates a new instance of SearchUsecase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSearchUsecase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SearchUsecase {
+	mock := &SearchUsecase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // TopicRepository is an autogenerated mock type for the TopicRepository type
@@ -42,17 +44,17 @@ func (_m *TopicRepository) Create(_a0 context.Context, _a1 entity.Topic) (int64,
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: ctx, id
-func (_m *TopicRepository) Delete(ctx context.Context, id int64) error {
-	ret := _m.Called(ctx, id)
+// Delete provides a mock function with given fields: ctx, id, deletedBy
+func (_m *TopicRepository) Delete(ctx context.Context, id int64, deletedBy int64) error {
+	ret := _m.Called(ctx, id, deletedBy)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, id, deletedBy)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -60,9 +62,9 @@ func (_m *TopicRepository) Delete(ctx context.Context, id int64) error {
 	return r0
 }
 
-// GetByCategory provides a mock function with given fields: ct, categoryID
-func (_m *TopicRepository) GetByCategory(ct context.Context, categoryID int64) ([]entity.Topic, error) {
-	ret := _m.Called(ct, categoryID)
+// GetByCategory provides a mock function with given fields: ctx, categoryID, sort, page
+func (_m *TopicRepository) GetByCategory(ctx context.Context, categoryID int64, sort entity.TopicSort, page entity.PageRequest) ([]entity.Topic, error) {
+	ret := _m.Called(ctx, categoryID, sort, page)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByCategory")
@@ -70,19 +72,19 @@ func (_m *TopicRepository) GetByCategory(ct context.Context, categoryID int64) (
 
 	var r0 []entity.Topic
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.Topic, error)); ok {
-		return rf(ct, categoryID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, entity.TopicSort, entity.PageRequest) ([]entity.Topic, error)); ok {
+		return rf(ctx, categoryID, sort, page)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.Topic); ok {
-		r0 = rf(ct, categoryID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, entity.TopicSort, entity.PageRequest) []entity.Topic); ok {
+		r0 = rf(ctx, categoryID, sort, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.Topic)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ct, categoryID)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, entity.TopicSort, entity.PageRequest) error); ok {
+		r1 = rf(ctx, categoryID, sort, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -120,17 +122,111 @@ func (_m *TopicRepository) GetByID(_a0 context.Context, _a1 int64) (*entity.Topi
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: ctx, id, title
-func (_m *TopicRepository) Update(ctx context.Context, id int64, title string) error {
-	ret := _m.Called(ctx, id, title)
+// GetByIDForUpdate provides a mock function with given fields: _a0, _a1
+func (_m *TopicRepository) GetByIDForUpdate(_a0 context.Context, _a1 int64) (*entity.Topic, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByIDForUpdate")
+	}
+
+	var r0 *entity.Topic
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*entity.Topic, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.Topic); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Topic)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx, before
+func (_m *TopicRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeDeleted")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *TopicRepository) Restore(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, id, title, version
+func (_m *TopicRepository) Update(ctx context.Context, id int64, title string, version *time.Time) error {
+	ret := _m.Called(ctx, id, title, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
-		r0 = rf(ctx, id, title)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, *time.Time) error); ok {
+		r0 = rf(ctx, id, title, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateState provides a mock function with given fields: ctx, id, pinned, locked
+func (_m *TopicRepository) UpdateState(ctx context.Context, id int64, pinned *bool, locked *bool) error {
+	ret := _m.Called(ctx, id, pinned, locked)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *bool, *bool) error); ok {
+		r0 = rf(ctx, id, pinned, locked)
 	} else {
 		r0 = ret.Error(0)
 	}
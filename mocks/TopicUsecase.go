@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/keshvan/forum-service-sstu-forum/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // TopicUsecase is an autogenerated mock type for the TopicUsecase type
@@ -14,9 +16,9 @@ type TopicUsecase struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: _a0, _a1
-func (_m *TopicUsecase) Create(_a0 context.Context, _a1 entity.Topic) (int64, error) {
-	ret := _m.Called(_a0, _a1)
+// Create provides a mock function with given fields: ctx, topic, content
+func (_m *TopicUsecase) Create(ctx context.Context, topic entity.Topic, content string) (int64, error) {
+	ret := _m.Called(ctx, topic, content)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Create")
@@ -24,17 +26,17 @@ func (_m *TopicUsecase) Create(_a0 context.Context, _a1 entity.Topic) (int64, er
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, entity.Topic) (int64, error)); ok {
-		return rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Topic, string) (int64, error)); ok {
+		return rf(ctx, topic, content)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, entity.Topic) int64); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Topic, string) int64); ok {
+		r0 = rf(ctx, topic, content)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, entity.Topic) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, entity.Topic, string) error); ok {
+		r1 = rf(ctx, topic, content)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -60,38 +62,45 @@ func (_m *TopicUsecase) Delete(ctx context.Context, topicID int64, userID int64,
 	return r0
 }
 
-// GetByCategory provides a mock function with given fields: ct, categoryID
-func (_m *TopicUsecase) GetByCategory(ct context.Context, categoryID int64) ([]entity.Topic, error) {
-	ret := _m.Called(ct, categoryID)
+// GetByCategory provides a mock function with given fields: ctx, categoryID, includeHidden, sort, page
+func (_m *TopicUsecase) GetByCategory(ctx context.Context, categoryID int64, includeHidden bool, sort entity.TopicSort, page entity.PageRequest) ([]entity.Topic, entity.PageInfo, error) {
+	ret := _m.Called(ctx, categoryID, includeHidden, sort, page)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetByCategory")
 	}
 
 	var r0 []entity.Topic
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]entity.Topic, error)); ok {
-		return rf(ct, categoryID)
+	var r1 entity.PageInfo
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool, entity.TopicSort, entity.PageRequest) ([]entity.Topic, entity.PageInfo, error)); ok {
+		return rf(ctx, categoryID, includeHidden, sort, page)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) []entity.Topic); ok {
-		r0 = rf(ct, categoryID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool, entity.TopicSort, entity.PageRequest) []entity.Topic); ok {
+		r0 = rf(ctx, categoryID, includeHidden, sort, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.Topic)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ct, categoryID)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bool, entity.TopicSort, entity.PageRequest) entity.PageInfo); ok {
+		r1 = rf(ctx, categoryID, includeHidden, sort, page)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(entity.PageInfo)
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(context.Context, int64, bool, entity.TopicSort, entity.PageRequest) error); ok {
+		r2 = rf(ctx, categoryID, includeHidden, sort, page)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetByID provides a mock function with given fields: ctx, id
-func (_m *TopicUsecase) GetByID(ctx context.Context, id int64) (*entity.Topic, error) {
+func (_m *TopicUsecase) GetByID(ctx context.Context, id int64) (*entity.Topic, bool, error) {
 	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
@@ -99,8 +108,9 @@ func (_m *TopicUsecase) GetByID(ctx context.Context, id int64) (*entity.Topic, e
 	}
 
 	var r0 *entity.Topic
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) (*entity.Topic, error)); ok {
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*entity.Topic, bool, error)); ok {
 		return rf(ctx, id)
 	}
 	if rf, ok := ret.Get(0).(func(context.Context, int64) *entity.Topic); ok {
@@ -111,26 +121,68 @@ func (_m *TopicUsecase) GetByID(ctx context.Context, id int64) (*entity.Topic, e
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
 		r1 = rf(ctx, id)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(bool)
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(context.Context, int64) error); ok {
+		r2 = rf(ctx, id)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Restore provides a mock function with given fields: ctx, topicID
+func (_m *TopicUsecase) Restore(ctx context.Context, topicID int64) error {
+	ret := _m.Called(ctx, topicID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, topicID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetState provides a mock function with given fields: ctx, topicID, userID, role, pinned, locked
+func (_m *TopicUsecase) SetState(ctx context.Context, topicID int64, userID int64, role string, pinned *bool, locked *bool) error {
+	ret := _m.Called(ctx, topicID, userID, role, pinned, locked)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, *bool, *bool) error); ok {
+		r0 = rf(ctx, topicID, userID, role, pinned, locked)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// Update provides a mock function with given fields: ctx, topicID, userID, role, title
-func (_m *TopicUsecase) Update(ctx context.Context, topicID int64, userID int64, role string, title string) error {
-	ret := _m.Called(ctx, topicID, userID, role, title)
+// Update provides a mock function with given fields: ctx, topicID, userID, role, title, version
+func (_m *TopicUsecase) Update(ctx context.Context, topicID int64, userID int64, role string, title string, version *time.Time) error {
+	ret := _m.Called(ctx, topicID, userID, role, title, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) error); ok {
-		r0 = rf(ctx, topicID, userID, role, title)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string, *time.Time) error); ok {
+		r0 = rf(ctx, topicID, userID, role, title, version)
 	} else {
 		r0 = ret.Error(0)
 	}